@@ -1,11 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"strings"
 
 	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
-	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"argo-parser/argowf"
 )
 
 func parseWorkflowFromYAML(yamlFile string) (*wfv1.Workflow, error) {
@@ -15,26 +21,216 @@ func parseWorkflowFromYAML(yamlFile string) (*wfv1.Workflow, error) {
 		return nil, fmt.Errorf("failed to read YAML file: %w", err)
 	}
 
-	// 创建 Workflow 对象
-	var workflow wfv1.Workflow
-
-	// 解析 YAML 到 Workflow 对象
-	if err := yaml.Unmarshal(yamlBytes, &workflow); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
+	// 按 kind 解析，只接受 Workflow
+	resource, err := argowf.ParseResource(yamlBytes)
+	if err != nil {
+		return nil, err
+	}
+	wf, ok := resource.(argowf.WorkflowResource)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is a %s, not a Workflow", argowf.ErrUnsupportedResource, yamlFile, resource.ResourceKind())
 	}
 
-	return &workflow, nil
+	return wf.Workflow, nil
 }
 
 func main() {
+	file := flag.String("f", "", "Argo Workflow YAML file, - for stdin; files can also be given as arguments")
+	format := flag.String("format", "table", "inspection output format: table, json, dot or mermaid")
+	depth := flag.Int("depth", 0, "with -format dot or mermaid, expand nested steps and DAG templates only to this depth; 0 expands all")
+	toGHA := flag.Bool("to-gha", false, "convert the workflow to GitHub Actions YAML")
+	output := flag.String("o", "", "write the GitHub Actions YAML to this file instead of stdout")
+	validate := flag.Bool("validate", false, "validate the workflows offline; exit 1 if any issue is found")
+	templatesDir := flag.String("templates", "", "directory of WorkflowTemplates and ClusterWorkflowTemplates used to resolve templateRef when validating")
+	compare := flag.String("compare", "", "GitHub Actions workflow the Argo workflow was converted from; exit 1 if the job graph differs")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [file ...]\n\nInspect Argo workflows; reads stdin when no file is given.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
 	// 解析工作流文件
-	wf, err := parseWorkflowFromYAML("linux-arm-npu-a2b4-1.yaml")
-	if err != nil {
-		panic(err)
+	files := flag.Args()
+	if *file != "" {
+		files = append([]string{*file}, files...)
+	}
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var resources []argowf.ParsedResource
+	for _, path := range files {
+		parsed, err := argowf.ReadResources(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		resources = append(resources, parsed...)
+	}
+	if len(resources) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no workflow found in %s\n", strings.Join(files, ", "))
+		os.Exit(1)
+	}
+
+	if *validate {
+		validateResources(resources, *templatesDir, *format)
+		return
+	}
+	if *toGHA || *compare != "" {
+		convertToGHA(resources, *toGHA, *output, *compare)
+		return
 	}
 
-	// 现在你可以访问工作流的各种属性
-	fmt.Printf("Workflow Name: %s\n", wf.Name)
-	fmt.Printf("Namespace: %s\n", wf.Namespace)
-	fmt.Printf("Entrypoint: %s\n", wf.Spec.Entrypoint)
+	reports := make([]argowf.WorkflowReport, 0, len(resources))
+	for _, parsed := range resources {
+		report := argowf.InspectResource(parsed.Resource)
+		report.Source = parsed.Source
+		reports = append(reports, report)
+	}
+	switch *format {
+	case "json":
+		if err := argowf.WriteReportsJSON(os.Stdout, reports); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "table":
+		for i, report := range reports {
+			if i > 0 {
+				fmt.Println()
+			}
+			if err := argowf.WriteReportTable(os.Stdout, report); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	case "dot", "mermaid":
+		// 每个工作流输出一个图，以空行分隔
+		write := argowf.WriteDOT
+		if *format == "mermaid" {
+			write = argowf.WriteMermaid
+		}
+		for i, report := range reports {
+			if i > 0 {
+				fmt.Println()
+			}
+			if err := write(os.Stdout, argowf.ReportGraph(report, *depth)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q, must be table, json, dot or mermaid\n", *format)
+		os.Exit(2)
+	}
+}
+
+// convertToGHA 反向转换工作流，无法转换的部分输出到 stderr；多个工作流以 --- 分隔输出
+func convertToGHA(resources []argowf.ParsedResource, write bool, output, compare string) {
+	if compare != "" && len(resources) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: -compare needs exactly one workflow, got %d\n", len(resources))
+		os.Exit(1)
+	}
+	var out bytes.Buffer
+	for i, parsed := range resources {
+		gha, warnings, err := argowf.ConvertArgoToGHA(parsed.Resource.AsWorkflow())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", parsed.Source, err)
+			os.Exit(1)
+		}
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s: %s\n", parsed.Source, w)
+		}
+		data, err := gha.Marshal()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to marshal GHA workflow: %v\n", err)
+			os.Exit(1)
+		}
+		if i > 0 {
+			out.WriteString("---\n")
+		}
+		out.Write(data)
+
+		// GHA → Argo → GHA 往返后 Job 集合和依赖关系应保持不变
+		if compare != "" {
+			original, err := ioutil.ReadFile(compare)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to read %s: %v\n", compare, err)
+				os.Exit(1)
+			}
+			want, err := argowf.ParseJobGraph(original)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", compare, err)
+				os.Exit(1)
+			}
+			diffs := argowf.CompareJobGraphs(want, gha.JobGraph())
+			for _, diff := range diffs {
+				fmt.Fprintf(os.Stderr, "round trip: %s\n", diff)
+			}
+			if len(diffs) > 0 {
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "round trip: job graph of %s preserved (%d jobs)\n", compare, len(want))
+		}
+	}
+	if !write {
+		return
+	}
+	if output == "" {
+		os.Stdout.Write(out.Bytes())
+	} else if err := ioutil.WriteFile(output, out.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// validateResources 校验工作流并输出问题，发现问题时以状态码 1 退出
+func validateResources(resources []argowf.ParsedResource, templatesDir, format string) {
+	var library *argowf.TemplateLibrary
+	if templatesDir != "" {
+		var err error
+		if library, err = argowf.LoadTemplateLibrary(templatesDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to load templates: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	type result struct {
+		Source string                   `json:"source"`
+		Kind   string                   `json:"kind"`
+		Name   string                   `json:"name"`
+		Issues []argowf.ValidationIssue `json:"issues"`
+	}
+	results := make([]result, 0, len(resources))
+	failed := false
+	for _, parsed := range resources {
+		issues := argowf.ValidateResource(parsed.Resource, library)
+		if issues == nil {
+			issues = []argowf.ValidationIssue{}
+		}
+		failed = failed || len(issues) > 0
+		results = append(results, result{Source: parsed.Source, Kind: parsed.Resource.ResourceKind(), Name: parsed.Resource.GetName(), Issues: issues})
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		for _, r := range results {
+			if len(r.Issues) == 0 {
+				fmt.Printf("%s: %s %s is valid\n", r.Source, r.Kind, r.Name)
+				continue
+			}
+			fmt.Printf("%s: %s %s has %d issues\n", r.Source, r.Kind, r.Name, len(r.Issues))
+			for _, issue := range r.Issues {
+				fmt.Printf("  %s\n", issue)
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
 }
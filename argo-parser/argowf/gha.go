@@ -0,0 +1,155 @@
+package argowf
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// --- GitHub Actions 工作流模型 ---
+
+// GHAWorkflow 是反向转换输出的 GHA 工作流，字段顺序即输出顺序
+type GHAWorkflow struct {
+	Name string            `yaml:"name,omitempty"`
+	On   GHATriggers       `yaml:"on"`
+	Env  map[string]string `yaml:"env,omitempty"`
+	Jobs GHAJobs           `yaml:"jobs"`
+}
+
+// GHATriggers 是 on 字段；Argo 工作流由人工或控制器提交，统一转换为 workflow_dispatch
+type GHATriggers struct {
+	WorkflowDispatch *GHADispatch `yaml:"workflow_dispatch"`
+}
+
+// GHADispatch 是 workflow_dispatch 事件的配置
+type GHADispatch struct {
+	Inputs GHAInputs `yaml:"inputs,omitempty"`
+}
+
+// GHAInput 是 workflow_dispatch 的一个输入
+type GHAInput struct {
+	ID          string   `yaml:"-"`
+	Description string   `yaml:"description,omitempty"`
+	Required    bool     `yaml:"required,omitempty"`
+	Default     string   `yaml:"default,omitempty"`
+	Type        string   `yaml:"type,omitempty"`
+	Options     []string `yaml:"options,omitempty"`
+}
+
+// GHAInputs 按 Argo 参数的声明顺序输出
+type GHAInputs []GHAInput
+
+// GHAJobs 按转换顺序输出的 Job 列表
+type GHAJobs []GHANamedJob
+
+// GHANamedJob 是带 Job ID 的 Job
+type GHANamedJob struct {
+	ID  string
+	Job *GHAJob
+}
+
+// GHAJob 是一个 GHA Job
+type GHAJob struct {
+	Needs           StringList        `yaml:"needs,omitempty"`
+	If              string            `yaml:"if,omitempty"`
+	RunsOn          StringList        `yaml:"runs-on"`
+	Container       *GHAContainer     `yaml:"container,omitempty"`
+	Strategy        *GHAStrategy      `yaml:"strategy,omitempty"`
+	TimeoutMinutes  int64             `yaml:"timeout-minutes,omitempty"`
+	ContinueOnError bool              `yaml:"continue-on-error,omitempty"`
+	Env             map[string]string `yaml:"env,omitempty"`
+	Outputs         map[string]string `yaml:"outputs,omitempty"`
+	Steps           []*GHAStep        `yaml:"steps"`
+}
+
+// GHAContainer 是 Job 的运行容器
+type GHAContainer struct {
+	Image string `yaml:"image"`
+}
+
+// GHAStrategy 是 Job 的矩阵策略
+type GHAStrategy struct {
+	Matrix map[string]interface{} `yaml:"matrix"`
+}
+
+// GHAStep 是一个 GHA Step
+type GHAStep struct {
+	ID               string            `yaml:"id,omitempty"`
+	Name             string            `yaml:"name,omitempty"`
+	If               string            `yaml:"if,omitempty"`
+	Uses             string            `yaml:"uses,omitempty"`
+	With             map[string]string `yaml:"with,omitempty"`
+	Shell            string            `yaml:"shell,omitempty"`
+	WorkingDirectory string            `yaml:"working-directory,omitempty"`
+	Env              map[string]string `yaml:"env,omitempty"`
+	TimeoutMinutes   int64             `yaml:"timeout-minutes,omitempty"`
+	ContinueOnError  bool              `yaml:"continue-on-error,omitempty"`
+	Run              BlockString       `yaml:"run,omitempty"`
+}
+
+// StringList 只有一个元素时输出为标量，如 needs: build
+type StringList []string
+
+// BlockString 多行时输出为 YAML 块标量，保持脚本可读
+type BlockString string
+
+// MarshalYAML 实现 yaml.Marshaler
+func (l StringList) MarshalYAML() (interface{}, error) {
+	if len(l) == 1 {
+		return l[0], nil
+	}
+	return []string(l), nil
+}
+
+// MarshalYAML 实现 yaml.Marshaler
+func (s BlockString) MarshalYAML() (interface{}, error) {
+	if !strings.Contains(string(s), "\n") {
+		return string(s), nil
+	}
+	// 块标量不能表示行尾空白，去掉后再输出
+	lines := strings.Split(string(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Style: yaml.LiteralStyle, Value: strings.Join(lines, "\n")}, nil
+}
+
+// MarshalYAML 实现 yaml.Marshaler，保持声明顺序
+func (inputs GHAInputs) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, input := range inputs {
+		var value yaml.Node
+		if err := value.Encode(input); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: input.ID}, &value)
+	}
+	return node, nil
+}
+
+// MarshalYAML 实现 yaml.Marshaler，保持转换顺序
+func (jobs GHAJobs) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, job := range jobs {
+		var value yaml.Node
+		if err := value.Encode(job.Job); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: job.ID}, &value)
+	}
+	return node, nil
+}
+
+// Marshal 把工作流编码为 YAML，缩进与仓库中的 GHA 工作流一致
+func (w *GHAWorkflow) Marshal() ([]byte, error) {
+	var b strings.Builder
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(w); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,465 @@
+package argowf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// --- 工作流检查 ---
+
+// templateTypeOrder 是模板列表中各类型的显示顺序
+var templateTypeOrder = []wfv1.TemplateType{
+	wfv1.TemplateTypeDAG,
+	wfv1.TemplateTypeSteps,
+	wfv1.TemplateTypeContainer,
+	wfv1.TemplateTypeContainerSet,
+	wfv1.TemplateTypeScript,
+	wfv1.TemplateTypeResource,
+	wfv1.TemplateTypeSuspend,
+	wfv1.TemplateTypeData,
+	wfv1.TemplateTypeHTTP,
+	wfv1.TemplateTypePlugin,
+	wfv1.TemplateTypeUnknown,
+}
+
+// WorkflowReport 是一个工作流的检查结果
+type WorkflowReport struct {
+	Source     string          `json:"source,omitempty"`
+	Kind       string          `json:"kind,omitempty"`
+	Name       string          `json:"name"`
+	Namespace  string          `json:"namespace,omitempty"`
+	Schedule   string          `json:"schedule,omitempty"` // CronWorkflow 的调度
+	Entrypoint string          `json:"entrypoint"`
+	Parameters []ParameterInfo `json:"parameters,omitempty"`
+	Volumes    []VolumeInfo    `json:"volumes,omitempty"`
+	Templates  []TemplateInfo  `json:"templates"`
+	Graph      *GraphNode      `json:"graph,omitempty"`
+}
+
+// TemplateInfo 是一个模板的摘要
+type TemplateInfo struct {
+	Name            string            `json:"name"`
+	Type            string            `json:"type"`
+	Image           string            `json:"image,omitempty"`
+	Inputs          []ParameterInfo   `json:"inputs,omitempty"`
+	Outputs         []ParameterInfo   `json:"outputs,omitempty"`
+	InputArtifacts  []ArtifactInfo    `json:"inputArtifacts,omitempty"`
+	OutputArtifacts []ArtifactInfo    `json:"outputArtifacts,omitempty"`
+	VolumeMounts    []MountInfo       `json:"volumeMounts,omitempty"`
+	Requests        map[string]string `json:"requests,omitempty"`
+	Limits          map[string]string `json:"limits,omitempty"`
+}
+
+// ParameterInfo 是一个参数，Value 为默认值、传入值或取值来源
+type ParameterInfo struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// ArtifactInfo 是一个制品及其存储位置
+type ArtifactInfo struct {
+	Name   string `json:"name"`
+	Path   string `json:"path,omitempty"`
+	Source string `json:"source,omitempty"`
+}
+
+// VolumeInfo 是工作流声明的卷
+type VolumeInfo struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Source string `json:"source,omitempty"`
+}
+
+// MountInfo 是容器的卷挂载
+type MountInfo struct {
+	Volume    string `json:"volume"`
+	MountPath string `json:"mountPath"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+// GraphNode 是从入口模板展开的调用树中的一个节点
+type GraphNode struct {
+	Name      string       `json:"name"`
+	Template  string       `json:"template"`
+	Type      string       `json:"type"`
+	Group     int          `json:"group,omitempty"`   // steps 模板中的步骤组序号，从 1 开始，同组并行
+	Depends   []string     `json:"depends,omitempty"` // DAG 任务的依赖
+	When      string       `json:"when,omitempty"`
+	WithItems int          `json:"withItems,omitempty"` // withItems 的元素个数
+	WithParam string       `json:"withParam,omitempty"`
+	Recursive bool         `json:"recursive,omitempty"` // 模板递归调用自身，不再展开
+	Children  []*GraphNode `json:"children,omitempty"`
+}
+
+// InspectResource 汇总 Argo 资源中工作流的参数、卷、模板和调用图
+func InspectResource(resource ArgoResource) WorkflowReport {
+	spec := resource.WorkflowSpec()
+	report := WorkflowReport{
+		Kind:       resource.ResourceKind(),
+		Name:       resource.GetName(),
+		Namespace:  resource.GetNamespace(),
+		Entrypoint: spec.Entrypoint,
+	}
+	if report.Name == "" {
+		report.Name = resource.GetGenerateName()
+	}
+	if cron, ok := resource.(CronWorkflowResource); ok {
+		report.Schedule = cron.Spec.Schedule
+		if cron.Spec.Timezone != "" {
+			report.Schedule += " (" + cron.Spec.Timezone + ")"
+		}
+	}
+	for _, p := range spec.Arguments.Parameters {
+		report.Parameters = append(report.Parameters, parameterInfo(p))
+	}
+	for _, claim := range spec.VolumeClaimTemplates {
+		source := claim.Spec.Resources.Requests.Storage().String()
+		if claim.Spec.StorageClassName != nil {
+			source += " " + *claim.Spec.StorageClassName
+		}
+		report.Volumes = append(report.Volumes, VolumeInfo{Name: claim.Name, Type: "volumeClaimTemplate", Source: source})
+	}
+	for _, volume := range spec.Volumes {
+		report.Volumes = append(report.Volumes, volumeInfo(volume))
+	}
+
+	templates := make(map[string]*wfv1.Template, len(spec.Templates))
+	for i := range spec.Templates {
+		tmpl := &spec.Templates[i]
+		templates[tmpl.Name] = tmpl
+		report.Templates = append(report.Templates, templateInfo(tmpl))
+	}
+	rank := map[string]int{}
+	for i, t := range templateTypeOrder {
+		rank[string(t)] = i
+	}
+	sort.SliceStable(report.Templates, func(i, j int) bool {
+		return rank[report.Templates[i].Type] < rank[report.Templates[j].Type]
+	})
+
+	if entry, ok := templates[spec.Entrypoint]; ok {
+		report.Graph = graphNode(entry.Name, entry, templates, map[string]bool{})
+	}
+	return report
+}
+
+func parameterInfo(p wfv1.Parameter) ParameterInfo {
+	info := ParameterInfo{Name: p.Name}
+	switch {
+	case p.Value != nil:
+		info.Value = p.Value.String()
+	case p.Default != nil:
+		info.Value = p.Default.String()
+	case p.ValueFrom != nil:
+		switch v := p.ValueFrom; {
+		case v.Path != "":
+			info.Value = "from path " + v.Path
+		case v.Parameter != "":
+			info.Value = "from " + v.Parameter
+		case v.Expression != "":
+			info.Value = "from expression " + v.Expression
+		case v.ConfigMapKeyRef != nil:
+			info.Value = "from configmap " + v.ConfigMapKeyRef.Name + "/" + v.ConfigMapKeyRef.Key
+		default:
+			info.Value = "from valueFrom"
+		}
+	}
+	return info
+}
+
+func artifactInfo(a wfv1.Artifact) ArtifactInfo {
+	info := ArtifactInfo{Name: a.Name, Path: a.Path}
+	switch {
+	case a.From != "":
+		info.Source = "from " + a.From
+	case a.FromExpression != "":
+		info.Source = "from expression " + a.FromExpression
+	case a.S3 != nil:
+		info.Source = "s3 " + a.S3.Key
+	case a.GCS != nil:
+		info.Source = "gcs " + a.GCS.Key
+	case a.OSS != nil:
+		info.Source = "oss " + a.OSS.Key
+	case a.Git != nil:
+		info.Source = "git " + a.Git.Repo
+	case a.HTTP != nil:
+		info.Source = "http " + a.HTTP.URL
+	case a.Raw != nil:
+		info.Source = "raw"
+	}
+	return info
+}
+
+func volumeInfo(v corev1.Volume) VolumeInfo {
+	info := VolumeInfo{Name: v.Name}
+	switch {
+	case v.PersistentVolumeClaim != nil:
+		info.Type, info.Source = "persistentVolumeClaim", v.PersistentVolumeClaim.ClaimName
+	case v.HostPath != nil:
+		info.Type, info.Source = "hostPath", v.HostPath.Path
+	case v.ConfigMap != nil:
+		info.Type, info.Source = "configMap", v.ConfigMap.Name
+	case v.Secret != nil:
+		info.Type, info.Source = "secret", v.Secret.SecretName
+	case v.EmptyDir != nil:
+		info.Type = "emptyDir"
+	case v.NFS != nil:
+		info.Type, info.Source = "nfs", v.NFS.Server+":"+v.NFS.Path
+	default:
+		info.Type = "other"
+	}
+	return info
+}
+
+func templateInfo(tmpl *wfv1.Template) TemplateInfo {
+	info := TemplateInfo{Name: tmpl.Name, Type: string(tmpl.GetType())}
+	for _, p := range tmpl.Inputs.Parameters {
+		info.Inputs = append(info.Inputs, parameterInfo(p))
+	}
+	for _, p := range tmpl.Outputs.Parameters {
+		info.Outputs = append(info.Outputs, parameterInfo(p))
+	}
+	for _, a := range tmpl.Inputs.Artifacts {
+		info.InputArtifacts = append(info.InputArtifacts, artifactInfo(a))
+	}
+	for _, a := range tmpl.Outputs.Artifacts {
+		info.OutputArtifacts = append(info.OutputArtifacts, artifactInfo(a))
+	}
+	if container := templateContainer(tmpl); container != nil {
+		info.Image = container.Image
+		for _, m := range container.VolumeMounts {
+			info.VolumeMounts = append(info.VolumeMounts, MountInfo{Volume: m.Name, MountPath: m.MountPath, ReadOnly: m.ReadOnly})
+		}
+		info.Requests = resourceList(container.Resources.Requests)
+		info.Limits = resourceList(container.Resources.Limits)
+	}
+	return info
+}
+
+func resourceList(list corev1.ResourceList) map[string]string {
+	if len(list) == 0 {
+		return nil
+	}
+	resources := make(map[string]string, len(list))
+	for name, quantity := range list {
+		resources[string(name)] = quantity.String()
+	}
+	return resources
+}
+
+// graphNode 展开模板调用；visiting 记录当前路径上的模板，递归调用只显示一次
+func graphNode(name string, tmpl *wfv1.Template, templates map[string]*wfv1.Template, visiting map[string]bool) *GraphNode {
+	node := &GraphNode{Name: name, Template: tmpl.Name, Type: string(tmpl.GetType())}
+	if visiting[tmpl.Name] {
+		node.Recursive = true
+		return node
+	}
+	visiting[tmpl.Name] = true
+	defer delete(visiting, tmpl.Name)
+
+	child := func(call templateCall) *GraphNode {
+		target := call.inline
+		if target == nil {
+			target = templates[call.template]
+		}
+		var n *GraphNode
+		switch {
+		case target != nil:
+			n = graphNode(call.name, target, templates, visiting)
+		case call.templateRef != nil:
+			n = &GraphNode{Name: call.name, Template: call.templateRef.Name + "/" + call.templateRef.Template, Type: "templateRef"}
+		default:
+			n = &GraphNode{Name: call.name, Template: call.template, Type: "missing"}
+		}
+		n.When, n.WithItems, n.WithParam = call.when, len(call.withItems), call.withParam
+		return n
+	}
+	switch {
+	case tmpl.Steps != nil:
+		for i, group := range tmpl.Steps {
+			for _, step := range group.Steps {
+				n := child(stepCall(step))
+				n.Group = i + 1
+				node.Children = append(node.Children, n)
+			}
+		}
+	case tmpl.DAG != nil:
+		for _, task := range tmpl.DAG.Tasks {
+			n := child(taskCall(task))
+			if task.Depends != "" {
+				n.Depends = []string{task.Depends}
+			} else {
+				n.Depends = task.Dependencies
+			}
+			node.Children = append(node.Children, n)
+		}
+	}
+	return node
+}
+
+// --- 输出 ---
+
+// WriteReportsJSON 以 JSON 数组输出检查结果
+func WriteReportsJSON(w io.Writer, reports []WorkflowReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(reports)
+}
+
+// WriteReportTable 以表格和树输出一个工作流的检查结果
+func WriteReportTable(w io.Writer, report WorkflowReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if report.Source != "" {
+		fmt.Fprintf(tw, "Source:\t%s\n", report.Source)
+	}
+	if report.Kind != "" {
+		fmt.Fprintf(tw, "Kind:\t%s\n", report.Kind)
+	}
+	fmt.Fprintf(tw, "Workflow Name:\t%s\n", report.Name)
+	fmt.Fprintf(tw, "Namespace:\t%s\n", report.Namespace)
+	if report.Schedule != "" {
+		fmt.Fprintf(tw, "Schedule:\t%s\n", report.Schedule)
+	}
+	fmt.Fprintf(tw, "Entrypoint:\t%s\n", report.Entrypoint)
+
+	if len(report.Parameters) > 0 {
+		fmt.Fprintf(tw, "\nPARAMETER\tVALUE\n")
+		for _, p := range report.Parameters {
+			fmt.Fprintf(tw, "%s\t%s\n", p.Name, p.Value)
+		}
+	}
+	if len(report.Volumes) > 0 {
+		fmt.Fprintf(tw, "\nVOLUME\tTYPE\tSOURCE\n")
+		for _, v := range report.Volumes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Name, v.Type, v.Source)
+		}
+	}
+
+	counts := map[string]int{}
+	for _, t := range report.Templates {
+		counts[t.Type]++
+	}
+	var summary []string
+	for _, t := range templateTypeOrder {
+		if n := counts[string(t)]; n > 0 {
+			summary = append(summary, fmt.Sprintf("%s=%d", strings.ToLower(string(t)), n))
+		}
+	}
+	fmt.Fprintf(tw, "\nTemplates:\t%s\n", strings.Join(summary, " "))
+	fmt.Fprintf(tw, "TEMPLATE\tTYPE\tIMAGE\tINPUTS\tOUTPUTS\tARTIFACTS\tVOLUMES\tREQUESTS\tLIMITS\n")
+	for _, t := range report.Templates {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			t.Name, strings.ToLower(t.Type), dash(t.Image),
+			dash(parameterNames(t.Inputs)), dash(parameterNames(t.Outputs)),
+			dash(artifactNames(t.InputArtifacts, t.OutputArtifacts)), dash(mountNames(t.VolumeMounts)),
+			dash(resourceString(t.Requests)), dash(resourceString(t.Limits)))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if report.Graph != nil {
+		fmt.Fprintf(w, "\nGraph:\n%s\n", graphLabel(report.Graph, false))
+		writeTree(w, report.Graph.Children, "", report.Graph.Type == string(wfv1.TemplateTypeSteps))
+	}
+	return nil
+}
+
+// writeTree 以 ASCII 树输出调用图
+func writeTree(w io.Writer, nodes []*GraphNode, prefix string, steps bool) {
+	for i, n := range nodes {
+		branch, indent := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, indent = "└── ", "    "
+		}
+		fmt.Fprintf(w, "%s%s%s\n", prefix, branch, graphLabel(n, steps))
+		writeTree(w, n.Children, prefix+indent, n.Type == string(wfv1.TemplateTypeSteps))
+	}
+}
+
+// graphLabel 返回树节点的描述，如 "2. build -> build-tmpl [container] (when: ...)"
+func graphLabel(n *GraphNode, steps bool) string {
+	var b strings.Builder
+	if steps && n.Group > 0 {
+		fmt.Fprintf(&b, "%d. ", n.Group)
+	}
+	b.WriteString(n.Name)
+	if n.Template != n.Name {
+		b.WriteString(" -> " + n.Template)
+	}
+	b.WriteString(" [" + strings.ToLower(n.Type) + "]")
+	var notes []string
+	if len(n.Depends) > 0 {
+		notes = append(notes, "depends: "+strings.Join(n.Depends, ", "))
+	}
+	if n.When != "" {
+		notes = append(notes, "when: "+n.When)
+	}
+	if n.WithItems > 0 {
+		notes = append(notes, fmt.Sprintf("withItems: %d", n.WithItems))
+	}
+	if n.WithParam != "" {
+		notes = append(notes, "withParam: "+n.WithParam)
+	}
+	if n.Recursive {
+		notes = append(notes, "recursive")
+	}
+	if len(notes) > 0 {
+		b.WriteString(" (" + strings.Join(notes, "; ") + ")")
+	}
+	return b.String()
+}
+
+func parameterNames(params []ParameterInfo) string {
+	names := make([]string, 0, len(params))
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func artifactNames(inputs, outputs []ArtifactInfo) string {
+	var names []string
+	for _, a := range inputs {
+		names = append(names, "<"+a.Name)
+	}
+	for _, a := range outputs {
+		names = append(names, ">"+a.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func mountNames(mounts []MountInfo) string {
+	names := make([]string, 0, len(mounts))
+	for _, m := range mounts {
+		names = append(names, m.Volume+":"+m.MountPath)
+	}
+	return strings.Join(names, ",")
+}
+
+func resourceString(resources map[string]string) string {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		names[i] = name + "=" + resources[name]
+	}
+	return strings.Join(names, ",")
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,188 @@
+package argowf
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// --- 工作流检查 ---
+
+const inspectedCron = `apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  name: nightly
+  namespace: ci
+spec:
+  schedule: "0 3 * * *"
+  timezone: Asia/Shanghai
+  workflowSpec:
+    entrypoint: main
+    arguments:
+      parameters:
+        - name: target
+          value: staging
+    volumeClaimTemplates:
+      - metadata:
+          name: workspace
+        spec:
+          storageClassName: fast
+          resources:
+            requests:
+              storage: 10Gi
+    volumes:
+      - name: cache
+        persistentVolumeClaim:
+          claimName: go-cache
+    templates:
+      - name: build
+        inputs:
+          parameters:
+            - name: arch
+        outputs:
+          parameters:
+            - name: digest
+              valueFrom:
+                path: /tmp/digest
+          artifacts:
+            - name: binary
+              path: /out/app
+        container:
+          image: golang:1.22
+          volumeMounts:
+            - name: workspace
+              mountPath: /work
+            - name: cache
+              mountPath: /go/pkg
+              readOnly: true
+          resources:
+            requests:
+              cpu: "2"
+              memory: 4Gi
+      - name: main
+        steps:
+          - - name: build
+              template: build
+              withItems: [amd64, arm64]
+          - - name: publish
+              templateRef:
+                name: library
+                template: publish
+            - name: lost
+              template: missing
+          - - name: again
+              template: main
+              when: "{{workflow.parameters.target}} == prod"
+`
+
+func TestInspectResource(t *testing.T) {
+	resource, err := ParseResource([]byte(inspectedCron))
+	if err != nil {
+		t.Fatalf("ParseResource: %v", err)
+	}
+	report := InspectResource(resource)
+
+	if report.Kind != "CronWorkflow" || report.Name != "nightly" || report.Namespace != "ci" || report.Entrypoint != "main" {
+		t.Errorf("report header = %s %s/%s entrypoint %s", report.Kind, report.Namespace, report.Name, report.Entrypoint)
+	}
+	if report.Schedule != "0 3 * * * (Asia/Shanghai)" {
+		t.Errorf("schedule = %q", report.Schedule)
+	}
+	if want := []ParameterInfo{{Name: "target", Value: "staging"}}; !reflect.DeepEqual(report.Parameters, want) {
+		t.Errorf("parameters = %+v, want %+v", report.Parameters, want)
+	}
+	wantVolumes := []VolumeInfo{
+		{Name: "workspace", Type: "volumeClaimTemplate", Source: "10Gi fast"},
+		{Name: "cache", Type: "persistentVolumeClaim", Source: "go-cache"},
+	}
+	if !reflect.DeepEqual(report.Volumes, wantVolumes) {
+		t.Errorf("volumes = %+v, want %+v", report.Volumes, wantVolumes)
+	}
+
+	// steps 模板排在 container 模板之前
+	if len(report.Templates) != 2 || report.Templates[0].Name != "main" || report.Templates[1].Name != "build" {
+		t.Fatalf("templates = %+v, want main then build", report.Templates)
+	}
+	wantBuild := TemplateInfo{
+		Name:            "build",
+		Type:            "Container",
+		Image:           "golang:1.22",
+		Inputs:          []ParameterInfo{{Name: "arch"}},
+		Outputs:         []ParameterInfo{{Name: "digest", Value: "from path /tmp/digest"}},
+		OutputArtifacts: []ArtifactInfo{{Name: "binary", Path: "/out/app"}},
+		VolumeMounts:    []MountInfo{{Volume: "workspace", MountPath: "/work"}, {Volume: "cache", MountPath: "/go/pkg", ReadOnly: true}},
+		Requests:        map[string]string{"cpu": "2", "memory": "4Gi"},
+	}
+	if !reflect.DeepEqual(report.Templates[1], wantBuild) {
+		t.Errorf("build template = %+v\nwant %+v", report.Templates[1], wantBuild)
+	}
+
+	// 调用图：步骤组序号、withItems、templateRef、缺失的模板和递归调用
+	graph := report.Graph
+	if graph == nil || graph.Template != "main" || len(graph.Children) != 4 {
+		t.Fatalf("graph = %+v, want main with 4 children", graph)
+	}
+	wantChildren := []GraphNode{
+		{Name: "build", Template: "build", Type: "Container", Group: 1, WithItems: 2},
+		{Name: "publish", Template: "library/publish", Type: "templateRef", Group: 2},
+		{Name: "lost", Template: "missing", Type: "missing", Group: 2},
+		{Name: "again", Template: "main", Type: "Steps", Group: 3, When: "{{workflow.parameters.target}} == prod", Recursive: true},
+	}
+	for i, want := range wantChildren {
+		if got := *graph.Children[i]; !reflect.DeepEqual(got, want) {
+			t.Errorf("child %d = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestWriteReports(t *testing.T) {
+	resource, err := ParseResource([]byte(inspectedCron))
+	if err != nil {
+		t.Fatalf("ParseResource: %v", err)
+	}
+	report := InspectResource(resource)
+	report.Source = "nightly.yaml"
+
+	var table bytes.Buffer
+	if err := WriteReportTable(&table, report); err != nil {
+		t.Fatalf("WriteReportTable: %v", err)
+	}
+	// 表格的列宽取决于内容，按空白分隔的字段比较每一行
+	lines := map[string]bool{}
+	for _, line := range strings.Split(table.String(), "\n") {
+		lines[strings.Join(strings.Fields(line), " ")] = true
+	}
+	for _, want := range []string{
+		"Source: nightly.yaml",
+		"Schedule: 0 3 * * * (Asia/Shanghai)",
+		"Templates: steps=1 container=1",
+		"main steps - - - - - - -",
+		"build container golang:1.22 arch digest >binary workspace:/work,cache:/go/pkg cpu=2,memory=4Gi -",
+		"main [steps]",
+		"├── 1. build [container] (withItems: 2)",
+		"├── 2. publish -> library/publish [templateref]",
+		"├── 2. lost -> missing [missing]",
+		"└── 3. again -> main [steps] (when: {{workflow.parameters.target}} == prod; recursive)",
+	} {
+		if !lines[want] {
+			t.Errorf("table output has no line %q:\n%s", want, table.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := WriteReportsJSON(&out, []WorkflowReport{report}); err != nil {
+		t.Fatalf("WriteReportsJSON: %v", err)
+	}
+	var decoded []WorkflowReport
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if len(decoded) != 1 || !reflect.DeepEqual(decoded[0], report) {
+		t.Errorf("JSON round trip = %+v, want %+v", decoded, report)
+	}
+	if strings.Contains(out.String(), `\u003e`) {
+		t.Errorf("JSON output escapes HTML characters:\n%s", out.String())
+	}
+}
@@ -0,0 +1,146 @@
+package argowf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow"
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// --- Argo 资源类型 ---
+
+// ArgoAPIVersion 是支持的 Argo 资源 API 版本
+const ArgoAPIVersion = workflow.APIVersion
+
+// ErrUnsupportedResource 表示文档不是支持的 Argo 资源：apiVersion 或 kind 未知
+var ErrUnsupportedResource = errors.New("unsupported resource")
+
+// supportedKinds 是可以解析的资源类型
+var supportedKinds = []string{
+	workflow.WorkflowKind,
+	workflow.WorkflowTemplateKind,
+	workflow.ClusterWorkflowTemplateKind,
+	workflow.CronWorkflowKind,
+}
+
+// ArgoResource 是 Workflow、WorkflowTemplate、ClusterWorkflowTemplate 和 CronWorkflow 的公共访问接口
+type ArgoResource interface {
+	metav1.Object
+	// ResourceKind 返回资源类型，如 WorkflowTemplate
+	ResourceKind() string
+	// WorkflowSpec 返回资源中的工作流定义，CronWorkflow 为每次运行提交的工作流
+	WorkflowSpec() *wfv1.WorkflowSpec
+	// Template 按名称查找模板，不存在时返回 nil
+	Template(name string) *wfv1.Template
+	// AsWorkflow 返回按该资源提交时得到的 Workflow，Kind 保留为原资源类型
+	AsWorkflow() *wfv1.Workflow
+}
+
+// WorkflowResource 是解析得到的 Workflow
+type WorkflowResource struct{ *wfv1.Workflow }
+
+// WorkflowTemplateResource 是解析得到的 WorkflowTemplate
+type WorkflowTemplateResource struct{ *wfv1.WorkflowTemplate }
+
+// ClusterWorkflowTemplateResource 是解析得到的 ClusterWorkflowTemplate
+type ClusterWorkflowTemplateResource struct{ *wfv1.ClusterWorkflowTemplate }
+
+// CronWorkflowResource 是解析得到的 CronWorkflow
+type CronWorkflowResource struct{ *wfv1.CronWorkflow }
+
+func (r WorkflowResource) ResourceKind() string             { return workflow.WorkflowKind }
+func (r WorkflowResource) WorkflowSpec() *wfv1.WorkflowSpec { return &r.Spec }
+func (r WorkflowResource) Template(name string) *wfv1.Template {
+	return findTemplate(r.WorkflowSpec(), name)
+}
+func (r WorkflowResource) AsWorkflow() *wfv1.Workflow { return r.Workflow }
+
+func (r WorkflowTemplateResource) ResourceKind() string             { return workflow.WorkflowTemplateKind }
+func (r WorkflowTemplateResource) WorkflowSpec() *wfv1.WorkflowSpec { return &r.Spec }
+func (r WorkflowTemplateResource) Template(name string) *wfv1.Template {
+	return findTemplate(r.WorkflowSpec(), name)
+}
+func (r WorkflowTemplateResource) AsWorkflow() *wfv1.Workflow {
+	return asWorkflow(r.ResourceKind(), r.ObjectMeta, r.Spec)
+}
+
+func (r ClusterWorkflowTemplateResource) ResourceKind() string {
+	return workflow.ClusterWorkflowTemplateKind
+}
+func (r ClusterWorkflowTemplateResource) WorkflowSpec() *wfv1.WorkflowSpec { return &r.Spec }
+func (r ClusterWorkflowTemplateResource) Template(name string) *wfv1.Template {
+	return findTemplate(r.WorkflowSpec(), name)
+}
+func (r ClusterWorkflowTemplateResource) AsWorkflow() *wfv1.Workflow {
+	return asWorkflow(r.ResourceKind(), r.ObjectMeta, r.Spec)
+}
+
+func (r CronWorkflowResource) ResourceKind() string             { return workflow.CronWorkflowKind }
+func (r CronWorkflowResource) WorkflowSpec() *wfv1.WorkflowSpec { return &r.Spec.WorkflowSpec }
+func (r CronWorkflowResource) Template(name string) *wfv1.Template {
+	return findTemplate(r.WorkflowSpec(), name)
+}
+func (r CronWorkflowResource) AsWorkflow() *wfv1.Workflow {
+	meta := r.ObjectMeta
+	if m := r.Spec.WorkflowMetadata; m != nil {
+		meta.Labels, meta.Annotations = m.Labels, m.Annotations
+	}
+	return asWorkflow(r.ResourceKind(), meta, r.Spec.WorkflowSpec)
+}
+
+func findTemplate(spec *wfv1.WorkflowSpec, name string) *wfv1.Template {
+	for i := range spec.Templates {
+		if spec.Templates[i].Name == name {
+			return &spec.Templates[i]
+		}
+	}
+	return nil
+}
+
+func asWorkflow(kind string, meta metav1.ObjectMeta, spec wfv1.WorkflowSpec) *wfv1.Workflow {
+	return &wfv1.Workflow{
+		TypeMeta:   metav1.TypeMeta{APIVersion: ArgoAPIVersion, Kind: kind},
+		ObjectMeta: meta,
+		Spec:       spec,
+	}
+}
+
+// ParseResource 按 apiVersion 和 kind 把一个 YAML 文档解析为对应类型的资源
+func ParseResource(doc []byte) (ArgoResource, error) {
+	var meta metav1.TypeMeta
+	if err := yaml.Unmarshal(doc, &meta); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
+	}
+	if meta.APIVersion != ArgoAPIVersion {
+		return nil, fmt.Errorf("%w: apiVersion %q, must be %s", ErrUnsupportedResource, meta.APIVersion, ArgoAPIVersion)
+	}
+
+	var (
+		resource ArgoResource
+		target   interface{}
+	)
+	switch meta.Kind {
+	case workflow.WorkflowKind:
+		r := WorkflowResource{&wfv1.Workflow{}}
+		resource, target = r, r.Workflow
+	case workflow.WorkflowTemplateKind:
+		r := WorkflowTemplateResource{&wfv1.WorkflowTemplate{}}
+		resource, target = r, r.WorkflowTemplate
+	case workflow.ClusterWorkflowTemplateKind:
+		r := ClusterWorkflowTemplateResource{&wfv1.ClusterWorkflowTemplate{}}
+		resource, target = r, r.ClusterWorkflowTemplate
+	case workflow.CronWorkflowKind:
+		r := CronWorkflowResource{&wfv1.CronWorkflow{}}
+		resource, target = r, r.CronWorkflow
+	default:
+		return nil, fmt.Errorf("%w: kind %q, must be one of %s", ErrUnsupportedResource, meta.Kind, strings.Join(supportedKinds, ", "))
+	}
+	if err := yaml.Unmarshal(doc, target); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", meta.Kind, err)
+	}
+	return resource, nil
+}
@@ -0,0 +1,112 @@
+package argowf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// --- Argo 资源类型 ---
+
+func TestParseResource(t *testing.T) {
+	spec := "  entrypoint: main\n  templates:\n    - name: main\n      container:\n        image: alpine\n"
+	tests := []struct {
+		name     string
+		doc      string
+		wantKind string
+	}{
+		{name: "Workflow", doc: "kind: Workflow\nmetadata:\n  generateName: w-\nspec:\n" + spec, wantKind: "Workflow"},
+		{name: "WorkflowTemplate", doc: "kind: WorkflowTemplate\nmetadata:\n  name: w\nspec:\n" + spec, wantKind: "WorkflowTemplate"},
+		{name: "ClusterWorkflowTemplate", doc: "kind: ClusterWorkflowTemplate\nmetadata:\n  name: w\nspec:\n" + spec, wantKind: "ClusterWorkflowTemplate"},
+		{
+			name:     "CronWorkflow",
+			doc:      "kind: CronWorkflow\nmetadata:\n  name: w\n  labels:\n    owner: cron\nspec:\n  schedule: \"0 3 * * *\"\n  workflowMetadata:\n    labels:\n      owner: workflow\n  workflowSpec:\n" + "    entrypoint: main\n    templates:\n      - name: main\n        container:\n          image: alpine\n",
+			wantKind: "CronWorkflow",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource, err := ParseResource([]byte("apiVersion: argoproj.io/v1alpha1\n" + tt.doc))
+			if err != nil {
+				t.Fatalf("ParseResource: %v", err)
+			}
+			if resource.ResourceKind() != tt.wantKind {
+				t.Errorf("ResourceKind() = %q, want %q", resource.ResourceKind(), tt.wantKind)
+			}
+			if spec := resource.WorkflowSpec(); spec.Entrypoint != "main" {
+				t.Errorf("WorkflowSpec().Entrypoint = %q, want main", spec.Entrypoint)
+			}
+			if tmpl := resource.Template("main"); tmpl == nil || tmpl.Container == nil || tmpl.Container.Image != "alpine" {
+				t.Errorf("Template(main) = %+v", tmpl)
+			}
+			if tmpl := resource.Template("missing"); tmpl != nil {
+				t.Errorf("Template(missing) = %+v, want nil", tmpl)
+			}
+
+			// AsWorkflow 保留原资源类型和元数据，CronWorkflow 使用 workflowMetadata 中的标签
+			wf := resource.AsWorkflow()
+			if wf.Kind != tt.wantKind || wf.APIVersion != ArgoAPIVersion || wf.Spec.Entrypoint != "main" {
+				t.Errorf("AsWorkflow() = %s %s entrypoint %q", wf.APIVersion, wf.Kind, wf.Spec.Entrypoint)
+			}
+			if wf.Name != resource.GetName() || wf.GenerateName != resource.GetGenerateName() {
+				t.Errorf("AsWorkflow() name = %q/%q, want %q/%q", wf.Name, wf.GenerateName, resource.GetName(), resource.GetGenerateName())
+			}
+			if tt.wantKind == "CronWorkflow" && wf.Labels["owner"] != "workflow" {
+				t.Errorf("AsWorkflow() labels = %v, want the workflowMetadata labels", wf.Labels)
+			}
+		})
+	}
+}
+
+func TestParseResourceUnsupported(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{name: "unknown kind", doc: "apiVersion: argoproj.io/v1alpha1\nkind: WorkflowEventBinding\nmetadata:\n  name: x\n", wantErr: `kind "WorkflowEventBinding", must be one of Workflow, WorkflowTemplate, ClusterWorkflowTemplate, CronWorkflow`},
+		{name: "other apiVersion", doc: "apiVersion: v1\nkind: Workflow\nmetadata:\n  name: x\n", wantErr: `apiVersion "v1"`},
+		{name: "missing apiVersion", doc: "kind: Workflow\n", wantErr: `apiVersion ""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseResource([]byte(tt.doc))
+			if !errors.Is(err, ErrUnsupportedResource) {
+				t.Fatalf("error = %v, want ErrUnsupportedResource", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 类型正确但内容无法解析时返回解析错误，而不是 ErrUnsupportedResource
+	_, err := ParseResource([]byte("apiVersion: argoproj.io/v1alpha1\nkind: Workflow\nspec:\n  templates: main\n"))
+	if err == nil || errors.Is(err, ErrUnsupportedResource) || !strings.Contains(err.Error(), "failed to unmarshal Workflow") {
+		t.Errorf("error = %v, want an unmarshal error", err)
+	}
+}
+
+func TestParseResources(t *testing.T) {
+	data := "# comment only\n---\napiVersion: argoproj.io/v1alpha1\nkind: Workflow\nmetadata:\n  name: a\n---\n---\napiVersion: argoproj.io/v1alpha1\nkind: WorkflowTemplate\nmetadata:\n  name: b\n"
+	resources, err := ParseResources([]byte(data), "all.yaml")
+	if err != nil {
+		t.Fatalf("ParseResources: %v", err)
+	}
+	// 空文档和只有注释的文档被跳过，多文档文件的来源带序号
+	var got []string
+	for _, r := range resources {
+		got = append(got, r.Source+" "+r.Resource.ResourceKind()+" "+r.Resource.GetName())
+	}
+	if want := "all.yaml#1 Workflow a,all.yaml#2 WorkflowTemplate b"; strings.Join(got, ",") != want {
+		t.Errorf("resources = %q, want %q", strings.Join(got, ","), want)
+	}
+
+	if _, err := ParseResources([]byte(data+"---\napiVersion: v1\nkind: ConfigMap\n"), "all.yaml"); err == nil || !strings.HasPrefix(err.Error(), "all.yaml#3: ") {
+		t.Errorf("error = %v, want it to name the third document", err)
+	}
+	resources, err = ParseResources([]byte("apiVersion: argoproj.io/v1alpha1\nkind: Workflow\nmetadata:\n  name: a\n"), "one.yaml")
+	if err != nil || len(resources) != 1 || resources[0].Source != "one.yaml" {
+		t.Errorf("single document = %+v, %v; want source one.yaml", resources, err)
+	}
+}
@@ -0,0 +1,82 @@
+// Package argowf 解析、检查、校验 Argo 工作流资源，并把 Argo Workflow 反向转换为 GitHub Actions
+package argowf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// --- YAML 解析 ---
+
+// splitYAMLDocuments 拆分多文档 YAML，跳过空文档和只有注释的文档
+func splitYAMLDocuments(data []byte) ([][]byte, error) {
+	var docs [][]byte
+	reader := yaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read YAML document: %w", err)
+		}
+		var content map[string]interface{}
+		if err := yaml.Unmarshal(doc, &content); err != nil {
+			return nil, fmt.Errorf("document %d: failed to unmarshal YAML: %w", len(docs)+1, err)
+		}
+		if len(content) > 0 {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+// ParsedResource 是从文件中解析出的一个 Argo 资源
+type ParsedResource struct {
+	Source   string // 文件名，多文档文件带文档序号，如 wf.yaml#2
+	Resource ArgoResource
+}
+
+// ReadResources 读取并解析文件中的所有资源，path 为 "-" 时读取标准输入
+func ReadResources(path string) ([]ParsedResource, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+		path = "<stdin>"
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read YAML file: %w", err)
+	}
+	return ParseResources(data, path)
+}
+
+// ParseResources 解析 data 中的所有 YAML 文档，path 用于标注来源；空文档被跳过，不支持的资源类型返回错误
+func ParseResources(data []byte, path string) ([]ParsedResource, error) {
+	docs, err := splitYAMLDocuments(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	resources := make([]ParsedResource, 0, len(docs))
+	for i, doc := range docs {
+		source := path
+		if len(docs) > 1 {
+			source = fmt.Sprintf("%s#%d", path, i+1)
+		}
+		resource, err := ParseResource(doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		resources = append(resources, ParsedResource{Source: source, Resource: resource})
+	}
+	return resources, nil
+}
@@ -0,0 +1,250 @@
+package argowf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+)
+
+// --- 图渲染 ---
+
+// RenderGraph 是输出为 DOT 或 Mermaid 的有向图；节点可以属于嵌套的分组
+type RenderGraph struct {
+	Name     string
+	Clusters []renderCluster
+	Nodes    []renderNode
+	Edges    []renderEdge
+}
+
+// renderCluster 是一个节点分组，Parent 为空表示顶层分组
+type renderCluster struct {
+	ID     string
+	Label  string
+	Parent string
+}
+
+// renderNode 是一个节点，Cluster 为空表示不属于任何分组；Label 中的换行分隔多行文字
+type renderNode struct {
+	ID      string
+	Label   string
+	Cluster string
+}
+
+// renderEdge 是一条依赖边，From 先于 To 执行
+type renderEdge struct {
+	From string
+	To   string
+}
+
+// addEdge 添加一条边，重复的边被忽略
+func (g *RenderGraph) addEdge(from, to string) {
+	for _, e := range g.Edges {
+		if e.From == from && e.To == to {
+			return
+		}
+	}
+	g.Edges = append(g.Edges, renderEdge{From: from, To: to})
+}
+
+// WriteDOT 以 Graphviz DOT 格式输出
+func WriteDOT(w io.Writer, g *RenderGraph) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "digraph %s {\n", dotQuote(g.Name))
+	fmt.Fprintf(b, "  rankdir=LR;\n")
+	fmt.Fprintf(b, "  node [shape=box, style=rounded];\n")
+	var writeCluster func(parent, indent string)
+	writeCluster = func(parent, indent string) {
+		for _, n := range g.Nodes {
+			if n.Cluster == parent {
+				fmt.Fprintf(b, "%s%s [label=%s];\n", indent, dotQuote(n.ID), dotQuote(n.Label))
+			}
+		}
+		for _, c := range g.Clusters {
+			if c.Parent == parent {
+				fmt.Fprintf(b, "%ssubgraph %s {\n", indent, dotQuote("cluster_"+c.ID))
+				fmt.Fprintf(b, "%s  label=%s;\n", indent, dotQuote(c.Label))
+				writeCluster(c.ID, indent+"  ")
+				fmt.Fprintf(b, "%s}\n", indent)
+			}
+		}
+	}
+	writeCluster("", "  ")
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "  %s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+	}
+	fmt.Fprintf(b, "}\n")
+	return b.Flush()
+}
+
+// WriteMermaid 以 Mermaid flowchart 格式输出，可以直接放入 Markdown 的 mermaid 代码块
+func WriteMermaid(w io.Writer, g *RenderGraph) error {
+	b := bufio.NewWriter(w)
+	if g.Name != "" {
+		fmt.Fprintf(b, "---\ntitle: %s\n---\n", mermaidQuote(g.Name))
+	}
+	fmt.Fprintf(b, "flowchart LR\n")
+	var writeCluster func(parent, indent string)
+	writeCluster = func(parent, indent string) {
+		for _, n := range g.Nodes {
+			if n.Cluster == parent {
+				fmt.Fprintf(b, "%s%s[\"%s\"]\n", indent, n.ID, mermaidQuote(n.Label))
+			}
+		}
+		for _, c := range g.Clusters {
+			if c.Parent == parent {
+				fmt.Fprintf(b, "%ssubgraph %s [\"%s\"]\n", indent, c.ID, mermaidQuote(c.Label))
+				writeCluster(c.ID, indent+"  ")
+				fmt.Fprintf(b, "%send\n", indent)
+			}
+		}
+	}
+	writeCluster("", "  ")
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "  %s --> %s\n", e.From, e.To)
+	}
+	return b.Flush()
+}
+
+// dotQuote 返回 DOT 的带引号字符串，换行输出为 \n
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// mermaidQuote 转义 Mermaid 标签中的引号，换行输出为 <br/>
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return strings.ReplaceAll(s, "\n", "<br/>")
+}
+
+// --- Argo 调用图 ---
+
+// ReportGraph 把工作流的调用图转换为图：调用 steps 或 DAG 模板的节点展开为分组，
+// 分组之间的依赖连接到分组的入口和出口节点。depth 大于 0 时只展开到该层，如 1 只显示入口模板的步骤或任务
+func ReportGraph(report WorkflowReport, depth int) *RenderGraph {
+	title := report.Name
+	if title == "" {
+		title = report.Source
+	}
+	g := &RenderGraph{Name: title}
+	if report.Graph == nil {
+		return g
+	}
+	b := &graphBuilder{graph: g, depth: depth}
+	b.addChildren(report.Graph, "", 1)
+	return g
+}
+
+// graphBuilder 保存一次转换的状态
+type graphBuilder struct {
+	graph *RenderGraph
+	depth int
+	ids   int
+}
+
+func (b *graphBuilder) nextID(prefix string) string {
+	b.ids++
+	return fmt.Sprintf("%s%d", prefix, b.ids)
+}
+
+// add 添加调用节点，返回执行时最先和最后运行的图节点
+func (b *graphBuilder) add(n *GraphNode, cluster string, level int) (entries, exits []string) {
+	if len(n.Children) == 0 || (b.depth > 0 && level > b.depth) {
+		id := b.nextID("n")
+		b.graph.Nodes = append(b.graph.Nodes, renderNode{ID: id, Label: graphNodeLabel(n), Cluster: cluster})
+		return []string{id}, []string{id}
+	}
+	id := b.nextID("c")
+	b.graph.Clusters = append(b.graph.Clusters, renderCluster{ID: id, Label: graphNodeLabel(n), Parent: cluster})
+	return b.addChildren(n, id, level)
+}
+
+// addChildren 添加 steps 或 DAG 模板的子节点和它们之间的依赖
+func (b *graphBuilder) addChildren(n *GraphNode, cluster string, level int) (entries, exits []string) {
+	type span struct{ entries, exits []string }
+	spans := make([]span, len(n.Children))
+	for i, child := range n.Children {
+		spans[i].entries, spans[i].exits = b.add(child, cluster, level+1)
+	}
+
+	if n.Type == string(wfv1.TemplateTypeSteps) {
+		// 步骤组依次执行，组内并行
+		var previous []string
+		for i := 0; i < len(n.Children); {
+			group := n.Children[i].Group
+			var groupEntries, groupExits []string
+			for ; i < len(n.Children) && n.Children[i].Group == group; i++ {
+				groupEntries = append(groupEntries, spans[i].entries...)
+				groupExits = append(groupExits, spans[i].exits...)
+			}
+			b.connect(previous, groupEntries)
+			if entries == nil {
+				entries = groupEntries
+			}
+			previous = groupExits
+		}
+		return entries, previous
+	}
+
+	// DAG：没有依赖的任务是入口，没有被依赖的任务是出口
+	index := map[string]int{}
+	for i, child := range n.Children {
+		index[child.Name] = i
+	}
+	depended := map[int]bool{}
+	for i, child := range n.Children {
+		var deps []string
+		for _, d := range child.Depends {
+			for _, token := range dependsTokenRegex.FindAllString(d, -1) {
+				deps = append(deps, strings.SplitN(token, ".", 2)[0])
+			}
+		}
+		hasDeps := false
+		for _, dep := range deps {
+			if j, ok := index[dep]; ok {
+				b.connect(spans[j].exits, spans[i].entries)
+				depended[j], hasDeps = true, true
+			}
+		}
+		if !hasDeps {
+			entries = append(entries, spans[i].entries...)
+		}
+	}
+	for i := range n.Children {
+		if !depended[i] {
+			exits = append(exits, spans[i].exits...)
+		}
+	}
+	return entries, exits
+}
+
+func (b *graphBuilder) connect(from, to []string) {
+	for _, f := range from {
+		for _, t := range to {
+			b.graph.addEdge(f, t)
+		}
+	}
+}
+
+// graphNodeLabel 返回节点标签：名称、模板和循环、条件
+func graphNodeLabel(n *GraphNode) string {
+	lines := []string{n.Name}
+	if n.Template != n.Name {
+		lines = append(lines, n.Template)
+	}
+	switch {
+	case n.WithItems > 0:
+		lines = append(lines, fmt.Sprintf("withItems: %d", n.WithItems))
+	case n.WithParam != "":
+		lines = append(lines, "withParam")
+	}
+	if n.When != "" {
+		lines = append(lines, "when: "+n.When)
+	}
+	return strings.Join(lines, "\n")
+}
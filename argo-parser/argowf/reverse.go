@@ -0,0 +1,1288 @@
+package argowf
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/parser"
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// --- Argo Workflow → GitHub Actions ---
+
+const (
+	// DefaultRunsOn 是转换后 Job 的 runs-on；Argo 通过资源和节点选择调度，GHA 需要人工选择 runner
+	DefaultRunsOn = "ubuntu-latest"
+
+	// gha-converter 写入模板的注解，反向转换时用于还原 Job ID 和 Step 名称
+	ghaJobAnnotation  = "argus.io/gha-job"
+	ghaStepAnnotation = "argus.io/gha-step"
+
+	// githubParameterPrefix 是 gha-converter 为 github 上下文声明的工作流参数前缀，如 github-ref 对应 github.ref
+	githubParameterPrefix = "github-"
+)
+
+var (
+	// argoTagRegex 匹配 Argo 模板变量，如 {{inputs.parameters.x}}、{{=expr}}
+	argoTagRegex = regexp.MustCompile(`\{\{\s*(=?[^{}]*?)\s*\}\}`)
+	// ghaExprRegex 匹配只包含一个 GHA 表达式的文本
+	ghaExprRegex = regexp.MustCompile(`^\$\{\{ (.*) \}\}$`)
+	// actionPlaceholderRegex 匹配 gha-converter 为 uses 步骤生成的占位脚本
+	actionPlaceholderRegex = regexp.MustCompile(`TODO: Manually implement GHA Action: ([^\s"]+)`)
+	// ghaIDRegex 是 GHA Job ID 和 Step ID 允许的字符之外的字符
+	ghaIDRegex = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+)
+
+// ReverseWarning 描述 Argo 工作流中没有 GHA 对应、被忽略或近似转换的部分
+type ReverseWarning struct {
+	Template string `json:"template,omitempty"` // 相关的模板、任务或步骤，工作流级为空
+	Message  string `json:"message"`
+}
+
+func (w ReverseWarning) String() string {
+	if w.Template == "" {
+		return w.Message
+	}
+	return w.Template + ": " + w.Message
+}
+
+// ghaValue 是 Argo 变量在 GHA 中的对应：表达式或字面量
+type ghaValue struct {
+	expr    string // GHA 表达式，不含 ${{ }}
+	literal string
+}
+
+func (v ghaValue) text() string {
+	if v.expr != "" {
+		return "${{ " + v.expr + " }}"
+	}
+	return v.literal
+}
+
+// operand 返回在 GHA 表达式中作为操作数的写法
+func (v ghaValue) operand() string {
+	if v.expr != "" {
+		return v.expr
+	}
+	return ghaString(v.literal)
+}
+
+// valueOf 把已转换的文本还原为 ghaValue
+func valueOf(text string) ghaValue {
+	if m := ghaExprRegex.FindStringSubmatch(text); m != nil && !strings.Contains(m[1], "${{") {
+		return ghaValue{expr: m[1]}
+	}
+	return ghaValue{literal: text}
+}
+
+// reverseScope 是一个模板中 Argo 变量对应的 GHA 写法
+type reverseScope struct {
+	template string            // 当前模板，用于报告
+	inputs   map[string]string // inputs.parameters 的值，已转换为 GHA 文本
+	item     *ghaValue         // withItems 中的 {{item}}
+	itemMap  map[string]string // 展开 withItems 时 {{item.key}} 的值
+	steps    map[string]string // Argo 步骤名 → GHA Step ID
+	tasks    map[string]string // DAG 任务名 → GHA Job ID
+	used     map[string]bool   // 被引用了输出的 Argo 步骤名
+}
+
+func (s *reverseScope) child(template string) *reverseScope {
+	return &reverseScope{template: template, inputs: map[string]string{}, steps: s.steps, tasks: s.tasks, used: s.used}
+}
+
+// templateCall 是 DAG 任务或步骤对模板的一次调用
+type templateCall struct {
+	name        string
+	template    string
+	templateRef *wfv1.TemplateRef
+	inline      *wfv1.Template
+	arguments   wfv1.Arguments
+	when        string
+	withItems   []wfv1.Item
+	withParam   string
+	sequence    *wfv1.Sequence
+	continueOn  *wfv1.ContinueOn
+}
+
+func taskCall(t wfv1.DAGTask) templateCall {
+	return templateCall{name: t.Name, template: t.Template, templateRef: t.TemplateRef, inline: t.Inline, arguments: t.Arguments,
+		when: t.When, withItems: t.WithItems, withParam: t.WithParam, sequence: t.WithSequence, continueOn: t.ContinueOn}
+}
+
+func stepCall(s wfv1.WorkflowStep) templateCall {
+	return templateCall{name: s.Name, template: s.Template, templateRef: s.TemplateRef, inline: s.Inline, arguments: s.Arguments,
+		when: s.When, withItems: s.WithItems, withParam: s.WithParam, sequence: s.WithSequence, continueOn: s.ContinueOn}
+}
+
+// leafStep 是展开 steps/dag 模板后得到的一个可执行模板调用，对应一个 GHA Step
+type leafStep struct {
+	name            string
+	template        *wfv1.Template
+	scope           *reverseScope
+	condition       string // GHA 条件，已合并外层 steps 模板的条件
+	continueOnError bool
+}
+
+// reverseConverter 保存一次反向转换的状态
+type reverseConverter struct {
+	wf        *wfv1.Workflow
+	templates map[string]*wfv1.Template
+	warnings  []ReverseWarning
+	reported  map[string]bool
+	jobIDs    map[string]bool
+	volumes   map[string]bool
+	// outputRefs 是 {{tasks.X.outputs.parameters.Y}} 引用的 Job 输出，转换完成后检查是否声明
+	outputRefs []jobOutputRef
+}
+
+// jobOutputRef 是对其他 Job 输出的一次引用
+type jobOutputRef struct {
+	where, job, output string
+}
+
+// ConvertArgoToGHA 把 Argo Workflow 转换为 GHA 工作流。
+// DAG 任务转换为 Job 和 needs，steps 模板展开为 Job 的 Step，when 转换为 if，withItems 转换为矩阵；
+// 没有 GHA 对应的特性不会导致失败，而是作为警告返回
+func ConvertArgoToGHA(wf *wfv1.Workflow) (*GHAWorkflow, []ReverseWarning, error) {
+	c := &reverseConverter{
+		wf:        wf,
+		templates: make(map[string]*wfv1.Template, len(wf.Spec.Templates)),
+		reported:  map[string]bool{},
+		jobIDs:    map[string]bool{},
+		volumes:   map[string]bool{},
+	}
+	for i := range wf.Spec.Templates {
+		c.templates[wf.Spec.Templates[i].Name] = &wf.Spec.Templates[i]
+	}
+	if wf.Spec.WorkflowTemplateRef != nil {
+		return nil, nil, fmt.Errorf("workflow references WorkflowTemplate %q, convert the WorkflowTemplate instead", wf.Spec.WorkflowTemplateRef.Name)
+	}
+	entry, ok := c.templates[wf.Spec.Entrypoint]
+	if !ok {
+		return nil, nil, fmt.Errorf("entrypoint %q not found in templates", wf.Spec.Entrypoint)
+	}
+
+	name := wf.Name
+	if name == "" {
+		name = strings.TrimSuffix(wf.GenerateName, "-")
+	}
+	gha := &GHAWorkflow{Name: name, On: GHATriggers{WorkflowDispatch: &GHADispatch{Inputs: c.inputs()}}}
+	c.checkWorkflow()
+
+	scope := &reverseScope{template: entry.Name, inputs: map[string]string{}, steps: map[string]string{}, tasks: map[string]string{}, used: map[string]bool{}}
+	switch {
+	case entry.DAG != nil:
+		gha.Jobs = c.dagJobs(entry, scope)
+	case entry.Steps != nil && c.hasNestedJobs(entry):
+		gha.Jobs = c.stageJobs(entry, scope)
+	default:
+		gha.Jobs = GHAJobs{c.job(templateCall{name: entry.Name, template: entry.Name}, nil, scope)}
+	}
+
+	declared := map[string]map[string]string{}
+	for _, job := range gha.Jobs {
+		declared[job.ID] = job.Job.Outputs
+	}
+	for _, ref := range c.outputRefs {
+		if _, ok := declared[ref.job][ref.output]; !ok {
+			c.warn(ref.where, "job %s has no output %q; declare it in the template outputs with valueFrom", ref.job, ref.output)
+		}
+	}
+	for _, volume := range sortedKeys(c.volumes) {
+		c.warn("", "volume %q is not converted; GHA jobs run on separate runners without shared volumes, pass files with actions/upload-artifact or actions/cache", volume)
+	}
+	gha.Env = hoistEnv(gha.Jobs)
+	return gha, c.warnings, nil
+}
+
+func (c *reverseConverter) warn(template, format string, args ...interface{}) {
+	w := ReverseWarning{Template: template, Message: fmt.Sprintf(format, args...)}
+	if key := w.String(); !c.reported[key] {
+		c.reported[key] = true
+		c.warnings = append(c.warnings, w)
+	}
+}
+
+// --- 工作流级 ---
+
+// inputs 把工作流参数转换为 workflow_dispatch 输入；github-* 参数由 GHA 的 github 上下文提供
+func (c *reverseConverter) inputs() GHAInputs {
+	var inputs GHAInputs
+	for _, p := range c.wf.Spec.Arguments.Parameters {
+		if strings.HasPrefix(p.Name, githubParameterPrefix) {
+			continue
+		}
+		input := GHAInput{ID: p.Name}
+		if p.Description != nil {
+			input.Description = p.Description.String()
+		}
+		switch {
+		case p.Value != nil:
+			input.Default = p.Value.String()
+		case p.Default != nil:
+			input.Default = p.Default.String()
+		case p.ValueFrom != nil:
+			c.warn("", "parameter %q uses valueFrom, which has no workflow_dispatch equivalent", p.Name)
+		default:
+			input.Required = true
+		}
+		if len(p.Enum) > 0 {
+			input.Type = "choice"
+			for _, option := range p.Enum {
+				input.Options = append(input.Options, option.String())
+			}
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs
+}
+
+// checkWorkflow 报告工作流级别没有 GHA 对应的配置
+func (c *reverseConverter) checkWorkflow() {
+	spec := c.wf.Spec
+	unsupported := []struct {
+		set     bool
+		feature string
+	}{
+		{spec.OnExit != "", "onExit handler " + strconv.Quote(spec.OnExit)},
+		{len(spec.Hooks) > 0, "lifecycle hooks"},
+		{spec.ArtifactRepositoryRef != nil, "artifactRepositoryRef"},
+		{len(spec.ImagePullSecrets) > 0, "imagePullSecrets (use jobs.<job_id>.container.credentials)"},
+		{spec.SchedulerName != "", "schedulerName " + strconv.Quote(spec.SchedulerName)},
+		{len(spec.NodeSelector) > 0 || spec.Affinity != nil || len(spec.Tolerations) > 0, "node scheduling (choose a runner with runs-on)"},
+		{spec.ServiceAccountName != "", "serviceAccountName"},
+		{spec.Parallelism != nil, "parallelism (use strategy.max-parallel or concurrency)"},
+		{spec.ActiveDeadlineSeconds != nil, "activeDeadlineSeconds (set timeout-minutes per job)"},
+		{spec.RetryStrategy != nil, "retryStrategy"},
+		{spec.Synchronization != nil, "synchronization (use concurrency)"},
+		{spec.PodGC != nil || spec.TTLStrategy != nil, "pod GC and TTL strategy"},
+		{spec.SecurityContext != nil, "securityContext"},
+		{spec.Metrics != nil, "metrics"},
+	}
+	for _, u := range unsupported {
+		if u.set {
+			c.warn("", "%s is not converted", u.feature)
+		}
+	}
+	for _, claim := range spec.VolumeClaimTemplates {
+		c.volumes[claim.Name] = true
+	}
+	for _, volume := range spec.Volumes {
+		c.volumes[volume.Name] = true
+	}
+}
+
+// checkTemplate 报告模板中没有 GHA 对应的配置
+func (c *reverseConverter) checkTemplate(tmpl *wfv1.Template, where string) {
+	var resources bool
+	if container := templateContainer(tmpl); container != nil {
+		resources = len(container.Resources.Limits) > 0 || len(container.Resources.Requests) > 0
+		for _, mount := range container.VolumeMounts {
+			c.volumes[mount.Name] = true
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil {
+				c.warn(where, "env %s uses valueFrom, which is not converted", env.Name)
+			}
+		}
+	}
+	unsupported := []struct {
+		set     bool
+		feature string
+	}{
+		{resources, "resource requests and limits (choose a runner with runs-on)"},
+		{len(tmpl.NodeSelector) > 0 || tmpl.Affinity != nil || len(tmpl.Tolerations) > 0, "node scheduling (choose a runner with runs-on)"},
+		{tmpl.RetryStrategy != nil, "retryStrategy"},
+		{len(tmpl.Inputs.Artifacts) > 0, "input artifacts (use actions/download-artifact)"},
+		{len(tmpl.Outputs.Artifacts) > 0, "output artifacts (use actions/upload-artifact)"},
+		{len(tmpl.Sidecars) > 0, "sidecars (use jobs.<job_id>.services)"},
+		{len(tmpl.InitContainers) > 0, "init containers"},
+		{tmpl.Synchronization != nil, "synchronization (use concurrency)"},
+		{tmpl.Memoize != nil, "memoize (use actions/cache)"},
+		{tmpl.PodSpecPatch != "", "podSpecPatch"},
+		{tmpl.SecurityContext != nil, "securityContext"},
+		{tmpl.Metrics != nil, "metrics"},
+	}
+	for _, u := range unsupported {
+		if u.set {
+			c.warn(where, "%s is not converted", u.feature)
+		}
+	}
+	for _, volume := range tmpl.Volumes {
+		c.volumes[volume.Name] = true
+	}
+}
+
+// --- Job ---
+
+// dagJobs 把入口 DAG 的每个任务转换为一个 Job
+func (c *reverseConverter) dagJobs(entry *wfv1.Template, scope *reverseScope) GHAJobs {
+	// 先分配 Job ID，依赖和 tasks.X 引用才能解析
+	for _, task := range entry.DAG.Tasks {
+		scope.tasks[task.Name] = c.jobID(c.annotatedJobID(taskCall(task), task.Name))
+	}
+	var jobs GHAJobs
+	for _, task := range entry.DAG.Tasks {
+		var needs []string
+		for _, dependency := range taskDependencies(task) {
+			id, ok := scope.tasks[dependency]
+			if !ok {
+				c.warn(task.Name, "depends on unknown task %q", dependency)
+				continue
+			}
+			needs = appendUnique(needs, id)
+		}
+		if task.Depends != "" && !simpleDepends(task.Depends) {
+			c.warn(task.Name, "depends %q is converted to needs on all referenced tasks; add if: conditions such as failure() for other task results", task.Depends)
+		}
+		job := c.job(taskCall(task), needs, scope)
+		job.ID = scope.tasks[task.Name]
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// stageJobs 把引用了 steps/dag 模板的入口 steps 模板转换为按组串行的 Job：每组的 Job 依赖上一组的所有 Job
+func (c *reverseConverter) stageJobs(entry *wfv1.Template, scope *reverseScope) GHAJobs {
+	var jobs GHAJobs
+	var previous []string
+	for _, group := range entry.Steps {
+		var current []string
+		for _, step := range group.Steps {
+			job := c.job(stepCall(step), previous, scope)
+			current = append(current, job.ID)
+			jobs = append(jobs, job)
+		}
+		previous = current
+	}
+	return jobs
+}
+
+// hasNestedJobs 判断 steps 模板是否引用了 steps/dag 模板，此时每个步骤转换为一个 Job
+func (c *reverseConverter) hasNestedJobs(tmpl *wfv1.Template) bool {
+	for _, group := range tmpl.Steps {
+		for _, step := range group.Steps {
+			if target := c.lookup(stepCall(step), tmpl.Name); target != nil && (target.Steps != nil || target.DAG != nil) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// annotatedJobID 优先使用 gha-converter 注解中的原始 Job ID
+func (c *reverseConverter) annotatedJobID(call templateCall, fallback string) string {
+	if tmpl := c.lookupQuiet(call); tmpl != nil {
+		if id := tmpl.Metadata.Annotations[ghaJobAnnotation]; id != "" {
+			return id
+		}
+	}
+	return fallback
+}
+
+// jobID 返回合法且不重复的 Job ID
+func (c *reverseConverter) jobID(name string) string {
+	id := ghaID(name)
+	unique := id
+	for i := 2; c.jobIDs[strings.ToLower(unique)]; i++ {
+		unique = fmt.Sprintf("%s-%d", id, i)
+	}
+	c.jobIDs[strings.ToLower(unique)] = true
+	return unique
+}
+
+// job 把一次模板调用转换为 Job；caller 是调用方所在的作用域
+func (c *reverseConverter) job(call templateCall, needs []string, caller *reverseScope) GHANamedJob {
+	named := GHANamedJob{Job: &GHAJob{Needs: needs, RunsOn: StringList{DefaultRunsOn}}}
+	if id, ok := caller.tasks[call.name]; ok {
+		named.ID = id
+	} else {
+		named.ID = c.jobID(c.annotatedJobID(call, call.name))
+	}
+	job := named.Job
+
+	// withItems → 矩阵，模板参数中的 {{item}} 对应 matrix.item
+	argScope := caller
+	switch {
+	case len(call.withItems) > 0:
+		items := make([]interface{}, 0, len(call.withItems))
+		for _, item := range call.withItems {
+			var value interface{}
+			if err := json.Unmarshal(item.Value, &value); err != nil {
+				value = item.String()
+			}
+			items = append(items, value)
+		}
+		job.Strategy = &GHAStrategy{Matrix: map[string]interface{}{"item": items}}
+		argScope = caller.child(caller.template)
+		argScope.inputs = caller.inputs
+		argScope.item = &ghaValue{expr: "matrix.item"}
+	case call.withParam != "":
+		c.warn(call.name, "withParam %q cannot be converted to a static matrix; generate the matrix in an earlier job and use fromJSON", call.withParam)
+	case call.sequence != nil:
+		c.warn(call.name, "withSequence is not converted; list the values in strategy.matrix")
+	}
+	if call.when != "" {
+		job.If = c.condition(call.name, call.when, caller)
+	}
+	if call.continueOn != nil && call.continueOn.Failed {
+		job.ContinueOnError = true
+	}
+
+	tmpl := c.lookup(call, caller.template)
+	if tmpl == nil {
+		return named
+	}
+	scope := c.enter(tmpl, call.arguments, argScope)
+	scope.steps = map[string]string{}
+	scope.used = map[string]bool{}
+	leaves := c.leaves(call.name, tmpl, scope, "", false)
+	job.Container = c.jobContainer(leaves)
+	for _, leaf := range leaves {
+		job.Steps = append(job.Steps, c.step(leaf, job.Container))
+	}
+	job.Outputs = c.jobOutputs(call.name, tmpl, scope, leaves)
+	// 只为被引用了输出的步骤设置 id
+	for i, leaf := range leaves {
+		if scope.used[leaf.name] {
+			job.Steps[i].ID = scope.steps[leaf.name]
+		}
+	}
+	if seconds := tmpl.ActiveDeadlineSeconds; seconds != nil && (tmpl.Steps != nil || tmpl.DAG != nil) {
+		job.TimeoutMinutes = timeoutMinutes(seconds.IntValue())
+	}
+	return named
+}
+
+// jobOutputs 把模板的输出参数转换为 Job 输出：可执行模板的输出来自唯一的 Step，steps/dag 模板的输出来自 valueFrom.parameter
+func (c *reverseConverter) jobOutputs(name string, tmpl *wfv1.Template, scope *reverseScope, leaves []leafStep) map[string]string {
+	outputs := map[string]string{}
+	for _, p := range tmpl.Outputs.Parameters {
+		switch {
+		case p.ValueFrom == nil:
+			continue
+		case p.ValueFrom.Path != "" && len(leaves) == 1 && leaves[0].template == tmpl:
+			scope.steps[name] = ghaID(name)
+			scope.used[name] = true
+			outputs[p.Name] = "${{ steps." + ghaID(name) + ".outputs." + p.Name + " }}"
+		case p.ValueFrom.Parameter != "":
+			outputs[p.Name] = c.text(p.ValueFrom.Parameter, scope)
+		}
+	}
+	if len(outputs) == 0 {
+		return nil
+	}
+	return outputs
+}
+
+// lookup 查找调用的模板，templateRef 引用的 WorkflowTemplate 不在本文件中，报告后返回 nil
+func (c *reverseConverter) lookup(call templateCall, where string) *wfv1.Template {
+	if tmpl := c.lookupQuiet(call); tmpl != nil {
+		return tmpl
+	}
+	if call.templateRef != nil {
+		c.warn(call.name, "templateRef %s/%s is not converted; inline the template or convert the WorkflowTemplate into a reusable workflow", call.templateRef.Name, call.templateRef.Template)
+	} else {
+		c.warn(where, "template %q not found", call.template)
+	}
+	return nil
+}
+
+func (c *reverseConverter) lookupQuiet(call templateCall) *wfv1.Template {
+	if call.inline != nil {
+		return call.inline
+	}
+	return c.templates[call.template]
+}
+
+// enter 计算被调用模板的 inputs.parameters：调用参数在调用方作用域中转换，默认值在模板自身作用域中转换
+func (c *reverseConverter) enter(tmpl *wfv1.Template, args wfv1.Arguments, caller *reverseScope) *reverseScope {
+	scope := caller.child(tmpl.Name)
+	for _, p := range tmpl.Inputs.Parameters {
+		if arg := args.GetParameterByName(p.Name); arg != nil && arg.Value != nil {
+			scope.inputs[p.Name] = c.text(arg.Value.String(), caller)
+			continue
+		}
+		switch {
+		case p.Value != nil:
+			scope.inputs[p.Name] = c.text(p.Value.String(), scope)
+		case p.Default != nil:
+			scope.inputs[p.Name] = c.text(p.Default.String(), scope)
+		default:
+			c.warn(tmpl.Name, "input parameter %q has no value", p.Name)
+		}
+	}
+	return scope
+}
+
+// leaves 把模板展开为按执行顺序排列的可执行模板；并行的步骤和 DAG 任务在 GHA Job 中串行执行
+func (c *reverseConverter) leaves(name string, tmpl *wfv1.Template, scope *reverseScope, condition string, continueOnError bool) []leafStep {
+	c.checkTemplate(tmpl, tmpl.Name)
+	var calls []templateCall
+	switch {
+	case tmpl.Steps != nil:
+		for _, group := range tmpl.Steps {
+			if len(group.Steps) > 1 {
+				c.warn(tmpl.Name, "parallel steps %s run sequentially in one job", stepNames(group.Steps))
+			}
+			for _, step := range group.Steps {
+				calls = append(calls, stepCall(step))
+			}
+		}
+	case tmpl.DAG != nil:
+		c.warn(tmpl.Name, "nested DAG runs sequentially in one job")
+		for _, task := range topologicalTasks(tmpl.DAG.Tasks) {
+			calls = append(calls, taskCall(task))
+		}
+	default:
+		return []leafStep{{name: name, template: tmpl, scope: scope, condition: condition, continueOnError: continueOnError}}
+	}
+
+	var leaves []leafStep
+	for _, call := range calls {
+		target := c.lookup(call, tmpl.Name)
+		if target == nil {
+			continue
+		}
+		when := condition
+		if call.when != "" {
+			when = andConditions(condition, c.condition(call.name, call.when, scope))
+		}
+		failOK := continueOnError || call.continueOn != nil && call.continueOn.Failed
+		if call.withParam != "" || call.sequence != nil {
+			c.warn(call.name, "withParam and withSequence are not converted inside a job; the step runs once")
+		}
+		if len(call.withItems) == 0 {
+			scope.steps[call.name] = ghaID(call.name)
+			leaves = append(leaves, c.leaves(call.name, target, c.enter(target, call.arguments, scope), when, failOK)...)
+			continue
+		}
+		// Job 内没有矩阵，withItems 按顺序展开为多个步骤
+		c.warn(call.name, "withItems inside a job is unrolled into %d sequential steps", len(call.withItems))
+		for i, item := range call.withItems {
+			itemScope := scope.child(scope.template)
+			itemScope.inputs = scope.inputs
+			itemScope.item = &ghaValue{literal: item.String()}
+			if m := item.GetMapVal(); m != nil {
+				itemScope.itemMap = map[string]string{}
+				for k, v := range m {
+					itemScope.itemMap[k] = v.String()
+				}
+			}
+			stepName := fmt.Sprintf("%s-%d", call.name, i)
+			leaves = append(leaves, c.leaves(stepName, target, c.enter(target, call.arguments, itemScope), when, failOK)...)
+		}
+	}
+	return leaves
+}
+
+// jobContainer 选择使用次数最多的镜像作为 Job 容器，次数相同时取靠后的步骤
+func (c *reverseConverter) jobContainer(leaves []leafStep) *GHAContainer {
+	counts := map[string]int{}
+	var best string
+	for _, leaf := range leaves {
+		image := c.image(leaf)
+		if image == "" {
+			continue
+		}
+		counts[image]++
+		if counts[image] >= counts[best] {
+			best = image
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	return &GHAContainer{Image: best}
+}
+
+func (c *reverseConverter) image(leaf leafStep) string {
+	if container := templateContainer(leaf.template); container != nil {
+		return c.text(container.Image, leaf.scope)
+	}
+	return ""
+}
+
+// --- Step ---
+
+// step 把可执行模板转换为 Step
+func (c *reverseConverter) step(leaf leafStep, jobContainer *GHAContainer) *GHAStep {
+	tmpl := leaf.template
+	step := &GHAStep{If: leaf.condition, ContinueOnError: leaf.continueOnError}
+	if name := tmpl.Metadata.Annotations[ghaStepAnnotation]; name != "" {
+		step.Name = name
+	} else {
+		step.Name = leaf.name
+	}
+	if seconds := tmpl.ActiveDeadlineSeconds; seconds != nil {
+		step.TimeoutMinutes = timeoutMinutes(seconds.IntValue())
+	}
+
+	var run string
+	var shell string
+	switch {
+	case tmpl.Script != nil:
+		if m := actionPlaceholderRegex.FindStringSubmatch(tmpl.Script.Source); m != nil {
+			// gha-converter 为 uses 步骤生成的占位脚本还原为 uses
+			step.Uses = m[1]
+			if step.Name == step.Uses {
+				step.Name = ""
+			}
+			return step
+		}
+		run = c.text(tmpl.Script.Source, leaf.scope)
+		shell = scriptShell(tmpl.Script.Command)
+	case tmpl.Container != nil:
+		command := append(append([]string{}, tmpl.Container.Command...), tmpl.Container.Args...)
+		for i := range command {
+			command[i] = c.text(command[i], leaf.scope)
+		}
+		image := c.text(tmpl.Container.Image, leaf.scope)
+		if jobContainer != nil && image != jobContainer.Image && !strings.Contains(image, "${{") {
+			// 与 Job 容器不同的镜像作为 Docker 容器 Action 运行
+			step.Uses = "docker://" + image
+			if len(tmpl.Container.Command) > 0 {
+				step.With = map[string]string{"entrypoint": command[0]}
+				command = command[1:]
+			}
+			if len(command) > 0 {
+				if step.With == nil {
+					step.With = map[string]string{}
+				}
+				step.With["args"] = shellJoin(command)
+			}
+			c.stepOutputs(leaf, step)
+			return step
+		}
+		if len(command) == 3 && (command[0] == "sh" || command[0] == "bash") && command[1] == "-c" {
+			shell, run = command[0], command[2]
+		} else if len(command) > 0 {
+			run = shellJoin(command)
+		} else {
+			c.warn(tmpl.Name, "container without command runs the image entrypoint, which has no run equivalent")
+		}
+	default:
+		c.warn(tmpl.Name, "template type %s has no GHA equivalent", tmpl.GetType())
+		run = fmt.Sprintf("echo %s\nexit 1", shellQuote("TODO: manually implement Argo "+string(tmpl.GetType())+" template "+tmpl.Name))
+	}
+
+	if jobContainer != nil {
+		if image := c.image(leaf); image != "" && image != jobContainer.Image {
+			c.warn(tmpl.Name, "runs in the job container %s instead of %s", jobContainer.Image, image)
+		}
+	}
+	if strings.TrimSpace(run) == strings.TrimSpace(step.Name) {
+		step.Name = ""
+	}
+	step.Run = BlockString(run)
+	step.Shell = shell
+	if container := templateContainer(tmpl); container != nil {
+		step.Env = c.env(container.Env, leaf.scope)
+		if dir := container.WorkingDir; dir != "" && dir != envValue(container.Env, "GITHUB_WORKSPACE") {
+			step.WorkingDirectory = c.text(dir, leaf.scope)
+		}
+	}
+	c.stepOutputs(leaf, step)
+	return step
+}
+
+// stepOutputs 把从文件读取的输出参数写入 $GITHUB_OUTPUT
+func (c *reverseConverter) stepOutputs(leaf leafStep, step *GHAStep) {
+	var lines []string
+	for _, p := range leaf.template.Outputs.Parameters {
+		if p.ValueFrom == nil || p.ValueFrom.Path == "" {
+			c.warn(leaf.template.Name, "output parameter %q is not read from a file and is not converted", p.Name)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("echo \"%s=$(cat %s)\" >> \"$GITHUB_OUTPUT\"", p.Name, shellQuote(p.ValueFrom.Path)))
+	}
+	if len(lines) == 0 {
+		return
+	}
+	if step.Uses != "" || step.Shell != "" && step.Shell != "sh" && step.Shell != "bash" {
+		c.warn(leaf.template.Name, "output parameters are only written to $GITHUB_OUTPUT for sh and bash steps")
+		return
+	}
+	run := strings.TrimRight(string(step.Run), "\n")
+	step.Run = BlockString(run + "\n" + strings.Join(lines, "\n") + "\n")
+}
+
+// env 转换容器环境变量，跳过 GHA runner 自带的变量
+func (c *reverseConverter) env(vars []corev1.EnvVar, scope *reverseScope) map[string]string {
+	env := map[string]string{}
+	for _, v := range vars {
+		if v.ValueFrom != nil || v.Name == "CI" || strings.HasPrefix(v.Name, "GITHUB_") || strings.HasPrefix(v.Name, "RUNNER_") {
+			continue
+		}
+		env[v.Name] = c.text(v.Value, scope)
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
+
+// hoistEnv 把所有 Step 都相同的环境变量提升到 Job，所有 Job 都相同的提升到工作流
+func hoistEnv(jobs GHAJobs) map[string]string {
+	var envs []map[string]string
+	for _, job := range jobs {
+		var stepEnvs []map[string]string
+		for _, step := range job.Job.Steps {
+			if step.Uses == "" || strings.HasPrefix(step.Uses, "docker://") {
+				stepEnvs = append(stepEnvs, step.Env)
+			}
+		}
+		job.Job.Env = commonEnv(stepEnvs)
+		for _, step := range job.Job.Steps {
+			step.Env = removeEnv(step.Env, job.Job.Env)
+		}
+		envs = append(envs, job.Job.Env)
+	}
+	env := commonEnv(envs)
+	for _, job := range jobs {
+		job.Job.Env = removeEnv(job.Job.Env, env)
+	}
+	return env
+}
+
+func commonEnv(envs []map[string]string) map[string]string {
+	if len(envs) == 0 {
+		return nil
+	}
+	common := map[string]string{}
+	for k, v := range envs[0] {
+		common[k] = v
+	}
+	for _, env := range envs[1:] {
+		for k, v := range common {
+			if other, ok := env[k]; !ok || other != v {
+				delete(common, k)
+			}
+		}
+	}
+	if len(common) == 0 {
+		return nil
+	}
+	return common
+}
+
+func removeEnv(env, common map[string]string) map[string]string {
+	for k := range common {
+		delete(env, k)
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
+
+// --- 变量和条件 ---
+
+// text 把含 Argo 变量的文本转换为含 GHA 表达式的文本；无法转换的变量原样保留并报告
+func (c *reverseConverter) text(s string, scope *reverseScope) string {
+	return argoTagRegex.ReplaceAllStringFunc(s, func(tag string) string {
+		value, ok := c.variable(argoTagRegex.FindStringSubmatch(tag)[1], scope)
+		if !ok {
+			return tag
+		}
+		return value.text()
+	})
+}
+
+// variable 转换一个 Argo 变量，name 不含 {{ }}
+func (c *reverseConverter) variable(name string, scope *reverseScope) (ghaValue, bool) {
+	if strings.HasPrefix(name, "=") {
+		expr, err := c.expression(name[1:], scope)
+		if err != nil {
+			c.warn(scope.template, "expression {{%s}} cannot be converted: %v", name, err)
+			return ghaValue{}, false
+		}
+		return ghaValue{expr: expr}, true
+	}
+	parts := strings.Split(name, ".")
+	switch {
+	case len(parts) == 3 && parts[0] == "workflow" && parts[1] == "parameters":
+		return c.workflowParameter(parts[2], scope), true
+	case name == "workflow.uid" || name == "workflow.name":
+		return ghaValue{expr: "github.run_id"}, true
+	case name == "pod.name":
+		return ghaValue{expr: "runner.name"}, true
+	case len(parts) == 3 && parts[0] == "inputs" && parts[1] == "parameters":
+		if value, ok := scope.inputs[parts[2]]; ok {
+			return valueOf(value), true
+		}
+		c.warn(scope.template, "input parameter %q is not declared", parts[2])
+		return ghaValue{}, false
+	case parts[0] == "item" && scope.item != nil:
+		if len(parts) == 1 {
+			return *scope.item, true
+		}
+		if scope.itemMap != nil {
+			if value, ok := scope.itemMap[strings.Join(parts[1:], ".")]; ok {
+				return ghaValue{literal: value}, true
+			}
+		}
+		if scope.item.expr != "" {
+			return ghaValue{expr: scope.item.expr + "." + strings.Join(parts[1:], ".")}, true
+		}
+	case len(parts) == 5 && parts[0] == "steps" && parts[2] == "outputs" && parts[3] == "parameters":
+		if id, ok := scope.steps[parts[1]]; ok {
+			scope.used[parts[1]] = true
+			return ghaValue{expr: "steps." + id + ".outputs." + parts[4]}, true
+		}
+	case len(parts) == 5 && parts[0] == "tasks" && parts[2] == "outputs" && parts[3] == "parameters":
+		if id, ok := scope.tasks[parts[1]]; ok {
+			c.outputRefs = append(c.outputRefs, jobOutputRef{where: scope.template, job: id, output: parts[4]})
+			return ghaValue{expr: "needs." + id + ".outputs." + parts[4]}, true
+		}
+	}
+	c.warn(scope.template, "variable {{%s}} has no GHA equivalent", name)
+	return ghaValue{}, false
+}
+
+// workflowParameter 转换工作流参数：github-* 参数对应 github 上下文，其他参数对应 workflow_dispatch 输入
+func (c *reverseConverter) workflowParameter(name string, scope *reverseScope) ghaValue {
+	if strings.HasPrefix(name, githubParameterPrefix) {
+		return ghaValue{expr: "github." + strings.ReplaceAll(strings.TrimPrefix(name, githubParameterPrefix), "-", "_")}
+	}
+	if c.wf.Spec.Arguments.GetParameterByName(name) == nil {
+		c.warn(scope.template, "workflow parameter %q is not declared", name)
+	}
+	return ghaValue{expr: "inputs." + name}
+}
+
+// condition 把 when 转换为 if；无法转换时报告并返回空，即总是执行
+func (c *reverseConverter) condition(where, when string, scope *reverseScope) string {
+	var (
+		result string
+		err    error
+	)
+	if s := strings.TrimSpace(when); strings.HasPrefix(s, "{{=") && strings.HasSuffix(s, "}}") && strings.Count(s, "}}") == 1 {
+		result, err = c.expression(s[3:len(s)-2], scope)
+	} else {
+		result, err = c.govaluate(s, scope)
+	}
+	if err != nil {
+		c.warn(where, "when %q cannot be converted to if, the job or step always runs: %v", when, err)
+		return ""
+	}
+	return result
+}
+
+// govaluate 转换 Argo 的 govaluate 条件：变量替换为 GHA 表达式，裸词和字符串转换为字符串字面量
+func (c *reverseConverter) govaluate(s string, scope *reverseScope) (string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		ch := s[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			i++
+		case ch == '\'' || ch == '"':
+			end := strings.IndexByte(s[i+1:], ch)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated string at offset %d", i)
+			}
+			value, err := c.word(s[i+1:i+1+end], scope, true)
+			if err != nil {
+				return "", err
+			}
+			tokens = append(tokens, value)
+			i += end + 2
+		case strings.HasPrefix(s[i:], "=~") || strings.HasPrefix(s[i:], "!~"):
+			return "", fmt.Errorf("regular expression operator %s has no GHA equivalent", s[i:i+2])
+		case strings.HasPrefix(s[i:], "==") || strings.HasPrefix(s[i:], "!=") || strings.HasPrefix(s[i:], ">=") ||
+			strings.HasPrefix(s[i:], "<=") || strings.HasPrefix(s[i:], "&&") || strings.HasPrefix(s[i:], "||"):
+			tokens = append(tokens, s[i:i+2])
+			i += 2
+		case strings.ContainsRune("()!<>", rune(ch)):
+			tokens = append(tokens, string(ch))
+			i++
+		default:
+			end := i
+			for end < len(s) && !strings.ContainsRune(" \t\n()!<>=&|'\"", rune(s[end])) {
+				if strings.HasPrefix(s[end:], "{{") {
+					close := strings.Index(s[end:], "}}")
+					if close < 0 {
+						return "", fmt.Errorf("unterminated variable at offset %d", end)
+					}
+					end += close + 2
+					continue
+				}
+				end++
+			}
+			if end == i {
+				return "", fmt.Errorf("unexpected %q at offset %d", ch, i)
+			}
+			value, err := c.word(s[i:end], scope, false)
+			if err != nil {
+				return "", err
+			}
+			tokens = append(tokens, value)
+			i = end
+		}
+	}
+	result := strings.Join(tokens, " ")
+	result = strings.ReplaceAll(strings.ReplaceAll(result, "( ", "("), " )", ")")
+	return strings.ReplaceAll(result, "! ", "!"), nil
+}
+
+// word 转换条件中的一个操作数；包含变量和文本的操作数转换为 format()
+func (c *reverseConverter) word(s string, scope *reverseScope, quoted bool) (string, error) {
+	var (
+		format strings.Builder
+		args   []string
+		last   int
+	)
+	for _, loc := range argoTagRegex.FindAllStringSubmatchIndex(s, -1) {
+		format.WriteString(escapeFormat(s[last:loc[0]]))
+		last = loc[1]
+		value, ok := c.variable(s[loc[2]:loc[3]], scope)
+		if !ok {
+			return "", fmt.Errorf("variable %s cannot be converted", s[loc[0]:loc[1]])
+		}
+		if value.expr == "" {
+			format.WriteString(escapeFormat(value.literal))
+			continue
+		}
+		format.WriteString("{" + strconv.Itoa(len(args)) + "}")
+		args = append(args, value.expr)
+	}
+	format.WriteString(escapeFormat(s[last:]))
+
+	switch {
+	case len(args) == 0:
+		literal := strings.NewReplacer("{{", "{", "}}", "}").Replace(format.String())
+		if !quoted {
+			if _, err := strconv.ParseFloat(literal, 64); err == nil || literal == "true" || literal == "false" {
+				return literal, nil
+			}
+		}
+		return ghaString(literal), nil
+	case format.String() == "{0}":
+		return args[0], nil
+	default:
+		return "format(" + ghaString(format.String()) + ", " + strings.Join(args, ", ") + ")", nil
+	}
+}
+
+// expression 转换 Argo 的 expr 表达式，支持 gha-converter 生成的写法：比较、逻辑运算、contains 等运算符和 lower/string 等转换
+func (c *reverseConverter) expression(src string, scope *reverseScope) (string, error) {
+	tree, err := parser.Parse(src)
+	if err != nil {
+		return "", err
+	}
+	return c.exprNode(tree.Node, scope, true)
+}
+
+func (c *reverseConverter) exprNode(node ast.Node, scope *reverseScope, top bool) (string, error) {
+	switch n := node.(type) {
+	case *ast.NilNode:
+		return "null", nil
+	case *ast.BoolNode:
+		return strconv.FormatBool(n.Value), nil
+	case *ast.IntegerNode:
+		return strconv.Itoa(n.Value), nil
+	case *ast.FloatNode:
+		return strconv.FormatFloat(n.Value, 'g', -1, 64), nil
+	case *ast.StringNode:
+		return ghaString(n.Value), nil
+	case *ast.IdentifierNode, *ast.MemberNode:
+		name, ok := memberPath(node)
+		if !ok {
+			return "", fmt.Errorf("unsupported member access %s", node.String())
+		}
+		value, ok := c.variable(name, scope)
+		if !ok {
+			return "", fmt.Errorf("variable %s cannot be converted", name)
+		}
+		return value.operand(), nil
+	case *ast.UnaryNode:
+		operand, err := c.exprNode(n.Node, scope, false)
+		if err != nil {
+			return "", err
+		}
+		switch n.Operator {
+		case "!", "not":
+			return "!" + operand, nil
+		case "-":
+			return "-" + operand, nil
+		}
+		return "", fmt.Errorf("unsupported operator %s", n.Operator)
+	case *ast.BinaryNode:
+		left, err := c.exprNode(n.Left, scope, false)
+		if err != nil {
+			return "", err
+		}
+		right, err := c.exprNode(n.Right, scope, false)
+		if err != nil {
+			return "", err
+		}
+		var result string
+		switch n.Operator {
+		case "==", "!=", "<", ">", "<=", ">=", "&&", "||":
+			result = left + " " + n.Operator + " " + right
+		case "and":
+			result = left + " && " + right
+		case "or":
+			result = left + " || " + right
+		case "contains", "startsWith", "endsWith":
+			return n.Operator + "(" + left + ", " + right + ")", nil
+		case "in":
+			return "contains(" + right + ", " + left + ")", nil
+		default:
+			return "", fmt.Errorf("operator %s has no GHA equivalent", n.Operator)
+		}
+		if top {
+			return result, nil
+		}
+		return "(" + result + ")", nil
+	case *ast.ConditionalNode:
+		cond, err := c.exprNode(n.Cond, scope, false)
+		if err != nil {
+			return "", err
+		}
+		exp1, err := c.exprNode(n.Exp1, scope, false)
+		if err != nil {
+			return "", err
+		}
+		exp2, err := c.exprNode(n.Exp2, scope, false)
+		if err != nil {
+			return "", err
+		}
+		// gha-converter 把非布尔值的 a || b、a && b 转换为三元表达式
+		var result string
+		switch {
+		case exp1 == truthyOperand(cond):
+			result = exp1 + " || " + exp2
+		case exp2 == truthyOperand(cond):
+			result = exp2 + " && " + exp1
+		default:
+			c.warn(scope.template, "ternary %s is converted to %s && %s || %s, which differs when %s is falsy", node.String(), cond, exp1, exp2, exp1)
+			result = cond + " && " + exp1 + " || " + exp2
+		}
+		if top {
+			return result, nil
+		}
+		return "(" + result + ")", nil
+	case *ast.BuiltinNode:
+		switch {
+		case (n.Name == "lower" || n.Name == "string" || n.Name == "float" || n.Name == "int") && len(n.Arguments) == 1:
+			// GHA 的比较不区分大小写并自动转换类型
+			return c.exprNode(n.Arguments[0], scope, top)
+		case (n.Name == "toJSON" || n.Name == "fromJSON") && len(n.Arguments) == 1:
+			arg, err := c.exprNode(n.Arguments[0], scope, true)
+			if err != nil {
+				return "", err
+			}
+			return n.Name + "(" + arg + ")", nil
+		}
+		return "", fmt.Errorf("function %s has no GHA equivalent", n.Name)
+	}
+	return "", fmt.Errorf("unsupported expression %s", node.String())
+}
+
+// truthyOperand 去掉 gha-converter 为非布尔值生成的真值判断 (string(x) != ”)
+func truthyOperand(cond string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(cond, "("), " != '')")
+}
+
+// memberPath 把 a.b['c'] 形式的成员访问还原为 Argo 变量名 a.b.c
+func memberPath(node ast.Node) (string, bool) {
+	switch n := node.(type) {
+	case *ast.IdentifierNode:
+		return n.Value, true
+	case *ast.MemberNode:
+		base, ok := memberPath(n.Node)
+		if !ok {
+			return "", false
+		}
+		property, ok := n.Property.(*ast.StringNode)
+		if !ok {
+			return "", false
+		}
+		return base + "." + property.Value, true
+	}
+	return "", false
+}
+
+// --- 辅助函数 ---
+
+// taskDependencies 返回 DAG 任务依赖的任务：dependencies 和 depends 表达式中引用的任务
+func taskDependencies(task wfv1.DAGTask) []string {
+	deps := append([]string{}, task.Dependencies...)
+	if task.Depends != "" {
+		for _, field := range strings.FieldsFunc(task.Depends, func(r rune) bool {
+			return strings.ContainsRune(" \t\n()&|!", r)
+		}) {
+			deps = appendUnique(deps, strings.SplitN(field, ".", 2)[0])
+		}
+	}
+	return deps
+}
+
+// simpleDepends 判断 depends 是否只是任务成功的合取，如 "a && b.Succeeded"
+func simpleDepends(depends string) bool {
+	for _, field := range strings.FieldsFunc(depends, func(r rune) bool { return strings.ContainsRune(" \t\n()", r) }) {
+		if field == "&&" {
+			continue
+		}
+		if strings.ContainsAny(field, "|!") || strings.Contains(field, ".") && !strings.HasSuffix(field, ".Succeeded") {
+			return false
+		}
+	}
+	return true
+}
+
+// topologicalTasks 按依赖顺序排列 DAG 任务，同层保持声明顺序；有循环时剩余任务按声明顺序追加
+func topologicalTasks(tasks []wfv1.DAGTask) []wfv1.DAGTask {
+	done := map[string]bool{}
+	var ordered []wfv1.DAGTask
+	for len(ordered) < len(tasks) {
+		progressed := false
+		for _, task := range tasks {
+			if done[task.Name] {
+				continue
+			}
+			ready := true
+			for _, dep := range taskDependencies(task) {
+				if !done[dep] {
+					ready = false
+				}
+			}
+			if ready {
+				done[task.Name] = true
+				ordered = append(ordered, task)
+				progressed = true
+			}
+		}
+		if !progressed {
+			for _, task := range tasks {
+				if !done[task.Name] {
+					done[task.Name] = true
+					ordered = append(ordered, task)
+				}
+			}
+		}
+	}
+	return ordered
+}
+
+func stepNames(steps []wfv1.WorkflowStep) string {
+	names := make([]string, 0, len(steps))
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func andConditions(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	}
+	return "(" + a + ") && (" + b + ")"
+}
+
+// templateContainer 返回 container 或 script 模板的容器
+func templateContainer(tmpl *wfv1.Template) *corev1.Container {
+	switch {
+	case tmpl.Container != nil:
+		return tmpl.Container
+	case tmpl.Script != nil:
+		return &tmpl.Script.Container
+	}
+	return nil
+}
+
+// scriptShell 根据 script 的 command 选择 GHA shell
+func scriptShell(command []string) string {
+	if len(command) == 0 {
+		return ""
+	}
+	switch name := path.Base(command[0]); name {
+	case "bash", "sh", "pwsh", "powershell":
+		return name
+	case "python", "python3":
+		return "python"
+	default:
+		return strings.Join(command, " ") + " {0}"
+	}
+}
+
+func timeoutMinutes(seconds int) int64 {
+	return int64((seconds + 59) / 60)
+}
+
+func envValue(vars []corev1.EnvVar, name string) string {
+	for _, v := range vars {
+		if v.Name == name {
+			return v.Value
+		}
+	}
+	return ""
+}
+
+// ghaID 把 Argo 名称转换为合法的 GHA Job/Step ID
+func ghaID(name string) string {
+	id := ghaIDRegex.ReplaceAllString(name, "-")
+	if id == "" || !(id[0] == '_' || id[0] >= 'a' && id[0] <= 'z' || id[0] >= 'A' && id[0] <= 'Z') {
+		id = "_" + id
+	}
+	return id
+}
+
+// ghaString 返回 GHA 表达式中的字符串字面量
+func ghaString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func escapeFormat(s string) string {
+	return strings.NewReplacer("{", "{{", "}", "}}").Replace(s)
+}
+
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func shellJoin(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		// 只有一个 GHA 表达式的参数在运行前替换，保持原样
+		if valueOf(word).expr != "" {
+			quoted[i] = word
+			continue
+		}
+		quoted[i] = shellQuote(word)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,272 @@
+package argowf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// --- Argo Workflow → GitHub Actions ---
+
+// convertDoc 解析 YAML 并反向转换，返回转换结果和警告文本
+func convertDoc(t *testing.T, doc string) (*GHAWorkflow, []string) {
+	t.Helper()
+	resource, err := ParseResource([]byte(doc))
+	if err != nil {
+		t.Fatalf("ParseResource: %v", err)
+	}
+	gha, warnings, err := ConvertArgoToGHA(resource.AsWorkflow())
+	if err != nil {
+		t.Fatalf("ConvertArgoToGHA: %v", err)
+	}
+	var messages []string
+	for _, w := range warnings {
+		messages = append(messages, w.String())
+	}
+	return gha, messages
+}
+
+const releaseWorkflow = `apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: release-
+spec:
+  entrypoint: main
+  arguments:
+    parameters:
+      - name: target
+        value: staging
+        enum: [staging, prod]
+      - name: version
+      - name: github-ref
+        value: refs/heads/main
+  templates:
+    - name: main
+      dag:
+        tasks:
+          - name: build
+            template: build
+            arguments:
+              parameters:
+                - name: arch
+                  value: "{{item}}"
+            withItems: [amd64, arm64]
+          - name: test
+            dependencies: [build]
+            template: test
+            when: "{{workflow.parameters.target}} == prod"
+          - name: deploy
+            depends: "test && build"
+            template: deploy
+            when: "{{=workflow.parameters['github-ref'] == 'refs/heads/main'}}"
+            arguments:
+              parameters:
+                - name: version
+                  value: "{{tasks.test.outputs.parameters.version}}"
+    - name: build
+      inputs:
+        parameters:
+          - name: arch
+      container:
+        image: golang:1.22
+        command: [go, build]
+        args: ["-o", "bin/{{inputs.parameters.arch}}", "./..."]
+        env:
+          - name: GOARCH
+            value: "{{inputs.parameters.arch}}"
+    - name: test
+      outputs:
+        parameters:
+          - name: version
+            valueFrom:
+              path: /tmp/version
+      script:
+        image: golang:1.22
+        command: [bash]
+        source: |
+          go test ./...
+          echo v1 > /tmp/version
+    - name: deploy
+      inputs:
+        parameters:
+          - name: version
+      retryStrategy:
+        limit: 2
+      steps:
+        - - name: push
+            template: push
+            arguments:
+              parameters:
+                - name: version
+                  value: "{{inputs.parameters.version}}"
+        - - name: notify
+            template: notify
+            when: "{{steps.push.status}} == Succeeded"
+    - name: push
+      inputs:
+        parameters:
+          - name: version
+      script:
+        image: alpine
+        command: [sh]
+        source: echo push {{inputs.parameters.version}} {{workflow.parameters.version}}
+    - name: notify
+      container:
+        image: alpine
+        command: [echo, done]
+`
+
+func TestConvertArgoToGHA(t *testing.T) {
+	gha, warnings := convertDoc(t, releaseWorkflow)
+
+	if gha.Name != "release" {
+		t.Errorf("name = %q, want release", gha.Name)
+	}
+
+	// 工作流参数转换为 workflow_dispatch 输入：enum 为 choice，没有值为必填，github-* 由 github 上下文提供
+	wantInputs := GHAInputs{
+		{ID: "target", Default: "staging", Type: "choice", Options: []string{"staging", "prod"}},
+		{ID: "version", Required: true},
+	}
+	if !reflect.DeepEqual(gha.On.WorkflowDispatch.Inputs, wantInputs) {
+		t.Errorf("inputs = %+v, want %+v", gha.On.WorkflowDispatch.Inputs, wantInputs)
+	}
+
+	jobs := map[string]*GHAJob{}
+	var ids []string
+	for _, job := range gha.Jobs {
+		jobs[job.ID] = job.Job
+		ids = append(ids, job.ID)
+	}
+	if want := []string{"build", "test", "deploy"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("jobs = %v, want %v", ids, want)
+	}
+
+	// withItems 转换为矩阵，模板参数和容器参数引用 matrix.item
+	build := jobs["build"]
+	if build.Strategy == nil || !reflect.DeepEqual(build.Strategy.Matrix["item"], []interface{}{"amd64", "arm64"}) {
+		t.Errorf("build strategy = %+v, want a matrix over item", build.Strategy)
+	}
+	if build.Env["GOARCH"] != "${{ matrix.item }}" {
+		t.Errorf("build env = %v, want GOARCH from matrix.item", build.Env)
+	}
+	if len(build.Steps) != 1 || build.Steps[0].Run != "go build -o 'bin/${{ matrix.item }}' ./..." {
+		t.Errorf("build steps = %+v", build.Steps)
+	}
+
+	// dependencies 和 depends 转换为 needs，when 转换为 if
+	test, deploy := jobs["test"], jobs["deploy"]
+	if !reflect.DeepEqual([]string(test.Needs), []string{"build"}) || test.If != "inputs.target == 'prod'" {
+		t.Errorf("test needs = %v, if = %q", test.Needs, test.If)
+	}
+	if !reflect.DeepEqual([]string(deploy.Needs), []string{"test", "build"}) || deploy.If != "github.ref == 'refs/heads/main'" {
+		t.Errorf("deploy needs = %v, if = %q", deploy.Needs, deploy.If)
+	}
+
+	// 从文件读取的输出参数写入 $GITHUB_OUTPUT，成为 Job 输出；其他 Job 通过 needs 引用
+	if test.Outputs["version"] != "${{ steps.test.outputs.version }}" {
+		t.Errorf("test outputs = %v", test.Outputs)
+	}
+	if step := test.Steps[0]; step.ID != "test" || step.Shell != "bash" || !strings.HasSuffix(string(step.Run), `echo "version=$(cat /tmp/version)" >> "$GITHUB_OUTPUT"`+"\n") {
+		t.Errorf("test step = %+v", step)
+	}
+
+	// steps 模板展开为 Step，调用参数在调用方作用域中转换
+	if len(deploy.Steps) != 2 {
+		t.Fatalf("deploy steps = %+v, want push and notify", deploy.Steps)
+	}
+	if push := deploy.Steps[0]; push.Run != "echo push ${{ needs.test.outputs.version }} ${{ inputs.version }}" || push.Shell != "sh" {
+		t.Errorf("push step = %+v", push)
+	}
+	if notify := deploy.Steps[1]; notify.If != "" || notify.Run != "echo done" {
+		t.Errorf("notify step = %+v, want no if", notify)
+	}
+
+	wantWarnings := []string{
+		"deploy: retryStrategy is not converted",
+		"deploy: variable {{steps.push.status}} has no GHA equivalent",
+		`notify: when "{{steps.push.status}} == Succeeded" cannot be converted to if, the job or step always runs: variable {{steps.push.status}} cannot be converted`,
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("warnings:\n%s\nwant:\n%s", strings.Join(warnings, "\n"), strings.Join(wantWarnings, "\n"))
+	}
+}
+
+func TestConvertArgoToGHAConditions(t *testing.T) {
+	tests := []struct {
+		when     string
+		want     string
+		wantWarn string // 为空表示不应产生警告
+	}{
+		{when: "true", want: "true"},
+		{when: "{{workflow.parameters.target}} == prod", want: "inputs.target == 'prod'"},
+		{when: "{{workflow.parameters.target}} != 'prod' && {{workflow.parameters.flag}} == true", want: "inputs.target != 'prod' && inputs.flag == true"},
+		{when: "{{workflow.parameters.count}} > 2 || !{{workflow.parameters.flag}}", want: "inputs.count > 2 || !inputs.flag"},
+		{when: "{{workflow.parameters.target}}-x == prod-x", want: "format('{0}-x', inputs.target) == 'prod-x'"},
+		{when: "{{=workflow.parameters['github-ref'] == 'refs/heads/main'}}", want: "github.ref == 'refs/heads/main'"},
+		{when: "{{=(lower(string(workflow.parameters['target'])) contains 'prod')}}", want: "contains(inputs.target, 'prod')"},
+		{when: "{{=(string(workflow.parameters['github-sha']) != '')}}", want: "github.sha != ''"},
+		{when: "{{workflow.parameters.target}} =~ 'p.*'", wantWarn: "regular expression operator =~ has no GHA equivalent"},
+		{when: "{{=sprig.upper(workflow.parameters.target) == 'X'}}", wantWarn: "unsupported expression sprig.upper(workflow.parameters.target)"},
+		{when: "{{workflow.status}} == Succeeded", wantWarn: "cannot be converted to if, the job or step always runs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.when, func(t *testing.T) {
+			gha, warnings := convertDoc(t, fmt.Sprintf(`apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  name: conditions
+spec:
+  entrypoint: main
+  arguments:
+    parameters:
+      - name: target
+      - name: flag
+      - name: count
+  templates:
+    - name: main
+      steps:
+        - - name: check
+            template: check
+            when: %q
+    - name: check
+      container:
+        image: alpine
+        command: [echo, ok]
+`, tt.when))
+			if got := gha.Jobs[0].Job.Steps[0].If; got != tt.want {
+				t.Errorf("if = %q, want %q", got, tt.want)
+			}
+			joined := strings.Join(warnings, "\n")
+			if tt.wantWarn == "" && joined != "" {
+				t.Errorf("unexpected warnings:\n%s", joined)
+			}
+			if tt.wantWarn != "" && !strings.Contains(joined, tt.wantWarn) {
+				t.Errorf("warnings:\n%s\nwant one containing %q", joined, tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestConvertArgoToGHAErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{name: "missing entrypoint", spec: "entrypoint: missing\n  templates:\n    - name: main\n      container:\n        image: alpine\n", wantErr: `entrypoint "missing" not found`},
+		{name: "workflowTemplateRef", spec: "workflowTemplateRef:\n    name: library\n", wantErr: `convert the WorkflowTemplate instead`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource, err := ParseResource([]byte("apiVersion: argoproj.io/v1alpha1\nkind: Workflow\nmetadata:\n  name: w\nspec:\n  " + tt.spec))
+			if err != nil {
+				t.Fatalf("ParseResource: %v", err)
+			}
+			if _, _, err := ConvertArgoToGHA(resource.AsWorkflow()); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package argowf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// --- 往返转换检查 ---
+
+// JobGraph 是工作流的 Job 依赖图，Job ID 统一为小写，GHA 的 needs 不区分大小写
+type JobGraph map[string][]string
+
+// ParseJobGraph 从 GHA 工作流 YAML 中读取 Job 和 needs，不要求工作流的其他部分能被完整解析
+func ParseJobGraph(data []byte) (JobGraph, error) {
+	var doc struct {
+		Jobs yaml.Node `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal GHA workflow: %w", err)
+	}
+	if doc.Jobs.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("GHA workflow has no jobs mapping")
+	}
+	graph := JobGraph{}
+	for i := 0; i+1 < len(doc.Jobs.Content); i += 2 {
+		id := strings.ToLower(doc.Jobs.Content[i].Value)
+		graph[id] = nil
+		job := doc.Jobs.Content[i+1]
+		for j := 0; j+1 < len(job.Content); j += 2 {
+			if job.Content[j].Value != "needs" {
+				continue
+			}
+			var needs []string
+			switch needsNode := job.Content[j+1]; needsNode.Kind {
+			case yaml.ScalarNode:
+				needs = []string{needsNode.Value}
+			case yaml.SequenceNode:
+				if err := needsNode.Decode(&needs); err != nil {
+					return nil, fmt.Errorf("job %s: invalid needs: %w", id, err)
+				}
+			}
+			for _, need := range needs {
+				graph[id] = append(graph[id], strings.ToLower(need))
+			}
+		}
+	}
+	return graph, nil
+}
+
+// JobGraph 返回转换结果的 Job 依赖图
+func (w *GHAWorkflow) JobGraph() JobGraph {
+	graph := JobGraph{}
+	for _, job := range w.Jobs {
+		id := strings.ToLower(job.ID)
+		graph[id] = nil
+		for _, need := range job.Job.Needs {
+			graph[id] = append(graph[id], strings.ToLower(need))
+		}
+	}
+	return graph
+}
+
+// ancestors 返回 Job 直接和间接依赖的所有 Job；比较传递闭包，冗余的 needs 不算差异
+func (g JobGraph) ancestors(id string) []string {
+	seen := map[string]bool{}
+	var visit func(string)
+	visit = func(id string) {
+		for _, need := range g[id] {
+			if !seen[need] {
+				seen[need] = true
+				visit(need)
+			}
+		}
+	}
+	visit(id)
+	return sortedKeys(seen)
+}
+
+// CompareJobGraphs 比较两个工作流的 Job 集合和依赖关系，返回差异描述；语义相同时返回空
+func CompareJobGraphs(want, got JobGraph) []string {
+	var diffs []string
+	ids := map[string]bool{}
+	for id := range want {
+		ids[id] = true
+	}
+	for id := range got {
+		ids[id] = true
+	}
+	for _, id := range sortedKeys(ids) {
+		_, inWant := want[id]
+		_, inGot := got[id]
+		switch {
+		case !inGot:
+			diffs = append(diffs, fmt.Sprintf("job %s is missing", id))
+		case !inWant:
+			diffs = append(diffs, fmt.Sprintf("job %s is unexpected", id))
+		default:
+			wantAncestors, gotAncestors := want.ancestors(id), got.ancestors(id)
+			if strings.Join(wantAncestors, ",") != strings.Join(gotAncestors, ",") {
+				diffs = append(diffs, fmt.Sprintf("job %s depends on [%s], want [%s]", id, strings.Join(gotAncestors, " "), strings.Join(wantAncestors, " ")))
+			}
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
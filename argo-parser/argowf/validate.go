@@ -0,0 +1,584 @@
+package argowf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow"
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// --- 离线校验 ---
+
+// MaxCronWorkflowNameLength 是 CronWorkflow 名称的长度限制：创建的 Workflow 在名称后追加 11 位时间戳，
+// 结果仍需是 63 个字符以内的标签
+const MaxCronWorkflowNameLength = 52
+
+// maxFieldNameLength 是 Argo 对模板、步骤和任务名称的长度限制
+const maxFieldNameLength = 128
+
+var (
+	// dns1123Regex 是 DNS-1123 标签的字符规则：小写字母、数字和 -，首尾为字母或数字
+	dns1123Regex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	// variableRegex 匹配 {{...}} 变量，不含 {{=expr}} 表达式
+	variableRegex = regexp.MustCompile(`\{\{\s*([^={}\s][^{}]*?)\s*\}\}`)
+	// expressionRegex 匹配 {{=expr}} 表达式
+	expressionRegex = regexp.MustCompile(`\{\{=([^{}]*)\}\}`)
+	// expressionRefRegex 匹配表达式中以下标访问的参数、步骤和任务，如 inputs.parameters['x']、tasks['build']
+	expressionRefRegex = regexp.MustCompile(`\b(inputs\.parameters|workflow\.parameters|steps|tasks)\[\s*['"]([^'"]+)['"]\s*\]`)
+	// dependsTokenRegex 匹配 depends 表达式中的任务引用，如 build、build.Succeeded
+	dependsTokenRegex = regexp.MustCompile(`[A-Za-z0-9_-]+(\.[A-Za-z]+)?`)
+)
+
+// dependsResults 是 depends 表达式中任务结果的合法取值
+var dependsResults = map[string]bool{
+	"Succeeded": true, "Failed": true, "Errored": true, "Skipped": true, "Omitted": true,
+	"Daemoned": true, "AnySucceeded": true, "AllFailed": true,
+}
+
+// ValidationIssue 是校验发现的一个问题
+type ValidationIssue struct {
+	Template string `json:"template,omitempty"` // 所在模板，工作流级为空
+	Field    string `json:"field,omitempty"`    // 所在字段，如 dag.tasks[deploy].dependencies
+	Message  string `json:"message"`
+}
+
+func (i ValidationIssue) String() string {
+	var where []string
+	if i.Template != "" {
+		where = append(where, "template "+i.Template)
+	}
+	if i.Field != "" {
+		where = append(where, i.Field)
+	}
+	if len(where) == 0 {
+		return i.Message
+	}
+	return strings.Join(where, " ") + ": " + i.Message
+}
+
+// TemplateLibrary 是从本地目录加载的 WorkflowTemplate 和 ClusterWorkflowTemplate，用于离线解析 templateRef
+type TemplateLibrary struct {
+	namespaced map[string]*wfv1.WorkflowSpec
+	cluster    map[string]*wfv1.WorkflowSpec
+}
+
+// LoadTemplateLibrary 递归加载目录中 .yaml/.yml 文件里的 WorkflowTemplate 和 ClusterWorkflowTemplate，其他资源被跳过
+func LoadTemplateLibrary(dir string) (*TemplateLibrary, error) {
+	library := &TemplateLibrary{namespaced: map[string]*wfv1.WorkflowSpec{}, cluster: map[string]*wfv1.WorkflowSpec{}}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read YAML file: %w", err)
+		}
+		docs, err := splitYAMLDocuments(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, doc := range docs {
+			resource, err := ParseResource(doc)
+			if errors.Is(err, ErrUnsupportedResource) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			switch r := resource.(type) {
+			case WorkflowTemplateResource:
+				library.namespaced[r.Name] = r.WorkflowSpec()
+			case ClusterWorkflowTemplateResource:
+				library.cluster[r.Name] = r.WorkflowSpec()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return library, nil
+}
+
+// spec 返回 WorkflowTemplate 或 ClusterWorkflowTemplate 的定义；library 为 nil 时所有引用都无法解析
+func (l *TemplateLibrary) spec(name string, clusterScope bool) (*wfv1.WorkflowSpec, error) {
+	kind := workflow.WorkflowTemplateKind
+	if clusterScope {
+		kind = workflow.ClusterWorkflowTemplateKind
+	}
+	if l == nil {
+		return nil, fmt.Errorf("%s %q cannot be resolved without a template directory", kind, name)
+	}
+	specs := l.namespaced
+	if clusterScope {
+		specs = l.cluster
+	}
+	spec, ok := specs[name]
+	if !ok {
+		return nil, fmt.Errorf("%s %q not found in the template directory", kind, name)
+	}
+	return spec, nil
+}
+
+// template 解析 templateRef
+func (l *TemplateLibrary) template(ref *wfv1.TemplateRef) (*wfv1.Template, error) {
+	spec, err := l.spec(ref.Name, ref.ClusterScope)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl := findTemplate(spec, ref.Template); tmpl != nil {
+		return tmpl, nil
+	}
+	return nil, fmt.Errorf("template %q not found in %s", ref.Template, ref.Name)
+}
+
+// ValidateResource 离线校验 Argo 资源：入口模板存在、模板引用可以解析、DAG 依赖存在且无循环、
+// 变量引用的参数和任务已声明、名称符合 DNS-1123。library 用于解析 templateRef，可以为 nil
+func ValidateResource(resource ArgoResource, library *TemplateLibrary) []ValidationIssue {
+	v := &validator{library: library, templates: map[string]*wfv1.Template{}}
+	spec := resource.WorkflowSpec()
+	kind := resource.ResourceKind()
+
+	// 1. 资源名称
+	switch name, generateName := resource.GetName(), resource.GetGenerateName(); {
+	case name != "":
+		v.checkSubdomain("", "metadata.name", name)
+		if kind == workflow.CronWorkflowKind && len(name) > MaxCronWorkflowNameLength {
+			v.add("", "metadata.name", "%q is longer than %d characters, the limit for CronWorkflow names", name, MaxCronWorkflowNameLength)
+		}
+	case generateName != "" && kind == workflow.WorkflowKind:
+		v.checkSubdomain("", "metadata.generateName", strings.TrimSuffix(generateName, "-"))
+	default:
+		v.add("", "metadata.name", "name is required")
+	}
+
+	// 2. workflowTemplateRef 引用的模板和参数可以在本工作流中使用
+	parameters := map[string]bool{}
+	entrypoint := spec.Entrypoint
+	if ref := spec.WorkflowTemplateRef; ref != nil {
+		refSpec, err := library.spec(ref.Name, ref.ClusterScope)
+		if err != nil {
+			v.add("", "spec.workflowTemplateRef", "%v", err)
+		} else {
+			for i := range refSpec.Templates {
+				v.templates[refSpec.Templates[i].Name] = &refSpec.Templates[i]
+			}
+			for _, p := range refSpec.Arguments.Parameters {
+				parameters[p.Name] = true
+			}
+			if entrypoint == "" {
+				entrypoint = refSpec.Entrypoint
+			}
+		}
+	}
+	for _, p := range spec.Arguments.Parameters {
+		parameters[p.Name] = true
+	}
+	v.parameters = parameters
+
+	// 3. 模板名称；本工作流的模板覆盖 workflowTemplateRef 中的同名模板
+	declared := map[string]bool{}
+	for i := range spec.Templates {
+		tmpl := &spec.Templates[i]
+		v.checkFieldName(tmpl.Name, "", fmt.Sprintf("spec.templates[%d].name", i), tmpl.Name)
+		if declared[tmpl.Name] {
+			v.add(tmpl.Name, "name", "duplicate template name")
+		}
+		declared[tmpl.Name] = true
+		v.templates[tmpl.Name] = tmpl
+	}
+
+	// 4. 入口模板；WorkflowTemplate 和 ClusterWorkflowTemplate 可以只提供模板库
+	switch {
+	case entrypoint != "":
+		if _, ok := v.templates[entrypoint]; !ok {
+			v.add("", "spec.entrypoint", "entrypoint template %q not found", entrypoint)
+		}
+	case kind == workflow.WorkflowKind || kind == workflow.CronWorkflowKind:
+		v.add("", "spec.entrypoint", "entrypoint is required")
+	}
+	if spec.OnExit != "" {
+		if _, ok := v.templates[spec.OnExit]; !ok {
+			v.add("", "spec.onExit", "onExit template %q not found", spec.OnExit)
+		}
+	}
+
+	// 5. 各模板
+	for i := range spec.Templates {
+		v.validateTemplate(&spec.Templates[i], spec.Templates[i].Name)
+	}
+	return v.issues
+}
+
+// validator 保存一次校验的状态
+type validator struct {
+	library    *TemplateLibrary
+	templates  map[string]*wfv1.Template
+	parameters map[string]bool
+	issues     []ValidationIssue
+}
+
+func (v *validator) add(template, field, format string, args ...interface{}) {
+	v.issues = append(v.issues, ValidationIssue{Template: template, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) checkSubdomain(template, field, name string) {
+	for _, message := range validation.IsDNS1123Subdomain(name) {
+		v.add(template, field, "%q is not a valid DNS-1123 name: %s", name, message)
+	}
+}
+
+// checkFieldName 检查模板、步骤和任务名称：DNS-1123 标签的字符规则，长度不超过 Argo 的限制
+func (v *validator) checkFieldName(name, template, field, label string) {
+	switch {
+	case name == "":
+		v.add(template, field, "name is required")
+	case len(name) > maxFieldNameLength:
+		v.add(template, field, "%q is longer than %d characters", label, maxFieldNameLength)
+	case !dns1123Regex.MatchString(name):
+		v.add(template, field, "%q is not a valid DNS-1123 name: must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character", label)
+	}
+}
+
+// resolve 解析步骤或任务引用的模板，无法解析时报告并返回 nil
+func (v *validator) resolve(call templateCall, template, field string) *wfv1.Template {
+	set := 0
+	for _, ok := range []bool{call.template != "", call.templateRef != nil, call.inline != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		v.add(template, field, "exactly one of template, templateRef or inline must be set")
+		return nil
+	}
+	switch {
+	case call.inline != nil:
+		v.validateTemplate(call.inline, template+"/"+call.name)
+		return call.inline
+	case call.templateRef != nil:
+		tmpl, err := v.library.template(call.templateRef)
+		if err != nil {
+			v.add(template, field+".templateRef", "%v", err)
+		}
+		return tmpl
+	}
+	tmpl, ok := v.templates[call.template]
+	if !ok {
+		v.add(template, field+".template", "template %q not found", call.template)
+	}
+	return tmpl
+}
+
+// checkArguments 检查调用为模板中没有默认值的输入参数提供了值
+func (v *validator) checkArguments(call templateCall, target *wfv1.Template, template, field string) {
+	if target == nil {
+		return
+	}
+	for _, p := range target.Inputs.Parameters {
+		if p.Value != nil || p.Default != nil || p.ValueFrom != nil {
+			continue
+		}
+		if call.arguments.GetParameterByName(p.Name) == nil {
+			v.add(template, field+".arguments", "input parameter %q of template %q is not provided", p.Name, target.Name)
+		}
+	}
+}
+
+// variableScope 是一处变量引用可以访问的输入、步骤和任务
+type variableScope struct {
+	template *wfv1.Template
+	nodes    map[string]*wfv1.Template // 可以引用的步骤或任务，值为其模板，无法解析时为 nil
+	kind     string                    // "steps" 或 "tasks"
+	all      map[string]bool           // 模板中的所有步骤或任务，用于区分不存在和不可访问
+	loop     bool                      // 在 withItems/withParam/withSequence 中，可以使用 item
+}
+
+// validateTemplate 校验一个模板；name 用于报告，内联模板为 "父模板/步骤名"
+func (v *validator) validateTemplate(tmpl *wfv1.Template, name string) {
+	switch {
+	case tmpl.Steps != nil:
+		v.validateSteps(tmpl, name)
+	case tmpl.DAG != nil:
+		v.validateDAG(tmpl, name)
+	default:
+		v.checkVariables(tmpl, name, "", variableScope{template: tmpl})
+	}
+}
+
+func (v *validator) validateSteps(tmpl *wfv1.Template, name string) {
+	all := map[string]bool{}
+	for _, group := range tmpl.Steps {
+		for _, step := range group.Steps {
+			all[step.Name] = true
+		}
+	}
+	// 步骤只能引用之前步骤组的输出
+	previous := map[string]*wfv1.Template{}
+	seen := map[string]bool{}
+	for i, group := range tmpl.Steps {
+		current := map[string]*wfv1.Template{}
+		for _, step := range group.Steps {
+			field := fmt.Sprintf("steps[%d][%s]", i, step.Name)
+			v.checkFieldName(step.Name, name, field, step.Name)
+			if seen[step.Name] {
+				v.add(name, field, "duplicate step name")
+			}
+			seen[step.Name] = true
+			call := stepCall(step)
+			target := v.resolve(call, name, field)
+			v.checkArguments(call, target, name, field)
+			loop := len(step.WithItems) > 0 || step.WithParam != "" || step.WithSequence != nil
+			// 内联模板的变量在 resolve 中按其自身的作用域检查
+			step.Inline = nil
+			v.checkVariables(step, name, field, variableScope{template: tmpl, nodes: previous, kind: "steps", all: all, loop: loop})
+			current[step.Name] = target
+		}
+		for k, t := range current {
+			previous[k] = t
+		}
+	}
+	v.checkVariables(tmpl.Outputs, name, "outputs", variableScope{template: tmpl, nodes: previous, kind: "steps", all: all})
+}
+
+func (v *validator) validateDAG(tmpl *wfv1.Template, name string) {
+	tasks := map[string]wfv1.DAGTask{}
+	for _, task := range tmpl.DAG.Tasks {
+		field := fmt.Sprintf("dag.tasks[%s]", task.Name)
+		v.checkFieldName(task.Name, name, field, task.Name)
+		if _, ok := tasks[task.Name]; ok {
+			v.add(name, field, "duplicate task name")
+		}
+		tasks[task.Name] = task
+	}
+	if tmpl.DAG.Target != "" {
+		for _, target := range strings.Fields(tmpl.DAG.Target) {
+			if _, ok := tasks[target]; !ok {
+				v.add(name, "dag.target", "target task %q not found", target)
+			}
+		}
+	}
+
+	// 依赖：dependencies 和 depends 只能使用一个，引用的任务必须存在
+	needs := map[string][]string{}
+	for _, task := range tmpl.DAG.Tasks {
+		field := fmt.Sprintf("dag.tasks[%s]", task.Name)
+		if len(task.Dependencies) > 0 && task.Depends != "" {
+			v.add(name, field, "dependencies and depends cannot both be set")
+		}
+		for _, dep := range task.Dependencies {
+			if _, ok := tasks[dep]; !ok {
+				v.add(name, field+".dependencies", "task %q not found", dep)
+				continue
+			}
+			needs[task.Name] = append(needs[task.Name], dep)
+		}
+		for _, token := range dependsTokenRegex.FindAllString(task.Depends, -1) {
+			dep, result := token, ""
+			if i := strings.IndexByte(token, '.'); i >= 0 {
+				dep, result = token[:i], token[i+1:]
+			}
+			if _, ok := tasks[dep]; !ok {
+				v.add(name, field+".depends", "task %q not found", dep)
+				continue
+			}
+			if result != "" && !dependsResults[result] {
+				v.add(name, field+".depends", "unknown task result %q in %q", result, token)
+			}
+			needs[task.Name] = appendUnique(needs[task.Name], dep)
+		}
+	}
+	names := make([]string, len(tmpl.DAG.Tasks))
+	for i, task := range tmpl.DAG.Tasks {
+		names[i] = task.Name
+	}
+	if cycle := FindCycle(names, needs); cycle != nil {
+		v.add(name, "dag.tasks", "dependency cycle: %s", strings.Join(cycle, " -> "))
+		// 有循环时祖先无法计算，不再检查任务引用
+		return
+	}
+
+	targets := map[string]*wfv1.Template{}
+	all := map[string]bool{}
+	for _, task := range tmpl.DAG.Tasks {
+		field := fmt.Sprintf("dag.tasks[%s]", task.Name)
+		call := taskCall(task)
+		targets[task.Name] = v.resolve(call, name, field)
+		v.checkArguments(call, targets[task.Name], name, field)
+		all[task.Name] = true
+	}
+	// 任务只能引用其直接或间接依赖的任务
+	for _, task := range tmpl.DAG.Tasks {
+		ancestors := map[string]*wfv1.Template{}
+		var visit func(string)
+		visit = func(id string) {
+			for _, dep := range needs[id] {
+				if _, ok := ancestors[dep]; !ok {
+					ancestors[dep] = targets[dep]
+					visit(dep)
+				}
+			}
+		}
+		visit(task.Name)
+		loop := len(task.WithItems) > 0 || task.WithParam != "" || task.WithSequence != nil
+		task.Inline = nil
+		v.checkVariables(task, name, fmt.Sprintf("dag.tasks[%s]", task.Name), variableScope{template: tmpl, nodes: ancestors, kind: "tasks", all: all, loop: loop})
+	}
+	v.checkVariables(tmpl.Outputs, name, "outputs", variableScope{template: tmpl, nodes: targets, kind: "tasks", all: all})
+}
+
+// FindCycle 按深度优先查找循环依赖，needs 为每个节点依赖的节点，按 ids 的顺序开始查找；
+// 返回首尾相同的节点序列，如 [a b a]，没有循环时返回 nil
+func FindCycle(ids []string, needs map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var stack []string
+	var visit func(string) []string
+	visit = func(id string) []string {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, dep := range needs[id] {
+			switch state[dep] {
+			case visiting:
+				for i, s := range stack {
+					if s == dep {
+						return append(append([]string{}, stack[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = visited
+		return nil
+	}
+	for _, id := range ids {
+		if state[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// checkVariables 检查 obj 中所有字符串里的变量引用
+func (v *validator) checkVariables(obj interface{}, template, field string, scope variableScope) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(obj); err != nil {
+		return
+	}
+	text := b.String()
+
+	var refs []string
+	for _, m := range variableRegex.FindAllStringSubmatch(text, -1) {
+		refs = append(refs, m[1])
+	}
+	for _, m := range expressionRegex.FindAllStringSubmatch(text, -1) {
+		for _, ref := range expressionRefRegex.FindAllStringSubmatch(m[1], -1) {
+			refs = append(refs, ref[1]+"."+ref[2])
+		}
+	}
+	reported := map[string]bool{}
+	for _, ref := range refs {
+		if message := v.checkReference(ref, scope); message != "" && !reported[ref] {
+			reported[ref] = true
+			v.add(template, field, "{{%s}}: %s", ref, message)
+		}
+	}
+}
+
+// checkReference 检查一个变量引用，返回问题描述；不检查的变量和合法的引用返回空
+func (v *validator) checkReference(ref string, scope variableScope) string {
+	parts := strings.Split(ref, ".")
+	switch parts[0] {
+	case "inputs":
+		if len(parts) < 3 {
+			return ""
+		}
+		switch parts[1] {
+		case "parameters":
+			for _, p := range scope.template.Inputs.Parameters {
+				if p.Name == parts[2] {
+					return ""
+				}
+			}
+			return fmt.Sprintf("input parameter %q is not declared", parts[2])
+		case "artifacts":
+			for _, a := range scope.template.Inputs.Artifacts {
+				if a.Name == parts[2] {
+					return ""
+				}
+			}
+			return fmt.Sprintf("input artifact %q is not declared", parts[2])
+		}
+	case "workflow":
+		if len(parts) >= 3 && parts[1] == "parameters" && !v.parameters[parts[2]] {
+			return fmt.Sprintf("workflow parameter %q is not declared", parts[2])
+		}
+	case "item":
+		if !scope.loop {
+			return "item is only available in withItems, withParam or withSequence"
+		}
+	case "steps", "tasks":
+		if parts[0] != scope.kind {
+			if parts[0] == "steps" {
+				return "steps can only be referenced in a steps template"
+			}
+			return "tasks can only be referenced in a dag template"
+		}
+		if len(parts) < 2 {
+			return ""
+		}
+		node := parts[1]
+		target, ok := scope.nodes[node]
+		if !ok {
+			if scope.all[node] {
+				if scope.kind == "tasks" {
+					return fmt.Sprintf("task %q is not a dependency", node)
+				}
+				return fmt.Sprintf("step %q does not run before this step", node)
+			}
+			return fmt.Sprintf("%s %q not found", strings.TrimSuffix(scope.kind, "s"), node)
+		}
+		if len(parts) >= 5 && parts[2] == "outputs" && target != nil {
+			switch parts[3] {
+			case "parameters":
+				for _, p := range target.Outputs.Parameters {
+					if p.Name == parts[4] {
+						return ""
+					}
+				}
+				return fmt.Sprintf("template %q has no output parameter %q", target.Name, parts[4])
+			case "artifacts":
+				for _, a := range target.Outputs.Artifacts {
+					if a.Name == parts[4] {
+						return ""
+					}
+				}
+				return fmt.Sprintf("template %q has no output artifact %q", target.Name, parts[4])
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,156 @@
+package argowf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- 离线校验 ---
+
+const libraryTemplate = `apiVersion: argoproj.io/v1alpha1
+kind: WorkflowTemplate
+metadata:
+  name: library
+spec:
+  templates:
+    - name: echo
+      inputs:
+        parameters:
+          - name: message
+      container:
+        image: alpine
+        args: ["{{inputs.parameters.message}}"]
+`
+
+// testLibrary 把 libraryTemplate 写入临时目录并加载，同一目录中的其他资源被跳过
+func testLibrary(t *testing.T) *TemplateLibrary {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"library.yaml": libraryTemplate,
+		"other.yml":    "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\n",
+		"README.md":    "not YAML",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	library, err := LoadTemplateLibrary(dir)
+	if err != nil {
+		t.Fatalf("LoadTemplateLibrary: %v", err)
+	}
+	return library
+}
+
+func TestValidateResource(t *testing.T) {
+	steps := `
+    - name: main
+      steps:
+        - - name: hello
+            templateRef:
+              name: library
+              template: echo
+            arguments:
+              parameters:
+                - name: message
+                  value: "{{workflow.parameters.greeting}}"
+`
+	tests := []struct {
+		name       string
+		doc        string
+		wantIssues []string // 问题描述应包含的文本，按顺序；为空表示校验通过
+	}{
+		{
+			name: "Workflow",
+			doc:  "apiVersion: argoproj.io/v1alpha1\nkind: Workflow\nmetadata:\n  generateName: hello-\nspec:\n  entrypoint: main\n  arguments:\n    parameters:\n      - name: greeting\n        value: hi\n  templates:" + steps,
+		},
+		{
+			name: "CronWorkflow",
+			doc:  "apiVersion: argoproj.io/v1alpha1\nkind: CronWorkflow\nmetadata:\n  name: nightly\nspec:\n  schedule: \"0 3 * * *\"\n  workflowSpec:\n    entrypoint: main\n    arguments:\n      parameters:\n        - name: greeting\n    templates:" + strings.ReplaceAll(steps, "\n", "\n  "),
+		},
+		{
+			name: "WorkflowTemplate without entrypoint",
+			doc:  libraryTemplate,
+		},
+		{
+			name:       "CronWorkflow without entrypoint",
+			doc:        "apiVersion: argoproj.io/v1alpha1\nkind: CronWorkflow\nmetadata:\n  name: nightly\nspec:\n  schedule: \"0 3 * * *\"\n  workflowSpec:\n    templates:\n      - name: main\n        container:\n          image: alpine\n",
+			wantIssues: []string{"entrypoint is required"},
+		},
+		{
+			name:       "CronWorkflow name too long",
+			doc:        "apiVersion: argoproj.io/v1alpha1\nkind: CronWorkflow\nmetadata:\n  name: " + strings.Repeat("a", 53) + "\nspec:\n  schedule: \"0 3 * * *\"\n  workflowSpec:\n    entrypoint: main\n    templates:\n      - name: main\n        container:\n          image: alpine\n",
+			wantIssues: []string{"longer than 52 characters"},
+		},
+		{
+			name: "Workflow name is not limited to 52 characters",
+			doc:  "apiVersion: argoproj.io/v1alpha1\nkind: Workflow\nmetadata:\n  name: " + strings.Repeat("a", 53) + "\nspec:\n  entrypoint: main\n  templates:\n    - name: main\n      container:\n        image: alpine\n",
+		},
+		{
+			name: "bad template references",
+			doc: `apiVersion: argoproj.io/v1alpha1
+kind: WorkflowTemplate
+metadata:
+  name: broken
+spec:
+  entrypoint: main
+  templates:
+    - name: main
+      dag:
+        tasks:
+          - name: a
+            template: missing
+          - name: b
+            templateRef:
+              name: library
+              template: missing
+          - name: c
+            templateRef:
+              name: unknown
+              template: echo
+          - name: d
+            templateRef:
+              name: library
+              template: echo
+`,
+			wantIssues: []string{
+				`template "missing" not found`,
+				`template "missing" not found in library`,
+				`WorkflowTemplate "unknown" not found in the template directory`,
+				`input parameter "message" of template "echo" is not provided`,
+			},
+		},
+	}
+	library := testLibrary(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource, err := ParseResource([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("ParseResource: %v", err)
+			}
+			issues := ValidateResource(resource, library)
+			if len(issues) != len(tt.wantIssues) {
+				t.Fatalf("issues = %v, want %d", issues, len(tt.wantIssues))
+			}
+			for i, want := range tt.wantIssues {
+				if !strings.Contains(issues[i].Message, want) {
+					t.Errorf("issue %d = %q, want it to contain %q", i, issues[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateResourceWithoutLibrary(t *testing.T) {
+	resource, err := ParseResource([]byte("apiVersion: argoproj.io/v1alpha1\nkind: Workflow\nmetadata:\n  name: hello\nspec:\n  entrypoint: main\n  templates:\n    - name: main\n      steps:\n        - - name: call\n            templateRef:\n              name: library\n              template: echo\n"))
+	if err != nil {
+		t.Fatalf("ParseResource: %v", err)
+	}
+	issues := ValidateResource(resource, nil)
+	if len(issues) == 0 || !strings.Contains(issues[0].Message, "cannot be resolved without a template directory") {
+		t.Errorf("issues = %v, want templateRef to be unresolvable without a library", issues)
+	}
+}
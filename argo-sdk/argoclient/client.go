@@ -0,0 +1,123 @@
+// Package argoclient 创建 Argo 和 Kubernetes 客户端，封装工作流的生命周期操作并跟踪工作流状态和日志，供 argo-sdk、gha-converter 和 argus 共用
+package argoclient
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/argoproj/argo-workflows/v3/pkg/apiclient"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// --- Argo 客户端 ---
+
+// 环境变量名称，与 argo CLI 保持一致
+const (
+	EnvArgoServer             = "ARGO_SERVER"
+	EnvArgoToken              = "ARGO_TOKEN"
+	EnvArgoSecure             = "ARGO_SECURE"
+	EnvArgoInsecureSkipVerify = "ARGO_INSECURE_SKIP_VERIFY"
+	EnvArgoNamespace          = "ARGO_NAMESPACE"
+)
+
+// DefaultNamespace 在命令行、kubeconfig 和 service account 都未指定 namespace 时使用
+const DefaultNamespace = "default"
+
+// ArgoClientConfig 是连接 Argo 的配置：
+//   - 设置 ServerURL 时通过 Argo Server 连接，使用 Token 认证
+//   - 否则直接连接 Kubernetes：显式 kubeconfig > $KUBECONFIG > ~/.kube/config > in-cluster service account
+type ArgoClientConfig struct {
+	ServerURL          string // Argo Server 地址，host:port
+	Token              string // Argo Server 的 Bearer token
+	Secure             bool   // 是否使用 TLS 连接 Argo Server
+	InsecureSkipVerify bool   // 是否跳过 Argo Server 证书校验
+	Kubeconfig         string // kubeconfig 路径，为空时使用默认加载规则
+	Context            string // kubeconfig 中的 context，为空时使用 current-context
+	Namespace          string // 工作流所在的 namespace，为空时取 kubeconfig context 或 service account 的 namespace
+}
+
+// RegisterFlags 注册连接参数，默认值来自环境变量
+func (c *ArgoClientConfig) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.ServerURL, "argo-server", os.Getenv(EnvArgoServer), "Argo Server host:port; connects to Kubernetes directly when empty (env "+EnvArgoServer+")")
+	fs.StringVar(&c.Token, "argo-token", os.Getenv(EnvArgoToken), "bearer token for Argo Server (env "+EnvArgoToken+")")
+	fs.BoolVar(&c.Secure, "argo-secure", os.Getenv(EnvArgoSecure) != "false", "use TLS when connecting to Argo Server (env "+EnvArgoSecure+")")
+	fs.BoolVar(&c.InsecureSkipVerify, "argo-insecure-skip-verify", os.Getenv(EnvArgoInsecureSkipVerify) == "true", "skip Argo Server certificate verification (env "+EnvArgoInsecureSkipVerify+")")
+	fs.StringVar(&c.Kubeconfig, "kubeconfig", "", "path to the kubeconfig file, defaults to $KUBECONFIG, ~/.kube/config or in-cluster config")
+	fs.StringVar(&c.Context, "context", "", "kubeconfig context to use")
+	fs.StringVar(&c.Namespace, "namespace", os.Getenv(EnvArgoNamespace), "namespace of the workflow, defaults to the kubeconfig context namespace (env "+EnvArgoNamespace+")")
+}
+
+// clientConfig 返回 kubeconfig 加载器；没有可用的 kubeconfig 时 client-go 自动回落到 in-cluster 配置
+func (c ArgoClientConfig) clientConfig() clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = c.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: c.Context}
+	if c.Namespace != "" {
+		overrides.Context.Namespace = c.Namespace
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+// ResolveNamespace 返回实际使用的 namespace：显式指定 > kubeconfig context / service account > default
+func (c ArgoClientConfig) ResolveNamespace() (string, error) {
+	if c.Namespace != "" {
+		return c.Namespace, nil
+	}
+	if c.ServerURL != "" {
+		// Argo Server 模式下不读取 kubeconfig
+		return DefaultNamespace, nil
+	}
+	namespace, _, err := c.clientConfig().Namespace()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve namespace: %w", err)
+	}
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return namespace, nil
+}
+
+// NewArgoClient 创建 Argo API 客户端，同时返回请求和工作流对象应使用的 namespace
+func NewArgoClient(config ArgoClientConfig) (context.Context, apiclient.Client, string, error) {
+	namespace, err := config.ResolveNamespace()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	opts := apiclient.Opts{ClientConfigSupplier: config.clientConfig}
+	if config.ServerURL != "" {
+		if config.Token == "" {
+			return nil, nil, "", fmt.Errorf("a token is required when connecting to Argo Server %s", config.ServerURL)
+		}
+		opts.ArgoServerOpts = apiclient.ArgoServerOpts{
+			URL:                config.ServerURL,
+			Secure:             config.Secure,
+			InsecureSkipVerify: config.InsecureSkipVerify,
+		}
+		// 兼容 `argo auth token` 输出的 "Bearer xxx" 格式
+		token := config.Token
+		if !strings.HasPrefix(token, "Bearer ") {
+			token = "Bearer " + token
+		}
+		opts.AuthSupplier = func() string { return token }
+	}
+
+	ctx, client, err := apiclient.NewClientFromOpts(opts)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to create Argo client: %w", err)
+	}
+	return ctx, client, namespace, nil
+}
+
+// NewKubernetesClient 创建 Kubernetes 客户端，kubeconfig 的加载顺序与 ArgoClientConfig 相同
+func NewKubernetesClient(kubeconfig, kubeContext string) (kubernetes.Interface, error) {
+	config, err := ArgoClientConfig{Kubeconfig: kubeconfig, Context: kubeContext}.clientConfig().ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig or in-cluster config: %w", err)
+	}
+	return kubernetes.NewForConfig(config)
+}
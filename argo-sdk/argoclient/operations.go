@@ -0,0 +1,171 @@
+package argoclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	workflowpkg "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"workflow-parser/ghawf"
+)
+
+// --- 工作流生命周期操作 ---
+
+// LabelGHAWorkflow 是 gha-converter 在工作流上添加的标签，值为清理后的 GHA 工作流名称
+const LabelGHAWorkflow = "argus.io/gha-workflow"
+
+// WorkflowOps 封装 WorkflowServiceClient 上的生命周期操作，所有操作都在同一个 namespace 中进行
+type WorkflowOps struct {
+	client    workflowpkg.WorkflowServiceClient
+	namespace string
+}
+
+// NewWorkflowOps 创建生命周期操作
+func NewWorkflowOps(client workflowpkg.WorkflowServiceClient, namespace string) *WorkflowOps {
+	return &WorkflowOps{client: client, namespace: namespace}
+}
+
+// List 按标签选择器列出工作流，按创建时间从新到旧排序
+func (o *WorkflowOps) List(ctx context.Context, selector string) ([]wfv1.Workflow, error) {
+	list, err := o.client.ListWorkflows(ctx, &workflowpkg.WorkflowListRequest{
+		Namespace:   o.namespace,
+		ListOptions: &metav1.ListOptions{LabelSelector: selector},
+		Fields:      "-items.status.nodes",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflows with selector %q: %w", selector, err)
+	}
+	workflows := list.Items
+	sort.SliceStable(workflows, func(i, j int) bool {
+		return workflows[j].CreationTimestamp.Before(&workflows[i].CreationTimestamp)
+	})
+	return workflows, nil
+}
+
+// LatestForGHA 返回由指定 GHA 工作流转换而来的最新一次运行的名称
+func (o *WorkflowOps) LatestForGHA(ctx context.Context, ghaName string) (string, error) {
+	workflows, err := o.List(ctx, GHAWorkflowSelector(ghaName))
+	if err != nil {
+		return "", err
+	}
+	if len(workflows) == 0 {
+		return "", fmt.Errorf("no workflow converted from GHA workflow %q in namespace %s", ghaName, o.namespace)
+	}
+	return workflows[0].Name, nil
+}
+
+// Resubmit 以原始参数重新提交工作流；memoized 为 true 时复用已成功节点的输出
+func (o *WorkflowOps) Resubmit(ctx context.Context, name string, memoized bool) (*wfv1.Workflow, error) {
+	wf, err := o.client.ResubmitWorkflow(ctx, &workflowpkg.WorkflowResubmitRequest{
+		Name:      name,
+		Namespace: o.namespace,
+		Memoized:  memoized,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resubmit workflow %s: %w", name, err)
+	}
+	return wf, nil
+}
+
+// Retry 从失败的节点开始重试；restartSuccessful 为 true 时同时重跑匹配 nodeFieldSelector 的成功节点
+func (o *WorkflowOps) Retry(ctx context.Context, name string, restartSuccessful bool, nodeFieldSelector string) (*wfv1.Workflow, error) {
+	wf, err := o.client.RetryWorkflow(ctx, &workflowpkg.WorkflowRetryRequest{
+		Name:              name,
+		Namespace:         o.namespace,
+		RestartSuccessful: restartSuccessful,
+		NodeFieldSelector: nodeFieldSelector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry workflow %s: %w", name, err)
+	}
+	return wf, nil
+}
+
+// Stop 停止工作流，仍会执行 exit handler
+func (o *WorkflowOps) Stop(ctx context.Context, name, message string) (*wfv1.Workflow, error) {
+	wf, err := o.client.StopWorkflow(ctx, &workflowpkg.WorkflowStopRequest{
+		Name:      name,
+		Namespace: o.namespace,
+		Message:   message,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stop workflow %s: %w", name, err)
+	}
+	return wf, nil
+}
+
+// Terminate 立即终止工作流，不执行 exit handler
+func (o *WorkflowOps) Terminate(ctx context.Context, name string) (*wfv1.Workflow, error) {
+	wf, err := o.client.TerminateWorkflow(ctx, &workflowpkg.WorkflowTerminateRequest{
+		Name:      name,
+		Namespace: o.namespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to terminate workflow %s: %w", name, err)
+	}
+	return wf, nil
+}
+
+// Suspend 暂停工作流，正在运行的 Pod 不受影响
+func (o *WorkflowOps) Suspend(ctx context.Context, name string) (*wfv1.Workflow, error) {
+	wf, err := o.client.SuspendWorkflow(ctx, &workflowpkg.WorkflowSuspendRequest{
+		Name:      name,
+		Namespace: o.namespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to suspend workflow %s: %w", name, err)
+	}
+	return wf, nil
+}
+
+// Resume 恢复被暂停的工作流
+func (o *WorkflowOps) Resume(ctx context.Context, name string) (*wfv1.Workflow, error) {
+	wf, err := o.client.ResumeWorkflow(ctx, &workflowpkg.WorkflowResumeRequest{
+		Name:      name,
+		Namespace: o.namespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume workflow %s: %w", name, err)
+	}
+	return wf, nil
+}
+
+// Delete 删除单个工作流
+func (o *WorkflowOps) Delete(ctx context.Context, name string) error {
+	_, err := o.client.DeleteWorkflow(ctx, &workflowpkg.WorkflowDeleteRequest{
+		Name:      name,
+		Namespace: o.namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete workflow %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteSelected 删除匹配标签选择器的所有工作流，返回已删除的名称；遇到错误时立即返回
+func (o *WorkflowOps) DeleteSelected(ctx context.Context, selector string) ([]string, error) {
+	if selector == "" {
+		return nil, fmt.Errorf("refusing to delete with an empty label selector")
+	}
+	workflows, err := o.List(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	var deleted []string
+	for _, wf := range workflows {
+		if err := o.Delete(ctx, wf.Name); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, wf.Name)
+	}
+	return deleted, nil
+}
+
+// GHAWorkflowSelector 返回匹配某个 GHA 工作流所有运行的标签选择器；
+// 标签值与转换器使用同一个 ghawf.SanitizeName，保证按 GHA 名称能找到转换后的工作流
+func GHAWorkflowSelector(ghaName string) string {
+	return LabelGHAWorkflow + "=" + ghawf.SanitizeName(ghaName)
+}
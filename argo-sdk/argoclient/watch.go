@@ -0,0 +1,203 @@
+package argoclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	workflowpkg "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo-workflows/v3/workflow/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// --- 工作流状态跟踪与日志 ---
+
+// gha-converter 写在模板上的注解，记录原始的 GHA Job/Step 名称，Watch 按它们把节点映射回 GHA
+const (
+	AnnotationGHAJob  = "argus.io/gha-job"
+	AnnotationGHAStep = "argus.io/gha-step"
+)
+
+// logDrainTimeout 是工作流结束后等待剩余日志输出的最长时间
+const logDrainTimeout = 5 * time.Second
+
+// WorkflowWatcher 跟踪一个工作流的节点状态变化，并输出各容器的日志
+type WorkflowWatcher struct {
+	client workflowpkg.WorkflowServiceClient
+	out    io.Writer
+
+	mu     sync.Mutex
+	phases map[string]wfv1.NodePhase // 节点 ID -> 最近一次输出的阶段
+	pods   map[string]string         // Pod 名称 -> GHA 显示名称
+}
+
+// NewWorkflowWatcher 创建 watcher，状态和日志写入 out
+func NewWorkflowWatcher(client workflowpkg.WorkflowServiceClient, out io.Writer) *WorkflowWatcher {
+	return &WorkflowWatcher{
+		client: client,
+		out:    out,
+		phases: make(map[string]wfv1.NodePhase),
+		pods:   make(map[string]string),
+	}
+}
+
+// Watch 阻塞直到工作流结束或 ctx 被取消，返回工作流的最终阶段
+func (w *WorkflowWatcher) Watch(ctx context.Context, namespace, name string) (wfv1.WorkflowPhase, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	logsDone := make(chan struct{})
+	go func() {
+		defer close(logsDone)
+		if err := w.streamLogs(ctx, namespace, name); err != nil && ctx.Err() == nil {
+			w.printf("log stream interrupted: %v\n", err)
+		}
+	}()
+
+	stream, err := w.client.WatchWorkflows(ctx, &workflowpkg.WatchWorkflowsRequest{
+		Namespace:   namespace,
+		ListOptions: &metav1.ListOptions{FieldSelector: "metadata.name=" + name},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to watch workflow %s: %w", name, err)
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", fmt.Errorf("watch of workflow %s ended: %w", name, err)
+		}
+		if event == nil || event.Object == nil {
+			continue
+		}
+		if event.Type == "DELETED" {
+			return "", fmt.Errorf("workflow %s was deleted", name)
+		}
+
+		wf := event.Object
+		w.report(wf)
+		if wf.Status.Fulfilled() {
+			// 工作流结束后日志流也会结束，等待剩余日志输出完
+			select {
+			case <-logsDone:
+			case <-time.After(logDrainTimeout):
+			}
+			w.printf("workflow %s finished: %s %s\n", wf.Name, wf.Status.Phase, wf.Status.Message)
+			return wf.Status.Phase, nil
+		}
+	}
+}
+
+// report 输出阶段发生变化的节点，并记录 Pod 名称到 GHA 名称的映射
+func (w *WorkflowWatcher) report(wf *wfv1.Workflow) {
+	nodeIDs := make([]string, 0, len(wf.Status.Nodes))
+	for id := range wf.Status.Nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	// 按开始时间排序，使输出顺序与执行顺序一致
+	sort.Slice(nodeIDs, func(i, j int) bool {
+		a, b := wf.Status.Nodes[nodeIDs[i]], wf.Status.Nodes[nodeIDs[j]]
+		if !a.StartedAt.Equal(&b.StartedAt) {
+			return a.StartedAt.Before(&b.StartedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, id := range nodeIDs {
+		node := wf.Status.Nodes[id]
+		displayName, mapped := ghaDisplayName(wf, node)
+		if !mapped && node.Type != wfv1.NodeTypePod {
+			// 只输出 GHA Job/Step 以及实际运行的 Pod，跳过 StepGroup 等中间节点
+			continue
+		}
+		if node.Type == wfv1.NodeTypePod {
+			podName := util.GeneratePodName(wf.Name, node.Name, node.TemplateName, node.ID, util.GetPodNameVersion())
+			w.pods[podName] = displayName
+		}
+		if w.phases[id] == node.Phase || node.Phase == "" {
+			continue
+		}
+		w.phases[id] = node.Phase
+		if node.Message != "" {
+			fmt.Fprintf(w.out, "[%s] %s: %s\n", displayName, node.Phase, node.Message)
+		} else {
+			fmt.Fprintf(w.out, "[%s] %s\n", displayName, node.Phase)
+		}
+	}
+}
+
+// streamLogs 持续输出工作流所有 Pod 的 main 容器日志，每行以 GHA 名称为前缀
+func (w *WorkflowWatcher) streamLogs(ctx context.Context, namespace, name string) error {
+	stream, err := w.client.WorkflowLogs(ctx, &workflowpkg.WorkflowLogRequest{
+		Name:       name,
+		Namespace:  namespace,
+		LogOptions: &corev1.PodLogOptions{Container: "main", Follow: true},
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		entry, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		w.mu.Lock()
+		displayName, ok := w.pods[entry.PodName]
+		if !ok {
+			displayName = entry.PodName
+		}
+		fmt.Fprintf(w.out, "[%s] %s\n", displayName, strings.TrimRight(entry.Content, "\n"))
+		w.mu.Unlock()
+	}
+}
+
+func (w *WorkflowWatcher) printf(format string, args ...interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(w.out, format, args...)
+}
+
+// ghaDisplayName 根据模板注解把 Argo 节点映射回 "job / step"；
+// 没有注解的模板（非 gha-converter 生成）使用节点的显示名称
+func ghaDisplayName(wf *wfv1.Workflow, node wfv1.NodeStatus) (string, bool) {
+	if tmpl := wf.GetTemplateByName(node.TemplateName); tmpl != nil {
+		job := tmpl.Metadata.Annotations[AnnotationGHAJob]
+		step := tmpl.Metadata.Annotations[AnnotationGHAStep]
+		switch {
+		case job != "" && step != "":
+			return job + " / " + step, true
+		case job != "":
+			return job, true
+		}
+	}
+	return node.DisplayName, false
+}
+
+// ExitCodeForPhase 把工作流最终阶段映射为进程退出码：成功 0、失败 1、错误 2，其他（如未结束）3
+func ExitCodeForPhase(phase wfv1.WorkflowPhase) int {
+	switch phase {
+	case wfv1.WorkflowSucceeded:
+		return 0
+	case wfv1.WorkflowFailed:
+		return 1
+	case wfv1.WorkflowError:
+		return 2
+	default:
+		return 3
+	}
+}
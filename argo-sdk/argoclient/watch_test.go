@@ -0,0 +1,228 @@
+package argoclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	workflowpkg "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo-workflows/v3/workflow/util"
+	"google.golang.org/grpc"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// --- WorkflowWatcher ---
+
+// fakeWatchClient 只实现 WatchWorkflows 和 WorkflowLogs，其他方法调用时 panic
+type fakeWatchClient struct {
+	workflowpkg.WorkflowServiceClient
+	events []*workflowpkg.WorkflowWatchEvent
+	end    error // 事件发送完后 Recv 返回的错误，为空时阻塞到 ctx 取消
+	logs   []*workflowpkg.LogEntry
+	ready  chan struct{} // 第一个事件处理完后关闭，之后才输出日志，保证 Pod 名称已经映射
+	once   sync.Once
+}
+
+func newFakeWatchClient(events []*workflowpkg.WorkflowWatchEvent, end error, logs []*workflowpkg.LogEntry) *fakeWatchClient {
+	return &fakeWatchClient{events: events, end: end, logs: logs, ready: make(chan struct{})}
+}
+
+func (c *fakeWatchClient) WatchWorkflows(ctx context.Context, in *workflowpkg.WatchWorkflowsRequest, opts ...grpc.CallOption) (workflowpkg.WorkflowService_WatchWorkflowsClient, error) {
+	return &fakeWatchStream{ctx: ctx, client: c}, nil
+}
+
+func (c *fakeWatchClient) WorkflowLogs(ctx context.Context, in *workflowpkg.WorkflowLogRequest, opts ...grpc.CallOption) (workflowpkg.WorkflowService_WorkflowLogsClient, error) {
+	return &fakeLogStream{ctx: ctx, client: c}, nil
+}
+
+type fakeWatchStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	client *fakeWatchClient
+	sent   int
+}
+
+func (s *fakeWatchStream) Recv() (*workflowpkg.WorkflowWatchEvent, error) {
+	if s.sent > 0 {
+		s.client.once.Do(func() { close(s.client.ready) })
+	}
+	if s.sent < len(s.client.events) {
+		s.sent++
+		return s.client.events[s.sent-1], nil
+	}
+	if s.client.end != nil {
+		return nil, s.client.end
+	}
+	<-s.ctx.Done()
+	return nil, s.ctx.Err()
+}
+
+type fakeLogStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	client *fakeWatchClient
+	sent   int
+}
+
+func (s *fakeLogStream) Recv() (*workflowpkg.LogEntry, error) {
+	select {
+	case <-s.client.ready:
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+	if s.sent < len(s.client.logs) {
+		s.sent++
+		return s.client.logs[s.sent-1], nil
+	}
+	return nil, io.EOF
+}
+
+// ghaWorkflow 返回 gha-converter 风格的工作流：build Job 的 DAG 任务，test Step 的 Pod，
+// 以及没有注解的 StepGroup 和 Pod
+func ghaWorkflow(phase wfv1.WorkflowPhase) *wfv1.Workflow {
+	annotated := func(name, job, step string) wfv1.Template {
+		template := wfv1.Template{Name: name, Metadata: wfv1.Metadata{Annotations: map[string]string{AnnotationGHAJob: job}}}
+		if step != "" {
+			template.Metadata.Annotations[AnnotationGHAStep] = step
+		}
+		return template
+	}
+	start := metav1.NewTime(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+	later := metav1.NewTime(start.Add(time.Second))
+	nodePhase := wfv1.NodeRunning
+	if phase == wfv1.WorkflowSucceeded {
+		nodePhase = wfv1.NodeSucceeded
+	}
+	return &wfv1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "ci-abcde", Namespace: "argo"},
+		Spec: wfv1.WorkflowSpec{Templates: []wfv1.Template{
+			annotated("build", "build", ""),
+			annotated("build-test", "build", "test"),
+			{Name: "plain"},
+		}},
+		Status: wfv1.WorkflowStatus{
+			Phase: phase,
+			Nodes: wfv1.Nodes{
+				"ci-abcde-1": {ID: "ci-abcde-1", Name: "ci-abcde.build", DisplayName: "build", TemplateName: "build", Type: wfv1.NodeTypeSteps, Phase: nodePhase, StartedAt: start},
+				"ci-abcde-2": {ID: "ci-abcde-2", Name: "ci-abcde.build[0]", DisplayName: "[0]", Type: wfv1.NodeTypeStepGroup, Phase: nodePhase, StartedAt: later},
+				"ci-abcde-3": {ID: "ci-abcde-3", Name: "ci-abcde.build[0].test", DisplayName: "test", TemplateName: "build-test", Type: wfv1.NodeTypePod, Phase: nodePhase, StartedAt: later},
+				"ci-abcde-4": {ID: "ci-abcde-4", Name: "ci-abcde.plain", DisplayName: "plain-pod", TemplateName: "plain", Type: wfv1.NodeTypePod, Phase: wfv1.NodeFailed, Message: "exit code 1", StartedAt: later},
+			},
+		},
+	}
+}
+
+func podName(wf *wfv1.Workflow, id string) string {
+	node := wf.Status.Nodes[id]
+	return util.GeneratePodName(wf.Name, node.Name, node.TemplateName, node.ID, util.GetPodNameVersion())
+}
+
+func TestWorkflowWatcher(t *testing.T) {
+	running, succeeded := ghaWorkflow(wfv1.WorkflowRunning), ghaWorkflow(wfv1.WorkflowSucceeded)
+	client := newFakeWatchClient(
+		[]*workflowpkg.WorkflowWatchEvent{
+			{Type: "ADDED", Object: running},
+			{Type: "MODIFIED", Object: succeeded},
+		},
+		nil,
+		[]*workflowpkg.LogEntry{
+			{PodName: podName(running, "ci-abcde-3"), Content: "ok\n"},
+			{PodName: "unknown-pod", Content: "hello"},
+		},
+	)
+	var out bytes.Buffer
+	phase, err := NewWorkflowWatcher(client, &out).Watch(context.Background(), "argo", "ci-abcde")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if phase != wfv1.WorkflowSucceeded {
+		t.Errorf("phase = %q, want Succeeded", phase)
+	}
+
+	// 节点按开始时间输出，映射为 "job / step"；StepGroup 被跳过，没有注解的 Pod 使用显示名称。
+	// 日志和状态来自两个流，日志只要求在工作流结束之前输出完
+	wantStatus := []string{
+		"[build] Running",
+		"[build / test] Running",
+		"[plain-pod] Failed: exit code 1",
+		"[build] Succeeded",
+		"[build / test] Succeeded",
+		"workflow ci-abcde finished: Succeeded ",
+	}
+	wantLogs := []string{"[build / test] ok", "[unknown-pod] hello"}
+	var status, logs []string
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		if strings.HasSuffix(line, "] ok") || strings.HasSuffix(line, "] hello") {
+			logs = append(logs, line)
+		} else {
+			status = append(status, line)
+		}
+	}
+	if strings.Join(status, "\n") != strings.Join(wantStatus, "\n") {
+		t.Errorf("status lines:\n%s\nwant:\n%s", strings.Join(status, "\n"), strings.Join(wantStatus, "\n"))
+	}
+	if strings.Join(logs, "\n") != strings.Join(wantLogs, "\n") {
+		t.Errorf("log lines = %q, want %q", logs, wantLogs)
+	}
+	if !strings.HasSuffix(out.String(), wantStatus[len(wantStatus)-1]+"\n") {
+		t.Errorf("output does not end with the final status:\n%s", out.String())
+	}
+}
+
+func TestWorkflowWatcherStreamErrors(t *testing.T) {
+	streamErr := errors.New("connection reset")
+	tests := []struct {
+		name    string
+		events  []*workflowpkg.WorkflowWatchEvent
+		end     error
+		wantErr error  // errors.Is 检查，为空时只检查 wantMsg
+		wantMsg string // 错误信息应包含的文本
+	}{
+		{name: "EOF before the workflow finishes", events: []*workflowpkg.WorkflowWatchEvent{{Type: "ADDED", Object: ghaWorkflow(wfv1.WorkflowRunning)}}, end: io.EOF, wantErr: io.ErrUnexpectedEOF, wantMsg: "watch of workflow ci-abcde ended"},
+		{name: "stream error", end: streamErr, wantErr: streamErr, wantMsg: "watch of workflow ci-abcde ended"},
+		{name: "deleted", events: []*workflowpkg.WorkflowWatchEvent{{Type: "DELETED", Object: ghaWorkflow(wfv1.WorkflowRunning)}}, end: io.EOF, wantMsg: "was deleted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeWatchClient(tt.events, tt.end, nil)
+			phase, err := NewWorkflowWatcher(client, io.Discard).Watch(context.Background(), "argo", "ci-abcde")
+			if err == nil {
+				t.Fatalf("Watch succeeded with phase %q, want an error", phase)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want it to wrap %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantMsg)
+			}
+			if code := ExitCodeForPhase(phase); code != 3 {
+				t.Errorf("exit code after an error = %d, want 3", code)
+			}
+		})
+	}
+}
+
+func TestExitCodeForPhase(t *testing.T) {
+	tests := []struct {
+		phase wfv1.WorkflowPhase
+		want  int
+	}{
+		{wfv1.WorkflowSucceeded, 0},
+		{wfv1.WorkflowFailed, 1},
+		{wfv1.WorkflowError, 2},
+		{wfv1.WorkflowRunning, 3},
+		{wfv1.WorkflowPending, 3},
+		{wfv1.WorkflowUnknown, 3},
+	}
+	for _, tt := range tests {
+		if got := ExitCodeForPhase(tt.phase); got != tt.want {
+			t.Errorf("ExitCodeForPhase(%q) = %d, want %d", tt.phase, got, tt.want)
+		}
+	}
+}
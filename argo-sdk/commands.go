@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	workflowpkg "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+
+	"argo-sdk/argoclient"
+)
+
+// --- 子命令 ---
+
+// command 是一个子命令，返回进程退出码
+type command struct {
+	usage string
+	run   func(ctx context.Context, cli *commandContext, args []string) int
+}
+
+// commandContext 是子命令共享的客户端和 namespace
+type commandContext struct {
+	client    workflowpkg.WorkflowServiceClient
+	namespace string
+	ops       *argoclient.WorkflowOps
+}
+
+var commands map[string]command
+
+// 子命令表在 init 中初始化，避免与引用它的子命令之间形成初始化循环
+func init() {
+	commands = map[string]command{
+		"submit":    {"submit [-watch]", runSubmit},
+		"watch":     {"watch <name | -gha name>", runWatch},
+		"resubmit":  {"resubmit [-memoized] [-watch] <name | -gha name>", runResubmit},
+		"retry":     {"retry [-restart-successful] [-node-field-selector s] [-watch] <name | -gha name>", runRetry},
+		"stop":      {"stop [-message m] <name | -gha name>", runStop},
+		"terminate": {"terminate <name | -gha name>", simpleOp("terminate", (*argoclient.WorkflowOps).Terminate)},
+		"suspend":   {"suspend <name | -gha name>", simpleOp("suspend", (*argoclient.WorkflowOps).Suspend)},
+		"resume":    {"resume <name | -gha name>", simpleOp("resume", (*argoclient.WorkflowOps).Resume)},
+		"delete":    {"delete <name | -gha name | -l selector>", runDelete},
+	}
+}
+
+// printUsage 输出所有子命令的用法
+func printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "usage: argo-sdk [connection flags] <command> [flags]\n\ncommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  argo-sdk %s\n", commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nconnection flags:\n")
+	flag.PrintDefaults()
+}
+
+// runSubmit 创建示例工作流
+func runSubmit(ctx context.Context, cli *commandContext, args []string) int {
+	fs := flag.NewFlagSet("submit", flag.ExitOnError)
+	watch := fs.Bool("watch", false, "watch the created workflow until it finishes and stream its logs")
+	fs.Parse(args)
+
+	createdWf, err := cli.client.CreateWorkflow(ctx, &workflowpkg.WorkflowCreateRequest{
+		Namespace: cli.namespace,
+		Workflow:  createSampleWorkflow(cli.namespace),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create workflow: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("工作流创建成功: %s\n", createdWf.Name)
+	if *watch {
+		return watchWorkflow(ctx, cli.client, createdWf.Namespace, createdWf.Name)
+	}
+	return 0
+}
+
+// runWatch 跟踪已有工作流的状态和日志，退出码反映最终阶段
+func runWatch(ctx context.Context, cli *commandContext, args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	gha := fs.String("gha", "", "watch the latest workflow converted from this GHA workflow name")
+	fs.Parse(args)
+
+	name, ok := resolveTarget(ctx, cli, fs, *gha)
+	if !ok {
+		return 1
+	}
+	return watchWorkflow(ctx, cli.client, cli.namespace, name)
+}
+
+func runResubmit(ctx context.Context, cli *commandContext, args []string) int {
+	fs := flag.NewFlagSet("resubmit", flag.ExitOnError)
+	gha := fs.String("gha", "", "resubmit the latest workflow converted from this GHA workflow name")
+	memoized := fs.Bool("memoized", false, "reuse outputs of nodes that already succeeded")
+	watch := fs.Bool("watch", false, "watch the new workflow until it finishes")
+	fs.Parse(args)
+
+	name, ok := resolveTarget(ctx, cli, fs, *gha)
+	if !ok {
+		return 1
+	}
+	wf, err := cli.ops.Resubmit(ctx, name, *memoized)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("工作流 %s 已重新提交为 %s\n", name, wf.Name)
+	if *watch {
+		return watchWorkflow(ctx, cli.client, wf.Namespace, wf.Name)
+	}
+	return 0
+}
+
+func runRetry(ctx context.Context, cli *commandContext, args []string) int {
+	fs := flag.NewFlagSet("retry", flag.ExitOnError)
+	gha := fs.String("gha", "", "retry the latest workflow converted from this GHA workflow name")
+	restartSuccessful := fs.Bool("restart-successful", false, "also restart succeeded nodes matching -node-field-selector")
+	nodeFieldSelector := fs.String("node-field-selector", "", "selector of nodes to restart, e.g. templateName=build")
+	watch := fs.Bool("watch", false, "watch the workflow until it finishes")
+	fs.Parse(args)
+
+	name, ok := resolveTarget(ctx, cli, fs, *gha)
+	if !ok {
+		return 1
+	}
+	wf, err := cli.ops.Retry(ctx, name, *restartSuccessful, *nodeFieldSelector)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("工作流 %s 已重试\n", wf.Name)
+	if *watch {
+		return watchWorkflow(ctx, cli.client, wf.Namespace, wf.Name)
+	}
+	return 0
+}
+
+func runStop(ctx context.Context, cli *commandContext, args []string) int {
+	fs := flag.NewFlagSet("stop", flag.ExitOnError)
+	gha := fs.String("gha", "", "stop the latest workflow converted from this GHA workflow name")
+	message := fs.String("message", "", "message recorded on the stopped workflow")
+	fs.Parse(args)
+
+	name, ok := resolveTarget(ctx, cli, fs, *gha)
+	if !ok {
+		return 1
+	}
+	if _, err := cli.ops.Stop(ctx, name, *message); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("工作流 %s 已停止\n", name)
+	return 0
+}
+
+// simpleOp 生成只需要工作流名称的子命令（terminate / suspend / resume）
+func simpleOp(verb string, op func(*argoclient.WorkflowOps, context.Context, string) (*wfv1.Workflow, error)) func(context.Context, *commandContext, []string) int {
+	return func(ctx context.Context, cli *commandContext, args []string) int {
+		fs := flag.NewFlagSet(verb, flag.ExitOnError)
+		gha := fs.String("gha", "", verb+" the latest workflow converted from this GHA workflow name")
+		fs.Parse(args)
+
+		name, ok := resolveTarget(ctx, cli, fs, *gha)
+		if !ok {
+			return 1
+		}
+		wf, err := op(cli.ops, ctx, name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("%s %s: %s\n", verb, wf.Name, wf.Status.Phase)
+		return 0
+	}
+}
+
+func runDelete(ctx context.Context, cli *commandContext, args []string) int {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	gha := fs.String("gha", "", "delete all workflows converted from this GHA workflow name")
+	selector := fs.String("l", "", "delete all workflows matching this label selector")
+	fs.Parse(args)
+
+	switch {
+	case *gha != "" || *selector != "":
+		// -gha 和 -l 同时指定时两个条件都需要满足
+		var selectors []string
+		if *gha != "" {
+			selectors = append(selectors, argoclient.GHAWorkflowSelector(*gha))
+		}
+		if *selector != "" {
+			selectors = append(selectors, *selector)
+		}
+		deleted, err := cli.ops.DeleteSelected(ctx, strings.Join(selectors, ","))
+		for _, name := range deleted {
+			fmt.Printf("已删除工作流 %s\n", name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(deleted) == 0 {
+			fmt.Println("没有匹配的工作流")
+		}
+		return 0
+	case fs.NArg() == 1:
+		if err := cli.ops.Delete(ctx, fs.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("已删除工作流 %s\n", fs.Arg(0))
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "usage: argo-sdk %s\n", commands["delete"].usage)
+		return 2
+	}
+}
+
+// resolveTarget 确定操作的工作流：位置参数给出名称，或通过 -gha 查找该 GHA 工作流最新的一次运行
+func resolveTarget(ctx context.Context, cli *commandContext, fs *flag.FlagSet, gha string) (string, bool) {
+	switch {
+	case gha != "" && fs.NArg() == 0:
+		name, err := cli.ops.LatestForGHA(ctx, gha)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return "", false
+		}
+		return name, true
+	case gha == "" && fs.NArg() == 1:
+		return fs.Arg(0), true
+	default:
+		fmt.Fprintf(os.Stderr, "usage: argo-sdk %s\n", commands[fs.Name()].usage)
+		return "", false
+	}
+}
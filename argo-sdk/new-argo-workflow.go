@@ -3,87 +3,66 @@ package main
 import (
 	"context"
 	"flag"
-	"fmt"
 	"log"
+	"os"
 
-	"github.com/argoproj/argo-workflows/v3/pkg/apiclient"
 	workflowpkg "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
 	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/tools/clientcmd"
+
+	"argo-sdk/argoclient"
 )
 
+// 用法：
+//
+//	argo-sdk [connection flags] <command> [flags]
+//
+// 不指定子命令时创建示例工作流，子命令见 commands
 func main() {
-	// 创建 API 客户端
-	ctx, client, err := createArgoClient()
-	if err != nil {
-		log.Fatalf("Failed to create client: %v", err)
-	}
-
-	// 创建工作流
-	workflow := createSampleWorkflow()
+	var clientConfig argoclient.ArgoClientConfig
+	clientConfig.RegisterFlags(flag.CommandLine)
+	flag.Usage = printUsage
+	flag.Parse()
 
-	// 提交工作流
-	workflowClient := client.NewWorkflowServiceClient()
-	createdWf, err := workflowClient.CreateWorkflow(ctx, &workflowpkg.WorkflowCreateRequest{
-		Namespace: "default",
-		Workflow:  workflow,
-	})
+	name, args := "submit", flag.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		printUsage()
+		os.Exit(2)
+	}
 
+	// 创建 API 客户端，请求和工作流对象使用同一个 namespace
+	ctx, client, namespace, err := argoclient.NewArgoClient(clientConfig)
 	if err != nil {
-		log.Fatalf("Failed to create workflow: %v", err)
+		log.Fatalf("Failed to create client: %v", err)
 	}
+	workflowClient := client.NewWorkflowServiceClient()
 
-	fmt.Printf("工作流创建成功: %s\n", createdWf.Name)
-	fmt.Printf("状态: %s\n", createdWf.Status.Phase)
+	os.Exit(cmd.run(ctx, &commandContext{
+		client:    workflowClient,
+		namespace: namespace,
+		ops:       argoclient.NewWorkflowOps(workflowClient, namespace),
+	}, args))
 }
 
-func createArgoClient() (context.Context, apiclient.Client, error) {
-	// 方法一：使用 kubeconfig 直接连接 Kubernetes 集群
-	var kubeconfig *string
-
-	// 方式1: 自动发现默认路径 [citation:9]
-	// if home := homedir.HomeDir(); home != "" {
-	// 	kubeconfig = flag.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
-	// } else {
-	// 	kubeconfig = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
-	// }
-
-	// 方式2: 通过绝对路径直接指定 [citation:9]
-	kubeconfig = flag.String("kubeconfig", "/home/k9s/argo/openmerlin-guiyang-006-cluster-kubeconfig.yaml", "absolute path to the kubeconfig file")
-
-	// 方式3: 通过环境变量指定 [citation:5]
-	// 你可以在运行程序前，在终端执行: export KUBECONFIG=/your/path/kubeconfig
-	// envKubeconfig := os.Getenv("KUBECONFIG")
-	// if envKubeconfig != "" {
-	// 	kubeconfig = &envKubeconfig
-	// }
-
-	flag.Parse()
-
-	// 使用 kubeconfig 配置
-	opts := apiclient.Opts{
-		ClientConfigSupplier: func() clientcmd.ClientConfig {
-			loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-			if kubeconfig != nil && *kubeconfig != "" {
-				loadingRules.ExplicitPath = *kubeconfig
-			}
-			return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-				loadingRules,
-				&clientcmd.ConfigOverrides{},
-			)
-		},
+// watchWorkflow 跟踪工作流直到结束，返回进程退出码
+func watchWorkflow(ctx context.Context, client workflowpkg.WorkflowServiceClient, namespace, name string) int {
+	phase, err := argoclient.NewWorkflowWatcher(client, os.Stdout).Watch(ctx, namespace, name)
+	if err != nil {
+		log.Printf("Failed to watch workflow: %v", err)
 	}
-
-	return apiclient.NewClientFromOpts(opts)
+	return argoclient.ExitCodeForPhase(phase)
 }
 
-func createSampleWorkflow() *wfv1.Workflow {
+func createSampleWorkflow(namespace string) *wfv1.Workflow {
 	return &wfv1.Workflow{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: "hello-world-",
-			Namespace:    "argo",
+			Namespace:    namespace,
 		},
 		Spec: wfv1.WorkflowSpec{
 			Entrypoint: "hello-world",
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"argo-sdk/argoclient"
+	"gha-converter/convert"
+)
+
+// --- convert ---
+
+// conversionFlags 是 convert 和 submit 共用的转换参数
+type conversionFlags struct {
+	tenant            string
+	event             string
+	repository        string
+	ref               string
+	sha               string
+	runnerNamespace   string
+	runnerConfigMaps  bool
+	kube              kubeFlags
+	workspaceClaim    string
+	workspaceSize     string
+	workspaceGC       string
+	workspacePerJob   bool
+	cacheBackend      string
+	cacheClaim        string
+	cacheArtifactRepo string
+}
+
+// kubeFlags 是访问 Kubernetes 的参数
+type kubeFlags struct {
+	kubeconfig  string
+	kubeContext string
+}
+
+func (k *kubeFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&k.kubeconfig, "kubeconfig", "", "path to a kubeconfig, defaults to $KUBECONFIG, ~/.kube/config or in-cluster config")
+	fs.StringVar(&k.kubeContext, "kube-context", "", "kubeconfig context to use, defaults to current-context")
+}
+
+func (c *conversionFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.tenant, "tenant", "default", "tenant recorded on the converted workflows")
+	fs.StringVar(&c.event, "event", "", "event that triggers the workflow, such as push; on.schedule workflows become CronWorkflows when empty or schedule")
+	fs.StringVar(&c.repository, "repo", "", "source repository as owner/repo, recorded as provenance")
+	fs.StringVar(&c.ref, "ref", "", "source ref, such as refs/heads/main, recorded as provenance")
+	fs.StringVar(&c.sha, "sha", "", "source commit SHA, recorded as provenance")
+	fs.StringVar(&c.runnerNamespace, "runner-namespace", "argo", "namespace of runs-on ConfigMaps")
+	fs.BoolVar(&c.runnerConfigMaps, "runner-configmaps", false, "look up runs-on labels in Kubernetes ConfigMaps instead of the built-in image mapping")
+	c.kube.register(fs)
+	fs.StringVar(&c.workspaceClaim, "workspace-claim", "", "existing ReadWriteMany PVC holding the workspaces in a directory per run; a PVC per run is created when empty")
+	fs.BoolVar(&c.workspacePerJob, "workspace-per-job", false, "give every job its own workspace instead of one shared by all jobs of a run")
+	fs.StringVar(&c.workspaceSize, "workspace-size", convert.DefaultWorkspaceSize, "workspace PVC size when the runs-on ConfigMap does not set one")
+	fs.StringVar(&c.workspaceGC, "workspace-gc", string(wfv1.VolumeClaimGCOnCompletion), "workspace PVC GC strategy: OnWorkflowCompletion or OnWorkflowSuccess")
+	fs.StringVar(&c.cacheBackend, "cache-backend", convert.CacheBackendArtifact, "backend for actions/cache: artifact, pvc or none")
+	fs.StringVar(&c.cacheClaim, "cache-claim", "", "PVC used by the pvc cache backend")
+	fs.StringVar(&c.cacheArtifactRepo, "cache-artifact-repository", "", "artifact repository for the artifact cache backend as configmap[:key], defaults to the namespace default")
+}
+
+// options 校验参数并返回转换配置
+func (c *conversionFlags) options() (convert.ConversionOptions, error) {
+	opts := convert.ConversionOptions{
+		TenantID:        c.tenant,
+		RunnerNamespace: c.runnerNamespace,
+		Source: convert.SourceContext{
+			Repository: c.repository,
+			Ref:        c.ref,
+			SHA:        c.sha,
+			EventName:  c.event,
+		},
+		Workspace: convert.WorkspaceOptions{ExistingClaim: c.workspaceClaim, DefaultSize: c.workspaceSize, PerJob: c.workspacePerJob},
+		Cache:     convert.CacheOptions{Backend: c.cacheBackend, Claim: c.cacheClaim},
+	}
+	var err error
+	if opts.Workspace.GC, err = convert.ParseVolumeClaimGC(c.workspaceGC); err != nil {
+		return opts, err
+	}
+	if _, err := resource.ParseQuantity(c.workspaceSize); err != nil {
+		return opts, fmt.Errorf("invalid workspace-size %q: %w", c.workspaceSize, err)
+	}
+	if opts.Cache.ArtifactRepositoryRef, err = convert.ParseArtifactRepositoryRef(c.cacheArtifactRepo); err != nil {
+		return opts, err
+	}
+	if err := opts.Cache.Validate(); err != nil {
+		return opts, err
+	}
+	if c.runnerConfigMaps {
+		client, err := argoclient.NewKubernetesClient(c.kube.kubeconfig, c.kube.kubeContext)
+		if err != nil {
+			return opts, fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		opts.Runners = convert.ConfigMapRunnerConfigs{Client: client}
+	}
+	return opts, nil
+}
+
+// convertInput 转换一个 GHA 工作流，警告输出到 stderr
+func convertInput(ctx context.Context, in input, opts convert.ConversionOptions) (*convert.ConversionOutput, error) {
+	if in.Path != stdinPath {
+		opts.Source.WorkflowPath = in.Path
+	}
+	output, warnings, err := convert.GHAtoArgo(ctx, string(in.Data), opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range warnings {
+		where := in.Path
+		if w.Line > 0 {
+			where = fmt.Sprintf("%s:%d:%d", where, w.Line, w.Column)
+		}
+		if w.Job != "" {
+			where += ": job " + w.Job
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s: %s [%s]\n", where, w.Message, w.Code)
+	}
+	return output, nil
+}
+
+// runConvert 转换 GHA 工作流，输出到标准输出或 -out-dir 下与输入同名的文件
+func runConvert(ctx context.Context, args []string) int {
+	fs := newFlagSet("convert")
+	format := outputFlag(fs, formatYAML, formatJSON)
+	outDir := fs.String("out-dir", "", "write each workflow to <out-dir>/<input name>.yaml or .json instead of stdout")
+	var conversion conversionFlags
+	conversion.register(fs)
+	if status, ok := parseFlags(fs, args); !ok {
+		return status
+	}
+	if err := checkFormat(*format, formatYAML, formatJSON); err != nil {
+		return usageError("%v", err)
+	}
+	opts, err := conversion.options()
+	if err != nil {
+		return usageError("%v", err)
+	}
+	inputs, err := readInputs(fs.Args())
+	if err != nil {
+		return usageError("%v", err)
+	}
+
+	status := exitOK
+	var docs []interface{}
+	for _, in := range inputs {
+		output, err := convertInput(ctx, in, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", in.Path, err)
+			status = exitFailed
+			continue
+		}
+		if *outDir == "" {
+			docs = append(docs, output.Objects()...)
+			continue
+		}
+		path := filepath.Join(*outDir, outputName(in, output)+"."+*format)
+		if err := writeFile(path, *format, output.Objects()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+		fmt.Fprintf(os.Stderr, "%s -> %s\n", in.Path, path)
+	}
+	if len(docs) > 0 {
+		if err := writeDocuments(os.Stdout, *format, docs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+	}
+	return status
+}
+
+// outputName 返回 -out-dir 下的文件名：输入文件名去掉扩展名，标准输入使用工作流名称
+func outputName(in input, output *convert.ConversionOutput) string {
+	if in.Path != stdinPath {
+		base := filepath.Base(in.Path)
+		return strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	return strings.TrimSuffix(output.Workflow.GenerateName, "-")
+}
+
+// writeFile 把资源写入文件，必要时创建目录；有多个 CronWorkflow 时写为多文档
+func writeFile(path, format string, docs []interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeDocuments(f, format, docs); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
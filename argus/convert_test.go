@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- convert ---
+
+func TestConvert(t *testing.T) {
+	t.Run("stdin to YAML", func(t *testing.T) {
+		r := runArgus(t, ghaWorkflow, "convert", "-repo", "octo/app")
+		r.check(t, exitOK, "kind: Workflow", "generateName: ci-", "argus.io/repository: octo/app")
+	})
+
+	t.Run("file to JSON", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{"ci.yml": ghaWorkflow})
+		r := runArgus(t, "", "convert", "-o", "json", filepath.Join(dir, "ci.yml"))
+		r.check(t, exitOK)
+		var wf struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		}
+		if err := json.Unmarshal([]byte(r.stdout), &wf); err != nil {
+			t.Fatalf("output is not a JSON object: %v\n%s", err, r.stdout)
+		}
+		if wf.Kind != "Workflow" || wf.Metadata.Annotations["argus.io/workflow-path"] != filepath.Join(dir, "ci.yml") {
+			t.Errorf("kind = %q, annotations = %v", wf.Kind, wf.Metadata.Annotations)
+		}
+	})
+
+	t.Run("directory to out-dir", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"ci.yml":      ghaWorkflow,
+			"nightly.yml": "name: nightly\non:\n  schedule:\n    - cron: \"0 3 * * *\"\n    - cron: \"0 4 * * *\"\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - run: make\n",
+			"notes.txt":   "not a workflow",
+		})
+		outDir := filepath.Join(t.TempDir(), "out")
+		r := runArgus(t, "", "convert", "-out-dir", outDir, dir)
+		r.check(t, exitOK)
+		if r.stdout != "" {
+			t.Errorf("stdout = %q, want nothing with -out-dir", r.stdout)
+		}
+		ci, err := os.ReadFile(filepath.Join(outDir, "ci.yaml"))
+		if err != nil || !strings.Contains(string(ci), "kind: Workflow") {
+			t.Errorf("ci.yaml: %v\n%s", err, ci)
+		}
+		// 两个 schedule 写为同一个文件中的两个 CronWorkflow
+		nightly, err := os.ReadFile(filepath.Join(outDir, "nightly.yaml"))
+		if err != nil || strings.Count(string(nightly), "kind: CronWorkflow") != 2 || !strings.Contains(string(nightly), "\n---\n") {
+			t.Errorf("nightly.yaml: %v\n%s", err, nightly)
+		}
+		if !strings.Contains(r.stderr, filepath.Join(dir, "ci.yml")+" -> "+filepath.Join(outDir, "ci.yaml")) {
+			t.Errorf("stderr = %q, want the written files", r.stderr)
+		}
+	})
+
+	t.Run("conversion error", func(t *testing.T) {
+		r := runArgus(t, "name: broken\non: push\njobs:\n  build: [\n", "convert")
+		r.check(t, exitFailed)
+		if !strings.Contains(r.stderr, "Error: <stdin>:") {
+			t.Errorf("stderr = %q, want the error of <stdin>", r.stderr)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		runArgus(t, "", "convert", filepath.Join(t.TempDir(), "missing.yml")).check(t, exitUsage)
+	})
+
+	t.Run("invalid workspace size", func(t *testing.T) {
+		r := runArgus(t, ghaWorkflow, "convert", "-workspace-size", "ten")
+		r.check(t, exitUsage)
+		if !strings.Contains(r.stderr, `invalid workspace-size "ten"`) {
+			t.Errorf("stderr = %q", r.stderr)
+		}
+	})
+}
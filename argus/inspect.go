@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"argo-parser/argowf"
+)
+
+// --- inspect ---
+
+// runInspect 输出 Argo 资源的参数、模板和调用图
+func runInspect(ctx context.Context, args []string) int {
+	fs := newFlagSet("inspect")
+	format := outputFlag(fs, formatText, formatYAML, formatJSON, formatDOT, formatMermaid)
+	depth := fs.Int("depth", 0, "with -o dot or mermaid, expand nested steps and DAG templates only to this depth; 0 expands all")
+	if status, ok := parseFlags(fs, args); !ok {
+		return status
+	}
+	if err := checkFormat(*format, formatText, formatYAML, formatJSON, formatDOT, formatMermaid); err != nil {
+		return usageError("%v", err)
+	}
+	inputs, err := readInputs(fs.Args())
+	if err != nil {
+		return usageError("%v", err)
+	}
+
+	reports := []argowf.WorkflowReport{}
+	for _, in := range inputs {
+		if !isArgoResource(in.Data) {
+			return usageError("%s is not an Argo resource; use 'argus plan' for GitHub Actions workflows", in.Path)
+		}
+		resources, err := argowf.ParseResources(in.Data, in.Path)
+		if err != nil {
+			return usageError("%v", err)
+		}
+		for _, parsed := range resources {
+			report := argowf.InspectResource(parsed.Resource)
+			report.Source = parsed.Source
+			reports = append(reports, report)
+		}
+	}
+
+	switch *format {
+	case formatYAML, formatJSON:
+		err = writeValue(os.Stdout, *format, reports)
+	case formatText:
+		err = writeEach(len(reports), func(i int) error { return argowf.WriteReportTable(os.Stdout, reports[i]) })
+	case formatDOT:
+		err = writeEach(len(reports), func(i int) error { return argowf.WriteDOT(os.Stdout, argowf.ReportGraph(reports[i], *depth)) })
+	case formatMermaid:
+		err = writeEach(len(reports), func(i int) error { return argowf.WriteMermaid(os.Stdout, argowf.ReportGraph(reports[i], *depth)) })
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	return exitOK
+}
+
+// writeEach 依次输出 n 个结果，以空行分隔
+func writeEach(n int, write func(i int) error) error {
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			if _, err := io.WriteString(os.Stdout, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := write(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
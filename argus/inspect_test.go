@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- inspect ---
+
+func TestInspect(t *testing.T) {
+	t.Run("stdin as text", func(t *testing.T) {
+		runArgus(t, argoWorkflow, "inspect").check(t, exitOK, "message", "hello", "main")
+	})
+
+	t.Run("file as JSON", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{"hello.yaml": argoWorkflow})
+		r := runArgus(t, "", "inspect", "-o", "json", filepath.Join(dir, "hello.yaml"))
+		r.check(t, exitOK)
+		var reports []struct {
+			Source string `json:"source"`
+		}
+		if err := json.Unmarshal([]byte(r.stdout), &reports); err != nil {
+			t.Fatalf("output is not a JSON array: %v\n%s", err, r.stdout)
+		}
+		if len(reports) != 1 || !strings.HasPrefix(reports[0].Source, filepath.Join(dir, "hello.yaml")) {
+			t.Errorf("reports = %+v", reports)
+		}
+	})
+
+	t.Run("graphs", func(t *testing.T) {
+		runArgus(t, argoWorkflow, "inspect", "-o", "dot").check(t, exitOK, "digraph")
+		runArgus(t, argoWorkflow, "inspect", "-o", "mermaid").check(t, exitOK, "flowchart")
+	})
+
+	t.Run("GitHub Actions workflow", func(t *testing.T) {
+		r := runArgus(t, ghaWorkflow, "inspect")
+		r.check(t, exitUsage)
+		if !strings.Contains(r.stderr, "use 'argus plan'") {
+			t.Errorf("stderr = %q", r.stderr)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// --- 参数、输入和输出 ---
+
+// 输出格式
+const (
+	formatText    = "text"
+	formatYAML    = "yaml"
+	formatJSON    = "json"
+	formatDOT     = "dot"
+	formatMermaid = "mermaid"
+)
+
+// stdinPath 是标准输入在提示信息和结果中的名称
+const stdinPath = "<stdin>"
+
+// newFlagSet 创建子命令的参数集，-h 和参数错误由 parseFlags 处理
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: argus %s\n\n%s\n\nflags:\n", commands[name].usage, commands[name].short)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags 解析参数，失败时返回的 ok 为 false，status 为应当使用的退出码
+func parseFlags(fs *flag.FlagSet, args []string) (status int, ok bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// outputFlag 注册 -o 参数，formats 的第一个为默认格式
+func outputFlag(fs *flag.FlagSet, formats ...string) *string {
+	return fs.String("o", formats[0], "output format: "+strings.Join(formats, ", "))
+}
+
+// checkFormat 校验 -o 参数
+func checkFormat(format string, formats ...string) error {
+	for _, f := range formats {
+		if f == format {
+			return nil
+		}
+	}
+	last := len(formats) - 1
+	return fmt.Errorf("unknown output format %q, must be %s or %s", format, strings.Join(formats[:last], ", "), formats[last])
+}
+
+// usageError 输出参数错误，返回 exitUsage
+func usageError(format string, args ...interface{}) int {
+	fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
+	return exitUsage
+}
+
+// input 是一个输入文件
+type input struct {
+	Path string // 文件路径，标准输入为 <stdin>
+	Data []byte
+}
+
+// readInputs 读取参数中的文件；目录展开为其中的 *.yml 和 *.yaml 文件，按文件名排序；
+// - 或没有参数时读取标准输入
+func readInputs(args []string) ([]input, error) {
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+	var inputs []input
+	for _, arg := range args {
+		if arg == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read stdin: %w", err)
+			}
+			inputs = append(inputs, input{Path: stdinPath, Data: data})
+			continue
+		}
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		files := []string{arg}
+		if info.IsDir() {
+			files = nil
+			for _, pattern := range []string{"*.yml", "*.yaml"} {
+				matches, _ := filepath.Glob(filepath.Join(arg, pattern))
+				files = append(files, matches...)
+			}
+			sort.Strings(files)
+			if len(files) == 0 {
+				return nil, fmt.Errorf("no *.yml or *.yaml file in %s", arg)
+			}
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, input{Path: file, Data: data})
+		}
+	}
+	return inputs, nil
+}
+
+// readsStdin 判断 readInputs 是否会读取标准输入
+func readsStdin(args []string) bool {
+	if len(args) == 0 {
+		return true
+	}
+	for _, arg := range args {
+		if arg == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// isArgoResource 判断输入是否为 Argo 资源：第一个文档的 apiVersion 属于 argoproj.io
+func isArgoResource(data []byte) bool {
+	var doc struct {
+		APIVersion string `json:"apiVersion"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	return strings.HasPrefix(doc.APIVersion, "argoproj.io/")
+}
+
+// writeValue 以 YAML 或 JSON 输出一个值
+func writeValue(w io.Writer, format string, v interface{}) error {
+	if format == formatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(v)
+	}
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// writeDocuments 输出多个资源：YAML 为以 --- 分隔的多文档，JSON 只有一个资源时为对象，否则为数组
+func writeDocuments(w io.Writer, format string, docs []interface{}) error {
+	if format == formatJSON {
+		if len(docs) == 1 {
+			return writeValue(w, format, docs[0])
+		}
+		return writeValue(w, format, docs)
+	}
+	for i, doc := range docs {
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if err := writeValue(w, format, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// --- 输入和输出 ---
+
+func TestReadInputs(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"b.yml":        "b",
+		"a.yaml":       "a",
+		"notes.txt":    "skipped",
+		"nested/c.yml": "not expanded",
+		"empty/.keep":  "",
+	})
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin.WriteString("from stdin")
+	stdin.Seek(0, 0)
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	t.Cleanup(func() { os.Stdin = oldStdin })
+
+	// 目录中的文件按名称排序，- 读取标准输入，不递归子目录
+	inputs, err := readInputs([]string{dir, "-", filepath.Join(dir, "notes.txt")})
+	if err != nil {
+		t.Fatalf("readInputs: %v", err)
+	}
+	var got []string
+	for _, in := range inputs {
+		got = append(got, strings.TrimPrefix(in.Path, dir+string(filepath.Separator))+"="+string(in.Data))
+	}
+	want := []string{"a.yaml=a", "b.yml=b", "<stdin>=from stdin", "notes.txt=skipped"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("inputs = %q, want %q", got, want)
+	}
+
+	for _, args := range [][]string{{filepath.Join(dir, "empty")}, {filepath.Join(dir, "missing.yml")}} {
+		if _, err := readInputs(args); err == nil {
+			t.Errorf("readInputs(%v) succeeded, want an error", args)
+		}
+	}
+	if !readsStdin(nil) || !readsStdin([]string{"a.yml", "-"}) || readsStdin([]string{"a.yml"}) {
+		t.Error("readsStdin: want true without arguments or with -, false otherwise")
+	}
+}
+
+func TestIsArgoResource(t *testing.T) {
+	tests := map[string]bool{
+		argoWorkflow:                        true,
+		ghaWorkflow:                         false,
+		"apiVersion: v1\nkind: ConfigMap\n": false,
+		"[":                                 false,
+	}
+	for data, want := range tests {
+		if got := isArgoResource([]byte(data)); got != want {
+			t.Errorf("isArgoResource(%.30q) = %v, want %v", data, got, want)
+		}
+	}
+}
+
+func TestWriteDocuments(t *testing.T) {
+	one := []interface{}{map[string]string{"kind": "Workflow"}}
+	two := append(one, map[string]string{"kind": "CronWorkflow"})
+	tests := []struct {
+		format string
+		docs   []interface{}
+		want   string
+	}{
+		{formatYAML, one, "kind: Workflow\n"},
+		{formatYAML, two, "kind: Workflow\n---\nkind: CronWorkflow\n"},
+		{formatJSON, one, "{\n  \"kind\": \"Workflow\"\n}\n"},
+		{formatJSON, two, "[\n  {\n    \"kind\": \"Workflow\"\n  },\n  {\n    \"kind\": \"CronWorkflow\"\n  }\n]\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeDocuments(&buf, tt.format, tt.docs); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s with %d documents:\n%s\nwant:\n%s", tt.format, len(tt.docs), buf.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// argus 是统一的命令行入口：转换、校验、检查、规划和提交工作流，并查询 runs-on 运行环境配置
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+)
+
+// 进程退出码，所有子命令一致
+const (
+	exitOK     = 0 // 成功
+	exitFailed = 1 // 校验发现问题，或转换、提交失败
+	exitUsage  = 2 // 参数错误、无法读取或解析输入
+)
+
+// --- 子命令 ---
+
+// command 是一个子命令，返回进程退出码
+type command struct {
+	usage string
+	short string
+	run   func(ctx context.Context, args []string) int
+}
+
+var commands map[string]command
+
+// 子命令表在 init 中初始化，避免与引用它的子命令之间形成初始化循环
+func init() {
+	commands = map[string]command{
+		"convert":       {"convert [flags] [file | dir | -]...", "convert GitHub Actions workflows to Argo Workflows or CronWorkflows", runConvert},
+		"validate":      {"validate [flags] [file | dir | -]...", "validate Argo resources and lint GitHub Actions workflows offline", runValidate},
+		"inspect":       {"inspect [flags] [file | dir | -]...", "show parameters, templates and the call graph of Argo resources", runInspect},
+		"plan":          {"plan [flags] [file | dir | -]...", "show the execution plan of GitHub Actions workflows, or simulate an event", runPlan},
+		"submit":        {"submit [flags] [file | dir | -]...", "submit Argo resources, converting GitHub Actions workflows first", runSubmit},
+		"runner-config": {"runner-config get [flags] <runs-on label>", "show the runner configuration of a runs-on label", runRunnerConfig},
+	}
+}
+
+// printUsage 输出所有子命令的用法
+func printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "usage: argus <command> [flags] [args]\n\ncommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", name, commands[name].short)
+	}
+	fmt.Fprintf(os.Stderr, "\nInputs are files, directories of *.yml/*.yaml files, or - for stdin; stdin is read when none is given.\n")
+	fmt.Fprintf(os.Stderr, "Exit status: %d success, %d issues found or conversion/submission failed, %d usage or input error.\n", exitOK, exitFailed, exitUsage)
+	fmt.Fprintf(os.Stderr, "Run 'argus <command> -h' for the flags of a command.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(exitUsage)
+	}
+	name := os.Args[1]
+	if name == "-h" || name == "-help" || name == "--help" || name == "help" {
+		printUsage()
+		os.Exit(exitOK)
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown command %q\n\n", name)
+		printUsage()
+		os.Exit(exitUsage)
+	}
+
+	// Ctrl-C 取消正在进行的转换和提交请求
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	status := cmd.run(ctx, os.Args[2:])
+	stop()
+	os.Exit(status)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- 子命令的测试工具 ---
+
+// ghaWorkflow 是测试用的 GHA 工作流：push 到 main 且修改 src 时运行，test 依赖 build
+const ghaWorkflow = `name: ci
+on:
+  push:
+    branches: [main]
+    paths: ["src/**"]
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make build
+  test:
+    needs: build
+    runs-on: ubuntu-latest
+    steps:
+      - run: make test
+`
+
+// argoWorkflow 是测试用的 Argo Workflow
+const argoWorkflow = `apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: hello-
+spec:
+  entrypoint: main
+  arguments:
+    parameters:
+      - name: message
+        value: hello
+  templates:
+    - name: main
+      container:
+        image: alpine:3.20
+        command: [echo, "{{workflow.parameters.message}}"]
+`
+
+// result 是一次子命令运行的结果
+type result struct {
+	status int
+	stdout string
+	stderr string
+}
+
+// runArgus 运行子命令：stdin 作为标准输入，标准输出和标准错误写入临时文件后返回
+func runArgus(t *testing.T, stdin string, args ...string) result {
+	t.Helper()
+	cmd, ok := commands[args[0]]
+	if !ok {
+		t.Fatalf("unknown command %q", args[0])
+	}
+	dir := t.TempDir()
+	open := func(name, content string) *os.File {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.WriteString(content); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	in, out, errOut := open("stdin", stdin), open("stdout", ""), open("stderr", "")
+	defer in.Close()
+	defer out.Close()
+	defer errOut.Close()
+
+	oldIn, oldOut, oldErr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = in, out, errOut
+	status := cmd.run(context.Background(), args[1:])
+	os.Stdin, os.Stdout, os.Stderr = oldIn, oldOut, oldErr
+
+	read := func(f *os.File) string {
+		data, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	return result{status: status, stdout: read(out), stderr: read(errOut)}
+}
+
+// writeFiles 在临时目录中写入文件，返回目录
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// check 比较退出码，输出应包含 wantStdout 中的每段文本
+func (r result) check(t *testing.T, wantStatus int, wantStdout ...string) {
+	t.Helper()
+	if r.status != wantStatus {
+		t.Fatalf("status = %d, want %d\nstdout:\n%s\nstderr:\n%s", r.status, wantStatus, r.stdout, r.stderr)
+	}
+	for _, want := range wantStdout {
+		if !strings.Contains(r.stdout, want) {
+			t.Errorf("stdout does not contain %q:\n%s", want, r.stdout)
+		}
+	}
+}
+
+// TestFlagErrors 每个子命令的 -h、未知参数和未知输出格式使用一致的退出码
+func TestFlagErrors(t *testing.T) {
+	for name := range commands {
+		args := []string{name}
+		if name == "runner-config" {
+			args = append(args, "get")
+		}
+		t.Run(name, func(t *testing.T) {
+			if r := runArgus(t, "", append(args, "-h")...); r.status != exitOK || !strings.Contains(r.stderr, "usage: argus "+name) {
+				t.Errorf("-h: status = %d, stderr = %q", r.status, r.stderr)
+			}
+			if r := runArgus(t, "", append(args, "-no-such-flag")...); r.status != exitUsage {
+				t.Errorf("unknown flag: status = %d, want %d", r.status, exitUsage)
+			}
+			if r := runArgus(t, "", append(args, "-o", "xml", "-")...); r.status != exitUsage || !strings.Contains(r.stderr, `unknown output format "xml"`) {
+				t.Errorf("-o xml: status = %d, stderr = %q", r.status, r.stderr)
+			}
+		})
+	}
+}
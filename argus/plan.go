@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nektos/act/pkg/model"
+
+	"workflow-parser/ghawf"
+)
+
+// --- plan ---
+
+// runPlan 输出 GHA 工作流的执行计划；指定 -payload 或 -changed-files 时改为模拟事件，报告哪些工作流和 Job 会运行
+func runPlan(ctx context.Context, args []string) int {
+	fs := newFlagSet("plan")
+	format := outputFlag(fs, formatText, formatYAML, formatJSON, formatDOT, formatMermaid)
+	event := fs.String("event", "", "event to plan for, such as push; all jobs are planned when empty")
+	payload := fs.String("payload", "", "event payload JSON file; simulates -event against the on filters and job conditions")
+	changedFiles := fs.String("changed-files", "", "file listing the changed files one per line for the simulation; paths filters are not checked when empty")
+	if status, ok := parseFlags(fs, args); !ok {
+		return status
+	}
+	simulate := *payload != "" || *changedFiles != ""
+	formats := []string{formatText, formatYAML, formatJSON, formatDOT, formatMermaid}
+	if simulate {
+		formats = formats[:3]
+	}
+	if err := checkFormat(*format, formats...); err != nil {
+		return usageError("%v", err)
+	}
+	if simulate && *event == "" {
+		return usageError("-payload and -changed-files need -event")
+	}
+	if *changedFiles == "-" && readsStdin(fs.Args()) {
+		return usageError("-changed-files - cannot be used when the workflows are read from stdin")
+	}
+	inputs, err := readInputs(fs.Args())
+	if err != nil {
+		return usageError("%v", err)
+	}
+
+	if simulate {
+		return simulateEvent(inputs, *event, *payload, *changedFiles, *format)
+	}
+	plan := &model.Plan{}
+	for _, in := range inputs {
+		planner, err := model.NewSingleWorkflowPlanner(filepath.Base(in.Path), bytes.NewReader(in.Data))
+		if err != nil {
+			return usageError("%s: failed to parse workflow: %v", in.Path, err)
+		}
+		var p *model.Plan
+		if *event == "" {
+			p, err = planner.PlanAll()
+		} else {
+			p, err = planner.PlanEvent(*event)
+		}
+		if err != nil {
+			return usageError("%s: failed to create plan: %v", in.Path, err)
+		}
+		mergeStages(plan, p)
+	}
+
+	report := ghawf.NewPlanReport(plan, *event)
+	switch *format {
+	case formatYAML, formatJSON:
+		err = writeValue(os.Stdout, *format, report)
+	case formatText:
+		writePlanText(os.Stdout, report)
+	case formatDOT:
+		err = ghawf.WriteDOT(os.Stdout, ghawf.PlanGraph(report))
+	case formatMermaid:
+		err = ghawf.WriteMermaid(os.Stdout, ghawf.PlanGraph(report))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	return exitOK
+}
+
+// mergeStages 与 act 合并目录中多个工作流的计划的方式一致：第 i 个阶段并入第 i 个阶段
+func mergeStages(plan, other *model.Plan) {
+	for i, stage := range other.Stages {
+		if i >= len(plan.Stages) {
+			plan.Stages = append(plan.Stages, &model.Stage{})
+		}
+		plan.Stages[i].Runs = append(plan.Stages[i].Runs, stage.Runs...)
+	}
+}
+
+// writePlanText 以文本输出执行计划
+func writePlanText(w io.Writer, report ghawf.PlanReport) {
+	event := report.Event
+	if event == "" {
+		event = "all events"
+	}
+	fmt.Fprintf(w, "Plan for %s: %d stages\n", event, len(report.Stages))
+	for _, stage := range report.Stages {
+		fmt.Fprintf(w, "  stage %d:\n", stage.Index)
+		for _, run := range stage.Runs {
+			fmt.Fprintf(w, "    - %s / %s", run.File, run.JobID)
+			if len(run.RunsOn) > 0 {
+				fmt.Fprintf(w, " (runs-on: %s)", strings.Join(run.RunsOn, ", "))
+			}
+			fmt.Fprintf(w, ": steps: %d", run.Steps)
+			if run.Matrix > 1 {
+				fmt.Fprintf(w, ", matrix: %d", run.Matrix)
+			}
+			if len(run.Needs) > 0 {
+				fmt.Fprintf(w, ", needs: %s", strings.Join(run.Needs, ", "))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// simulateEvent 模拟事件并输出哪些工作流和 Job 会运行
+func simulateEvent(inputs []input, event, payload, changedFiles, format string) int {
+	ctx, err := ghawf.LoadEventContext(event, payload, changedFiles)
+	if err != nil {
+		return usageError("%v", err)
+	}
+	workflows := make([]*model.Workflow, 0, len(inputs))
+	for _, in := range inputs {
+		wf, err := model.ReadWorkflow(bytes.NewReader(in.Data), false)
+		if err != nil {
+			return usageError("%s: failed to parse workflow: %v", in.Path, err)
+		}
+		wf.File = filepath.Base(in.Path)
+		workflows = append(workflows, wf)
+	}
+
+	plan := ghawf.SimulateEvent(workflows, ctx)
+	if format == formatText {
+		ghawf.WriteEventPlanText(os.Stdout, plan)
+		return exitOK
+	}
+	if err := writeValue(os.Stdout, format, plan); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	return exitOK
+}
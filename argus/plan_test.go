@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- plan ---
+
+func TestPlan(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"ci.yml":       ghaWorkflow,
+		"payload.json": `{"ref": "refs/heads/main"}`,
+		"changed.txt":  "docs/a.md\n",
+	})
+	workflow := filepath.Join(dir, "ci.yml")
+
+	t.Run("all events", func(t *testing.T) {
+		runArgus(t, "", "plan", workflow).check(t, exitOK,
+			"Plan for all events: 2 stages",
+			"ci.yml / build (runs-on: ubuntu-latest): steps: 1",
+			"ci.yml / test (runs-on: ubuntu-latest): steps: 1, needs: build",
+		)
+	})
+
+	t.Run("stdin as JSON", func(t *testing.T) {
+		r := runArgus(t, ghaWorkflow, "plan", "-event", "push", "-o", "json")
+		r.check(t, exitOK)
+		var report struct {
+			Event  string            `json:"event"`
+			Stages []json.RawMessage `json:"stages"`
+		}
+		if err := json.Unmarshal([]byte(r.stdout), &report); err != nil {
+			t.Fatalf("output is not a JSON object: %v\n%s", err, r.stdout)
+		}
+		if report.Event != "push" || len(report.Stages) != 2 {
+			t.Errorf("event = %q, %d stages, want push and 2 stages", report.Event, len(report.Stages))
+		}
+	})
+
+	t.Run("graph", func(t *testing.T) {
+		runArgus(t, "", "plan", "-o", "mermaid", workflow).check(t, exitOK, "flowchart")
+	})
+
+	t.Run("simulated event", func(t *testing.T) {
+		payload := filepath.Join(dir, "payload.json")
+		runArgus(t, "", "plan", "-event", "push", "-payload", payload, "-changed-files", filepath.Join(dir, "changed.txt"), workflow).
+			check(t, exitOK, "ci.yml (ci): not triggered: no changed file matches paths [src/**]")
+		// 变更的文件从标准输入读取
+		runArgus(t, "src/main.go\n", "plan", "-event", "push", "-payload", payload, "-changed-files", "-", workflow).
+			check(t, exitOK, "ci.yml (ci): triggered", "+ build", "+ test")
+	})
+
+	t.Run("usage errors", func(t *testing.T) {
+		tests := []struct {
+			args    []string
+			wantErr string
+		}{
+			{[]string{"-payload", "payload.json", workflow}, "-payload and -changed-files need -event"},
+			{[]string{"-event", "push", "-changed-files", "-"}, "cannot be used when the workflows are read from stdin"},
+			{[]string{"-event", "push", "-payload", "payload.json", "-o", "dot", workflow}, `unknown output format "dot"`},
+		}
+		for _, tt := range tests {
+			r := runArgus(t, "", append([]string{"plan"}, tt.args...)...)
+			if r.status != exitUsage || !strings.Contains(r.stderr, tt.wantErr) {
+				t.Errorf("plan %v: status = %d, stderr = %q, want %q", tt.args, r.status, r.stderr, tt.wantErr)
+			}
+		}
+	})
+}
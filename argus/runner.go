@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"argo-sdk/argoclient"
+	"gha-converter/convert"
+)
+
+// --- runner-config ---
+
+// runnerConfigResult 是 runner-config get 的输出
+type runnerConfigResult struct {
+	Label     string `json:"label"`
+	Namespace string `json:"namespace"`
+	// ConfigMap 是按 runs-on 标签查找的 ConfigMap；builtin 为 true 时表示只使用了内置的镜像映射
+	ConfigMap string `json:"configMap,omitempty"`
+	Builtin   bool   `json:"builtin,omitempty"`
+	convert.RunnerConfig
+}
+
+// runRunnerConfig 查询 runs-on 标签对应的运行环境，与转换时的查找方式一致
+func runRunnerConfig(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] != "get" {
+		return usageError("usage: argus %s", commands["runner-config"].usage)
+	}
+	fs := newFlagSet("runner-config")
+	format := outputFlag(fs, formatYAML, formatJSON)
+	namespace := fs.String("namespace", "argo", "namespace of runs-on ConfigMaps")
+	builtin := fs.Bool("builtin", false, "show the built-in image mapping without contacting the cluster")
+	var kube kubeFlags
+	kube.register(fs)
+	if status, ok := parseFlags(fs, args[1:]); !ok {
+		return status
+	}
+	if err := checkFormat(*format, formatYAML, formatJSON); err != nil {
+		return usageError("%v", err)
+	}
+	if fs.NArg() != 1 {
+		return usageError("runner-config get takes exactly one runs-on label")
+	}
+	label := fs.Arg(0)
+
+	var runners convert.RunnerConfigProvider = convert.StaticRunnerConfigs{}
+	result := runnerConfigResult{Label: label, Namespace: *namespace, Builtin: *builtin}
+	if !*builtin {
+		client, err := argoclient.NewKubernetesClient(kube.kubeconfig, kube.kubeContext)
+		if err != nil {
+			return usageError("failed to create Kubernetes client: %v", err)
+		}
+		runners = convert.ConfigMapRunnerConfigs{Client: client}
+		result.ConfigMap = convert.RunnerConfigMapName(label)
+	}
+	config, err := runners.RunnerConfig(ctx, *namespace, label)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailed
+	}
+	result.RunnerConfig = *config
+	if err := writeValue(os.Stdout, *format, result); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	return exitOK
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- runner-config ---
+
+func TestRunnerConfig(t *testing.T) {
+	runArgus(t, "", "runner-config", "get", "-builtin", "ubuntu-latest").
+		check(t, exitOK, "label: ubuntu-latest", "namespace: argo", "builtin: true", "image: ubuntu:22.04")
+	runArgus(t, "", "runner-config", "get", "-builtin", "-o", "json", "-namespace", "ci", "ubuntu-24.04-arm").
+		check(t, exitOK, `"namespace": "ci"`, `"arch": "ARM64"`)
+
+	for _, args := range [][]string{
+		{"runner-config"},
+		{"runner-config", "list"},
+		{"runner-config", "get", "-builtin"},
+		{"runner-config", "get", "-builtin", "ubuntu-latest", "windows-latest"},
+	} {
+		r := runArgus(t, "", args...)
+		if r.status != exitUsage || !strings.HasPrefix(r.stderr, "Error: ") {
+			t.Errorf("%v: status = %d, stderr = %q, want a usage error", args, r.status, r.stderr)
+		}
+	}
+}
+
+// fakeKubernetes 是只提供 ConfigMap 的 Kubernetes API，返回写入了该服务地址的 kubeconfig 路径
+func fakeKubernetes(t *testing.T, configMaps map[string]map[string]string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		namespace, name, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/namespaces/"), "/configmaps/")
+		data, found := configMaps[namespace+"/"+name]
+		if !ok || !found {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "NotFound", "code": http.StatusNotFound,
+				"message": "configmaps \"" + name + "\" not found",
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"kind": "ConfigMap", "apiVersion": "v1",
+			"metadata": map[string]string{"name": name, "namespace": namespace},
+			"data":     data,
+		})
+	}))
+	t.Cleanup(server.Close)
+	kubeconfig := "apiVersion: v1\nkind: Config\ncurrent-context: test\n" +
+		"clusters:\n- name: test\n  cluster:\n    server: " + server.URL + "\n" +
+		"contexts:\n- name: test\n  context:\n    cluster: test\n    user: test\n" +
+		"users:\n- name: test\n  user: {}\n"
+	return filepath.Join(writeFiles(t, map[string]string{"kubeconfig": kubeconfig}), "kubeconfig")
+}
+
+// TestRunnerConfigFromConfigMap 按 runs-on 标签读取 ConfigMap，ConfigMap 不存在时使用内置映射
+func TestRunnerConfigFromConfigMap(t *testing.T) {
+	kubeconfig := fakeKubernetes(t, map[string]map[string]string{
+		"runners/gpu-large": {"image": "registry.example.com/cuda:12", "arch": "x64", "workspaceSize": "50Gi"},
+		"runners/broken":    {"workspaceSize": "fifty"},
+	})
+
+	runArgus(t, "", "runner-config", "get", "-kubeconfig", kubeconfig, "-namespace", "runners", "GPU Large").
+		check(t, exitOK, "configMap: gpu-large", "image: registry.example.com/cuda:12", "arch: X64", "size: 50Gi")
+	runArgus(t, "", "runner-config", "get", "-kubeconfig", kubeconfig, "-namespace", "runners", "ubuntu-latest").
+		check(t, exitOK, "configMap: ubuntu-latest", "image: ubuntu:22.04")
+
+	r := runArgus(t, "", "runner-config", "get", "-kubeconfig", kubeconfig, "-namespace", "runners", "broken")
+	r.check(t, exitFailed)
+	if !strings.Contains(r.stderr, `key "workspaceSize"`) {
+		t.Errorf("stderr = %q", r.stderr)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+
+	"argo-parser/argowf"
+	"argo-sdk/argoclient"
+	"gha-converter/convert"
+)
+
+// --- submit ---
+
+// submitResult 是一个资源的提交结果，失败时 Error 不为空
+type submitResult struct {
+	Source string `json:"source"`
+	convert.SubmittedWorkflow
+	Error string `json:"error,omitempty"`
+}
+
+// runSubmit 提交 Argo Workflow 和 CronWorkflow；GHA 工作流先转换再提交。
+// 连接、namespace 解析和 -watch 的状态跟踪都使用 argo-sdk 的 argoclient
+func runSubmit(ctx context.Context, args []string) int {
+	fs := newFlagSet("submit")
+	format := outputFlag(fs, formatText, formatYAML, formatJSON)
+	dryRun := fs.Bool("dry-run", false, "use a server-side dry run instead of creating the workflows")
+	watch := fs.Bool("watch", false, "follow the status and logs of the created workflows until they finish; exits 1 unless all succeed")
+	var argoConfig argoclient.ArgoClientConfig
+	fs.StringVar(&argoConfig.Namespace, "namespace", os.Getenv(argoclient.EnvArgoNamespace), "namespace to create the workflows in, defaults to the kubeconfig context namespace (env "+argoclient.EnvArgoNamespace+")")
+	fs.StringVar(&argoConfig.ServerURL, "argo-server", os.Getenv(argoclient.EnvArgoServer), "Argo Server host:port; uses kubeconfig when empty (env "+argoclient.EnvArgoServer+")")
+	fs.StringVar(&argoConfig.Token, "argo-token", os.Getenv(argoclient.EnvArgoToken), "bearer token for Argo Server (env "+argoclient.EnvArgoToken+")")
+	fs.BoolVar(&argoConfig.Secure, "argo-secure", os.Getenv(argoclient.EnvArgoSecure) != "false", "use TLS when connecting to Argo Server (env "+argoclient.EnvArgoSecure+")")
+	fs.BoolVar(&argoConfig.InsecureSkipVerify, "argo-insecure-skip-verify", os.Getenv(argoclient.EnvArgoInsecureSkipVerify) == "true", "skip Argo Server certificate verification (env "+argoclient.EnvArgoInsecureSkipVerify+")")
+	var conversion conversionFlags
+	conversion.register(fs)
+	if status, ok := parseFlags(fs, args); !ok {
+		return status
+	}
+	if err := checkFormat(*format, formatText, formatYAML, formatJSON); err != nil {
+		return usageError("%v", err)
+	}
+	opts, err := conversion.options()
+	if err != nil {
+		return usageError("%v", err)
+	}
+	inputs, err := readInputs(fs.Args())
+	if err != nil {
+		return usageError("%v", err)
+	}
+
+	// 先解析和转换所有输入，任何一个无法提交时不创建任何工作流
+	type pending struct {
+		source string
+		output *convert.ConversionOutput
+	}
+	var queue []pending
+	for _, in := range inputs {
+		if !isArgoResource(in.Data) {
+			output, err := convertInput(ctx, in, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", in.Path, err)
+				return exitFailed
+			}
+			queue = append(queue, pending{in.Path, output})
+			continue
+		}
+		resources, err := argowf.ParseResources(in.Data, in.Path)
+		if err != nil {
+			return usageError("%v", err)
+		}
+		for _, parsed := range resources {
+			switch r := parsed.Resource.(type) {
+			case argowf.WorkflowResource:
+				queue = append(queue, pending{parsed.Source, &convert.ConversionOutput{Workflow: r.Workflow}})
+			case argowf.CronWorkflowResource:
+				queue = append(queue, pending{parsed.Source, &convert.ConversionOutput{Crons: []*wfv1.CronWorkflow{r.CronWorkflow}}})
+			default:
+				return usageError("%s: cannot submit a %s, only Workflow and CronWorkflow", parsed.Source, r.ResourceKind())
+			}
+		}
+	}
+
+	argoConfig.Kubeconfig = conversion.kube.kubeconfig
+	argoConfig.Context = conversion.kube.kubeContext
+	apiCtx, client, namespace, err := argoclient.NewArgoClient(argoConfig)
+	if err != nil {
+		return usageError("%v", err)
+	}
+	cronClient, err := client.NewCronWorkflowServiceClient()
+	if err != nil {
+		return usageError("failed to create Argo CronWorkflow client: %v", err)
+	}
+	workflowClient := client.NewWorkflowServiceClient()
+	submitter := convert.NewArgoSubmitterWithClient(apiCtx, workflowClient, cronClient)
+	status := exitOK
+	results := make([]submitResult, 0, len(queue))
+	for _, p := range queue {
+		result := submitResult{Source: p.source}
+		submitted, err := submitter.Submit(ctx, p.output, convert.SubmitOptions{Namespace: namespace, DryRun: *dryRun})
+		if err != nil {
+			result.Error = err.Error()
+			status = exitFailed
+		} else {
+			result.SubmittedWorkflow = *submitted
+		}
+		results = append(results, result)
+	}
+
+	if *format != formatText {
+		if err := writeValue(os.Stdout, *format, results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+	} else {
+		printSubmitResults(results)
+	}
+	if *watch && !*dryRun {
+		// YAML/JSON 输出时状态和日志写到标准错误，标准输出只有提交结果
+		out := os.Stdout
+		if *format != formatText {
+			out = os.Stderr
+		}
+		// 请求使用 apiclient 上下文的认证信息，随 ctx 一起取消
+		watchCtx, cancel := context.WithCancel(apiCtx)
+		stop := context.AfterFunc(ctx, cancel)
+		succeeded := watchSubmitted(watchCtx, argoclient.NewWorkflowWatcher(workflowClient, out), results)
+		stop()
+		cancel()
+		if !succeeded {
+			status = exitFailed
+		}
+	}
+	return status
+}
+
+// printSubmitResults 以文本输出提交结果，错误写到标准错误
+func printSubmitResults(results []submitResult) {
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Fprintf(os.Stderr, "Error: %s: %s\n", r.Source, r.Error)
+			continue
+		}
+		// 有多个 schedule 的工作流创建了多个 CronWorkflow
+		names := r.Names
+		if len(names) == 0 {
+			names = []string{r.Name}
+		}
+		// 已存在的 CronWorkflow 被更新而不是创建
+		verb := "created"
+		if r.Updated {
+			verb = "updated"
+		}
+		for _, name := range names {
+			if r.DryRun {
+				fmt.Printf("%s: %s %s/%s validated (dry run)\n", r.Source, r.Kind, r.Namespace, name)
+			} else {
+				fmt.Printf("%s: %s %s/%s %s\n", r.Source, r.Kind, r.Namespace, name, verb)
+			}
+		}
+	}
+}
+
+// watchSubmitted 依次跟踪创建的工作流直到结束，全部成功时返回 true；
+// CronWorkflow 按计划运行，不跟踪
+func watchSubmitted(ctx context.Context, watcher *argoclient.WorkflowWatcher, results []submitResult) bool {
+	succeeded := true
+	for _, r := range results {
+		if r.Error != "" || r.Kind != "Workflow" {
+			continue
+		}
+		phase, err := watcher.Watch(ctx, r.Namespace, r.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", r.Source, err)
+		}
+		if argoclient.ExitCodeForPhase(phase) != 0 {
+			succeeded = false
+		}
+	}
+	return succeeded
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	cronworkflowpkg "github.com/argoproj/argo-workflows/v3/pkg/apiclient/cronworkflow"
+	workflowpkg "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"argo-sdk/argoclient"
+)
+
+// --- submit ---
+
+// fakeArgoServer 是只实现创建请求的 Argo Server，记录收到的资源和认证头
+type fakeArgoServer struct {
+	workflowpkg.UnimplementedWorkflowServiceServer
+	cron fakeCronServer
+
+	mu             sync.Mutex
+	workflows      []*wfv1.Workflow
+	crons          []*wfv1.CronWorkflow
+	authorizations []string
+}
+
+type fakeCronServer struct {
+	cronworkflowpkg.UnimplementedCronWorkflowServiceServer
+	argo *fakeArgoServer
+}
+
+func (s *fakeArgoServer) record(ctx context.Context) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.authorizations = append(s.authorizations, md.Get("authorization")...)
+}
+
+func (s *fakeArgoServer) CreateWorkflow(ctx context.Context, req *workflowpkg.WorkflowCreateRequest) (*wfv1.Workflow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.record(ctx)
+	s.workflows = append(s.workflows, req.Workflow)
+	created := req.Workflow.DeepCopy()
+	created.Name, created.Namespace = created.GenerateName+"x7k2p", req.Namespace
+	return created, nil
+}
+
+func (s *fakeCronServer) CreateCronWorkflow(ctx context.Context, req *cronworkflowpkg.CreateCronWorkflowRequest) (*wfv1.CronWorkflow, error) {
+	s.argo.mu.Lock()
+	defer s.argo.mu.Unlock()
+	s.argo.record(ctx)
+	s.argo.crons = append(s.argo.crons, req.CronWorkflow)
+	created := req.CronWorkflow.DeepCopy()
+	created.Namespace = req.Namespace
+	return created, nil
+}
+
+// startArgoServer 启动 gRPC 服务，返回 host:port
+func startArgoServer(t *testing.T) (*fakeArgoServer, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	argo := &fakeArgoServer{}
+	argo.cron.argo = argo
+	server := grpc.NewServer()
+	workflowpkg.RegisterWorkflowServiceServer(server, argo)
+	cronworkflowpkg.RegisterCronWorkflowServiceServer(server, &argo.cron)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return argo, listener.Addr().String()
+}
+
+func TestSubmit(t *testing.T) {
+	// 连接参数的默认值来自环境变量，测试中清空，避免读取开发环境的配置
+	for _, env := range []string{argoclient.EnvArgoServer, argoclient.EnvArgoToken, argoclient.EnvArgoSecure, argoclient.EnvArgoNamespace} {
+		t.Setenv(env, "")
+	}
+	argo, addr := startArgoServer(t)
+	connect := []string{"submit", "-argo-server", addr, "-argo-token", "s3cr3t", "-argo-secure=false", "-namespace", "ci"}
+
+	dir := writeFiles(t, map[string]string{
+		"hello.yaml":  argoWorkflow,
+		"nightly.yml": "name: nightly\non:\n  schedule:\n    - cron: \"0 3 * * *\"\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - run: make\n",
+	})
+	r := runArgus(t, ghaWorkflow, append(connect, "-", dir)...)
+	r.check(t, exitOK,
+		"<stdin>: Workflow ci/ci-x7k2p created",
+		"hello.yaml: Workflow ci/hello-x7k2p created",
+		"nightly.yml: CronWorkflow ci/nightly created",
+	)
+	if len(argo.workflows) != 2 || len(argo.crons) != 1 {
+		t.Fatalf("created %d workflows and %d CronWorkflows, want 2 and 1", len(argo.workflows), len(argo.crons))
+	}
+	// GHA 工作流先转换，Argo 资源原样提交
+	if argo.workflows[0].Spec.Entrypoint != "main-dag" || argo.workflows[1].Spec.Entrypoint != "main" {
+		t.Errorf("entrypoints = %q, %q", argo.workflows[0].Spec.Entrypoint, argo.workflows[1].Spec.Entrypoint)
+	}
+	for _, auth := range argo.authorizations {
+		if auth != "Bearer s3cr3t" {
+			t.Errorf("authorization = %q, want Bearer s3cr3t", auth)
+		}
+	}
+
+	r = runArgus(t, argoWorkflow, append(connect, "-o", "json")...)
+	r.check(t, exitOK)
+	var results []submitResult
+	if err := json.Unmarshal([]byte(r.stdout), &results); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, r.stdout)
+	}
+	if len(results) != 1 || results[0].Source != stdinPath || results[0].Name != "hello-x7k2p" {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+// TestSubmitRejectsBeforeConnecting 无法提交的输入在连接 Argo 之前就报错，不创建任何工作流
+func TestSubmitRejectsBeforeConnecting(t *testing.T) {
+	argo, addr := startArgoServer(t)
+	connect := []string{"submit", "-argo-server", addr, "-argo-token", "s3cr3t", "-argo-secure=false", "-namespace", "ci"}
+	template := strings.Replace(argoWorkflow, "kind: Workflow", "kind: WorkflowTemplate", 1)
+	template = strings.Replace(template, "generateName: hello-", "name: hello", 1)
+
+	tests := []struct {
+		name       string
+		stdin      string
+		wantStatus int
+		wantErr    string
+	}{
+		{name: "WorkflowTemplate", stdin: template, wantStatus: exitUsage, wantErr: "cannot submit a WorkflowTemplate"},
+		{name: "conversion error", stdin: "name: broken\non: push\njobs:\n  build: [\n", wantStatus: exitFailed, wantErr: "Error: <stdin>:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := runArgus(t, tt.stdin, connect...)
+			if r.status != tt.wantStatus || !strings.Contains(r.stderr, tt.wantErr) {
+				t.Errorf("status = %d, stderr = %q, want %d and %q", r.status, r.stderr, tt.wantStatus, tt.wantErr)
+			}
+		})
+	}
+	if len(argo.workflows)+len(argo.crons) != 0 {
+		t.Errorf("created %d resources, want none", len(argo.workflows)+len(argo.crons))
+	}
+
+	// 连接 Argo Server 需要 token
+	r := runArgus(t, argoWorkflow, "submit", "-argo-server", addr, "-argo-token", "", "-argo-secure=false")
+	if r.status != exitUsage || !strings.Contains(r.stderr, "a token is required") {
+		t.Errorf("without a token: status = %d, stderr = %q", r.status, r.stderr)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"argo-parser/argowf"
+	"workflow-parser/ghawf"
+)
+
+// --- validate ---
+
+// kindGitHubWorkflow 是 GHA 工作流在校验结果中的 kind
+const kindGitHubWorkflow = "GitHubWorkflow"
+
+// validationResult 是一个资源的校验结果：Argo 资源为 issues，GHA 工作流为 findings
+type validationResult struct {
+	Source   string                   `json:"source"`
+	Kind     string                   `json:"kind"`
+	Name     string                   `json:"name,omitempty"`
+	Issues   []argowf.ValidationIssue `json:"issues,omitempty"`
+	Findings []ghawf.Finding          `json:"findings,omitempty"`
+}
+
+// failed 判断结果是否应使命令以 exitFailed 退出：Argo 资源有任何问题，或 GHA 工作流有 error 级别的问题
+func (r validationResult) failed() bool {
+	if len(r.Issues) > 0 {
+		return true
+	}
+	for _, f := range r.Findings {
+		if f.Level == ghawf.LevelError {
+			return true
+		}
+	}
+	return false
+}
+
+// runValidate 离线校验 Argo 资源并检查 GHA 工作流，按 apiVersion 区分两者
+func runValidate(ctx context.Context, args []string) int {
+	fs := newFlagSet("validate")
+	format := outputFlag(fs, formatText, formatYAML, formatJSON)
+	templatesDir := fs.String("templates", "", "directory of WorkflowTemplates and ClusterWorkflowTemplates used to resolve templateRef")
+	runnersDir := fs.String("runners", "", "directory of runner ConfigMap YAML files; runs-on labels without a ConfigMap are reported")
+	runnerLabels := fs.String("runner-labels", "", "comma-separated runs-on labels that have a runner ConfigMap in the cluster")
+	secrets := fs.String("secrets", "", "comma-separated secrets configured for the repository; references to other secrets are reported")
+	if status, ok := parseFlags(fs, args); !ok {
+		return status
+	}
+	if err := checkFormat(*format, formatText, formatYAML, formatJSON); err != nil {
+		return usageError("%v", err)
+	}
+
+	var library *argowf.TemplateLibrary
+	if *templatesDir != "" {
+		var err error
+		if library, err = argowf.LoadTemplateLibrary(*templatesDir); err != nil {
+			return usageError("failed to load templates: %v", err)
+		}
+	}
+	lintOpts := ghawf.LintOptions{RunnerLabels: map[string]bool{}, Secrets: map[string]bool{}}
+	if *runnersDir != "" {
+		labels, err := ghawf.LoadRunnerConfigMaps(*runnersDir)
+		if err != nil {
+			return usageError("failed to load runner ConfigMaps: %v", err)
+		}
+		lintOpts.RunnerLabels = labels
+	}
+	for _, label := range splitList(*runnerLabels) {
+		lintOpts.RunnerLabels[ghawf.SanitizeName(label)] = true
+	}
+	for _, secret := range splitList(*secrets) {
+		lintOpts.Secrets[secret] = true
+	}
+	inputs, err := readInputs(fs.Args())
+	if err != nil {
+		return usageError("%v", err)
+	}
+
+	results := []validationResult{}
+	for _, in := range inputs {
+		if isArgoResource(in.Data) {
+			resources, err := argowf.ParseResources(in.Data, in.Path)
+			if err != nil {
+				return usageError("%v", err)
+			}
+			for _, parsed := range resources {
+				results = append(results, validationResult{
+					Source: parsed.Source,
+					Kind:   parsed.Resource.ResourceKind(),
+					Name:   parsed.Resource.GetName(),
+					Issues: argowf.ValidateResource(parsed.Resource, library),
+				})
+			}
+			continue
+		}
+		findings, err := ghawf.LintWorkflow(in.Path, in.Data, lintOpts)
+		if err != nil {
+			return usageError("%v", err)
+		}
+		results = append(results, validationResult{Source: in.Path, Kind: kindGitHubWorkflow, Findings: findings})
+	}
+
+	if *format == formatText {
+		for _, r := range results {
+			writeValidationText(r)
+		}
+	} else if err := writeValue(os.Stdout, *format, results); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	for _, r := range results {
+		if r.failed() {
+			return exitFailed
+		}
+	}
+	return exitOK
+}
+
+// writeValidationText 以文本输出一个校验结果
+func writeValidationText(r validationResult) {
+	name := r.Kind
+	if r.Name != "" {
+		name += " " + r.Name
+	}
+	switch n := len(r.Issues) + len(r.Findings); {
+	case n == 0:
+		fmt.Printf("%s: %s is valid\n", r.Source, name)
+		return
+	case n == 1:
+		fmt.Printf("%s: %s has 1 issue\n", r.Source, name)
+	default:
+		fmt.Printf("%s: %s has %d issues\n", r.Source, name, n)
+	}
+	for _, issue := range r.Issues {
+		fmt.Printf("  %s\n", issue)
+	}
+	for _, f := range r.Findings {
+		fmt.Printf("  %d:%d: %s: %s [%s]\n", f.Line, f.Column, f.Level, f.Message, f.Rule)
+	}
+}
+
+// splitList 拆分逗号分隔的列表，忽略空元素
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- validate ---
+
+// injectionWorkflow 把事件数据直接插入 run 脚本，runs-on 标签没有运行环境 ConfigMap
+const injectionWorkflow = `name: ci
+on: push
+jobs:
+  build:
+    runs-on: gpu-large
+    steps:
+      - run: echo "${{ github.event.head_commit.message }}"
+`
+
+func TestValidate(t *testing.T) {
+	t.Run("valid files in a directory", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{"ci.yml": ghaWorkflow, "hello.yaml": argoWorkflow})
+		r := runArgus(t, "", "validate", "-runner-labels", "ubuntu-latest", dir)
+		r.check(t, exitOK,
+			filepath.Join(dir, "ci.yml")+": GitHubWorkflow is valid",
+			filepath.Join(dir, "hello.yaml")+": Workflow is valid",
+		)
+	})
+
+	t.Run("lint findings", func(t *testing.T) {
+		r := runArgus(t, injectionWorkflow, "validate")
+		r.check(t, exitFailed,
+			"<stdin>: GitHubWorkflow has 2 issues",
+			"5:14: warning: ",
+			"7:24: error: github.event.head_commit.message is controlled by the event sender",
+			"[script-injection]",
+		)
+	})
+
+	t.Run("warnings only", func(t *testing.T) {
+		// 只有 warning 级别的问题时不失败
+		r := runArgus(t, strings.Replace(injectionWorkflow, "${{ github.event.head_commit.message }}", "hello", 1), "validate")
+		r.check(t, exitOK, "[unknown-runner]")
+	})
+
+	t.Run("Argo issues as JSON", func(t *testing.T) {
+		r := runArgus(t, strings.Replace(argoWorkflow, "entrypoint: main", "entrypoint: missing", 1), "validate", "-o", "json", "-")
+		r.check(t, exitFailed)
+		var results []validationResult
+		if err := json.Unmarshal([]byte(r.stdout), &results); err != nil {
+			t.Fatalf("output is not a JSON array: %v\n%s", err, r.stdout)
+		}
+		if len(results) != 1 || results[0].Source != stdinPath || len(results[0].Issues) != 1 || results[0].Issues[0].Field != "spec.entrypoint" {
+			t.Errorf("results = %+v", results)
+		}
+	})
+
+	t.Run("unloadable templates", func(t *testing.T) {
+		r := runArgus(t, argoWorkflow, "validate", "-templates", filepath.Join(t.TempDir(), "missing"))
+		r.check(t, exitUsage)
+		if !strings.Contains(r.stderr, "failed to load templates") {
+			t.Errorf("stderr = %q", r.stderr)
+		}
+	})
+}
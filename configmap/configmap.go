@@ -7,35 +7,11 @@ import (
 	"log"
 	"os"
 
-	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
-)
-
-// GetConfigMap 获取指定 namespace 下的 ConfigMap
-func GetConfigMap(clientset *kubernetes.Clientset, namespace, configMapName string) (*corev1.ConfigMap, error) {
-	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.TODO(), configMapName, metav1.GetOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get ConfigMap %s in namespace %s: %v", configMapName, namespace, err)
-	}
-	return configMap, nil
-}
-
-// GetConfigMapValue 获取 ConfigMap 中指定 key 的值
-func GetConfigMapValue(clientset *kubernetes.Clientset, namespace, configMapName, key string) (string, error) {
-	configMap, err := GetConfigMap(clientset, namespace, configMapName)
-	if err != nil {
-		return "", err
-	}
-
-	value, exists := configMap.Data[key]
-	if !exists {
-		return "", fmt.Errorf("key %s not found in ConfigMap %s", key, configMapName)
-	}
 
-	return value, nil
-}
+	"configmap/configmaps"
+)
 
 func main() {
 	// Define command-line flags
@@ -76,7 +52,8 @@ func main() {
 	}
 
 	// Get the entire ConfigMap
-	configMap, err := GetConfigMap(clientset, *namespace, *configMapName)
+	ctx := context.Background()
+	configMap, err := configmaps.Get(ctx, clientset, *namespace, *configMapName)
 	if err != nil {
 		log.Fatalf("Error getting ConfigMap: %v", err)
 	}
@@ -87,7 +64,7 @@ func main() {
 	}
 
 	// Get specific key value
-	value, err := GetConfigMapValue(clientset, *namespace, *configMapName, *key)
+	value, err := configmaps.Value(ctx, clientset, *namespace, *configMapName, *key)
 	if err != nil {
 		log.Printf("Error getting key '%s': %v", *key, err)
 	} else {
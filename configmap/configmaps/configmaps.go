@@ -0,0 +1,37 @@
+// Package configmaps 读取 Kubernetes ConfigMap，供 configmap 命令和 runs-on 运行环境查找共用
+package configmaps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ErrKeyNotFound 表示 ConfigMap 存在但没有指定的 key
+var ErrKeyNotFound = errors.New("key not found")
+
+// Get 获取指定 namespace 下的 ConfigMap；错误包装了 API 错误，调用方可以用 apierrors.IsNotFound 判断是否存在
+func Get(ctx context.Context, client kubernetes.Interface, namespace, name string) (*corev1.ConfigMap, error) {
+	configMap, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ConfigMap %s in namespace %s: %w", name, namespace, err)
+	}
+	return configMap, nil
+}
+
+// Value 获取 ConfigMap 中指定 key 的值，key 不存在时返回包装 ErrKeyNotFound 的错误
+func Value(ctx context.Context, client kubernetes.Interface, namespace, name, key string) (string, error) {
+	configMap, err := Get(ctx, client, namespace, name)
+	if err != nil {
+		return "", err
+	}
+	value, ok := configMap.Data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s in ConfigMap %s/%s", ErrKeyNotFound, key, namespace, name)
+	}
+	return value, nil
+}
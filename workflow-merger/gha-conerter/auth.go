@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// --- 认证与多租户 ---
+
+// DefaultTenant 是未启用认证时所有作业归属的租户
+const DefaultTenant = "default"
+
+// ErrUnauthenticated 表示请求没有携带该认证方式所需的凭据
+var ErrUnauthenticated = errors.New("no credentials")
+
+// Principal 是通过认证的调用方
+type Principal struct {
+	TenantID string // 作业归属的租户
+	Subject  string // 调用方标识（API Key 名称、JWT sub 或证书 CN）
+	Method   string // 认证方式：apikey、jwt、mtls、none
+	Admin    bool   // 是否允许访问 /admin 接口
+}
+
+// Authenticator 从请求中识别调用方
+// 请求未携带对应凭据时返回 ErrUnauthenticated，凭据无效时返回其他错误
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+type principalKey struct{}
+
+// principalFromContext 取出 requireAuth 注入的调用方
+func principalFromContext(ctx context.Context) *Principal {
+	if p, ok := ctx.Value(principalKey{}).(*Principal); ok {
+		return p
+	}
+	return &Principal{TenantID: DefaultTenant, Method: "none"}
+}
+
+// requireAuth 认证请求并把调用方放入请求上下文，认证失败返回 401
+func requireAuth(auth Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := auth.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gha-converter"`)
+			http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+// requireAdmin 只允许管理员访问，必须包裹在 requireAuth 内部
+func requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !principalFromContext(r.Context()).Admin {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AuthConfig 是认证相关的命令行配置
+type AuthConfig struct {
+	Methods      string // 逗号分隔：none、apikey、jwt、mtls
+	APIKeysFile  string
+	JWKSFile     string
+	JWTIssuer    string
+	JWTAudience  string
+	TenantClaim  string
+	AdminClaim   string
+	AdminTenants string // mTLS 管理员租户，逗号分隔
+}
+
+// Build 按配置组合认证方式
+func (c AuthConfig) Build() (Authenticator, error) {
+	methods := strings.Split(c.Methods, ",")
+	var chain ChainAuthenticator
+	for _, method := range methods {
+		switch strings.TrimSpace(method) {
+		case "", "none":
+			if len(methods) > 1 {
+				return nil, errors.New("authentication method none cannot be combined with other methods")
+			}
+			return NoneAuthenticator{}, nil
+		case "apikey":
+			if c.APIKeysFile == "" {
+				return nil, errors.New("apikey authentication requires -api-keys-file")
+			}
+			auth, err := LoadAPIKeys(c.APIKeysFile)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, auth)
+		case "jwt":
+			if c.JWKSFile == "" {
+				return nil, errors.New("jwt authentication requires -jwks-file")
+			}
+			auth, err := LoadJWKS(c.JWKSFile, c.JWTIssuer, c.JWTAudience, c.TenantClaim, c.AdminClaim)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, auth)
+		case "mtls":
+			var admins []string
+			for _, tenant := range strings.Split(c.AdminTenants, ",") {
+				if tenant = strings.TrimSpace(tenant); tenant != "" {
+					admins = append(admins, tenant)
+				}
+			}
+			chain = append(chain, NewMTLSAuthenticator(admins))
+		default:
+			return nil, fmt.Errorf("unknown authentication method %q", method)
+		}
+	}
+	return chain, nil
+}
+
+// NoneAuthenticator 不做认证，所有请求都属于默认租户（保持原有行为）；
+// 匿名调用方不是管理员，/admin 接口需要配置其他认证方式
+type NoneAuthenticator struct{}
+
+func (NoneAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	return &Principal{TenantID: DefaultTenant, Method: "none"}, nil
+}
+
+// ChainAuthenticator 依次尝试多种认证方式，第一个识别出凭据的认证方式决定结果
+type ChainAuthenticator []Authenticator
+
+func (c ChainAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	for _, auth := range c {
+		principal, err := auth.Authenticate(r)
+		if errors.Is(err, ErrUnauthenticated) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, ErrUnauthenticated
+}
+
+// --- 静态 API Key ---
+
+// apiKeyEntry 是 API Key 文件中的一项
+type apiKeyEntry struct {
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	Tenant string `json:"tenant"`
+	Admin  bool   `json:"admin"`
+}
+
+// APIKeyAuthenticator 校验 X-API-Key 请求头
+type APIKeyAuthenticator struct {
+	keys []apiKeyEntry
+}
+
+// LoadAPIKeys 从 JSON 文件加载 API Key 列表：[{"key": "...", "name": "...", "tenant": "...", "admin": false}]
+func LoadAPIKeys(path string) (*APIKeyAuthenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key file: %w", err)
+	}
+	var keys []apiKeyEntry
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse API key file: %w", err)
+	}
+	for i, key := range keys {
+		if key.Key == "" || key.Tenant == "" {
+			return nil, fmt.Errorf("API key entry %d must set both key and tenant", i)
+		}
+	}
+	return &APIKeyAuthenticator{keys: keys}, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	presented := r.Header.Get("X-API-Key")
+	if presented == "" {
+		return nil, ErrUnauthenticated
+	}
+	for _, key := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(key.Key)) == 1 {
+			return &Principal{TenantID: key.Tenant, Subject: key.Name, Method: "apikey", Admin: key.Admin}, nil
+		}
+	}
+	return nil, errors.New("invalid API key")
+}
+
+// --- JWT Bearer Token (JWKS 文件) ---
+
+// JWTAuthenticator 使用本地 JWKS 文件中的公钥校验 Authorization: Bearer 令牌
+type JWTAuthenticator struct {
+	keys        map[string]interface{} // kid -> 公钥
+	issuer      string
+	audience    string
+	tenantClaim string
+	adminClaim  string
+}
+
+// jwk 是 JWKS 中单个密钥的必要字段
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// LoadJWKS 从 JWKS 文件加载 RSA/EC 公钥
+func LoadJWKS(path, issuer, audience, tenantClaim, adminClaim string) (*JWTAuthenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, key := range set.Keys {
+		publicKey, err := key.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK %q: %w", key.Kid, err)
+		}
+		keys[key.Kid] = publicKey
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS file contains no keys")
+	}
+	return &JWTAuthenticator{
+		keys:        keys,
+		issuer:      issuer,
+		audience:    audience,
+		tenantClaim: tenantClaim,
+		adminClaim:  adminClaim,
+	}, nil
+}
+
+// publicKey 把 JWK 转换为 crypto 公钥
+func (k jwk) publicKey() (interface{}, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("bad modulus: %w", err)
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("bad exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("bad x coordinate: %w", err)
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("bad y coordinate: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, ErrUnauthenticated
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+	}
+	if a.issuer != "" {
+		options = append(options, jwt.WithIssuer(a.issuer))
+	}
+	if a.audience != "" {
+		options = append(options, jwt.WithAudience(a.audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(strings.TrimPrefix(header, "Bearer "), claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := a.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	}, options...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bearer token: %w", err)
+	}
+
+	tenant, _ := claims[a.tenantClaim].(string)
+	if tenant == "" {
+		return nil, fmt.Errorf("bearer token has no %q claim", a.tenantClaim)
+	}
+	subject, _ := claims.GetSubject()
+	admin, _ := claims[a.adminClaim].(bool)
+	return &Principal{TenantID: tenant, Subject: subject, Method: "jwt", Admin: admin}, nil
+}
+
+// --- mTLS 客户端证书 ---
+
+// MTLSAuthenticator 使用已验证的客户端证书识别调用方
+// 租户取证书 Subject 的第一个 Organization，没有时取 CommonName
+type MTLSAuthenticator struct {
+	adminTenants map[string]bool
+}
+
+// NewMTLSAuthenticator 创建 mTLS 认证器，adminTenants 中的租户可以访问管理接口
+func NewMTLSAuthenticator(adminTenants []string) *MTLSAuthenticator {
+	admins := make(map[string]bool, len(adminTenants))
+	for _, tenant := range adminTenants {
+		admins[tenant] = true
+	}
+	return &MTLSAuthenticator{adminTenants: admins}
+}
+
+func (a *MTLSAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrUnauthenticated
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	tenant := certTenant(cert)
+	if tenant == "" {
+		return nil, errors.New("client certificate has no organization or common name")
+	}
+	return &Principal{TenantID: tenant, Subject: cert.Subject.CommonName, Method: "mtls", Admin: a.adminTenants[tenant]}, nil
+}
+
+// certTenant 从证书 Subject 中取租户
+func certTenant(cert *x509.Certificate) string {
+	if len(cert.Subject.Organization) > 0 && cert.Subject.Organization[0] != "" {
+		return cert.Subject.Organization[0]
+	}
+	return cert.Subject.CommonName
+}
+
+// --- 租户配置 ---
+
+// TenantSettings 是单个租户的转换配置
+type TenantSettings struct {
+	RunnerNamespace   string `json:"runnerNamespace"`   // runs-on ConfigMap 所在的 namespace
+	WorkflowNamespace string `json:"workflowNamespace"` // /submit 提交工作流的 namespace
+	WorkspaceClaim    string `json:"workspaceClaim"`    // 工作区使用的已有 PVC，为空时每次运行创建新的 PVC
+}
+
+// TenantConfig 保存所有租户的配置，未列出的租户使用默认值
+type TenantConfig struct {
+	Default TenantSettings            `json:"default"`
+	Tenants map[string]TenantSettings `json:"tenants"`
+}
+
+// LoadTenantConfig 从 JSON 文件加载租户配置；path 为空时返回只有默认值的配置
+func LoadTenantConfig(path string, defaults TenantSettings) (*TenantConfig, error) {
+	config := &TenantConfig{Default: defaults}
+	if path == "" {
+		return config, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenant config: %w", err)
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse tenant config: %w", err)
+	}
+	if config.Default.RunnerNamespace == "" {
+		config.Default.RunnerNamespace = defaults.RunnerNamespace
+	}
+	if config.Default.WorkflowNamespace == "" {
+		config.Default.WorkflowNamespace = defaults.WorkflowNamespace
+	}
+	if config.Default.WorkspaceClaim == "" {
+		config.Default.WorkspaceClaim = defaults.WorkspaceClaim
+	}
+	return config, nil
+}
+
+// For 返回租户的配置，未配置的字段回落到默认值
+func (c *TenantConfig) For(tenant string) TenantSettings {
+	settings, ok := c.Tenants[tenant]
+	if !ok {
+		return c.Default
+	}
+	if settings.RunnerNamespace == "" {
+		settings.RunnerNamespace = c.Default.RunnerNamespace
+	}
+	if settings.WorkflowNamespace == "" {
+		settings.WorkflowNamespace = c.Default.WorkflowNamespace
+	}
+	if settings.WorkspaceClaim == "" {
+		settings.WorkspaceClaim = c.Default.WorkspaceClaim
+	}
+	return settings
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// --- 配置：命令行参数优先，其次是环境变量，最后是默认值 ---
+
+// 环境变量名称
+const (
+	EnvWorkers       = "GHA_CONVERTER_WORKERS"
+	EnvMinWorkers    = "GHA_CONVERTER_MIN_WORKERS"
+	EnvMaxWorkers    = "GHA_CONVERTER_MAX_WORKERS"
+	EnvMaxQueue      = "GHA_CONVERTER_MAX_QUEUE"
+	EnvJobTimeout    = "GHA_CONVERTER_JOB_TIMEOUT"
+	EnvScaleInterval = "GHA_CONVERTER_SCALE_INTERVAL"
+	EnvScaleUpWait   = "GHA_CONVERTER_SCALE_UP_WAIT"
+	EnvScaleDownWait = "GHA_CONVERTER_SCALE_DOWN_WAIT"
+
+	EnvMaxQueuePerClient = "GHA_CONVERTER_MAX_QUEUE_PER_CLIENT"
+	EnvMaxBodyBytes      = "GHA_CONVERTER_MAX_BODY_BYTES"
+	EnvRateLimit         = "GHA_CONVERTER_RATE_LIMIT"
+	EnvRateBurst         = "GHA_CONVERTER_RATE_BURST"
+
+	EnvAuth             = "GHA_CONVERTER_AUTH"
+	EnvAPIKeysFile      = "GHA_CONVERTER_API_KEYS_FILE"
+	EnvJWKSFile         = "GHA_CONVERTER_JWKS_FILE"
+	EnvJWTIssuer        = "GHA_CONVERTER_JWT_ISSUER"
+	EnvJWTAudience      = "GHA_CONVERTER_JWT_AUDIENCE"
+	EnvJWTTenantClaim   = "GHA_CONVERTER_JWT_TENANT_CLAIM"
+	EnvJWTAdminClaim    = "GHA_CONVERTER_JWT_ADMIN_CLAIM"
+	EnvMTLSAdminTenants = "GHA_CONVERTER_MTLS_ADMIN_TENANTS"
+	EnvTLSCert          = "GHA_CONVERTER_TLS_CERT"
+	EnvTLSKey           = "GHA_CONVERTER_TLS_KEY"
+	EnvTLSClientCA      = "GHA_CONVERTER_TLS_CLIENT_CA"
+	EnvTenantConfig     = "GHA_CONVERTER_TENANT_CONFIG"
+	EnvRunnerNamespace  = "GHA_CONVERTER_RUNNER_NAMESPACE"
+	EnvRunnerConfigMaps = "GHA_CONVERTER_RUNNER_CONFIGMAPS"
+	EnvKubeContext      = "GHA_CONVERTER_KUBE_CONTEXT"
+
+	EnvWorkspaceClaim  = "GHA_CONVERTER_WORKSPACE_CLAIM"
+	EnvWorkspaceSize   = "GHA_CONVERTER_WORKSPACE_SIZE"
+	EnvWorkspaceGC     = "GHA_CONVERTER_WORKSPACE_GC"
+	EnvWorkspacePerJob = "GHA_CONVERTER_WORKSPACE_PER_JOB"
+
+	EnvCacheBackend            = "GHA_CONVERTER_CACHE_BACKEND"
+	EnvCacheClaim              = "GHA_CONVERTER_CACHE_CLAIM"
+	EnvCacheArtifactRepository = "GHA_CONVERTER_CACHE_ARTIFACT_REPOSITORY"
+
+	EnvSubmit                 = "GHA_CONVERTER_SUBMIT"
+	EnvArgoNamespace          = "GHA_CONVERTER_ARGO_NAMESPACE"
+	EnvArgoServer             = "ARGO_SERVER"
+	EnvArgoToken              = "ARGO_TOKEN"
+	EnvArgoSecure             = "ARGO_SECURE"
+	EnvArgoInsecureSkipVerify = "ARGO_INSECURE_SKIP_VERIFY"
+
+	EnvWebhookReposDir   = "GHA_CONVERTER_WEBHOOK_REPOS_DIR"
+	EnvWebhookSecret     = "GHA_CONVERTER_WEBHOOK_SECRET"
+	EnvWebhookInsecure   = "GHA_CONVERTER_WEBHOOK_INSECURE"
+	EnvWebhookTenant     = "GHA_CONVERTER_WEBHOOK_TENANT"
+	EnvWebhookDeliveries = "GHA_CONVERTER_WEBHOOK_DELIVERIES"
+)
+
+// envString 读取字符串环境变量，未设置时返回默认值
+func envString(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// envInt 读取整数环境变量，未设置或无效时返回默认值
+func envInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q: %v", name, value, err)
+		return def
+	}
+	return n
+}
+
+// envFloat 读取浮点数环境变量，未设置或无效时返回默认值
+func envFloat(name string, def float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q: %v", name, value, err)
+		return def
+	}
+	return f
+}
+
+// envDuration 读取时长环境变量（如 "30s"），未设置或无效时返回默认值
+func envDuration(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q: %v", name, value, err)
+		return def
+	}
+	return d
+}
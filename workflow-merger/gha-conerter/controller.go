@@ -0,0 +1,529 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nektos/act/pkg/model"
+
+	"gha-converter/convert"
+	"workflow-parser/ghawf"
+)
+
+// --- webhook 控制器：按 on 触发条件转换并提交工作流 ---
+
+// WorkflowDir 是仓库中存放工作流的目录，与 workflow-parser 扫描的目录相同
+const WorkflowDir = ".argus/workflows"
+
+// DefaultDeliveryRetention 是投递记录的保留时间；GitHub 允许重新投递最近 3 天内的 webhook
+const DefaultDeliveryRetention = 7 * 24 * time.Hour
+
+// webhook 请求头，GitHub 和 Gitea 使用不同的名称
+const (
+	HeaderGitHubEvent     = "X-GitHub-Event"
+	HeaderGitHubDelivery  = "X-GitHub-Delivery"
+	HeaderGitHubSignature = "X-Hub-Signature-256" // sha256=<hex>
+	HeaderGiteaEvent      = "X-Gitea-Event"
+	HeaderGiteaDelivery   = "X-Gitea-Delivery"
+	HeaderGiteaSignature  = "X-Gitea-Signature" // <hex>
+)
+
+var (
+	// ErrInvalidSignature 表示 webhook 签名缺失或不匹配
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrDeliveryInProgress 表示同一投递正在处理
+	ErrDeliveryInProgress = errors.New("delivery is being processed")
+)
+
+// Webhook 用于 /webhook，未启用时为 nil
+var Webhook *WebhookController
+
+// WebhookController 接收 GitHub/Gitea webhook，对仓库中 on 匹配的工作流执行转换并提交到 Argo
+type WebhookController struct {
+	Secret     []byte // HMAC-SHA256 签名密钥，为空时拒绝所有投递
+	Insecure   bool   // 不校验签名，仅用于没有配置密钥的本地测试
+	Source     WorkflowSource
+	Tenant     string // 作业所属的租户，决定 runner namespace 和提交的 namespace
+	Deliveries *DeliveryLog
+}
+
+// SourceWorkflow 是仓库中的一个工作流文件
+type SourceWorkflow struct {
+	Path    string // 相对仓库根目录的路径，如 .argus/workflows/ci.yml
+	Content []byte
+}
+
+// WorkflowSource 读取仓库中的工作流
+type WorkflowSource interface {
+	Workflows(repository string) ([]SourceWorkflow, error)
+}
+
+// DirWorkflowSource 从本地检出读取工作流：<Root>/<owner>/<repo>/.argus/workflows，检出由外部（如 git-sync）保持最新。
+// 检出必须是仓库的默认分支，控制器只处理使用默认分支工作流的事件，见 workflowBranch
+type DirWorkflowSource struct {
+	Root string
+}
+
+// Workflows 按文件名顺序返回仓库的 .yml/.yaml 工作流，仓库没有检出或没有工作流目录时返回空
+func (s DirWorkflowSource) Workflows(repository string) ([]SourceWorkflow, error) {
+	owner, name, ok := strings.Cut(repository, "/")
+	if !ok || !validPathSegment(owner) || !validPathSegment(name) {
+		return nil, fmt.Errorf("invalid repository %q", repository)
+	}
+	dir := filepath.Join(s.Root, owner, name, filepath.FromSlash(WorkflowDir))
+	var files []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	workflows := make([]SourceWorkflow, 0, len(files))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read workflow: %w", err)
+		}
+		workflows = append(workflows, SourceWorkflow{Path: path.Join(WorkflowDir, filepath.Base(file)), Content: content})
+	}
+	return workflows, nil
+}
+
+// validPathSegment 检查 owner 或仓库名可以安全地作为一级目录名
+func validPathSegment(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\`)
+}
+
+// --- 投递记录 ---
+
+// DeliveryRecord 是一次投递的处理结果
+type DeliveryRecord struct {
+	ID         string            `json:"delivery"`
+	Event      string            `json:"event"`
+	Repository string            `json:"repository,omitempty"`
+	Ref        string            `json:"ref,omitempty"`
+	Received   time.Time         `json:"received"`
+	Error      string            `json:"error,omitempty"`   // 读取仓库工作流失败等整体错误
+	Skipped    string            `json:"skipped,omitempty"` // 整个投递被忽略的原因，如事件不在默认分支上
+	Workflows  []WebhookWorkflow `json:"workflows"`
+}
+
+// WebhookWorkflow 是投递对一个工作流的处理结果
+type WebhookWorkflow struct {
+	Path      string                      `json:"path"`
+	Name      string                      `json:"name,omitempty"`
+	Triggered bool                        `json:"triggered"`
+	Reason    string                      `json:"reason,omitempty"` // 未触发的原因
+	Notes     []string                    `json:"notes,omitempty"`  // 无法检查、按通过处理的条件
+	Workflow  *convert.SubmittedWorkflow  `json:"workflow,omitempty"`
+	Warnings  []convert.ConversionWarning `json:"warnings,omitempty"`
+	Error     string                      `json:"error,omitempty"`
+}
+
+// failed 判断投递是否有失败的部分，失败的投递在重新投递时会重试
+func (r DeliveryRecord) failed() bool {
+	if r.Error != "" {
+		return true
+	}
+	for _, wf := range r.Workflows {
+		if wf.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryLog 记录已处理的投递，用于幂等：成功处理过的投递 ID 不会再次提交。
+// 设置文件路径时每条记录追加为一行 JSON，重启后恢复
+type DeliveryLog struct {
+	mu        sync.Mutex
+	path      string
+	retention time.Duration
+	records   map[string]DeliveryRecord
+	pending   map[string]bool
+}
+
+// LoadDeliveryLog 创建投递记录；path 为空时只保存在内存中，否则读取已有记录并丢弃过期的记录
+func LoadDeliveryLog(path string, retention time.Duration) (*DeliveryLog, error) {
+	l := &DeliveryLog{
+		path:      path,
+		retention: retention,
+		records:   map[string]DeliveryRecord{},
+		pending:   map[string]bool{},
+	}
+	if path == "" {
+		return l, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open delivery log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var record DeliveryRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("failed to parse delivery log %s line %d: %w", path, line, err)
+		}
+		l.records[record.ID] = record // 同一投递的后一条记录覆盖前一条
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read delivery log: %w", err)
+	}
+	l.prune(time.Now())
+	return l, l.compact()
+}
+
+// Begin 开始处理一次投递：已成功处理过时返回上次的记录和 duplicate；上次处理失败时返回上次的记录，
+// 调用方只重试失败的工作流；同一投递正在处理时返回 ErrDeliveryInProgress。
+// 未返回 duplicate 或错误时，调用方必须调用 Finish
+func (l *DeliveryLog) Begin(id string) (previous DeliveryRecord, duplicate bool, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.pending[id] {
+		return DeliveryRecord{}, false, ErrDeliveryInProgress
+	}
+	previous, found := l.records[id]
+	if found && !previous.failed() {
+		return previous, true, nil
+	}
+	l.pending[id] = true
+	return previous, false, nil
+}
+
+// Finish 保存投递的处理结果；写入文件失败时内存中的记录仍然有效
+func (l *DeliveryLog) Finish(record DeliveryRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.pending, record.ID)
+	l.records[record.ID] = record
+	l.prune(time.Now())
+	if l.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode delivery %s: %w", record.ID, err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open delivery log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write delivery log: %w", err)
+	}
+	return nil
+}
+
+// prune 丢弃超过保留时间的记录，调用方需持有锁
+func (l *DeliveryLog) prune(now time.Time) {
+	if l.retention <= 0 {
+		return
+	}
+	for id, record := range l.records {
+		if now.Sub(record.Received) > l.retention {
+			delete(l.records, id)
+		}
+	}
+}
+
+// compact 用当前记录重写文件，去掉过期和被覆盖的记录
+func (l *DeliveryLog) compact() error {
+	var buf bytes.Buffer
+	for _, record := range l.records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode delivery %s: %w", record.ID, err)
+		}
+		buf.Write(append(data, '\n'))
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write delivery log: %w", err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return fmt.Errorf("failed to replace delivery log: %w", err)
+	}
+	return nil
+}
+
+// --- HTTP 处理器 ---
+
+// handleWebhook (POST /webhook) 接收 GitHub/Gitea webhook。请求通过签名认证，不经过 API 认证。
+// 处理完成后返回每个工作流是否触发以及提交结果；重复的投递直接返回上次的结果
+func (c *WebhookController) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// 1. 读取请求体并校验签名
+	body, err := readLimitedBody(w, r, MaxBodyBytes)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Failed to read request body", http.StatusInternalServerError)
+		return
+	}
+	if err := c.verifySignature(r.Header, body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// 2. 解析事件；Gitea 同时发送 GitHub 的请求头，优先使用 Gitea 的
+	event := WebhookEvent{Delivery: firstHeader(r.Header, HeaderGiteaDelivery, HeaderGitHubDelivery)}
+	event.Name = firstHeader(r.Header, HeaderGiteaEvent, HeaderGitHubEvent)
+	if event.Name == "" || event.Delivery == "" {
+		http.Error(w, "Missing webhook event or delivery header", http.StatusBadRequest)
+		return
+	}
+	if event.Name == "ping" {
+		writeWebhookResponse(w, http.StatusOK, map[string]interface{}{"delivery": event.Delivery, "message": "pong"})
+		return
+	}
+	if err := json.Unmarshal(body, &event.Payload); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid webhook payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	event.ChangedFiles = changedFilesFromPayload(event.Payload)
+	// pull_request 负载不含文件列表，文件未知时不触发有 paths 过滤器的工作流，而不是全部触发
+	event.StrictPaths = true
+	github := ghawf.GitHubContextFor(event.EventContext)
+	if github.Repository == "" {
+		http.Error(w, "Webhook payload has no repository.full_name", http.StatusBadRequest)
+		return
+	}
+
+	// 3. 按投递 ID 去重
+	previous, duplicate, err := c.Deliveries.Begin(event.Delivery)
+	if errors.Is(err, ErrDeliveryInProgress) {
+		http.Error(w, fmt.Sprintf("Delivery %s is being processed", event.Delivery), http.StatusConflict)
+		return
+	}
+	if duplicate {
+		log.Printf("Webhook delivery %s already processed, skipping", event.Delivery)
+		writeWebhookResponse(w, http.StatusOK, webhookResponse{DeliveryRecord: previous, Duplicate: true})
+		return
+	}
+
+	// 4. 转换并提交；发送方超时断开后处理继续进行，结果记录在投递记录中
+	record, err := c.process(context.WithoutCancel(r.Context()), event, github, previous)
+	if logErr := c.Deliveries.Finish(record); logErr != nil {
+		log.Printf("Failed to record webhook delivery %s: %v", event.Delivery, logErr)
+	}
+	status := http.StatusOK
+	if err != nil {
+		status = errorStatusCode(err)
+	}
+	writeWebhookResponse(w, status, webhookResponse{DeliveryRecord: record})
+}
+
+// webhookResponse 是 /webhook 的响应
+type webhookResponse struct {
+	DeliveryRecord
+	Duplicate bool `json:"duplicate"`
+}
+
+// process 检查仓库中每个工作流的触发条件，转换并提交触发的工作流；上次投递已提交的工作流不会再次提交。
+// 返回的错误是第一个失败，用于决定响应状态码
+func (c *WebhookController) process(ctx context.Context, event WebhookEvent, github *model.GithubContext, previous DeliveryRecord) (DeliveryRecord, error) {
+	record := DeliveryRecord{
+		ID:         event.Delivery,
+		Event:      event.Name,
+		Repository: github.Repository,
+		Ref:        github.Ref,
+		Received:   time.Now(),
+		Workflows:  []WebhookWorkflow{},
+	}
+	// 删除分支或标签的 push 不触发工作流
+	if ghawf.PayloadString(event.Payload, "deleted") == "true" {
+		return record, nil
+	}
+	// 工作流源只有默认分支的检出，使用其他分支工作流的事件不能按检出中的定义运行
+	defaultBranch := ghawf.PayloadString(event.Payload, "repository", "default_branch")
+	if defaultBranch == "" {
+		record.Skipped = "the payload has no repository.default_branch"
+		return record, nil
+	}
+	if branch := workflowBranch(event, github); branch != defaultBranch {
+		record.Skipped = fmt.Sprintf("%s uses the workflows of %s, only the default branch %s is served", event.Name, describeBranch(branch, github.Ref), defaultBranch)
+		log.Printf("Webhook delivery %s skipped: %s", event.Delivery, record.Skipped)
+		return record, nil
+	}
+	sources, err := c.Source.Workflows(github.Repository)
+	if err != nil {
+		record.Error = err.Error()
+		return record, err
+	}
+	submitted := map[string]*convert.SubmittedWorkflow{}
+	for _, wf := range previous.Workflows {
+		if wf.Workflow != nil {
+			submitted[wf.Path] = wf.Workflow
+		}
+	}
+
+	var firstErr error
+	fail := func(result *WebhookWorkflow, err error) {
+		result.Error = err.Error()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	// 1. 检查触发条件，触发的工作流作为提交作业排队
+	principal := &Principal{TenantID: c.Tenant, Subject: github.Repository, Method: "webhook"}
+	client := "webhook:" + github.Repository
+	jobs := map[int]ConversionJob{}
+	for _, source := range sources {
+		result := WebhookWorkflow{Path: source.Path}
+		wf, err := model.ReadWorkflow(bytes.NewReader(source.Content), false)
+		if err != nil {
+			fail(&result, fmt.Errorf("%w: %s: %v", convert.ErrInvalidWorkflow, source.Path, err))
+			record.Workflows = append(record.Workflows, result)
+			continue
+		}
+		result.Name = wf.Name
+		result.Triggered, result.Reason, result.Notes = ghawf.MatchTrigger(wf, event.EventContext, github)
+		if prior, ok := submitted[source.Path]; ok && result.Triggered {
+			result.Workflow = prior
+		} else if result.Triggered {
+			job := newJob(ctx, principal, source.Content)
+			job.Options.Source = convert.SourceContext{
+				Repository:   github.Repository,
+				Ref:          github.Ref,
+				SHA:          github.Sha,
+				WorkflowPath: source.Path,
+				Actor:        github.Actor,
+				EventName:    event.Name,
+				Delivery:     event.Delivery,
+			}
+			job.Submit = &convert.SubmitOptions{Namespace: Tenants.For(c.Tenant).WorkflowNamespace}
+			job.Done = make(chan struct{})
+			// 不放入 ActiveJobs：webhook 作业属于 -webhook-tenant，不能被该租户的 API 客户端取消
+			if err := Queue.Push(client, job); err != nil {
+				job.Cancel()
+				fail(&result, fmt.Errorf("failed to queue %s: %w", source.Path, err))
+			} else {
+				jobs[len(record.Workflows)] = job
+			}
+		}
+		record.Workflows = append(record.Workflows, result)
+	}
+
+	// 2. 等待所有作业完成
+	for i, job := range jobs {
+		<-job.Done
+		result := &record.Workflows[i]
+		res, ok := takeJobResult(job.JobID)
+		if !ok {
+			fail(result, fmt.Errorf("failed to submit %s: %w", result.Path, ErrMissingResult))
+			continue
+		}
+		result.Warnings = res.Warnings
+		if res.Error != nil {
+			fail(result, fmt.Errorf("failed to submit %s: %w", result.Path, res.Error))
+			continue
+		}
+		result.Workflow = res.Workflow
+		log.Printf("Webhook delivery %s submitted %s as %s/%s", event.Delivery, result.Path, res.Workflow.Namespace, res.Workflow.Name)
+	}
+	return record, firstErr
+}
+
+// workflowBranch 返回事件运行的工作流定义所在的分支：pull_request 为目标分支，分支 push 为该分支，
+// 标签 push 为 base_ref（标签创建在该分支的最新提交上时才有），没有 ref 的事件为默认分支。无法确定时返回空
+func workflowBranch(event WebhookEvent, github *model.GithubContext) string {
+	switch {
+	case strings.HasPrefix(event.Name, "pull_request"):
+		return github.BaseRef
+	case strings.HasPrefix(github.Ref, "refs/heads/"):
+		return strings.TrimPrefix(github.Ref, "refs/heads/")
+	case strings.HasPrefix(github.Ref, "refs/tags/"):
+		baseRef := ghawf.PayloadString(event.Payload, "base_ref")
+		if !strings.HasPrefix(baseRef, "refs/heads/") {
+			return ""
+		}
+		return strings.TrimPrefix(baseRef, "refs/heads/")
+	case github.Ref == "":
+		return ghawf.PayloadString(event.Payload, "repository", "default_branch")
+	}
+	return ""
+}
+
+// describeBranch 描述 workflowBranch 的结果，分支未知时使用事件的 ref
+func describeBranch(branch, ref string) string {
+	if branch != "" {
+		return "branch " + branch
+	}
+	return "an unknown branch (ref " + ref + ")"
+}
+
+// verifySignature 校验 GitHub 的 X-Hub-Signature-256 或 Gitea 的 X-Gitea-Signature；没有密钥时只有 Insecure 才放行
+func (c *WebhookController) verifySignature(header http.Header, body []byte) error {
+	if c.Insecure {
+		return nil
+	}
+	if len(c.Secret) == 0 {
+		return ErrInvalidSignature
+	}
+	signature := strings.TrimPrefix(header.Get(HeaderGitHubSignature), "sha256=")
+	if signature == "" {
+		signature = header.Get(HeaderGiteaSignature)
+	}
+	got, err := hex.DecodeString(signature)
+	if signature == "" || err != nil {
+		return ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, c.Secret)
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// firstHeader 返回第一个非空的请求头
+func firstHeader(header http.Header, names ...string) string {
+	for _, name := range names {
+		if value := header.Get(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// writeWebhookResponse 以 JSON 写入响应
+func writeWebhookResponse(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
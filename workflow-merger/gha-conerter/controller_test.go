@@ -0,0 +1,420 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"gha-converter/convert"
+)
+
+// --- /webhook：回放录制的投递 ---
+
+const webhookSecret = "It's a Secret to Everybody"
+
+// webhookRepository 是 octo/app 检出中的工作流
+var webhookRepository = map[string]string{
+	"ci.yml": `name: ci
+on:
+  push:
+    branches: [main]
+    paths: ["src/**"]
+  pull_request:
+    branches: [main]
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make test
+`,
+	"release.yml": `name: release
+on:
+  push:
+    tags: ["v*"]
+jobs:
+  publish:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make publish
+`,
+	"lint.yml": `name: lint
+on:
+  pull_request:
+    paths: ["src/**"]
+jobs:
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make lint
+`,
+	"docs.yml": `name: docs
+on:
+  push:
+    paths: ["docs/**"]
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make docs
+`,
+}
+
+// newWebhookController 创建读取临时检出的控制器
+func newWebhookController(t *testing.T) *WebhookController {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "octo", "app", filepath.FromSlash(WorkflowDir))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range webhookRepository {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deliveries, err := LoadDeliveryLog("", DefaultDeliveryRetention)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &WebhookController{
+		Secret:     []byte(webhookSecret),
+		Source:     DirWorkflowSource{Root: filepath.Dir(filepath.Dir(filepath.Dir(filepath.Dir(dir))))},
+		Tenant:     DefaultTenant,
+		Deliveries: deliveries,
+	}
+}
+
+// delivery 是一次录制的投递
+type delivery struct {
+	gitea     bool   // 使用 Gitea 的请求头
+	event     string // 事件名称
+	id        string // 投递 ID
+	payload   string // testdata/webhook 中的负载文件
+	signature string // 非空时替换正确的签名
+}
+
+// replay 按录制的请求头发送投递，返回响应
+func replay(t *testing.T, c *WebhookController, d delivery) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", "webhook", d.payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return send(c, d, body)
+}
+
+// replayEdited 修改录制的负载后发送投递
+func replayEdited(t *testing.T, c *WebhookController, d delivery, edit func(payload map[string]interface{})) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", "webhook", d.payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	edit(payload)
+	if body, err = json.Marshal(payload); err != nil {
+		t.Fatal(err)
+	}
+	return send(c, d, body)
+}
+
+// send 按投递的请求头签名并发送请求体
+func send(c *WebhookController, d delivery, body []byte) *httptest.ResponseRecorder {
+	mac := hmac.New(sha256.New, []byte(webhookSecret))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+	if d.signature != "" {
+		signature = d.signature
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if d.gitea {
+		// Gitea 同时发送 GitHub 兼容的请求头
+		req.Header.Set(HeaderGiteaEvent, d.event)
+		req.Header.Set(HeaderGiteaDelivery, d.id)
+		req.Header.Set(HeaderGiteaSignature, signature)
+		req.Header.Set(HeaderGitHubEvent, d.event)
+		req.Header.Set(HeaderGitHubDelivery, d.id)
+	} else {
+		req.Header.Set(HeaderGitHubEvent, d.event)
+		req.Header.Set(HeaderGitHubDelivery, d.id)
+		req.Header.Set(HeaderGitHubSignature, "sha256="+signature)
+	}
+	rec := httptest.NewRecorder()
+	c.handleWebhook(rec, req)
+	return rec
+}
+
+func decodeWebhookResponse(t *testing.T, rec *httptest.ResponseRecorder) webhookResponse {
+	t.Helper()
+	var resp webhookResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body, err)
+	}
+	return resp
+}
+
+// triggered 返回被触发的工作流路径
+func triggered(resp webhookResponse) []string {
+	var paths []string
+	for _, wf := range resp.Workflows {
+		if wf.Triggered {
+			paths = append(paths, wf.Path)
+		}
+	}
+	return paths
+}
+
+func TestWebhookReplay(t *testing.T) {
+	tests := []struct {
+		name          string
+		delivery      delivery
+		wantStatus    int
+		wantTriggered []string
+		wantRef       string
+	}{
+		{
+			name:          "github push",
+			delivery:      delivery{event: "push", id: "f2a5c3e0-8a4b-11f0-9c57-2b3c4d5e6f70", payload: "github-push.json"},
+			wantStatus:    http.StatusOK,
+			wantTriggered: []string{".argus/workflows/ci.yml"},
+			wantRef:       "refs/heads/main",
+		},
+		{
+			name:          "github tag push",
+			delivery:      delivery{event: "push", id: "0b7c9d10-8a4c-11f0-8d2e-6f7a8b9c0d1e", payload: "github-push-tag.json"},
+			wantStatus:    http.StatusOK,
+			wantTriggered: []string{".argus/workflows/release.yml"},
+			wantRef:       "refs/tags/v1.2.0",
+		},
+		{
+			name:          "github pull request",
+			delivery:      delivery{event: "pull_request", id: "3e1f7a20-8a4d-11f0-9b3c-4d5e6f708192", payload: "github-pull-request.json"},
+			wantStatus:    http.StatusOK,
+			wantTriggered: []string{".argus/workflows/ci.yml"},
+			wantRef:       "refs/pull/42/merge",
+		},
+		{
+			name:          "gitea synchronized pull request",
+			delivery:      delivery{gitea: true, event: "pull_request", id: "9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d", payload: "gitea-pull-request.json"},
+			wantStatus:    http.StatusOK,
+			wantTriggered: []string{".argus/workflows/ci.yml"},
+			wantRef:       "refs/pull/7/merge",
+		},
+		{
+			name:       "bad signature",
+			delivery:   delivery{event: "push", id: "5c6d7e80-8a4e-11f0-8f1a-2b3c4d5e6f70", payload: "github-push.json", signature: "00"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "ping",
+			delivery:   delivery{event: "ping", id: "7d8e9fa0-8a4e-11f0-9a2b-3c4d5e6f7081", payload: "github-ping.json"},
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argo := newFakeArgo()
+			setupServer(t, argo.submitter())
+			// webhook 作业不应出现在 ActiveJobs 中，否则可以被 -webhook-tenant 的 API 客户端取消
+			var active []string
+			var mu sync.Mutex
+			argo.onCreate = func() {
+				ActiveJobs.Range(func(key, _ interface{}) bool {
+					mu.Lock()
+					active = append(active, key.(string))
+					mu.Unlock()
+					return true
+				})
+			}
+			c := newWebhookController(t)
+
+			rec := replay(t, c, tt.delivery)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			created := argo.created()
+			if len(created) != len(tt.wantTriggered) {
+				t.Fatalf("CreateWorkflow called %d times, want %d", len(created), len(tt.wantTriggered))
+			}
+			if len(active) > 0 {
+				t.Errorf("webhook jobs %v are cancellable through ActiveJobs", active)
+			}
+			if tt.wantTriggered == nil {
+				return
+			}
+
+			resp := decodeWebhookResponse(t, rec)
+			if got := triggered(resp); !equalStrings(got, tt.wantTriggered) {
+				t.Errorf("triggered = %v, want %v", got, tt.wantTriggered)
+			}
+			if resp.Ref != tt.wantRef || resp.Repository != "octo/app" {
+				t.Errorf("repository = %q, ref = %q, want octo/app, %q", resp.Repository, resp.Ref, tt.wantRef)
+			}
+			for _, req := range created {
+				labels := req.Workflow.Labels
+				if req.Namespace != "workflows" || labels[convert.LabelDelivery] != tt.delivery.id || labels[convert.LabelRepository] != "octo-app" {
+					t.Errorf("CreateWorkflow namespace = %q, labels = %v", req.Namespace, labels)
+				}
+			}
+
+			// 重新投递同一 ID 不会再次提交
+			rec = replay(t, c, tt.delivery)
+			if resp := decodeWebhookResponse(t, rec); rec.Code != http.StatusOK || !resp.Duplicate {
+				t.Errorf("redelivery: status = %d, duplicate = %v", rec.Code, resp.Duplicate)
+			}
+			if got := len(argo.created()); got != len(tt.wantTriggered) {
+				t.Errorf("redelivery created %d workflows, want none", got-len(tt.wantTriggered))
+			}
+		})
+	}
+}
+
+// TestWebhookDefaultBranchOnly 工作流源是默认分支的检出，使用其他分支工作流的事件被忽略
+func TestWebhookDefaultBranchOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		delivery delivery
+		edit     func(payload map[string]interface{})
+		wantWhy  string
+	}{
+		{
+			name:     "push to another branch",
+			delivery: delivery{event: "push", id: "c3d4e5f6-8a51-11f0-8e9f-708192a3b4c5", payload: "github-push.json"},
+			edit:     func(p map[string]interface{}) { p["ref"] = "refs/heads/dev" },
+			wantWhy:  "push uses the workflows of branch dev, only the default branch main is served",
+		},
+		{
+			name:     "tag not on the default branch head",
+			delivery: delivery{event: "push", id: "d4e5f6a7-8a51-11f0-9fa0-8192a3b4c5d6", payload: "github-push-tag.json"},
+			edit:     func(p map[string]interface{}) { delete(p, "base_ref") },
+			wantWhy:  "an unknown branch (ref refs/tags/v1.2.0)",
+		},
+		{
+			name:     "pull request into another branch",
+			delivery: delivery{event: "pull_request", id: "e5f6a7b8-8a51-11f0-8ab1-92a3b4c5d6e7", payload: "github-pull-request.json"},
+			edit: func(p map[string]interface{}) {
+				p["pull_request"].(map[string]interface{})["base"].(map[string]interface{})["ref"] = "release"
+			},
+			wantWhy: "pull_request uses the workflows of branch release",
+		},
+		{
+			name:     "no default branch",
+			delivery: delivery{event: "push", id: "f6a7b8c9-8a51-11f0-9bc2-a3b4c5d6e7f8", payload: "github-push.json"},
+			edit:     func(p map[string]interface{}) { delete(p["repository"].(map[string]interface{}), "default_branch") },
+			wantWhy:  "the payload has no repository.default_branch",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argo := newFakeArgo()
+			setupServer(t, argo.submitter())
+			c := newWebhookController(t)
+
+			rec := replayEdited(t, c, tt.delivery, tt.edit)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+			}
+			if got := len(argo.created()); got != 0 {
+				t.Errorf("CreateWorkflow called %d times, want 0", got)
+			}
+			resp := decodeWebhookResponse(t, rec)
+			if !strings.Contains(resp.Skipped, tt.wantWhy) || len(resp.Workflows) != 0 {
+				t.Errorf("skipped = %q, workflows = %+v, want %q", resp.Skipped, resp.Workflows, tt.wantWhy)
+			}
+		})
+	}
+}
+
+// TestWebhookPullRequestPaths pull_request 负载不含文件列表，有 paths 过滤器的工作流不触发
+func TestWebhookPullRequestPaths(t *testing.T) {
+	argo := newFakeArgo()
+	setupServer(t, argo.submitter())
+	c := newWebhookController(t)
+
+	rec := replay(t, c, delivery{event: "pull_request", id: "a7b8c9d0-8a52-11f0-8cd3-b4c5d6e7f809", payload: "github-pull-request.json"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	for _, wf := range decodeWebhookResponse(t, rec).Workflows {
+		if wf.Path != ".argus/workflows/lint.yml" {
+			continue
+		}
+		if wf.Triggered || wf.Reason != "paths cannot be checked: the changed files are unknown" {
+			t.Errorf("lint.yml: triggered = %v, reason = %q", wf.Triggered, wf.Reason)
+		}
+		return
+	}
+	t.Error("lint.yml is missing from the response")
+}
+
+// TestWebhookRetryAfterArgoError 提交失败的投递在重新投递时重试
+func TestWebhookRetryAfterArgoError(t *testing.T) {
+	argo := newFakeArgo()
+	setupServer(t, argo.submitter())
+	c := newWebhookController(t)
+	push := delivery{event: "push", id: "a1b2c3d4-8a4f-11f0-8b7c-5d6e7f8091a2", payload: "github-push.json"}
+
+	argo.err = errors.New("admission webhook denied the request")
+	rec := replay(t, c, push)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusInternalServerError, rec.Body)
+	}
+	if resp := decodeWebhookResponse(t, rec); resp.Workflows[0].Error == "" {
+		t.Errorf("submission error is not recorded: %+v", resp.Workflows[0])
+	}
+
+	argo.err = nil
+	rec = replay(t, c, push)
+	if resp := decodeWebhookResponse(t, rec); rec.Code != http.StatusOK || resp.Duplicate {
+		t.Fatalf("retry: status = %d, duplicate = %v: %s", rec.Code, resp.Duplicate, rec.Body)
+	}
+	if got := len(argo.created()); got != 1 {
+		t.Errorf("CreateWorkflow called %d times, want 1", got)
+	}
+}
+
+// TestWebhookRequiresSecret 没有密钥时只有 Insecure 才接受投递
+func TestWebhookRequiresSecret(t *testing.T) {
+	for _, insecure := range []bool{false, true} {
+		argo := newFakeArgo()
+		setupServer(t, argo.submitter())
+		c := newWebhookController(t)
+		c.Secret = nil
+		c.Insecure = insecure
+
+		rec := replay(t, c, delivery{event: "push", id: "b2c3d4e5-8a50-11f0-9d8e-6f708192a3b4", payload: "github-push.json"})
+		want := http.StatusUnauthorized
+		if insecure {
+			want = http.StatusOK
+		}
+		if rec.Code != want {
+			t.Errorf("insecure = %v: status = %d, want %d: %s", insecure, rec.Code, want, rec.Body)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,383 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// --- actions/cache ---
+
+// 缓存后端
+const (
+	CacheBackendArtifact = "artifact" // 工作流制品仓库（S3 兼容），只支持精确匹配 key
+	CacheBackendPVC      = "pvc"      // 共享的缓存 PVC，支持 restore-keys 前缀匹配
+	CacheBackendNone     = "none"     // 不转换，actions/cache 生成占位步骤
+)
+
+const (
+	// CacheVolumeName 是缓存 PVC 的卷名称
+	CacheVolumeName = "gha-cache"
+	// CacheMountPath 是缓存 PVC 在容器中的挂载路径
+	CacheMountPath = "/cache"
+	// CacheArtifactPrefix 是缓存在制品仓库中的 key 前缀
+	CacheArtifactPrefix = "gha-cache"
+	// cacheArchivePath 是缓存归档在容器中的临时路径
+	cacheArchivePath = "/tmp/gha-cache.tgz"
+)
+
+// CacheOptions 是 actions/cache 的转换配置
+type CacheOptions struct {
+	Backend               string                      // CacheBackendArtifact / CacheBackendPVC / CacheBackendNone
+	Claim                 string                      // CacheBackendPVC 使用的 PVC
+	ArtifactRepositoryRef *wfv1.ArtifactRepositoryRef // CacheBackendArtifact 使用的制品仓库，nil 时使用 namespace 默认仓库
+}
+
+// Validate 校验缓存配置
+func (o CacheOptions) Validate() error {
+	switch o.Backend {
+	case CacheBackendArtifact, CacheBackendNone:
+		return nil
+	case CacheBackendPVC:
+		if o.Claim == "" {
+			return fmt.Errorf("cache backend %q requires a cache claim", CacheBackendPVC)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid cache backend %q, must be %s, %s or %s", o.Backend, CacheBackendArtifact, CacheBackendPVC, CacheBackendNone)
+	}
+}
+
+// ParseArtifactRepositoryRef 解析 "configmap:key" 格式的制品仓库引用，空字符串返回 nil
+func ParseArtifactRepositoryRef(ref string) (*wfv1.ArtifactRepositoryRef, error) {
+	if ref == "" {
+		return nil, nil
+	}
+	configMap, key, _ := strings.Cut(ref, ":")
+	if configMap == "" {
+		return nil, fmt.Errorf("invalid artifact repository ref %q, expected configmap[:key]", ref)
+	}
+	return &wfv1.ArtifactRepositoryRef{ConfigMap: configMap, Key: key}, nil
+}
+
+// cacheAction 是 actions/cache 的三种用法
+type cacheAction int
+
+const (
+	cacheRestoreAndSave cacheAction = iota // actions/cache：恢复，Job 成功结束后保存
+	cacheRestoreOnly                       // actions/cache/restore
+	cacheSaveOnly                          // actions/cache/save
+)
+
+// cacheActionFor 识别 uses 中的 actions/cache
+func cacheActionFor(uses string) (cacheAction, bool) {
+	action, _, _ := strings.Cut(uses, "@")
+	switch action {
+	case "actions/cache":
+		return cacheRestoreAndSave, true
+	case "actions/cache/restore":
+		return cacheRestoreOnly, true
+	case "actions/cache/save":
+		return cacheSaveOnly, true
+	default:
+		return 0, false
+	}
+}
+
+// cacheStep 是一个 actions/cache 步骤的转换输入
+type cacheStep struct {
+	action   cacheAction
+	name     string            // Argo 步骤名称
+	template string            // Argo 模板名称前缀
+	image    string            // 运行缓存脚本的镜像，需要 bash、tar、gzip 和 sha256sum
+	env      []corev1.EnvVar   // github 上下文环境变量
+	with     map[string]string // key / restore-keys / path
+}
+
+// convertedCache 是 actions/cache 转换后的步骤和模板
+type convertedCache struct {
+	steps     []wfv1.ParallelSteps // 在原位置执行的步骤
+	post      []wfv1.ParallelSteps // Job 所有步骤成功后执行的步骤
+	templates []wfv1.Template
+	warnings  []string       // 无法转换的输入
+	problems  []cacheProblem // 无法编译的表达式
+}
+
+// cacheProblem 是 actions/cache 某个输入中无法编译的表达式
+type cacheProblem struct {
+	input    string // key / restore-keys / path
+	problems []exprProblem
+}
+
+// convertCacheStep 把 actions/cache 转换为计算 key、恢复和保存三个步骤：
+// key 在单独的步骤中计算，因为制品仓库的 key 必须在 Pod 启动前确定
+// exprs 是步骤模板内的表达式上下文
+func convertCacheStep(step cacheStep, exprs *exprContext, opts CacheOptions) (*convertedCache, error) {
+	if step.with["key"] == "" {
+		return nil, fmt.Errorf("step %s: actions/cache requires 'key'", step.name)
+	}
+	if step.with["path"] == "" {
+		return nil, fmt.Errorf("step %s: actions/cache requires 'path'", step.name)
+	}
+
+	result := &convertedCache{}
+	report := func(input string, offset int, problems []exprProblem) {
+		for i := range problems {
+			problems[i].offset += offset
+		}
+		if len(problems) > 0 {
+			result.problems = append(result.problems, cacheProblem{input: input, problems: problems})
+		}
+	}
+
+	// 1. 计算 key 和 restore-keys，表达式在 key 步骤中求值
+	keyExprs := exprs.forStep()
+	key, problems := keyExprs.interpolate(step.with["key"], targetShellWord)
+	report("key", 0, problems)
+	var restoreKeys []string
+	offset := 0
+	for _, line := range strings.SplitAfter(step.with["restore-keys"], "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			compiled, problems := keyExprs.interpolate(trimmed, targetShellWord)
+			report("restore-keys", offset+strings.Index(line, trimmed), problems)
+			restoreKeys = append(restoreKeys, compiled)
+		}
+		offset += len(line)
+	}
+	if len(restoreKeys) > 0 && opts.Backend == CacheBackendArtifact && step.action != cacheSaveOnly {
+		result.warnings = append(result.warnings, fmt.Sprintf("step %s: restore-keys need the %q cache backend and are ignored", step.name, CacheBackendPVC))
+	}
+	keyScript := cacheScriptHeader + fmt.Sprintf(`key=%s
+[ -n "$key" ] || { echo "cache key is empty" >&2; exit 1; }
+printf '%%s' "$key" > /tmp/key
+printf '%%s/%%s' "$(cache_name "${GITHUB_REPOSITORY:-default}")" "$(cache_name "$key")" > /tmp/key-file
+: > /tmp/restore-keys
+restore_keys=(%s)
+for restore_key in "${restore_keys[@]}"; do
+  printf '%%s\n' "$(cache_name "$restore_key")" >> /tmp/restore-keys
+done
+echo "Cache key: $key"
+`, key, strings.Join(restoreKeys, " "))
+
+	keyTemplate := cacheTemplate(step, step.template+"-key", keyScript, false)
+	keyInputs, keyStepArguments := keyExprs.stepParameters()
+	keyTemplate.Inputs.Parameters = keyInputs
+	keyTemplate.Outputs.Parameters = []wfv1.Parameter{
+		outputFromPath("key", "/tmp/key"),
+		outputFromPath("key-file", "/tmp/key-file"),
+		outputFromPath("restore-keys", "/tmp/restore-keys"),
+	}
+	keyStep := step.name + "-key"
+	result.templates = append(result.templates, keyTemplate)
+	result.steps = append(result.steps, singleStep(wfv1.WorkflowStep{
+		Name:      keyStep,
+		Template:  keyTemplate.Name,
+		Arguments: wfv1.Arguments{Parameters: keyStepArguments},
+	}))
+	keyArguments := wfv1.Arguments{Parameters: []wfv1.Parameter{
+		{Name: "key", Value: wfv1.AnyStringPtr("{{steps." + keyStep + ".outputs.parameters.key}}")},
+		{Name: "key-file", Value: wfv1.AnyStringPtr("{{steps." + keyStep + ".outputs.parameters.key-file}}")},
+		{Name: "restore-keys", Value: wfv1.AnyStringPtr("{{steps." + keyStep + ".outputs.parameters.restore-keys}}")},
+	}}
+
+	// 2. 恢复：步骤名称与 GHA 步骤一致，cache-hit 输出可以通过 steps.<name>.outputs.parameters.cache-hit 引用
+	if step.action != cacheSaveOnly {
+		restoreTemplate := cacheTemplate(step, step.template, cacheRestoreScript(opts.Backend), true)
+		restoreTemplate.Outputs.Parameters = []wfv1.Parameter{outputFromPath("cache-hit", "/tmp/cache-hit")}
+		if opts.Backend == CacheBackendArtifact {
+			restoreTemplate.Inputs.Artifacts = []wfv1.Artifact{cacheArtifact()}
+		}
+		result.templates = append(result.templates, restoreTemplate)
+		result.steps = append(result.steps, singleStep(wfv1.WorkflowStep{
+			Name:      step.name,
+			Template:  restoreTemplate.Name,
+			Arguments: keyArguments,
+		}))
+	}
+
+	// 3. 保存：actions/cache 在 Job 结束时保存，精确命中时跳过；actions/cache/save 在原位置保存
+	if step.action != cacheRestoreOnly {
+		// path 中的 glob 由保存脚本展开
+		pathExprs := exprs.forStep()
+		paths, problems := pathExprs.interpolate(step.with["path"], targetEnv)
+		report("path", 0, problems)
+		pathInputs, pathArguments := pathExprs.stepParameters()
+
+		saveTemplate := cacheTemplate(step, step.template+"-save", cacheSaveScript(opts.Backend), true)
+		saveTemplate.Inputs.Parameters = append(saveTemplate.Inputs.Parameters, pathInputs...)
+		saveTemplate.Script.Env = append(saveTemplate.Script.Env, corev1.EnvVar{Name: "CACHE_PATHS", Value: paths})
+		if opts.Backend == CacheBackendArtifact {
+			saveTemplate.Outputs.Artifacts = []wfv1.Artifact{cacheArtifact()}
+		}
+		result.templates = append(result.templates, saveTemplate)
+
+		saveArguments := keyArguments
+		saveArguments.Parameters = append(append([]wfv1.Parameter{}, keyArguments.Parameters...), pathArguments...)
+		saveStep := wfv1.WorkflowStep{Name: step.name + "-save", Template: saveTemplate.Name, Arguments: saveArguments}
+		if step.action == cacheRestoreAndSave {
+			saveStep.When = "{{steps." + step.name + ".outputs.parameters.cache-hit}} != true"
+			result.post = append(result.post, singleStep(saveStep))
+		} else {
+			result.steps = append(result.steps, singleStep(saveStep))
+		}
+	}
+	return result, nil
+}
+
+// addCacheVolume 声明缓存卷或制品仓库引用，只在工作流使用了 actions/cache 时调用
+func addCacheVolume(wf *wfv1.Workflow, opts CacheOptions) {
+	switch opts.Backend {
+	case CacheBackendPVC:
+		wf.Spec.Volumes = append(wf.Spec.Volumes, corev1.Volume{
+			Name: CacheVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: opts.Claim},
+			},
+		})
+	case CacheBackendArtifact:
+		if opts.ArtifactRepositoryRef != nil {
+			wf.Spec.ArtifactRepositoryRef = opts.ArtifactRepositoryRef
+		}
+	}
+}
+
+// cacheTemplate 创建运行缓存脚本的模板；withInputs 为 true 时接收 key 步骤的输出，PVC 后端挂载缓存卷
+func cacheTemplate(step cacheStep, name, source string, withInputs bool) wfv1.Template {
+	template := wfv1.Template{
+		Name: name,
+		Script: &wfv1.ScriptTemplate{
+			Container: corev1.Container{
+				Image:   step.image,
+				Command: []string{"bash"},
+				Env:     append([]corev1.EnvVar{}, step.env...),
+			},
+			Source: source,
+		},
+	}
+	if withInputs {
+		// 通过环境变量传入，避免 key 中的特殊字符破坏脚本
+		template.Inputs.Parameters = []wfv1.Parameter{{Name: "key"}, {Name: "key-file"}, {Name: "restore-keys", Default: wfv1.AnyStringPtr("")}}
+		template.Script.Env = append(template.Script.Env,
+			corev1.EnvVar{Name: "CACHE_KEY", Value: "{{inputs.parameters.key}}"},
+			corev1.EnvVar{Name: "CACHE_KEY_FILE", Value: "{{inputs.parameters.key-file}}"},
+			corev1.EnvVar{Name: "CACHE_RESTORE_KEYS", Value: "{{inputs.parameters.restore-keys}}"},
+		)
+	}
+	if strings.Contains(source, CacheMountPath) {
+		template.Script.VolumeMounts = append(template.Script.VolumeMounts, corev1.VolumeMount{Name: CacheVolumeName, MountPath: CacheMountPath})
+	}
+	return template
+}
+
+// cacheArtifact 是制品仓库中的缓存归档；只指定 key，桶和凭据来自制品仓库配置
+func cacheArtifact() wfv1.Artifact {
+	return wfv1.Artifact{
+		Name:     "cache",
+		Path:     cacheArchivePath,
+		Optional: true, // 恢复时缓存可能不存在，保存时可能没有匹配的文件
+		Archive:  &wfv1.ArchiveStrategy{None: &wfv1.NoneStrategy{}},
+		ArtifactLocation: wfv1.ArtifactLocation{
+			S3: &wfv1.S3Artifact{Key: CacheArtifactPrefix + "/{{inputs.parameters.key-file}}.tgz"},
+		},
+	}
+}
+
+func outputFromPath(name, path string) wfv1.Parameter {
+	return wfv1.Parameter{Name: name, ValueFrom: &wfv1.ValueFrom{Path: path}}
+}
+
+func singleStep(step wfv1.WorkflowStep) wfv1.ParallelSteps {
+	return wfv1.ParallelSteps{Steps: []wfv1.WorkflowStep{step}}
+}
+
+// cacheScriptHeader 定义缓存脚本共用的函数：hashFiles 和把 key 转换为文件名的 cache_name
+const cacheScriptHeader = `set -euo pipefail
+cd "${GITHUB_WORKSPACE:-/workspace}"
+
+` + hashFilesFunction + `
+cache_name() {
+  printf '%s' "$1" | tr -c 'A-Za-z0-9._-' '_'
+}
+`
+
+// cacheRestoreScript 返回恢复脚本：先找精确匹配的 key，PVC 后端再按 restore-keys 前缀找最新的缓存
+func cacheRestoreScript(backend string) string {
+	if backend == CacheBackendArtifact {
+		return cacheScriptHeader + fmt.Sprintf(`hit=false
+if [ -s %[1]s ]; then
+  echo "Restoring cache for key $CACHE_KEY"
+  tar -xzPf %[1]s
+  hit=true
+else
+  echo "Cache not found for key $CACHE_KEY"
+fi
+printf '%%s' "$hit" > /tmp/cache-hit
+`, cacheArchivePath)
+	}
+	return cacheScriptHeader + fmt.Sprintf(`hit=false
+archive="%[1]s/$CACHE_KEY_FILE.tgz"
+if [ -f "$archive" ]; then
+  hit=true
+else
+  archive=""
+  scope=$(dirname "$CACHE_KEY_FILE")
+  while IFS= read -r prefix; do
+    [ -n "$prefix" ] || continue
+    archive=$(ls -t "%[1]s/$scope/$prefix"*.tgz 2>/dev/null | head -n1 || true)
+    [ -z "$archive" ] || break
+  done <<< "$CACHE_RESTORE_KEYS"
+fi
+if [ -n "$archive" ]; then
+  echo "Restoring cache from $archive"
+  tar -xzPf "$archive"
+else
+  echo "Cache not found for key $CACHE_KEY"
+fi
+printf '%%s' "$hit" > /tmp/cache-hit
+`, CacheMountPath)
+}
+
+// cacheSaveScript 返回保存脚本：把 path 中匹配的文件打包，PVC 后端先写临时文件再改名，避免读到不完整的归档
+func cacheSaveScript(backend string) string {
+	collect := cacheScriptHeader + `paths=()
+shopt -s globstar nullglob
+while IFS= read -r pattern; do
+  [ -n "$pattern" ] || continue
+  pattern="${pattern/#\~/$HOME}"
+  for file in $pattern; do
+    [ -e "$file" ] && paths+=("$file")
+  done
+done <<< "$CACHE_PATHS"
+shopt -u globstar nullglob
+if [ ${#paths[@]} -eq 0 ]; then
+  echo "No files matched the cache path, nothing to save"
+  exit 0
+fi
+`
+	if backend == CacheBackendArtifact {
+		return collect + fmt.Sprintf(`tar -czPf %s -- "${paths[@]}"
+echo "Saved cache for key $CACHE_KEY"
+`, cacheArchivePath)
+	}
+	return collect + fmt.Sprintf(`archive="%[1]s/$CACHE_KEY_FILE.tgz"
+if [ -f "$archive" ]; then
+  echo "Cache for key $CACHE_KEY already exists"
+  exit 0
+fi
+mkdir -p "$(dirname "$archive")"
+tmp=$(mktemp "$archive.XXXXXX")
+tar -czPf "$tmp" -- "${paths[@]}"
+mv "$tmp" "$archive"
+echo "Saved cache for key $CACHE_KEY"
+`, CacheMountPath)
+}
+
+// shellQuote 用单引号包裹字符串，空字符串返回空
+func shellQuote(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
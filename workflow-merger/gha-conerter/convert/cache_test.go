@@ -0,0 +1,317 @@
+package convert
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+)
+
+// --- actions/cache 的保存和恢复 ---
+
+// cacheWorkflow 的 key 依赖 go.sum 的 hashFiles()，restore-keys 按前缀回落
+const cacheWorkflow = `name: cache
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - name: cache
+        uses: actions/cache@v4
+        with:
+          path: |
+            vendor
+            out/*.bin
+          key: deps-${{ hashFiles('**/go.sum') }}
+          restore-keys: |
+            deps-
+`
+
+// cacheBackend 在本地模拟缓存后端：PVC 后端是一个共享目录，制品仓库是按 key 保存归档的假 S3
+type cacheBackend struct {
+	opts      CacheOptions
+	dir       string            // PVC 后端挂载到 CacheMountPath 的目录
+	artifacts map[string][]byte // 制品仓库：artifact key -> 归档内容
+}
+
+// cacheRun 是一次 Job 运行：独立的工作区和 /tmp，与缓存后端共享
+type cacheRun struct {
+	t         *testing.T
+	backend   *cacheBackend
+	templates map[string]*wfv1.Template
+	workspace string
+	tmp       string
+	outputs   map[string]string // key 步骤的输出
+}
+
+func newCacheRun(t *testing.T, backend *cacheBackend, files map[string]string) *cacheRun {
+	t.Helper()
+	output, _, err := GHAtoArgo(context.Background(), cacheWorkflow, ConversionOptions{Cache: backend.opts})
+	if err != nil {
+		t.Fatalf("GHAtoArgo: %v", err)
+	}
+	run := &cacheRun{t: t, backend: backend, templates: map[string]*wfv1.Template{}, workspace: t.TempDir(), tmp: t.TempDir(), outputs: map[string]string{}}
+	for i := range output.Workflow.Spec.Templates {
+		template := &output.Workflow.Spec.Templates[i]
+		run.templates[template.Name] = template
+	}
+	writeFiles(t, run.workspace, files)
+	return run
+}
+
+// exec 运行模板的脚本：CacheMountPath 和 /tmp 替换为测试目录，模拟卷挂载
+func (r *cacheRun) exec(name string, env map[string]string) string {
+	r.t.Helper()
+	template, ok := r.templates[name]
+	if !ok {
+		r.t.Fatalf("template %s not found", name)
+	}
+	source := strings.ReplaceAll(template.Script.Source, "/tmp/", r.tmp+"/")
+	if r.backend.dir != "" {
+		source = strings.ReplaceAll(source, CacheMountPath+"/", r.backend.dir+"/")
+	}
+	cmd := exec.Command("bash", "-c", source)
+	cmd.Env = append(os.Environ(), "GITHUB_WORKSPACE="+r.workspace, "GITHUB_REPOSITORY=octo/repo")
+	for _, e := range template.Script.Env {
+		if e.Name == "CACHE_PATHS" {
+			cmd.Env = append(cmd.Env, e.Name+"="+e.Value)
+		}
+	}
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("template %s failed: %v\n%s", name, err, out)
+	}
+	return string(out)
+}
+
+// keyEnv 把 key 步骤的输出作为恢复和保存步骤的输入
+func (r *cacheRun) keyEnv() map[string]string {
+	return map[string]string{
+		"CACHE_KEY":          r.outputs["key"],
+		"CACHE_KEY_FILE":     r.outputs["key-file"],
+		"CACHE_RESTORE_KEYS": r.outputs["restore-keys"],
+	}
+}
+
+func (r *cacheRun) key() {
+	r.exec("build-cache-key", nil)
+	for _, name := range []string{"key", "key-file", "restore-keys"} {
+		data, err := os.ReadFile(filepath.Join(r.tmp, name))
+		if err != nil {
+			r.t.Fatal(err)
+		}
+		r.outputs[name] = string(data)
+	}
+}
+
+// restore 返回 cache-hit；制品仓库后端由 Argo 在 Pod 启动前下载归档
+func (r *cacheRun) restore() string {
+	if r.backend.artifacts != nil {
+		if archive, ok := r.backend.artifacts[r.artifactKey()]; ok {
+			if err := os.WriteFile(strings.Replace(cacheArchivePath, "/tmp", r.tmp, 1), archive, 0o644); err != nil {
+				r.t.Fatal(err)
+			}
+		}
+	}
+	r.exec("build-cache", r.keyEnv())
+	hit, err := os.ReadFile(filepath.Join(r.tmp, "cache-hit"))
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return string(hit)
+}
+
+// save 运行保存步骤；制品仓库后端由 Argo 在 Pod 结束后上传归档
+func (r *cacheRun) save() {
+	r.exec("build-cache-save", r.keyEnv())
+	if r.backend.artifacts != nil {
+		archive, err := os.ReadFile(strings.Replace(cacheArchivePath, "/tmp", r.tmp, 1))
+		if err == nil {
+			r.backend.artifacts[r.artifactKey()] = archive
+		}
+	}
+}
+
+func (r *cacheRun) artifactKey() string {
+	return strings.ReplaceAll(cacheArtifact().S3.Key, "{{inputs.parameters.key-file}}", r.outputs["key-file"])
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// ghaHashFiles 是 GHA hashFiles() 的参考实现：按路径排序，对每个文件的 SHA-256 摘要再做一次 SHA-256
+func ghaHashFiles(files map[string]string, match func(string) bool) string {
+	var names []string
+	for name := range files {
+		if match(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	total := sha256.New()
+	for _, name := range names {
+		sum := sha256.Sum256([]byte(files[name]))
+		total.Write(sum[:])
+	}
+	return hex.EncodeToString(total.Sum(nil))
+}
+
+func requireTools(t *testing.T, tools ...string) {
+	t.Helper()
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s is not installed", tool)
+		}
+	}
+}
+
+func TestCacheSaveRestore(t *testing.T) {
+	requireTools(t, "bash", "tar", "gzip", "sha256sum")
+
+	sources := map[string]string{
+		"go.sum":     "example.com/a v1.0.0 h1:abc=\n",
+		"sub/go.sum": "example.com/b v2.0.0 h1:def=\n",
+		"main.go":    "package main\n",
+	}
+	cached := map[string]string{
+		"vendor/modules.txt": "# example.com/a v1.0.0\n",
+		"out/app.bin":        "binary",
+	}
+	changed := map[string]string{"go.sum": "example.com/a v1.1.0 h1:xyz=\n"}
+
+	tests := []struct {
+		name    string
+		backend func(t *testing.T) *cacheBackend
+		// 修改 go.sum 后 key 变化：PVC 后端按 restore-keys 前缀恢复，制品仓库只支持精确匹配
+		wantPrefixRestore bool
+	}{
+		{
+			name: "pvc",
+			backend: func(t *testing.T) *cacheBackend {
+				return &cacheBackend{opts: CacheOptions{Backend: CacheBackendPVC, Claim: "cache"}, dir: t.TempDir()}
+			},
+			wantPrefixRestore: true,
+		},
+		{
+			name: "artifact",
+			backend: func(t *testing.T) *cacheBackend {
+				return &cacheBackend{opts: CacheOptions{Backend: CacheBackendArtifact}, artifacts: map[string][]byte{}}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := tt.backend(t)
+
+			// 1. 第一次运行：key 与 GHA 的 hashFiles 一致，缓存未命中，Job 结束后保存
+			first := newCacheRun(t, backend, sources)
+			first.key()
+			wantKey := "deps-" + ghaHashFiles(sources, func(name string) bool { return filepath.Base(name) == "go.sum" })
+			if first.outputs["key"] != wantKey {
+				t.Fatalf("key = %q, want %q", first.outputs["key"], wantKey)
+			}
+			if hit := first.restore(); hit != "false" {
+				t.Fatalf("first run cache-hit = %q, want false", hit)
+			}
+			writeFiles(t, first.workspace, cached)
+			first.save()
+
+			// 2. 相同的输入：精确命中并恢复文件
+			second := newCacheRun(t, backend, sources)
+			second.key()
+			if second.outputs["key-file"] != first.outputs["key-file"] {
+				t.Fatalf("key-file changed between runs: %q != %q", second.outputs["key-file"], first.outputs["key-file"])
+			}
+			if hit := second.restore(); hit != "true" {
+				t.Fatalf("second run cache-hit = %q, want true", hit)
+			}
+			for name, content := range cached {
+				data, err := os.ReadFile(filepath.Join(second.workspace, name))
+				if err != nil || string(data) != content {
+					t.Errorf("restored %s = %q, %v; want %q", name, data, err, content)
+				}
+			}
+
+			// 3. go.sum 变化后 key 不同，不是精确命中
+			inputs := map[string]string{}
+			for name, content := range sources {
+				inputs[name] = content
+			}
+			for name, content := range changed {
+				inputs[name] = content
+			}
+			third := newCacheRun(t, backend, inputs)
+			third.key()
+			if third.outputs["key"] == first.outputs["key"] {
+				t.Fatalf("key did not change after go.sum changed: %q", third.outputs["key"])
+			}
+			if hit := third.restore(); hit != "false" {
+				t.Fatalf("third run cache-hit = %q, want false", hit)
+			}
+			_, err := os.Stat(filepath.Join(third.workspace, "vendor/modules.txt"))
+			if restored := err == nil; restored != tt.wantPrefixRestore {
+				t.Errorf("restored from restore-keys prefix = %v, want %v", restored, tt.wantPrefixRestore)
+			}
+		})
+	}
+}
+
+func TestHashFilesMatchesGitHubActions(t *testing.T) {
+	requireTools(t, "bash", "sha256sum")
+
+	files := map[string]string{
+		"package-lock.json":     `{"lockfileVersion": 3}`,
+		"web/package-lock.json": `{"lockfileVersion": 2}`,
+		"go.sum":                "example.com/a v1.0.0 h1:abc=\n",
+		"docs/readme.md":        "# docs\n",
+	}
+	tests := []struct {
+		name     string
+		patterns []string
+		match    func(string) bool
+	}{
+		{"single file", []string{"go.sum"}, func(name string) bool { return name == "go.sum" }},
+		{"recursive glob", []string{"**/package-lock.json"}, func(name string) bool { return filepath.Base(name) == "package-lock.json" }},
+		{"several patterns", []string{"go.sum", "**/package-lock.json"}, func(name string) bool { return name == "go.sum" || filepath.Base(name) == "package-lock.json" }},
+		{"no match", []string{"**/*.lock"}, func(string) bool { return false }},
+	}
+	workspace := t.TempDir()
+	writeFiles(t, workspace, files)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command("bash", "-c", hashFilesFunction+`hashFiles "$@"`, "bash")
+			cmd.Args = append(cmd.Args, tt.patterns...)
+			cmd.Env = append(os.Environ(), "GITHUB_WORKSPACE="+workspace)
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("hashFiles failed: %v", err)
+			}
+			if got, want := strings.TrimSpace(string(out)), ghaHashFiles(files, tt.match); got != want {
+				t.Errorf("hashFiles(%v) = %q, want %q", tt.patterns, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,479 @@
+// Package convert 把 GitHub Actions 工作流转换为 Argo Workflow 或 CronWorkflow，并提交到 Argo
+package convert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/nektos/act/pkg/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"argo-parser/argowf"
+	"workflow-parser/ghawf"
+)
+
+// 不支持（或尚未转换）的 GHA 特性告警代码
+const (
+	WarnUnsupportedAction = "unsupported-action" // 'uses' 步骤只生成占位符
+	WarnEmptyStep         = "empty-step"         // 既没有 'run' 也没有 'uses' 的步骤
+	WarnJobIf             = "job-if"             // Job 级 'if' 无法转换为 DAG 任务的 when，该 Job 不会执行
+	WarnStepIf            = "step-if"            // Step 级 'if' 无法转换为步骤的 when，该步骤不会执行
+	WarnMatrix            = "matrix"             // 'strategy.matrix' 未展开
+	WarnServices          = "services"           // 'services' 未转换
+	WarnJobContainer      = "job-container"      // 'container' 未转换
+	WarnGitHubContext     = "github-context"     // 无法映射的 ${{ github.* }} 属性
+	WarnWorkspace         = "workspace"          // working-directory 无法转换为容器的工作目录
+	WarnCache             = "cache"              // actions/cache 中无法转换的输入
+	WarnExpression        = "expression"         // 无法编译的 ${{ }} 表达式
+	WarnSchedule          = "schedule"           // on.schedule 或 concurrency 无法完整转换为 CronWorkflow
+)
+
+// ConversionWarning 描述一次转换中被忽略或仅部分转换的 GHA 特性
+type ConversionWarning struct {
+	Code    string `json:"code"`
+	Job     string `json:"job,omitempty"`
+	Line    int    `json:"line,omitempty"` // 在 GHA YAML 中的行列，从 1 开始，未知时为 0
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ConversionOptions 是与租户相关的转换配置
+type ConversionOptions struct {
+	TenantID        string               // 发起转换的租户
+	RunnerNamespace string               // runs-on ConfigMap 所在的 namespace
+	Runners         RunnerConfigProvider // runs-on 运行环境查询，nil 时使用内置映射
+	Source          SourceContext        // GHA 工作流的来源，写入标签和注解
+	Workspace       WorkspaceOptions     // 共享工作区配置
+	Cache           CacheOptions         // actions/cache 配置
+}
+
+// GHAtoArgo 使用 nektos/act 解析器执行转换，on.schedule 触发的工作流输出为 CronWorkflow
+// ctx 被取消或超时后，转换会在下一个 Job/Step 处中止
+func GHAtoArgo(ctx context.Context, ghaYAML string, opts ConversionOptions) (*ConversionOutput, []ConversionWarning, error) {
+	if opts.Runners == nil {
+		opts.Runners = StaticRunnerConfigs{}
+	}
+
+	// 1. 使用 nektos/act/pkg/model 解析 GHA YAML
+	ghaReader := strings.NewReader(ghaYAML)
+	ghaWF, err := model.ReadWorkflow(ghaReader, false) // 添加第二个参数 false
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: failed to parse GHA YAML using 'act': %v", ErrInvalidWorkflow, err)
+	}
+
+	var warnings []ConversionWarning
+	warn := func(code, job, format string, args ...interface{}) {
+		warnings = append(warnings, ConversionWarning{Code: code, Job: job, Message: fmt.Sprintf(format, args...)})
+	}
+	// reportExpressions 记录字段中无法编译的表达式及其位置；code 为空时使用表达式自身的告警代码
+	source := ghawf.NewYAMLSource(ghaYAML)
+	reportExpressions := func(code, job string, problems []exprProblem, path ...interface{}) {
+		node := source.Node(path...)
+		for _, problem := range problems {
+			warning := ConversionWarning{Code: code, Job: job, Message: fmt.Sprintf("%s: %s: %s", fieldPath(path...), problem.expr, problem.message)}
+			if warning.Code == "" {
+				warning.Code = problem.code
+			}
+			warning.Line, warning.Column = source.Position(node, problem.offset)
+			warnings = append(warnings, warning)
+		}
+	}
+
+	// 2. 创建 Argo Workflow 基础结构
+	argoWF := &wfv1.Workflow{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "Workflow",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: ghawf.SanitizeName(ghaWF.Name) + "-",
+		},
+		Spec: wfv1.WorkflowSpec{
+			Templates: []wfv1.Template{},
+		},
+	}
+
+	// 定时触发：事件名称为 schedule，github.event.schedule 来自工作流参数，
+	// 每个 schedule 输出一个 CronWorkflow，参数值是各自的 cron 表达式
+	schedules := ghaSchedules(ghaWF)
+	scheduled := scheduledConversion(schedules, opts.Source)
+	if scheduled {
+		if err := validateSchedules(schedules); err != nil {
+			return nil, nil, err
+		}
+		opts.Source.EventName = "schedule"
+	}
+
+	// github 上下文：参数承载来源信息，容器中注入 GITHUB_* 环境变量
+	github := githubContext{workflow: ghaWF.Name, scheduled: scheduled}
+	if github.workflow == "" {
+		github.workflow = opts.Source.WorkflowPath
+	}
+	argoWF.Spec.Arguments.Parameters = githubArguments(opts.Source)
+	if scheduled {
+		argoWF.Spec.Arguments.Parameters = append(argoWF.Spec.Arguments.Parameters, wfv1.Parameter{Name: githubScheduleParameter, Value: wfv1.AnyStringPtr(schedules[0])})
+	}
+
+	// 表达式上下文：workflow env -> job env -> step env 逐层编译
+	workflowExprs := newExprContext(github, ghaWF)
+	// 无法编译的表达式按原文保留；用到它们的步骤与条件一样不执行，见 neverRun
+	workflowEnvFailed := false
+	workflowEnv := workflowExprs.compileEnv(ghaWF.Env, func(key string, problems []exprProblem) {
+		reportExpressions("", "", problems, "env", key)
+		workflowEnvFailed = workflowEnvFailed || len(problems) > 0
+	})
+	neverRun := func(job, step string) {
+		warn(WarnExpression, job, "step %s uses expressions that cannot be converted in its run, env or working-directory; it is never run", step)
+	}
+
+	// 3. 编排 Job (GHA Job -> Argo DAG Task)
+	var jobNames []string
+	jobTemplates := make(map[string]wfv1.Template)
+	jobNeeds := make(map[string][]string)       // GHA Job ID -> needs 中的 Job ID
+	jobTemplateNames := make(map[string]string) // GHA Job ID -> 模板名称
+	jobConditions := make(map[string]string)
+	jobWorkspaces := make(map[string]WorkspaceConfig)
+	usesCache := false
+
+	for jobName, ghaJob := range ghaWF.Jobs {
+		if err := ctx.Err(); err != nil {
+			return nil, warnings, fmt.Errorf("conversion aborted at job %s: %w", jobName, err)
+		}
+
+		// 只有一个组合的 matrix 不需要展开，matrix.* 在转换时求值
+		matrix := staticMatrix(ghaJob)
+		if ghaJob.Strategy != nil && ghaJob.Strategy.RawMatrix.Kind != 0 && matrix == nil {
+			warn(WarnMatrix, jobName, "strategy.matrix is not expanded")
+		}
+
+		// 修复：调用 RunsOn() 方法并获取第一个运行环境，从 runs-on 同名 ConfigMap 查询镜像
+		runner := &RunnerConfig{Image: "alpine:latest"}
+		if runsOn := ghaJob.RunsOn(); len(runsOn) > 0 {
+			runner, err = opts.Runners.RunnerConfig(ctx, opts.RunnerNamespace, runsOn[0])
+			if err != nil {
+				return nil, warnings, fmt.Errorf("job %s: %w", jobName, err)
+			}
+		}
+		jobExprs := workflowExprs.forJob(jobName, runner.Arch, matrix)
+		jobEnvFailed := workflowEnvFailed
+		jobEnv := jobExprs.compileEnv(ghaJob.Environment(), func(key string, problems []exprProblem) {
+			reportExpressions("", jobName, problems, "jobs", jobName, "env", key)
+			jobEnvFailed = jobEnvFailed || len(problems) > 0
+		})
+		if len(ghaJob.Services) > 0 {
+			warn(WarnServices, jobName, "%d service container(s) are not converted", len(ghaJob.Services))
+		}
+		if ghaJob.RawContainer.Kind != 0 {
+			warn(WarnJobContainer, jobName, "job container is not converted, runs-on image is used instead")
+		}
+
+		jobTemplateName := ghawf.SanitizeName(jobName)
+		jobNames = append(jobNames, jobTemplateName)
+
+		if ghaJob.If.Value != "" {
+			when, problems := jobExprs.condition(ghaJob.If.Value)
+			reportExpressions(WarnJobIf, jobName, problems, "jobs", jobName, "if")
+			jobConditions[jobTemplateName] = when
+		}
+
+		// 修复：调用 Needs() 方法而不是直接访问字段；依赖在所有 Job 转换后统一解析
+		jobNeeds[jobName] = ghaJob.Needs()
+		jobTemplateNames[jobName] = jobTemplateName
+
+		// 为 GHA Job 创建一个 Argo "steps" 模板
+		jobTemplate := wfv1.Template{
+			Name:     jobTemplateName,
+			Metadata: wfv1.Metadata{Annotations: map[string]string{AnnotationGHAJob: jobName}},
+			Steps:    []wfv1.ParallelSteps{}, // 修复：使用正确的类型
+		}
+
+		jobWorkspaces[jobTemplateName] = runner.Workspace
+
+		// 挂载工作区并按 runner.arch 选择节点，再合并 runs-on ConfigMap 中的模板配置
+		finalizeStepTemplate := func(template *wfv1.Template) error {
+			mountWorkspace(&template.Script.Container, jobTemplateName, opts.Workspace)
+			selectRunnerArch(template, runner.Arch)
+			return applyTemplatePatch(template, runner.TemplatePatch)
+		}
+
+		// GHA 步骤 -> Argo 模板
+		var stepTemplates []wfv1.Template
+		var postSteps []wfv1.ParallelSteps // Job 结束时执行的步骤，如 actions/cache 的保存
+
+		stepNames := map[string]bool{}
+		for i, ghaStep := range ghaJob.Steps {
+			if err := ctx.Err(); err != nil {
+				return nil, warnings, fmt.Errorf("conversion aborted at job %s step %d: %w", jobName, i, err)
+			}
+
+			// 步骤的 env 和 if：if 在 Job 的 steps 模板中求值，其他表达式在步骤模板内求值
+			stepExprs := jobExprs.forStep()
+			failed := jobEnvFailed // 步骤的 run、env 或 working-directory 中有无法编译的表达式
+			stepEnv := stepExprs.compileEnv(ghaStep.Environment(), func(key string, problems []exprProblem) {
+				reportExpressions("", jobName, problems, "jobs", jobName, "steps", i, "env", key)
+				failed = failed || len(problems) > 0
+			})
+			containerEnv := mergeEnv(github.env(jobName, runner.Arch), workflowEnv, jobEnv, stepEnv)
+			var when string
+			if ghaStep.If.Value != "" {
+				var problems []exprProblem
+				when, problems = jobExprs.condition(ghaStep.If.Value)
+				reportExpressions(WarnStepIf, jobName, problems, "jobs", jobName, "steps", i, "if")
+			}
+
+			stepName := ghawf.SanitizeName(ghaStep.Name)
+			if ghaStep.Name == "" || stepNames[stepName] {
+				// 未命名或清理后重名的步骤按序号区分，避免模板名称冲突
+				stepName = fmt.Sprintf("step-%d", i)
+			}
+			stepNames[stepName] = true
+			stepTemplateName := fmt.Sprintf("%s-%s", jobTemplateName, stepName)
+			metadata := func() wfv1.Metadata {
+				return wfv1.Metadata{Annotations: map[string]string{
+					AnnotationGHAJob:  jobName,
+					AnnotationGHAStep: ghaStep.String(),
+				}}
+			}
+
+			// a. actions/cache 转换为计算 key、恢复和保存步骤
+			if action, ok := cacheActionFor(ghaStep.Uses); ok && opts.Cache.Backend != CacheBackendNone {
+				cache, err := convertCacheStep(cacheStep{
+					action:   action,
+					name:     stepName,
+					template: stepTemplateName,
+					image:    runner.Image,
+					env:      containerEnv,
+					with:     ghaStep.With,
+				}, stepExprs, opts.Cache)
+				if err != nil {
+					return nil, warnings, fmt.Errorf("job %s: %w", jobName, err)
+				}
+				for _, message := range cache.warnings {
+					warn(WarnCache, jobName, "%s", message)
+				}
+				for _, problem := range cache.problems {
+					reportExpressions(WarnCache, jobName, problem.problems, "jobs", jobName, "steps", i, "with", problem.input)
+					failed = failed || len(problem.problems) > 0
+				}
+				if failed {
+					neverRun(jobName, stepName)
+					when = "false"
+				}
+				if when != "" {
+					// 条件作用于转换出的每个步骤
+					for _, steps := range [][]wfv1.ParallelSteps{cache.steps, cache.post} {
+						for j := range steps {
+							step := &steps[j].Steps[0]
+							if step.When == "" {
+								step.When = when
+							} else {
+								step.When = "(" + when + ") && (" + step.When + ")"
+							}
+						}
+					}
+				}
+				if ghaStep.ID != "" && action != cacheSaveOnly {
+					jobExprs.steps[strings.ToLower(ghaStep.ID)] = stepRef{name: stepName, outputs: map[string]bool{"cache-hit": true}}
+				}
+				for _, template := range cache.templates {
+					template.Metadata = metadata()
+					if err := finalizeStepTemplate(&template); err != nil {
+						return nil, warnings, fmt.Errorf("job %s: failed to apply runner template: %w", jobName, err)
+					}
+					stepTemplates = append(stepTemplates, template)
+				}
+				jobTemplate.Steps = append(jobTemplate.Steps, cache.steps...)
+				postSteps = append(cache.post, postSteps...) // post 步骤按声明的逆序执行
+				usesCache = true
+				continue
+			}
+
+			// b. 创建 GHA step 对应的 Argo Template
+			baseImage := runner.Image
+
+			stepTemplate := wfv1.Template{
+				Name:     stepTemplateName,
+				Metadata: metadata(),
+			}
+
+			if ghaStep.Run != "" {
+				// 转换 GHA 'run' -> Argo 'script'，${{ }} 与 GHA 一样在执行前按文本替换
+				script, problems := stepExprs.interpolate(ghaStep.Run, targetScript)
+				reportExpressions("", jobName, problems, "jobs", jobName, "steps", i, "run")
+				failed = failed || len(problems) > 0
+				workDir, cd := stepWorkingDirectory(ghaWF, jobName, i, stepExprs, func(problems []exprProblem, path ...interface{}) {
+					reportExpressions(WarnWorkspace, jobName, problems, path...)
+					failed = failed || len(problems) > 0
+				})
+				if failed {
+					// 与无法编译的 if 一样失败关闭：按原文保留的 ${{ }} 不能交给 bash 执行
+					neverRun(jobName, stepName)
+					when = "false"
+				}
+				script = cd + script
+				if stepExprs.hashFiles {
+					script = hashFilesFunction + script
+				}
+				script = runnerPrologue + script
+				stepTemplate.Script = &wfv1.ScriptTemplate{
+					Container: corev1.Container{ // 修复：使用 corev1.Container
+						Image:      baseImage,
+						Command:    []string{"bash", "-c"}, // GHA 默认使用 bash
+						Env:        containerEnv,
+						WorkingDir: workDir,
+					},
+					Source: script,
+				}
+			} else if ghaStep.Uses != "" {
+				// 转换 GHA 'uses' -> 占位符 (Placeholder)
+				warn(WarnUnsupportedAction, jobName, "action %s is replaced by a placeholder step", ghaStep.Uses)
+				withParams := ""
+				if ghaStep.With != nil {
+					withParams = fmt.Sprintf("Parameters (with): %v", ghaStep.With)
+				}
+
+				stepTemplate.Script = &wfv1.ScriptTemplate{
+					Container: corev1.Container{ // 修复：使用 corev1.Container
+						Image:   "alpine:latest",
+						Command: []string{"sh", "-c"},
+						Env:     containerEnv,
+					},
+					Source: fmt.Sprintf(`
+echo "****************************************************************"
+echo "TODO: Manually implement GHA Action: %s"
+echo "%s"
+echo "****************************************************************"
+exit 1
+`, ghaStep.Uses, withParams),
+				}
+			} else {
+				// 跳过空步骤
+				warn(WarnEmptyStep, jobName, "step %d has neither 'run' nor 'uses' and is skipped", i)
+				continue
+			}
+
+			// c. 挂载共享工作区并合并 runs-on ConfigMap 中的模板配置，然后添加到 Job 的 "steps" 序列中
+			// 引用的其他步骤的输出通过输入参数传入
+			inputs, arguments := stepExprs.stepParameters()
+			stepTemplate.Inputs.Parameters = inputs
+			if err := finalizeStepTemplate(&stepTemplate); err != nil {
+				return nil, warnings, fmt.Errorf("job %s: failed to apply runner template: %w", jobName, err)
+			}
+			stepTemplates = append(stepTemplates, stepTemplate)
+			jobTemplate.Steps = append(jobTemplate.Steps, wfv1.ParallelSteps{
+				Steps: []wfv1.WorkflowStep{
+					{
+						Name:      stepName,
+						Template:  stepTemplateName,
+						Arguments: wfv1.Arguments{Parameters: arguments},
+						When:      when,
+					},
+				},
+			})
+			if ghaStep.ID != "" {
+				jobExprs.steps[strings.ToLower(ghaStep.ID)] = stepRef{name: stepName}
+			}
+		}
+		jobTemplate.Steps = append(jobTemplate.Steps, postSteps...)
+
+		// 存储这个 Job 模板和它依赖的 Step 模板
+		jobTemplates[jobTemplateName] = jobTemplate
+		argoWF.Spec.Templates = append(argoWF.Spec.Templates, jobTemplate)
+		argoWF.Spec.Templates = append(argoWF.Spec.Templates, stepTemplates...)
+	}
+
+	// 4. 把 needs 映射为模板名称，缺失的依赖和循环依赖直接报错
+	jobDependencies, err := resolveJobDependencies(jobNeeds, jobTemplateNames)
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	// 5. 设置 Entrypoint (入口点)
+	if len(jobNames) == 1 && jobConditions[jobNames[0]] == "" {
+		// 单 Job 工作流：直接以该 Job 模板为入口
+		argoWF.Spec.Entrypoint = jobNames[0]
+	} else {
+		// 多 Job 工作流：创建一个 DAG (有向无环图)
+		dagTemplate := wfv1.Template{
+			Name: "main-dag",
+			DAG:  &wfv1.DAGTemplate{},
+		}
+		for _, jobTplName := range jobNames {
+			dagTask := wfv1.DAGTask{
+				Name:     jobTplName,
+				Template: jobTplName,
+				When:     jobConditions[jobTplName],
+			}
+			// 添加 GHA 的 'needs' 依赖
+			if deps, ok := jobDependencies[jobTplName]; ok && len(deps) > 0 {
+				dagTask.Dependencies = deps
+			}
+			dagTemplate.DAG.Tasks = append(dagTemplate.DAG.Tasks, dagTask)
+		}
+
+		argoWF.Spec.Entrypoint = dagTemplate.Name
+		argoWF.Spec.Templates = append(argoWF.Spec.Templates, dagTemplate)
+	}
+
+	// Argo v3.5+ 需要设置 Parallelism
+	parallelism := int64(50) // 修复：使用 int64 而不是 IntOrString
+	argoWF.Spec.Parallelism = &parallelism
+
+	// 没有默认值的 inputs 作为工作流参数，提交时可以用 -p 覆盖
+	argoWF.Spec.Arguments.Parameters = append(argoWF.Spec.Arguments.Parameters, workflowExprs.inputArguments()...)
+
+	// 6. 声明工作区卷：默认所有 Job 共享一个，PerJob 时每个 Job 一个
+	if err := addWorkspaceVolume(argoWF, jobWorkspaces, opts.Workspace, warn); err != nil {
+		return nil, warnings, err
+	}
+
+	if usesCache {
+		addCacheVolume(argoWF, opts.Cache)
+	}
+
+	// 7. 写入来源标签和注解
+	stampProvenance(argoWF, ghaWF.Name, ghaYAML, opts)
+
+	// 8. 离线校验转换结果，不把 Argo 会拒绝的工作流返回给调用方
+	if err := validateOutput(argowf.WorkflowResource{Workflow: argoWF}); err != nil {
+		return nil, warnings, err
+	}
+
+	// 9. on.schedule 触发的工作流包装为 CronWorkflow，每个 cron 表达式一个
+	output := &ConversionOutput{Workflow: argoWF}
+	if scheduled {
+		policy, message := concurrencyPolicy(source.Node("concurrency"))
+		if message != "" {
+			warning := ConversionWarning{Code: WarnSchedule, Message: message}
+			warning.Line, warning.Column = source.Position(source.Node("concurrency"), 0)
+			warnings = append(warnings, warning)
+		}
+		if len(schedules) > 1 && policy != wfv1.AllowConcurrent {
+			warnings = append(warnings, ConversionWarning{Code: WarnSchedule, Message: fmt.Sprintf("concurrency applies to each of the %d CronWorkflows separately, runs of different schedules can overlap", len(schedules))})
+		}
+		output.Crons = cronWorkflowsFor(argoWF, cronBaseName(opts.Source.Repository, github.workflow), schedules, policy)
+		for _, cron := range output.Crons {
+			if err := validateOutput(argowf.CronWorkflowResource{CronWorkflow: cron}); err != nil {
+				return nil, warnings, err
+			}
+		}
+	}
+	return output, warnings, nil
+}
+
+// --- 辅助函数 ---
+
+// mapRunsOnToImage 简单映射 GHA 'runs-on' 到容器镜像
+func mapRunsOnToImage(runsOn string) string {
+	if strings.Contains(runsOn, "ubuntu-22.04") || strings.Contains(runsOn, "ubuntu-latest") {
+		return "ubuntu:22.04"
+	}
+	if strings.Contains(runsOn, "ubuntu-20.04") {
+		return "ubuntu:20.04"
+	}
+	// 默认值
+	return "alpine:latest"
+}
@@ -0,0 +1,75 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"argo-parser/argowf"
+)
+
+// --- Job 依赖 ---
+
+// ErrInvalidWorkflow 表示 GHA 工作流本身有错误，而不是转换失败；HTTP 接口返回 422
+var ErrInvalidWorkflow = errors.New("invalid workflow")
+
+// resolveJobDependencies 把 needs 中的 GHA Job ID 映射为模板名称，映射前检查模板名称冲突、不存在的依赖和循环依赖。
+// needs 和 templateNames 的键都是 GHA Job ID，返回值的键和元素都是模板名称
+func resolveJobDependencies(needs map[string][]string, templateNames map[string]string) (map[string][]string, error) {
+	jobIDs := make([]string, 0, len(templateNames))
+	for id := range templateNames {
+		jobIDs = append(jobIDs, id)
+	}
+	sort.Strings(jobIDs)
+
+	// 1. 不同的 Job ID 清理后可能得到相同的模板名称
+	owners := make(map[string]string, len(jobIDs))
+	for _, id := range jobIDs {
+		name := templateNames[id]
+		if other, ok := owners[name]; ok {
+			return nil, fmt.Errorf("%w: jobs %q and %q both map to the template name %q", ErrInvalidWorkflow, other, id, name)
+		}
+		owners[name] = id
+	}
+
+	// 2. needs 中的 Job ID 不区分大小写，统一为声明时的写法
+	canonical := make(map[string]string, len(jobIDs))
+	for _, id := range jobIDs {
+		canonical[strings.ToLower(id)] = id
+	}
+	resolved := make(map[string][]string, len(jobIDs))
+	var missing []string
+	for _, id := range jobIDs {
+		for _, need := range needs[id] {
+			target, ok := canonical[strings.ToLower(need)]
+			if !ok {
+				missing = append(missing, fmt.Sprintf("%q needs %q", id, need))
+				continue
+			}
+			resolved[id] = append(resolved[id], target)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: needs references unknown jobs: %s", ErrInvalidWorkflow, strings.Join(missing, ", "))
+	}
+
+	// 3. 循环依赖
+	if cycle := argowf.FindCycle(jobIDs, resolved); cycle != nil {
+		return nil, fmt.Errorf("%w: dependency cycle between jobs: %s", ErrInvalidWorkflow, strings.Join(cycle, " -> "))
+	}
+
+	// 4. 映射为模板名称，去掉重复的依赖
+	dependencies := make(map[string][]string, len(jobIDs))
+	for _, id := range jobIDs {
+		name := templateNames[id]
+		seen := map[string]bool{}
+		for _, target := range resolved[id] {
+			if dependency := templateNames[target]; !seen[dependency] {
+				seen[dependency] = true
+				dependencies[name] = append(dependencies[name], dependency)
+			}
+		}
+	}
+	return dependencies, nil
+}
@@ -0,0 +1,1305 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/nektos/act/pkg/model"
+	"github.com/rhysd/actionlint"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// --- GHA 表达式 ---
+//
+// ${{ }} 使用 actionlint 的解析器（act 的 exprparser 同样基于它）解析后编译：
+// 转换时已知的值（字面量、静态 env、inputs 默认值、只有一个组合的 matrix）直接求值，
+// 运行时的值编译为 Argo 变量、Argo 表达式（{{=...}}）或 bash，无法编译的部分连同位置一起返回
+
+// exprTarget 是编译结果的使用位置，决定运行时的值如何表示
+type exprTarget int
+
+const (
+	targetScript    exprTarget = iota // run 脚本，与 GHA 一样按文本替换
+	targetShellWord                   // 一个 bash 单词，字面量使用单引号
+	targetEnv                         // 容器环境变量的值，可以用 $(NAME) 引用之前声明的环境变量
+	targetCondition                   // 步骤或 DAG 任务的 when
+)
+
+func (t exprTarget) String() string {
+	switch t {
+	case targetScript:
+		return "a run script"
+	case targetShellWord:
+		return "a shell word"
+	case targetEnv:
+		return "an environment variable"
+	default:
+		return "a condition"
+	}
+}
+
+// exprValue 是表达式的编译结果：静态值，或以下几种运行时表示之一
+type exprValue struct {
+	static bool
+	value  interface{} // 静态值：nil、bool、float64、string、[]interface{}、map[string]interface{}
+
+	argo    string      // Argo 表达式（expr 语法），为空表示无法在 Argo 中求值
+	boolean bool        // argo 的结果是布尔值
+	tag     string      // 可以直接写入模板字段的 Argo 变量，如 {{workflow.uid}}
+	env     string      // 从容器环境变量读取
+	shell   string      // 只能由 bash 求值的命令替换，如 $(hashFiles ...)
+	parts   []exprValue // format() 的结果，按顺序拼接
+	path    []string    // 尚未解析完的上下文引用，如 steps.<id>
+	filter  bool        // .* 过滤后的数组，属性访问作用于每个元素
+}
+
+func staticValue(value interface{}) exprValue {
+	return exprValue{static: true, value: value}
+}
+
+// argoVariable 创建来自 Argo 变量的运行时值：tag 用于文本替换，argo 用于表达式
+func argoVariable(tag, argo string) exprValue {
+	return exprValue{tag: tag, argo: argo}
+}
+
+// exprProblem 是无法编译的表达式，offset 是在字段值中的字节偏移量
+type exprProblem struct {
+	code    string // 告警代码
+	offset  int
+	expr    string // 原始的 ${{ ... }} 文本
+	message string
+}
+
+// exprError 是编译错误，offset 是在表达式中的字节偏移量
+type exprError struct {
+	code    string
+	offset  int
+	message string
+}
+
+func (e *exprError) Error() string { return e.message }
+
+func errorAt(node actionlint.ExprNode, format string, args ...interface{}) *exprError {
+	return &exprError{code: WarnExpression, offset: node.Token().Offset, message: fmt.Sprintf(format, args...)}
+}
+
+// stepRef 是已转换的、可以通过 steps.<id> 引用的步骤
+type stepRef struct {
+	name    string          // Argo 步骤名称
+	outputs map[string]bool // 可以引用的输出参数
+}
+
+// exprContext 是编译表达式时可用的上下文
+type exprContext struct {
+	github githubContext
+	jobID  string
+	arch   string                 // runner.arch，为空时在运行时读取 $RUNNER_ARCH
+	inputs map[string]interface{} // 有默认值的 inputs，键为小写
+	params map[string]bool        // 引用的无默认值 inputs 对应的工作流参数，所有上下文共享
+	matrix map[string]interface{} // 只有一个组合时的 matrix，nil 表示 matrix 未展开
+	env    map[string]exprValue   // 已声明的 env
+	steps  map[string]stepRef     // 已转换的步骤，键为小写的 GHA 步骤 id
+
+	template   bool              // 在步骤模板内求值：steps 的输出需要通过输入参数传入
+	stepInputs map[string]string // template 为 true 时引用的步骤输出：输入参数名 -> Argo 变量
+	hashFiles  bool              // 使用了 hashFiles()，脚本需要定义该函数
+}
+
+// newExprContext 创建工作流级的表达式上下文
+func newExprContext(github githubContext, wf *model.Workflow) *exprContext {
+	return &exprContext{
+		github: github,
+		inputs: workflowInputs(wf),
+		params: map[string]bool{},
+		env:    map[string]exprValue{},
+		steps:  map[string]stepRef{},
+	}
+}
+
+// forJob 创建 Job 级的上下文；matrix 为 nil 表示 matrix 未展开
+func (c *exprContext) forJob(jobID, arch string, matrix map[string]interface{}) *exprContext {
+	job := *c
+	job.jobID = jobID
+	job.arch = arch
+	job.matrix = matrix
+	job.env = copyExprValues(c.env)
+	job.steps = map[string]stepRef{}
+	return &job
+}
+
+// forStep 创建步骤模板内的上下文，env 与 Job 隔离，已转换的步骤与 Job 共享
+func (c *exprContext) forStep() *exprContext {
+	step := *c
+	step.env = copyExprValues(c.env)
+	step.template = true
+	step.stepInputs = map[string]string{}
+	step.hashFiles = false
+	return &step
+}
+
+func copyExprValues(values map[string]exprValue) map[string]exprValue {
+	result := make(map[string]exprValue, len(values))
+	for key, value := range values {
+		result[key] = value
+	}
+	return result
+}
+
+// inputParameterPrefix 是无默认值的 inputs 对应的工作流参数名前缀
+const inputParameterPrefix = "input-"
+
+// workflowInputs 返回 workflow_dispatch 和 workflow_call 中有默认值的输入
+func workflowInputs(wf *model.Workflow) map[string]interface{} {
+	inputs := map[string]interface{}{}
+	events := map[string]bool{}
+	for _, event := range wf.On() {
+		events[event] = true
+	}
+	if dispatch := wf.WorkflowDispatchConfig(); events["workflow_dispatch"] && dispatch != nil {
+		for name, input := range dispatch.Inputs {
+			if input.Default == "" {
+				continue
+			}
+			var value interface{} = input.Default
+			switch input.Type {
+			case "boolean":
+				value = input.Default == "true"
+			case "number":
+				value = toNumber(input.Default)
+			}
+			inputs[strings.ToLower(name)] = value
+		}
+	}
+	if events["workflow_call"] {
+		call := wf.WorkflowCallConfig()
+		for name, input := range call.Inputs {
+			if input.Default.Kind == 0 {
+				continue
+			}
+			var value interface{}
+			if input.Default.Decode(&value) == nil {
+				inputs[strings.ToLower(name)] = normalizeValue(value)
+			}
+		}
+	}
+	return inputs
+}
+
+// staticMatrix 返回只有一个组合的 matrix，其他情况返回 nil
+func staticMatrix(job *model.Job) map[string]interface{} {
+	if job.Strategy == nil || job.Strategy.RawMatrix.Kind != yaml.MappingNode {
+		return nil
+	}
+	combinations, err := job.GetMatrixes()
+	if err != nil || len(combinations) != 1 {
+		return nil
+	}
+	matrix, _ := normalizeValue(combinations[0]).(map[string]interface{})
+	return matrix
+}
+
+// compileEnv 编译 env 中的值并加入上下文，返回对应的容器环境变量；report 接收每个值中无法编译的表达式
+func (c *exprContext) compileEnv(env map[string]string, report func(key string, problems []exprProblem)) []corev1.EnvVar {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]corev1.EnvVar, 0, len(keys))
+	for _, key := range keys {
+		parts, problems := c.parseTemplate(env[key])
+		value, renderProblems := c.render(parts, targetEnv)
+		if problems = append(problems, renderProblems...); len(problems) > 0 {
+			report(key, problems)
+		}
+		if s, ok := staticTemplate(parts); ok {
+			c.env[key] = staticValue(s)
+		} else {
+			c.env[key] = exprValue{env: key}
+		}
+		result = append(result, corev1.EnvVar{Name: key, Value: value})
+	}
+	return result
+}
+
+// mergeEnv 合并环境变量，后出现的同名变量覆盖之前的值
+func mergeEnv(lists ...[]corev1.EnvVar) []corev1.EnvVar {
+	var result []corev1.EnvVar
+	index := map[string]int{}
+	for _, list := range lists {
+		for _, env := range list {
+			if i, ok := index[env.Name]; ok {
+				result[i] = env
+				continue
+			}
+			index[env.Name] = len(result)
+			result = append(result, env)
+		}
+	}
+	return result
+}
+
+// stepParameters 返回步骤模板引用的其他步骤的输出：模板的输入参数和步骤的实参
+func (c *exprContext) stepParameters() ([]wfv1.Parameter, []wfv1.Parameter) {
+	names := make([]string, 0, len(c.stepInputs))
+	for name := range c.stepInputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var inputs, arguments []wfv1.Parameter
+	for _, name := range names {
+		inputs = append(inputs, wfv1.Parameter{Name: name})
+		arguments = append(arguments, wfv1.Parameter{Name: name, Value: wfv1.AnyStringPtr(c.stepInputs[name])})
+	}
+	return inputs, arguments
+}
+
+// --- 模板字符串 ---
+
+// templatePart 是字符串中的一段：字面量，或编译后的 ${{ }}
+type templatePart struct {
+	literal string
+	expr    *exprValue
+	offset  int // ${{ 在字符串中的偏移量
+}
+
+// parseTemplate 拆分并编译字符串中的 ${{ }}，无法编译的表达式作为字面量保留
+func (c *exprContext) parseTemplate(s string) ([]templatePart, []exprProblem) {
+	var parts []templatePart
+	var problems []exprProblem
+	pos := 0
+	for {
+		i := strings.Index(s[pos:], "${{")
+		if i < 0 {
+			break
+		}
+		start := pos + i
+		if start > pos {
+			parts = append(parts, templatePart{literal: s[pos:start], offset: pos})
+		}
+		value, end, problem := c.compileInterpolation(s, start, false)
+		if problem != nil {
+			problems = append(problems, *problem)
+			parts = append(parts, templatePart{literal: s[start:end], offset: start})
+		} else {
+			parts = append(parts, templatePart{literal: s[start:end], expr: &value, offset: start})
+		}
+		pos = end
+	}
+	if pos < len(s) {
+		parts = append(parts, templatePart{literal: s[pos:], offset: pos})
+	}
+	return parts, problems
+}
+
+// compileInterpolation 编译从 start 开始的 ${{ ... }}，返回值、结束位置和错误；status 为 true 时允许状态函数
+func (c *exprContext) compileInterpolation(s string, start int, status bool) (exprValue, int, *exprProblem) {
+	body := start + len("${{")
+	lexer := actionlint.NewExprLexer(s[body:])
+	node, parseErr := actionlint.NewExprParser().Parse(lexer)
+	end := body + lexer.Offset()
+	if parseErr == nil && !strings.HasSuffix(s[:end], "}}") {
+		parseErr = &actionlint.ExprError{Message: "missing closing }}", Offset: end - body}
+	}
+	if parseErr != nil {
+		// 跳过到下一个 }}
+		if close := strings.Index(s[body:], "}}"); close >= 0 {
+			end = body + close + len("}}")
+		} else {
+			end = len(s)
+		}
+		return exprValue{}, end, &exprProblem{code: WarnExpression, offset: body + parseErr.Offset, expr: s[start:end], message: parseErr.Message}
+	}
+	value, err := c.valueWithStatus(node, status)
+	if err != nil {
+		return exprValue{}, end, &exprProblem{code: err.code, offset: body + err.offset, expr: s[start:end], message: err.message}
+	}
+	return value, end, nil
+}
+
+// render 按使用位置输出模板字符串，无法表示的表达式保持原样
+func (c *exprContext) render(parts []templatePart, target exprTarget) (string, []exprProblem) {
+	var b strings.Builder
+	var problems []exprProblem
+	for _, part := range parts {
+		if part.expr != nil {
+			if s, ok := part.expr.render(target); ok {
+				b.WriteString(s)
+				continue
+			}
+			problems = append(problems, exprProblem{code: WarnExpression, offset: part.offset, expr: part.literal, message: "the value is only known at runtime and cannot be used in " + target.String()})
+		}
+		if target == targetShellWord {
+			b.WriteString(shellQuote(part.literal))
+		} else {
+			b.WriteString(part.literal)
+		}
+	}
+	return b.String(), problems
+}
+
+// interpolate 编译字符串中的 ${{ }} 并按使用位置输出
+func (c *exprContext) interpolate(s string, target exprTarget) (string, []exprProblem) {
+	parts, problems := c.parseTemplate(s)
+	result, renderProblems := c.render(parts, target)
+	return result, append(problems, renderProblems...)
+}
+
+// staticTemplate 在所有表达式都是静态值时返回求值后的字符串
+func staticTemplate(parts []templatePart) (string, bool) {
+	var b strings.Builder
+	for _, part := range parts {
+		if part.expr == nil {
+			b.WriteString(part.literal)
+			continue
+		}
+		if !part.expr.static {
+			return "", false
+		}
+		b.WriteString(toString(part.expr.value))
+	}
+	return b.String(), true
+}
+
+// condition 把 GHA 的 if 编译为 when；静态为真时返回空字符串。
+// if 可以省略 ${{ }}；失败后 GHA 跳过后续步骤，Argo 同样如此，因此隐式的 success() 不需要转换。
+// 无法编译的条件返回 "false"：宁可跳过也不执行条件不明的 Job 或步骤
+func (c *exprContext) condition(s string) (string, []exprProblem) {
+	when, problems := c.compileCondition(s)
+	if len(problems) > 0 && when != "" {
+		for i := range problems {
+			problems[i].message += "; it is never run"
+		}
+		return "false", problems
+	}
+	return when, problems
+}
+
+// compileCondition 编译 if；无法编译时返回的问题不为空
+func (c *exprContext) compileCondition(s string) (string, []exprProblem) {
+	trimmed := strings.TrimSpace(s)
+	lead := strings.Index(s, trimmed)
+
+	conditional := *c
+	conditional.template = false // when 在 Job 的 steps 模板中求值，可以直接引用 steps
+
+	var value exprValue
+	if strings.HasPrefix(trimmed, "${{") {
+		compiled, end, problem := conditional.compileInterpolation(s, lead, true)
+		if problem != nil {
+			return "false", []exprProblem{*problem}
+		}
+		if strings.TrimSpace(s[end:]) != "" {
+			// 混合了文本的 if 在 GHA 中是非空字符串，总是为真，与 GHA 一致地执行
+			return "", []exprProblem{{code: WarnExpression, offset: lead, expr: trimmed, message: "a condition mixing text and ${{ }} is always true"}}
+		}
+		value = compiled
+	} else {
+		lexer := actionlint.NewExprLexer(trimmed + "}}") // 词法分析器以 }} 作为结束标记
+		node, parseErr := actionlint.NewExprParser().Parse(lexer)
+		if parseErr != nil {
+			return "false", []exprProblem{{code: WarnExpression, offset: lead + parseErr.Offset, expr: trimmed, message: parseErr.Message}}
+		}
+		var err *exprError
+		if value, err = conditional.valueWithStatus(node, true); err != nil {
+			return "false", []exprProblem{{code: err.code, offset: lead + err.offset, expr: trimmed, message: err.message}}
+		}
+	}
+
+	if value.static {
+		if truthy(value.value) {
+			return "", nil
+		}
+		return "false", nil
+	}
+	when, ok := value.render(targetCondition)
+	if !ok {
+		return "false", []exprProblem{{code: WarnExpression, offset: lead, expr: trimmed, message: "the condition depends on values that are only known inside the container"}}
+	}
+	return when, nil
+}
+
+// --- 编译 ---
+
+// runtimeContexts 是运行时才能确定的上下文，按路径解析
+var runtimeContexts = map[string]bool{
+	"github": true, "env": true, "runner": true, "steps": true, "inputs": true, "matrix": true,
+	"secrets": true, "vars": true, "needs": true, "job": true, "jobs": true, "strategy": true,
+}
+
+// value 编译表达式，结果必须是完整的值
+func (c *exprContext) value(node actionlint.ExprNode) (exprValue, *exprError) {
+	return c.valueWithStatus(node, false)
+}
+
+func (c *exprContext) valueWithStatus(node actionlint.ExprNode, status bool) (exprValue, *exprError) {
+	v, err := c.compile(node, status)
+	if err != nil {
+		return v, err
+	}
+	if v.path != nil {
+		return v, errorAt(node, "%s cannot be used as a value", strings.Join(v.path, "."))
+	}
+	return v, nil
+}
+
+func (c *exprContext) compile(node actionlint.ExprNode, status bool) (exprValue, *exprError) {
+	switch n := node.(type) {
+	case *actionlint.NullNode:
+		return staticValue(nil), nil
+	case *actionlint.BoolNode:
+		return staticValue(n.Value), nil
+	case *actionlint.IntNode:
+		return staticValue(float64(n.Value)), nil
+	case *actionlint.FloatNode:
+		return staticValue(n.Value), nil
+	case *actionlint.StringNode:
+		return staticValue(n.Value), nil
+	case *actionlint.VariableNode:
+		if !runtimeContexts[n.Name] {
+			return exprValue{}, errorAt(n, "unknown context %q", n.Name)
+		}
+		return c.resolve(n, []string{n.Name})
+	case *actionlint.ObjectDerefNode:
+		receiver, err := c.compile(n.Receiver, status)
+		if err != nil {
+			return receiver, err
+		}
+		return c.property(n, receiver, n.Property)
+	case *actionlint.IndexAccessNode:
+		receiver, err := c.compile(n.Operand, status)
+		if err != nil {
+			return receiver, err
+		}
+		index, err := c.valueWithStatus(n.Index, status)
+		if err != nil {
+			return index, err
+		}
+		if !index.static {
+			return exprValue{}, errorAt(n.Index, "index must be known at conversion time")
+		}
+		if receiver.path != nil || receiver.static {
+			if s, ok := index.value.(string); ok {
+				return c.property(n, receiver, strings.ToLower(s))
+			}
+		}
+		if !receiver.static {
+			return exprValue{}, errorAt(n, "cannot index a value that is only known at runtime")
+		}
+		if list, ok := receiver.value.([]interface{}); ok {
+			i := toNumber(index.value)
+			if i >= 0 && i < float64(len(list)) && i == math.Trunc(i) {
+				return staticValue(list[int(i)]), nil
+			}
+		}
+		return staticValue(nil), nil
+	case *actionlint.ArrayDerefNode:
+		receiver, err := c.valueWithStatus(n.Receiver, status)
+		if err != nil {
+			return receiver, err
+		}
+		if !receiver.static {
+			return exprValue{}, errorAt(n, "object filters (.*) need a value known at conversion time")
+		}
+		var items []interface{}
+		switch v := receiver.value.(type) {
+		case []interface{}:
+			items = v
+		case map[string]interface{}:
+			for _, key := range sortedKeys(v) {
+				items = append(items, v[key])
+			}
+		}
+		return exprValue{static: true, value: items, filter: true}, nil
+	case *actionlint.NotOpNode:
+		operand, err := c.valueWithStatus(n.Operand, status)
+		if err != nil {
+			return operand, err
+		}
+		if operand.static {
+			return staticValue(!truthy(operand.value)), nil
+		}
+		argo, ok := operand.truthyArgo()
+		if !ok {
+			return exprValue{}, errorAt(n, "the operand of ! is only known inside the container")
+		}
+		return exprValue{argo: "!" + argo, boolean: true}, nil
+	case *actionlint.LogicalOpNode:
+		return c.logical(n, status)
+	case *actionlint.CompareOpNode:
+		return c.compare(n, status)
+	case *actionlint.FuncCallNode:
+		return c.call(n, status)
+	default:
+		return exprValue{}, errorAt(node, "unsupported expression")
+	}
+}
+
+// property 访问 receiver.<name>：上下文引用继续解析，静态值直接求值
+func (c *exprContext) property(node actionlint.ExprNode, receiver exprValue, name string) (exprValue, *exprError) {
+	if receiver.path != nil {
+		return c.resolve(node, append(append([]string{}, receiver.path...), name))
+	}
+	if !receiver.static {
+		return exprValue{}, errorAt(node, "cannot access property %q of a value that is only known at runtime", name)
+	}
+	if receiver.filter {
+		var result []interface{}
+		for _, item := range receiver.value.([]interface{}) {
+			if object, ok := item.(map[string]interface{}); ok {
+				if value, ok := lookup(object, name); ok {
+					result = append(result, value)
+				}
+			}
+		}
+		return exprValue{static: true, value: result, filter: true}, nil
+	}
+	if object, ok := receiver.value.(map[string]interface{}); ok {
+		value, _ := lookup(object, name)
+		return staticValue(value), nil
+	}
+	return staticValue(nil), nil
+}
+
+// resolve 解析上下文引用，路径不完整时返回 path 供后续的属性访问继续解析
+func (c *exprContext) resolve(node actionlint.ExprNode, path []string) (exprValue, *exprError) {
+	partial := exprValue{path: path}
+	switch path[0] {
+	case "matrix":
+		if c.matrix == nil {
+			return exprValue{}, errorAt(node, "matrix values are only known when strategy.matrix has a single combination")
+		}
+		return staticValue(c.matrix), nil
+	case "inputs":
+		if len(path) == 1 {
+			return partial, nil
+		}
+		value, ok := c.inputs[path[1]]
+		if !ok {
+			return c.inputParameter(path[1]), nil
+		}
+		return staticValue(value), nil
+	case "env":
+		if len(path) == 1 {
+			return partial, nil
+		}
+		if len(path) > 2 {
+			return staticValue(nil), nil
+		}
+		for key, value := range c.env {
+			if strings.EqualFold(key, path[1]) {
+				return value, nil
+			}
+		}
+		// 未声明的变量（如 GITHUB_* 或写入 $GITHUB_ENV 的变量）在运行时读取；属性名已转为小写，按惯例使用大写
+		return exprValue{env: strings.ToUpper(path[1])}, nil
+	case "github":
+		if len(path) == 1 {
+			return partial, nil
+		}
+		if value, ok := c.github.property(path[1], c.jobID); ok && len(path) == 2 {
+			return value, nil
+		}
+		if c.github.scheduled && path[1] == "event" && len(path) == 2 {
+			return partial, nil
+		}
+		if c.github.scheduled && path[1] == "event" && path[2] == "schedule" && len(path) == 3 {
+			return argoVariable("{{workflow.parameters."+githubScheduleParameter+"}}", "workflow.parameters['"+githubScheduleParameter+"']"), nil
+		}
+		return exprValue{}, &exprError{code: WarnGitHubContext, offset: node.Token().Offset, message: fmt.Sprintf("github.%s has no Argo equivalent", strings.Join(path[1:], "."))}
+	case "runner":
+		if len(path) == 1 {
+			return partial, nil
+		}
+		switch path[1] {
+		case "os":
+			return staticValue("Linux"), nil
+		case "arch":
+			if c.arch == "" {
+				return exprValue{env: "RUNNER_ARCH"}, nil
+			}
+			return staticValue(c.arch), nil
+		case "temp":
+			return staticValue("/tmp"), nil
+		case "name":
+			return exprValue{env: "RUNNER_NAME"}, nil
+		}
+		return exprValue{}, errorAt(node, "runner.%s is not available", path[1])
+	case "steps":
+		if len(path) < 3 {
+			return partial, nil
+		}
+		step, ok := c.steps[path[1]]
+		if !ok {
+			return exprValue{}, errorAt(node, "step %q is not defined before this point", path[1])
+		}
+		if path[2] != "outputs" {
+			return exprValue{}, errorAt(node, "steps.%s.%s is not supported, only outputs can be referenced", path[1], path[2])
+		}
+		if len(path) == 3 {
+			return partial, nil
+		}
+		if !step.outputs[path[3]] {
+			return exprValue{}, errorAt(node, "step %q has no output %q; only outputs of actions/cache are converted", path[1], path[3])
+		}
+		return c.stepOutput(step.name, path[3]), nil
+	default:
+		return exprValue{}, errorAt(node, "the %s context is not supported", path[0])
+	}
+}
+
+// inputParameter 引用没有默认值的输入：值来自工作流参数，提交时可以用 -p 覆盖，未提供时与 GHA 一样为空字符串
+func (c *exprContext) inputParameter(name string) exprValue {
+	parameter := inputParameterPrefix + name
+	c.params[parameter] = true
+	return argoVariable("{{workflow.parameters."+parameter+"}}", "workflow.parameters['"+parameter+"']")
+}
+
+// inputArguments 返回引用过的输入对应的工作流参数，默认值为空字符串
+func (c *exprContext) inputArguments() []wfv1.Parameter {
+	names := make([]string, 0, len(c.params))
+	for name := range c.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]wfv1.Parameter, 0, len(names))
+	for _, name := range names {
+		params = append(params, wfv1.Parameter{Name: name, Value: wfv1.AnyStringPtr("")})
+	}
+	return params
+}
+
+// stepOutput 引用步骤的输出参数；步骤模板内无法访问 steps，改为通过输入参数传入
+func (c *exprContext) stepOutput(step, output string) exprValue {
+	tag := "{{steps." + step + ".outputs.parameters." + output + "}}"
+	if !c.template {
+		return argoVariable(tag, "steps['"+step+"'].outputs.parameters['"+output+"']")
+	}
+	parameter := step + "-" + output
+	c.stepInputs[parameter] = tag
+	return argoVariable("{{inputs.parameters."+parameter+"}}", "inputs.parameters['"+parameter+"']")
+}
+
+func (c *exprContext) logical(n *actionlint.LogicalOpNode, status bool) (exprValue, *exprError) {
+	left, err := c.valueWithStatus(n.Left, status)
+	if err != nil {
+		return left, err
+	}
+	and := n.Kind == actionlint.LogicalOpNodeKindAnd
+	if left.static {
+		// GHA 的 && 和 || 返回操作数本身
+		if truthy(left.value) != and {
+			return left, nil
+		}
+		return c.valueWithStatus(n.Right, status)
+	}
+	right, err := c.valueWithStatus(n.Right, status)
+	if err != nil {
+		return right, err
+	}
+	leftArgo, ok1 := left.argoExpr()
+	rightArgo, ok2 := right.argoExpr()
+	leftTruthy, _ := left.truthyArgo()
+	if !ok1 || !ok2 {
+		return exprValue{}, errorAt(n, "the operands of %s are only known inside the container", n.Kind)
+	}
+	if left.isBoolean() && right.isBoolean() {
+		return exprValue{argo: "(" + leftArgo + " " + n.Kind.String() + " " + rightArgo + ")", boolean: true}, nil
+	}
+	if and {
+		return exprValue{argo: "(" + leftTruthy + " ? " + rightArgo + " : " + leftArgo + ")"}, nil
+	}
+	return exprValue{argo: "(" + leftTruthy + " ? " + leftArgo + " : " + rightArgo + ")"}, nil
+}
+
+func (c *exprContext) compare(n *actionlint.CompareOpNode, status bool) (exprValue, *exprError) {
+	left, err := c.valueWithStatus(n.Left, status)
+	if err != nil {
+		return left, err
+	}
+	right, err := c.valueWithStatus(n.Right, status)
+	if err != nil {
+		return right, err
+	}
+	if left.static && right.static {
+		return staticValue(compareValues(n.Kind, left.value, right.value)), nil
+	}
+	leftArgo, ok1 := left.argoExpr()
+	rightArgo, ok2 := right.argoExpr()
+	if !ok1 || !ok2 {
+		return exprValue{}, errorAt(n, "the operands of %s are only known inside the container", n.Kind)
+	}
+	// 运行时的值都是字符串：与数字比较大小时转为数字，其他情况与 GHA 一样忽略大小写比较字符串
+	numeric := false
+	for _, operand := range []exprValue{left, right} {
+		if operand.static && !n.Kind.IsEqualityOp() {
+			switch operand.value.(type) {
+			case float64, bool:
+				numeric = true
+			}
+		}
+	}
+	op := n.Kind.String()
+	if numeric {
+		return exprValue{argo: "(float(" + leftArgo + ") " + op + " float(" + rightArgo + "))", boolean: true}, nil
+	}
+	return exprValue{argo: "(" + left.lowerArgo() + " " + op + " " + right.lowerArgo() + ")", boolean: true}, nil
+}
+
+func (c *exprContext) call(n *actionlint.FuncCallNode, status bool) (exprValue, *exprError) {
+	name := strings.ToLower(n.Callee)
+	switch name {
+	case "success", "always", "failure", "cancelled":
+		if !status {
+			return exprValue{}, errorAt(n, "%s() can only be used in if", name)
+		}
+		if len(n.Args) > 0 {
+			return exprValue{}, errorAt(n, "%s() takes no arguments", name)
+		}
+		switch name {
+		case "success":
+			return staticValue(true), nil
+		case "cancelled":
+			// 工作流停止后不会再调度步骤
+			return staticValue(false), nil
+		default:
+			return exprValue{}, errorAt(n, "%s() is not supported: Argo does not run the remaining steps after a failure", name)
+		}
+	}
+
+	args := make([]exprValue, len(n.Args))
+	allStatic := true
+	for i, arg := range n.Args {
+		value, err := c.valueWithStatus(arg, status)
+		if err != nil {
+			return value, err
+		}
+		args[i] = value
+		allStatic = allStatic && value.static
+	}
+	arity := func(min, max int) *exprError {
+		if len(args) < min || (max >= 0 && len(args) > max) {
+			return errorAt(n, "wrong number of arguments for %s()", n.Callee)
+		}
+		return nil
+	}
+
+	switch name {
+	case "contains", "startswith", "endswith":
+		if err := arity(2, 2); err != nil {
+			return exprValue{}, err
+		}
+		if allStatic {
+			return staticValue(stringFunction(name, args[0].value, args[1].value)), nil
+		}
+		return argoStringFunction(n, name, args[0], args[1])
+	case "format":
+		if err := arity(1, -1); err != nil {
+			return exprValue{}, err
+		}
+		return formatValue(n, args)
+	case "join":
+		if err := arity(1, 2); err != nil {
+			return exprValue{}, err
+		}
+		if !allStatic {
+			return exprValue{}, errorAt(n, "join() needs values known at conversion time")
+		}
+		separator := ","
+		if len(args) == 2 {
+			separator = toString(args[1].value)
+		}
+		list, ok := args[0].value.([]interface{})
+		if !ok {
+			return staticValue(toString(args[0].value)), nil
+		}
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = toString(item)
+		}
+		return staticValue(strings.Join(items, separator)), nil
+	case "tojson":
+		if err := arity(1, 1); err != nil {
+			return exprValue{}, err
+		}
+		if args[0].static {
+			data, _ := json.MarshalIndent(args[0].value, "", "  ")
+			return staticValue(string(data)), nil
+		}
+		if argo, ok := args[0].argoExpr(); ok {
+			return exprValue{argo: "toJSON(" + argo + ")"}, nil
+		}
+		return exprValue{}, errorAt(n, "toJSON() argument is only known inside the container")
+	case "fromjson":
+		if err := arity(1, 1); err != nil {
+			return exprValue{}, err
+		}
+		if args[0].static {
+			var value interface{}
+			if err := json.Unmarshal([]byte(toString(args[0].value)), &value); err != nil {
+				return exprValue{}, errorAt(n, "fromJSON(): %v", err)
+			}
+			return staticValue(normalizeValue(value)), nil
+		}
+		if argo, ok := args[0].argoExpr(); ok {
+			return exprValue{argo: "fromJSON(" + argo + ")"}, nil
+		}
+		return exprValue{}, errorAt(n, "fromJSON() argument is only known inside the container")
+	case "hashfiles":
+		if err := arity(1, -1); err != nil {
+			return exprValue{}, err
+		}
+		if !allStatic {
+			return exprValue{}, errorAt(n, "hashFiles() patterns must be known at conversion time")
+		}
+		patterns := make([]string, len(args))
+		for i, arg := range args {
+			patterns[i] = shellQuote(toString(arg.value))
+		}
+		c.hashFiles = true
+		return exprValue{shell: "$(hashFiles " + strings.Join(patterns, " ") + ")"}, nil
+	default:
+		return exprValue{}, errorAt(n, "unknown function %s()", n.Callee)
+	}
+}
+
+// formatValue 实现 format()：{0} 替换为参数，{{ 和 }} 表示花括号；运行时的参数按顺序拼接
+func formatValue(n *actionlint.FuncCallNode, args []exprValue) (exprValue, *exprError) {
+	if !args[0].static {
+		return exprValue{}, errorAt(n, "the format string of format() must be known at conversion time")
+	}
+	format := toString(args[0].value)
+	var parts []exprValue
+	var literal strings.Builder
+	for i := 0; i < len(format); i++ {
+		ch := format[i]
+		switch {
+		case ch == '{' && i+1 < len(format) && format[i+1] == '{':
+			literal.WriteByte('{')
+			i++
+		case ch == '}' && i+1 < len(format) && format[i+1] == '}':
+			literal.WriteByte('}')
+			i++
+		case ch == '{':
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				return exprValue{}, errorAt(n, "format(): unclosed { in %q", format)
+			}
+			index, err := strconv.Atoi(format[i+1 : i+end])
+			if err != nil || index+1 >= len(args) {
+				return exprValue{}, errorAt(n, "format(): invalid placeholder %q", format[i:i+end+1])
+			}
+			arg := args[index+1]
+			if arg.static {
+				literal.WriteString(toString(arg.value))
+			} else {
+				if literal.Len() > 0 {
+					parts = append(parts, staticValue(literal.String()))
+					literal.Reset()
+				}
+				parts = append(parts, arg)
+			}
+			i += end
+		default:
+			literal.WriteByte(ch)
+		}
+	}
+	if len(parts) == 0 {
+		return staticValue(literal.String()), nil
+	}
+	if literal.Len() > 0 {
+		parts = append(parts, staticValue(literal.String()))
+	}
+
+	result := exprValue{parts: parts}
+	var argo []string
+	for _, part := range parts {
+		expr, ok := part.argoExpr()
+		if !ok {
+			argo = nil
+			break
+		}
+		argo = append(argo, "string("+expr+")")
+	}
+	if argo != nil {
+		result.argo = "(" + strings.Join(argo, " + ") + ")"
+	}
+	return result, nil
+}
+
+// argoStringFunction 把 contains / startsWith / endsWith 编译为忽略大小写的 Argo 表达式
+func argoStringFunction(n *actionlint.FuncCallNode, name string, search, item exprValue) (exprValue, *exprError) {
+	itemArgo, ok := item.argoExpr()
+	if !ok {
+		return exprValue{}, errorAt(n, "the arguments of %s() are only known inside the container", n.Callee)
+	}
+	if list, isList := search.value.([]interface{}); search.static && isList && name == "contains" {
+		items := make([]string, len(list))
+		for i, v := range list {
+			items[i] = argoLiteral(strings.ToLower(toString(v)))
+		}
+		return exprValue{argo: "(lower(string(" + itemArgo + ")) in [" + strings.Join(items, ", ") + "])", boolean: true}, nil
+	}
+	if _, ok := search.argoExpr(); !ok {
+		return exprValue{}, errorAt(n, "the arguments of %s() are only known inside the container", n.Callee)
+	}
+	op := map[string]string{"contains": "contains", "startswith": "startsWith", "endswith": "endsWith"}[name]
+	return exprValue{argo: "(" + search.lowerArgo() + " " + op + " " + item.lowerArgo() + ")", boolean: true}, nil
+}
+
+// --- 运行时表示 ---
+
+// render 按使用位置输出值
+func (v exprValue) render(target exprTarget) (string, bool) {
+	switch {
+	case v.static:
+		switch target {
+		case targetCondition:
+			return strconv.FormatBool(truthy(v.value)), true
+		case targetShellWord:
+			return shellQuote(toString(v.value)), true
+		default:
+			return toString(v.value), true
+		}
+	case target == targetCondition:
+		if argo, ok := v.truthyArgo(); ok {
+			return "{{=" + argo + "}}", true
+		}
+		return "", false
+	case v.parts != nil:
+		var b strings.Builder
+		for _, part := range v.parts {
+			s, ok := part.render(target)
+			if !ok {
+				return "", false
+			}
+			b.WriteString(s)
+		}
+		return b.String(), true
+	case v.tag != "":
+		if target == targetShellWord {
+			return shellQuote(v.tag), true
+		}
+		return v.tag, true
+	case v.env != "":
+		switch target {
+		case targetScript:
+			return "${" + v.env + "}", true
+		case targetShellWord:
+			return `"${` + v.env + `:-}"`, true
+		default:
+			return "$(" + v.env + ")", true
+		}
+	case v.shell != "":
+		switch target {
+		case targetScript:
+			return v.shell, true
+		case targetShellWord:
+			return `"` + v.shell + `"`, true
+		default:
+			return "", false
+		}
+	case v.argo != "":
+		if target == targetShellWord {
+			return shellQuote("{{=" + v.argo + "}}"), true
+		}
+		return "{{=" + v.argo + "}}", true
+	}
+	return "", false
+}
+
+// argoExpr 返回值对应的 Argo 表达式，env 和 shell 的值在容器外不可见
+func (v exprValue) argoExpr() (string, bool) {
+	if v.static {
+		return argoLiteral(v.value), true
+	}
+	return v.argo, v.argo != ""
+}
+
+// isBoolean 判断值在 Argo 表达式中是否为布尔值
+func (v exprValue) isBoolean() bool {
+	if v.static {
+		_, ok := v.value.(bool)
+		return ok
+	}
+	return v.boolean
+}
+
+// lowerArgo 返回转换为小写字符串的 Argo 表达式，用于忽略大小写的比较；调用前需确认 argoExpr 可用
+func (v exprValue) lowerArgo() string {
+	if v.static {
+		return argoLiteral(strings.ToLower(toString(v.value)))
+	}
+	return "lower(string(" + v.argo + "))"
+}
+
+// truthyArgo 返回值按 GHA 规则转换为布尔值的 Argo 表达式；运行时的非布尔值都是字符串
+func (v exprValue) truthyArgo() (string, bool) {
+	argo, ok := v.argoExpr()
+	if !ok || v.isBoolean() {
+		return argo, ok
+	}
+	if v.static {
+		return strconv.FormatBool(truthy(v.value)), true
+	}
+	return "(string(" + argo + ") != '')", true
+}
+
+// argoLiteral 把静态值写成 Argo 表达式中的字面量
+func argoLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return toString(v)
+	case string:
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+	default:
+		data, _ := json.Marshal(v)
+		return "fromJSON(" + argoLiteral(string(data)) + ")"
+	}
+}
+
+// --- 静态求值，规则与 GHA 一致 ---
+
+// normalizeValue 把 YAML/JSON 解码的值转换为表达式使用的类型
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = normalizeValue(item)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = normalizeValue(item)
+		}
+		return result
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = normalizeValue(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// lookup 忽略大小写查找对象属性
+func lookup(object map[string]interface{}, name string) (interface{}, bool) {
+	if value, ok := object[name]; ok {
+		return value, true
+	}
+	for key, value := range object {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	default:
+		return true
+	}
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NaN"
+		case math.IsInf(v, 1):
+			return "Infinity"
+		case math.IsInf(v, -1):
+			return "-Infinity"
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	case []interface{}:
+		return "Array"
+	default:
+		return "Object"
+	}
+}
+
+func toNumber(value interface{}) float64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case float64:
+		return v
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return 0
+		}
+		if strings.HasPrefix(s, "0x") {
+			if n, err := strconv.ParseInt(s[2:], 16, 64); err == nil {
+				return float64(n)
+			}
+		}
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+		return math.NaN()
+	default:
+		return math.NaN()
+	}
+}
+
+// compareValues 比较两个静态值：类型不同时转为数字，字符串忽略大小写，数组和对象只与自身相等
+func compareValues(kind actionlint.CompareOpNodeKind, left, right interface{}) bool {
+	var cmp int
+	ls, lok := left.(string)
+	rs, rok := right.(string)
+	switch {
+	case lok && rok:
+		cmp = strings.Compare(strings.ToLower(ls), strings.ToLower(rs))
+	case isComposite(left) || isComposite(right):
+		if kind == actionlint.CompareOpNodeKindNotEq {
+			return true
+		}
+		return false
+	case left == nil && right == nil:
+		cmp = 0
+	default:
+		l, r := toNumber(left), toNumber(right)
+		if math.IsNaN(l) || math.IsNaN(r) {
+			return kind == actionlint.CompareOpNodeKindNotEq
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	}
+	switch kind {
+	case actionlint.CompareOpNodeKindLess:
+		return cmp < 0
+	case actionlint.CompareOpNodeKindLessEq:
+		return cmp <= 0
+	case actionlint.CompareOpNodeKindGreater:
+		return cmp > 0
+	case actionlint.CompareOpNodeKindGreaterEq:
+		return cmp >= 0
+	case actionlint.CompareOpNodeKindEq:
+		return cmp == 0
+	default:
+		return cmp != 0
+	}
+}
+
+func isComposite(value interface{}) bool {
+	switch value.(type) {
+	case []interface{}, map[string]interface{}:
+		return true
+	}
+	return false
+}
+
+// stringFunction 对静态值求值 contains / startsWith / endsWith，字符串比较忽略大小写
+func stringFunction(name string, search, item interface{}) bool {
+	if list, ok := search.([]interface{}); ok && name == "contains" {
+		for _, element := range list {
+			if compareValues(actionlint.CompareOpNodeKindEq, element, item) {
+				return true
+			}
+		}
+		return false
+	}
+	s, sub := strings.ToLower(toString(search)), strings.ToLower(toString(item))
+	switch name {
+	case "contains":
+		return strings.Contains(s, sub)
+	case "startswith":
+		return strings.HasPrefix(s, sub)
+	default:
+		return strings.HasSuffix(s, sub)
+	}
+}
+
+// hashFilesFunction 是 hashFiles() 的 bash 实现：与 GHA 一致，对每个匹配文件的 SHA-256 摘要再做一次 SHA-256，
+// 没有匹配文件时返回空字符串；相对路径基于 $GITHUB_WORKSPACE
+const hashFilesFunction = `hashFiles() {
+  local pattern file files
+  shopt -s globstar nullglob
+  files=$(cd "${GITHUB_WORKSPACE:-/workspace}" && for pattern in "$@"; do for file in $pattern; do [ -f "$file" ] && printf '%s\n' "$file"; done; done | LC_ALL=C sort -u)
+  shopt -u globstar nullglob
+  [ -n "$files" ] || return 0
+  while IFS= read -r file; do
+    printf '%b' "$(cd "${GITHUB_WORKSPACE:-/workspace}" && sha256sum "$file" | cut -c1-64 | sed 's/../\\x&/g')"
+  done <<< "$files" | sha256sum | cut -c1-64
+}
+`
+
+// --- 源码位置 ---
+
+// fieldPath 把节点路径格式化为 jobs.build.steps[0].run
+func fieldPath(path ...interface{}) string {
+	var b strings.Builder
+	for _, element := range path {
+		switch key := element.(type) {
+		case int:
+			fmt.Fprintf(&b, "[%d]", key)
+		default:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			fmt.Fprint(&b, key)
+		}
+	}
+	return b.String()
+}
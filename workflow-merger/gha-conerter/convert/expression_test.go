@@ -0,0 +1,423 @@
+package convert
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/nektos/act/pkg/model"
+)
+
+// --- if 条件 ---
+
+func TestConditionFailsClosed(t *testing.T) {
+	tests := []struct {
+		name      string
+		on        string
+		condition string
+		wantWhen  string
+		wantWarn  string // 为空表示不应产生告警
+	}{
+		{name: "static true", on: "push", condition: "${{ true }}"},
+		{name: "static false", on: "push", condition: "${{ false }}", wantWhen: "false"},
+		{name: "input without default", on: "workflow_dispatch", condition: "${{ inputs.type == 'full' }}", wantWhen: "{{=(lower(string(workflow.parameters['input-type'])) == 'full')}}"},
+		{name: "unsupported context", on: "push", condition: "${{ vars.RELEASE == 'true' }}", wantWhen: "false", wantWarn: "never run"},
+		{name: "github.event", on: "pull_request", condition: "${{ !github.event.pull_request.draft }}", wantWhen: "false", wantWarn: "never run"},
+		{name: "text mixed with expression", on: "push", condition: "${{ false }} ok", wantWarn: "always true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := "name: ci\non: " + tt.on + "\njobs:\n  build:\n    runs-on: ubuntu-latest\n    if: \"" + tt.condition + "\"\n    steps:\n      - name: test\n        if: \"" + tt.condition + "\"\n        run: make test\n"
+			output, warnings, err := GHAtoArgo(context.Background(), workflow, ConversionOptions{})
+			if err != nil {
+				t.Fatalf("GHAtoArgo: %v", err)
+			}
+
+			var stepWhen, jobWhen string
+			for _, template := range output.Workflow.Spec.Templates {
+				for _, parallel := range template.Steps {
+					for _, step := range parallel.Steps {
+						if step.Name == "test" {
+							stepWhen = step.When
+						}
+					}
+				}
+				if template.DAG != nil {
+					for _, task := range template.DAG.Tasks {
+						jobWhen = task.When
+					}
+				}
+			}
+			if stepWhen != tt.wantWhen || jobWhen != tt.wantWhen {
+				t.Errorf("when = job %q, step %q; want %q", jobWhen, stepWhen, tt.wantWhen)
+			}
+
+			var messages []string
+			for _, warning := range warnings {
+				messages = append(messages, warning.Message)
+			}
+			joined := strings.Join(messages, "\n")
+			if tt.wantWarn == "" && joined != "" {
+				t.Errorf("unexpected warnings:\n%s", joined)
+			}
+			if tt.wantWarn != "" && (len(messages) != 2 || !strings.Contains(joined, tt.wantWarn)) {
+				t.Errorf("warnings = %q, want one job and one step warning containing %q", messages, tt.wantWarn)
+			}
+		})
+	}
+}
+
+// --- 函数与编译目标 ---
+
+// testExprContext 返回 build Job 中步骤的表达式上下文：inputs.tags 有默认值，inputs.target 没有，
+// env.STATIC 在转换时已知，env.DYNAMIC 只在容器中可知
+func testExprContext(t *testing.T) *exprContext {
+	t.Helper()
+	wf, err := model.ReadWorkflow(strings.NewReader(`name: ci
+on:
+  workflow_dispatch:
+    inputs:
+      tags:
+        default: '["a","b"]'
+      target:
+        type: string
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make
+`), false)
+	if err != nil {
+		t.Fatalf("ReadWorkflow: %v", err)
+	}
+	c := newExprContext(githubContext{workflow: "ci"}, wf).forJob("build", "", nil)
+	c.env["STATIC"] = staticValue("Hello")
+	c.env["DYNAMIC"] = exprValue{env: "DYNAMIC"}
+	return c.forStep()
+}
+
+func TestInterpolateFunctions(t *testing.T) {
+	const target = "{{workflow.parameters.input-target}}"
+	tests := []struct {
+		name  string
+		expr  string
+		want  map[exprTarget]string // 每个编译目标的结果
+		wantP map[exprTarget]string // 每个编译目标应产生的问题，为空表示没有问题
+	}{
+		{
+			name: "contains static",
+			expr: "${{ contains('Hello World', 'world') }}",
+			want: map[exprTarget]string{targetScript: "true", targetEnv: "true", targetShellWord: "'true'"},
+		},
+		{
+			name: "contains runtime",
+			expr: "${{ contains(inputs.target, 'prod') }}",
+			want: map[exprTarget]string{
+				targetScript:    "{{=(lower(string(workflow.parameters['input-target'])) contains 'prod')}}",
+				targetEnv:       "{{=(lower(string(workflow.parameters['input-target'])) contains 'prod')}}",
+				targetShellWord: "'{{=(lower(string(workflow.parameters['\\''input-target'\\''])) contains '\\''prod'\\'')}}'",
+			},
+		},
+		{
+			name: "contains array",
+			expr: "${{ contains(fromJSON(inputs.tags), inputs.target) }}",
+			want: map[exprTarget]string{targetScript: "{{=(lower(string(workflow.parameters['input-target'])) in ['a', 'b'])}}"},
+		},
+		{
+			name: "startsWith runtime",
+			expr: "${{ startsWith(github.ref, 'refs/tags/') }}",
+			want: map[exprTarget]string{targetScript: "{{=(lower(string(workflow.parameters['github-ref'])) startsWith 'refs/tags/')}}"},
+		},
+		{
+			name: "endsWith static env",
+			expr: "${{ endsWith(env.STATIC, 'LO') }}",
+			want: map[exprTarget]string{targetScript: "true"},
+		},
+		{
+			name:  "endsWith container env",
+			expr:  "${{ endsWith(env.DYNAMIC, 'x') }}",
+			want:  map[exprTarget]string{targetScript: "${{ endsWith(env.DYNAMIC, 'x') }}"},
+			wantP: map[exprTarget]string{targetScript: "only known inside the container"},
+		},
+		{
+			name: "format static",
+			expr: "${{ format('{0}-{1}', 'a', 'b') }}",
+			want: map[exprTarget]string{targetScript: "a-b"},
+		},
+		{
+			name: "format escaped braces",
+			expr: "${{ format('{{0}} {0}', inputs.target) }}",
+			want: map[exprTarget]string{targetScript: "{0} " + target},
+		},
+		{
+			name: "format container env",
+			expr: "${{ format('{0}/{1}', env.DYNAMIC, inputs.target) }}",
+			want: map[exprTarget]string{
+				targetScript:    "${DYNAMIC}/" + target,
+				targetEnv:       "$(DYNAMIC)/" + target,
+				targetShellWord: `"${DYNAMIC:-}"'/''` + target + `'`,
+			},
+		},
+		{
+			name:  "format invalid placeholder",
+			expr:  "${{ format('{1}', 'a') }}",
+			want:  map[exprTarget]string{targetScript: "${{ format('{1}', 'a') }}"},
+			wantP: map[exprTarget]string{targetScript: `invalid placeholder "{1}"`},
+		},
+		{
+			name: "join static",
+			expr: "${{ join(fromJSON('[1,2,3]'), '+') }}",
+			want: map[exprTarget]string{targetScript: "1+2+3"},
+		},
+		{
+			name: "join default separator",
+			expr: "${{ join(fromJSON(inputs.tags)) }}",
+			want: map[exprTarget]string{targetScript: "a,b"},
+		},
+		{
+			name:  "join runtime separator",
+			expr:  "${{ join(fromJSON(inputs.tags), inputs.target) }}",
+			want:  map[exprTarget]string{targetScript: "${{ join(fromJSON(inputs.tags), inputs.target) }}"},
+			wantP: map[exprTarget]string{targetScript: "join() needs values known at conversion time"},
+		},
+		{
+			name: "toJSON static",
+			expr: `${{ toJSON(fromJSON('{"a":1}')) }}`,
+			want: map[exprTarget]string{targetScript: "{\n  \"a\": 1\n}"},
+		},
+		{
+			name: "toJSON runtime",
+			expr: "${{ toJSON(inputs.target) }}",
+			want: map[exprTarget]string{targetScript: "{{=toJSON(workflow.parameters['input-target'])}}"},
+		},
+		{
+			name:  "toJSON container env",
+			expr:  "${{ toJSON(env.DYNAMIC) }}",
+			want:  map[exprTarget]string{targetScript: "${{ toJSON(env.DYNAMIC) }}"},
+			wantP: map[exprTarget]string{targetScript: "toJSON() argument is only known inside the container"},
+		},
+		{
+			name: "fromJSON index",
+			expr: `${{ fromJSON('{"a":[1,2]}').a[1] }}`,
+			want: map[exprTarget]string{targetScript: "2"},
+		},
+		{
+			name:  "fromJSON invalid",
+			expr:  "${{ fromJSON('nope') }}",
+			want:  map[exprTarget]string{targetScript: "${{ fromJSON('nope') }}"},
+			wantP: map[exprTarget]string{targetScript: "fromJSON(): invalid character"},
+		},
+		{
+			name: "hashFiles",
+			expr: "${{ hashFiles('**/go.sum', 'go.mod') }}",
+			want: map[exprTarget]string{
+				targetScript:    "$(hashFiles '**/go.sum' 'go.mod')",
+				targetShellWord: `"$(hashFiles '**/go.sum' 'go.mod')"`,
+				targetEnv:       "${{ hashFiles('**/go.sum', 'go.mod') }}",
+			},
+			wantP: map[exprTarget]string{targetEnv: "cannot be used in an environment variable"},
+		},
+		{
+			name:  "hashFiles runtime pattern",
+			expr:  "${{ hashFiles(inputs.target) }}",
+			want:  map[exprTarget]string{targetScript: "${{ hashFiles(inputs.target) }}"},
+			wantP: map[exprTarget]string{targetScript: "hashFiles() patterns must be known at conversion time"},
+		},
+		{
+			name: "container env",
+			expr: "${{ env.DYNAMIC }}",
+			want: map[exprTarget]string{targetScript: "${DYNAMIC}", targetEnv: "$(DYNAMIC)", targetShellWord: `"${DYNAMIC:-}"`},
+		},
+		{
+			name:  "unsupported context",
+			expr:  "${{ vars.X }}",
+			want:  map[exprTarget]string{targetScript: "${{ vars.X }}", targetEnv: "${{ vars.X }}"},
+			wantP: map[exprTarget]string{targetScript: "the vars context is not supported", targetEnv: "the vars context is not supported"},
+		},
+		{
+			name:  "unknown function",
+			expr:  "${{ unknown(1) }}",
+			want:  map[exprTarget]string{targetScript: "${{ unknown(1) }}"},
+			wantP: map[exprTarget]string{targetScript: "unknown function unknown()"},
+		},
+		{
+			name:  "wrong arity",
+			expr:  "${{ contains('a') }}",
+			want:  map[exprTarget]string{targetScript: "${{ contains('a') }}"},
+			wantP: map[exprTarget]string{targetScript: "wrong number of arguments for contains()"},
+		},
+		{
+			name:  "status function outside if",
+			expr:  "${{ success() }}",
+			want:  map[exprTarget]string{targetScript: "${{ success() }}"},
+			wantP: map[exprTarget]string{targetScript: "success() can only be used in if"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testExprContext(t)
+			for target, want := range tt.want {
+				got, problems := c.interpolate("x="+tt.expr, target)
+				if target != targetShellWord {
+					want = "x=" + want
+				} else {
+					want = "'x='" + want
+				}
+				if got != want {
+					t.Errorf("%s: got %q, want %q", target, got, want)
+				}
+				wantProblem := tt.wantP[target]
+				if wantProblem == "" && len(problems) > 0 {
+					t.Errorf("%s: unexpected problems %+v", target, problems)
+				}
+				if wantProblem != "" {
+					// 问题带有表达式原文和它在字段中的偏移
+					if len(problems) != 1 || !strings.Contains(problems[0].message, wantProblem) {
+						t.Errorf("%s: problems = %+v, want one containing %q", target, problems, wantProblem)
+					} else if problems[0].expr != tt.expr || problems[0].offset < 2 {
+						t.Errorf("%s: problem at %q offset %d, want %q after \"x=\"", target, problems[0].expr, problems[0].offset, tt.expr)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestConditionFunctions(t *testing.T) {
+	tests := []struct {
+		expr     string
+		want     string
+		wantWarn string // 为空表示不应产生问题
+	}{
+		{expr: "contains('Hello World', 'world')", want: ""},
+		{expr: "startsWith(github.ref, 'refs/tags/')", want: "{{=(lower(string(workflow.parameters['github-ref'])) startsWith 'refs/tags/')}}"},
+		{expr: "github.sha", want: "{{=(string(workflow.parameters['github-sha']) != '')}}"},
+		{expr: "success()", want: ""},
+		{expr: "endsWith(env.DYNAMIC, 'x')", want: "false", wantWarn: "only known inside the container; it is never run"},
+		{expr: "hashFiles('go.sum') != ''", want: "false", wantWarn: "; it is never run"},
+		{expr: "format('{0}/{1}', env.DYNAMIC, inputs.target) == 'a/b'", want: "false", wantWarn: "; it is never run"},
+		{expr: "vars.X == 'y'", want: "false", wantWarn: "the vars context is not supported; it is never run"},
+		{expr: "github.event.pull_request.number == 1", want: "false", wantWarn: "github.event has no Argo equivalent; it is never run"},
+		{expr: "unknown(1)", want: "false", wantWarn: "unknown function unknown(); it is never run"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			when, problems := testExprContext(t).condition(tt.expr)
+			if when != tt.want {
+				t.Errorf("when = %q, want %q", when, tt.want)
+			}
+			if tt.wantWarn == "" && len(problems) > 0 {
+				t.Errorf("unexpected problems %+v", problems)
+			}
+			if tt.wantWarn != "" && (len(problems) != 1 || !strings.Contains(problems[0].message, tt.wantWarn)) {
+				t.Errorf("problems = %+v, want one containing %q", problems, tt.wantWarn)
+			}
+		})
+	}
+}
+
+// --- 无法编译的表达式 ---
+
+func TestUnsupportedExpressionFailsClosed(t *testing.T) {
+	tests := []struct {
+		name     string
+		step     string // build Job 中 test 步骤的字段
+		job      string // build Job 的其他字段
+		wantWhen string
+		wantWarn string // 无法编译的表达式的告警，以及它的行号
+		wantLine int
+		check    func(t *testing.T, template wfv1.Template)
+	}{
+		{
+			name: "run",
+			step: "run: echo ${{ inputs.target }} ${{ env.DYNAMIC }}",
+			check: func(t *testing.T, template wfv1.Template) {
+				if want := "echo {{workflow.parameters.input-target}} ${DYNAMIC}"; !strings.HasSuffix(template.Script.Source, want) {
+					t.Errorf("script = %q, want it to end with %q", template.Script.Source, want)
+				}
+			},
+		},
+		{
+			name: "env",
+			step: "env:\n          TARGET: \"${{ inputs.target }}-${{ env.DYNAMIC }}\"\n        run: make",
+			check: func(t *testing.T, template wfv1.Template) {
+				if env := template.Script.Env; len(env) == 0 || env[len(env)-1].Name != "TARGET" || env[len(env)-1].Value != "{{workflow.parameters.input-target}}-$(DYNAMIC)" {
+					t.Errorf("env = %+v, want TARGET={{workflow.parameters.input-target}}-$(DYNAMIC)", env)
+				}
+			},
+		},
+		{
+			name: "static working-directory",
+			step: "working-directory: ${{ format('src/{0}', 'app') }}\n        run: make",
+			check: func(t *testing.T, template wfv1.Template) {
+				if template.Script.WorkingDir != DefaultWorkspacePath+"/src/app" || strings.Contains(template.Script.Source, "cd --") {
+					t.Errorf("workingDir = %q, script = %q; want a static working directory", template.Script.WorkingDir, template.Script.Source)
+				}
+			},
+		},
+		{
+			name: "dynamic working-directory",
+			step: "working-directory: src/${{ inputs.target }}\n        run: make",
+			check: func(t *testing.T, template wfv1.Template) {
+				if !strings.Contains(template.Script.Source, "cd -- 'src/''{{workflow.parameters.input-target}}' || exit 1\nmake") {
+					t.Errorf("script = %q, want it to change into the dynamic directory", template.Script.Source)
+				}
+			},
+		},
+		{name: "unsupported in run", step: "run: echo ${{ vars.TARGET }}", wantWhen: "false", wantWarn: "jobs.build.steps[0].run: ${{ vars.TARGET }}: the vars context is not supported", wantLine: 12},
+		{name: "unsupported in env", step: "env:\n          TARGET: ${{ fromJSON('nope') }}\n        run: make", wantWhen: "false", wantWarn: "fromJSON(): invalid character", wantLine: 13},
+		{name: "runtime value in env", step: "env:\n          KEY: ${{ hashFiles('go.sum') }}\n        run: make", wantWhen: "false", wantWarn: "cannot be used in an environment variable", wantLine: 13},
+		{name: "unsupported in working-directory", step: "working-directory: ${{ vars.DIR }}\n        run: make", wantWhen: "false", wantWarn: "the vars context is not supported", wantLine: 12},
+		{name: "unsupported in job env", job: "env:\n      TARGET: ${{ vars.TARGET }}\n    ", step: "run: make", wantWhen: "false", wantWarn: "the vars context is not supported", wantLine: 11},
+		{name: "unsupported in run with if", step: "if: inputs.target == 'prod'\n        run: echo ${{ github.event.after }}", wantWhen: "false", wantWarn: "github.event has no Argo equivalent", wantLine: 13},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := "name: ci\non:\n  workflow_dispatch:\n    inputs:\n      target:\n        type: string\njobs:\n  build:\n    runs-on: ubuntu-latest\n    " + tt.job + "steps:\n      - name: test\n        " + tt.step + "\n"
+			output, warnings, err := GHAtoArgo(context.Background(), workflow, ConversionOptions{})
+			if err != nil {
+				t.Fatalf("GHAtoArgo: %v", err)
+			}
+
+			var when string
+			for _, template := range output.Workflow.Spec.Templates {
+				for _, parallel := range template.Steps {
+					for _, step := range parallel.Steps {
+						if step.Name == "test" {
+							when = step.When
+						}
+					}
+				}
+				if template.Name == "build-test" && tt.check != nil {
+					tt.check(t, template)
+				}
+			}
+			if when != tt.wantWhen {
+				t.Errorf("when = %q, want %q", when, tt.wantWhen)
+			}
+
+			if tt.wantWarn == "" {
+				for _, warning := range warnings {
+					if warning.Code == WarnExpression || warning.Code == WarnGitHubContext {
+						t.Errorf("unexpected warning %+v", warning)
+					}
+				}
+				return
+			}
+			var found, neverRun bool
+			for _, warning := range warnings {
+				if strings.Contains(warning.Message, tt.wantWarn) {
+					found = true
+					if warning.Line != tt.wantLine {
+						t.Errorf("warning %q at line %d, want %d", warning.Message, warning.Line, tt.wantLine)
+					}
+				}
+				neverRun = neverRun || warning.Code == WarnExpression && strings.Contains(warning.Message, "step test uses expressions that cannot be converted")
+			}
+			if !found || !neverRun {
+				t.Errorf("warnings = %+v, want %q and a warning that the step is never run", warnings, tt.wantWarn)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package convert
+
+import (
+	"path"
+	"strings"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// --- github 上下文 ---
+
+// DefaultWorkspacePath 是 $GITHUB_WORKSPACE 的路径
+const DefaultWorkspacePath = "/workspace"
+
+// RunnerFilesPath 是工作区中存放 $GITHUB_ENV、$GITHUB_OUTPUT 等运行文件的目录，每个 Job 一个子目录；
+// 工作区在步骤之间共享，因此前面步骤写入 $GITHUB_ENV 和 $GITHUB_PATH 的内容在后续步骤中生效
+const RunnerFilesPath = DefaultWorkspacePath + "/.runner"
+
+// runnerFiles 是 GHA 中步骤通过文件与 runner 交换数据的环境变量及其文件名
+var runnerFiles = []struct{ env, file string }{
+	{"GITHUB_OUTPUT", "output"},
+	{"GITHUB_ENV", "env"},
+	{"GITHUB_PATH", "path"},
+	{"GITHUB_EVENT_PATH", "event.json"},
+}
+
+// runnerPrologue 在 run 步骤的脚本之前执行：创建运行文件，未指定 runner.arch 时按节点架构设置 $RUNNER_ARCH，
+// 再加载之前步骤写入 $GITHUB_ENV（NAME=value 或 NAME<<DELIMITER 多行格式）和 $GITHUB_PATH 的内容
+const runnerPrologue = `mkdir -p "${GITHUB_ENV%/*}"
+touch "$GITHUB_OUTPUT" "$GITHUB_ENV" "$GITHUB_PATH"
+[ -s "$GITHUB_EVENT_PATH" ] || printf '{}\n' > "$GITHUB_EVENT_PATH"
+if [ -z "${RUNNER_ARCH:-}" ]; then
+  case "$(uname -m)" in
+    x86_64 | amd64) RUNNER_ARCH=X64 ;;
+    aarch64 | arm64) RUNNER_ARCH=ARM64 ;;
+    arm*) RUNNER_ARCH=ARM ;;
+    i?86) RUNNER_ARCH=X86 ;;
+  esac
+  export RUNNER_ARCH
+fi
+while IFS= read -r __line || [ -n "$__line" ]; do
+  case "$__line" in
+    *'<<'*)
+      __name=${__line%%<<*} __delimiter=${__line#*<<} __value=
+      while IFS= read -r __line && [ "$__line" != "$__delimiter" ]; do __value+=${__value:+$'\n'}$__line; done
+      export "$__name=$__value" ;;
+    *=*) export "${__line%%=*}=${__line#*=}" ;;
+  esac
+done < "$GITHUB_ENV"
+while IFS= read -r __line; do [ -z "$__line" ] || PATH=$__line:$PATH; done < "$GITHUB_PATH"
+export PATH
+unset __line __name __delimiter __value
+`
+
+// githubParameter 描述一个来自工作流参数的 github 上下文属性
+type githubParameter struct {
+	property  string                     // github.<property>
+	parameter string                     // 工作流参数名
+	env       string                     // 注入到容器中的环境变量
+	value     func(SourceContext) string // 参数默认值
+}
+
+// githubParameters 的值来自工作流参数，提交时可以用 -p 覆盖，默认值取自 SourceContext
+var githubParameters = []githubParameter{
+	{"repository", "github-repository", "GITHUB_REPOSITORY", func(s SourceContext) string { return s.Repository }},
+	{"repository_owner", "github-repository-owner", "GITHUB_REPOSITORY_OWNER", func(s SourceContext) string {
+		owner, _, _ := strings.Cut(s.Repository, "/")
+		return owner
+	}},
+	{"ref", "github-ref", "GITHUB_REF", func(s SourceContext) string { return s.Ref }},
+	{"ref_name", "github-ref-name", "GITHUB_REF_NAME", func(s SourceContext) string {
+		for _, prefix := range []string{"refs/heads/", "refs/tags/"} {
+			if strings.HasPrefix(s.Ref, prefix) {
+				return strings.TrimPrefix(s.Ref, prefix)
+			}
+		}
+		return s.Ref
+	}},
+	{"sha", "github-sha", "GITHUB_SHA", func(s SourceContext) string { return s.SHA }},
+	{"actor", "github-actor", "GITHUB_ACTOR", func(s SourceContext) string { return s.Actor }},
+	{"event_name", "github-event-name", "GITHUB_EVENT_NAME", func(s SourceContext) string { return s.EventName }},
+}
+
+// githubContext 是一次转换中 github 上下文的取值
+type githubContext struct {
+	workflow  string // github.workflow
+	scheduled bool   // 输出 CronWorkflow 时 github.event.schedule 来自工作流参数
+}
+
+// githubArguments 返回承载 github 上下文的工作流参数
+func githubArguments(source SourceContext) []wfv1.Parameter {
+	params := make([]wfv1.Parameter, 0, len(githubParameters))
+	for _, p := range githubParameters {
+		params = append(params, wfv1.Parameter{Name: p.parameter, Value: wfv1.AnyStringPtr(p.value(source))})
+	}
+	return params
+}
+
+// env 返回注入到 job 每个步骤容器中的 GHA 兼容环境变量；arch 为空时 $RUNNER_ARCH 由 runnerPrologue 设置
+func (c githubContext) env(jobID, arch string) []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{Name: "CI", Value: "true"},
+		{Name: "GITHUB_ACTIONS", Value: "true"},
+		{Name: "GITHUB_WORKFLOW", Value: c.workflow},
+		{Name: "GITHUB_JOB", Value: jobID},
+		{Name: "GITHUB_RUN_ID", Value: "{{workflow.uid}}"},
+		{Name: "GITHUB_RUN_ATTEMPT", Value: "1"},
+		{Name: "GITHUB_WORKSPACE", Value: DefaultWorkspacePath},
+		{Name: "GITHUB_SERVER_URL", Value: "https://github.com"},
+		{Name: "GITHUB_API_URL", Value: "https://api.github.com"},
+		{Name: "RUNNER_OS", Value: "Linux"},
+		{Name: "RUNNER_NAME", Value: "{{pod.name}}"},
+		{Name: "RUNNER_TEMP", Value: "/tmp"},
+	}
+	if arch != "" {
+		env = append(env, corev1.EnvVar{Name: "RUNNER_ARCH", Value: arch})
+	}
+	for _, f := range runnerFiles {
+		env = append(env, corev1.EnvVar{Name: f.env, Value: path.Join(RunnerFilesPath, jobID, f.file)})
+	}
+	for _, p := range githubParameters {
+		env = append(env, corev1.EnvVar{Name: p.env, Value: "{{workflow.parameters." + p.parameter + "}}"})
+	}
+	return env
+}
+
+// property 返回 github.<name> 的值：常量，或来自工作流参数的 Argo 变量
+func (c githubContext) property(name, jobID string) (exprValue, bool) {
+	switch name {
+	case "workflow":
+		return staticValue(c.workflow), true
+	case "job":
+		return staticValue(jobID), true
+	case "run_id":
+		return argoVariable("{{workflow.uid}}", "workflow.uid"), true
+	case "run_attempt":
+		return staticValue("1"), true
+	case "workspace":
+		return staticValue(DefaultWorkspacePath), true
+	case "server_url":
+		return staticValue("https://github.com"), true
+	case "api_url":
+		return staticValue("https://api.github.com"), true
+	}
+	for _, p := range githubParameters {
+		if p.property == name {
+			return argoVariable("{{workflow.parameters."+p.parameter+"}}", "workflow.parameters['"+p.parameter+"']"), true
+		}
+	}
+	return exprValue{}, false
+}
@@ -0,0 +1,131 @@
+package convert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+
+	"argo-sdk/argoclient"
+	"workflow-parser/ghawf"
+)
+
+// --- 来源信息：标签和注解 ---
+
+// Version 是转换器版本，发布时通过 -ldflags "-X gha-converter/convert.Version=..." 注入
+var Version = "dev"
+
+// 模板上记录原始 GHA 名称的注解，argoclient.WorkflowWatcher 用它们把 Argo 节点映射回 GHA Job/Step
+const (
+	AnnotationGHAJob  = argoclient.AnnotationGHAJob
+	AnnotationGHAStep = argoclient.AnnotationGHAStep
+)
+
+// 工作流和每个模板上的来源标签；标签值经过清理，用于查找和去重
+const (
+	LabelGHAWorkflow      = argoclient.LabelGHAWorkflow // argo-sdk 的生命周期操作按此标签查找工作流
+	LabelRepository       = "argus.io/repository"
+	LabelRef              = "argus.io/ref"
+	LabelSHA              = "argus.io/sha"
+	LabelTenant           = "argus.io/tenant"
+	LabelInputHash        = "argus.io/input-hash" // 输入 YAML 的 SHA-256 前 32 位
+	LabelConverterVersion = "argus.io/converter-version"
+	LabelDelivery         = "argus.io/delivery" // 由 webhook 触发时的投递 ID
+)
+
+// 来源注解，保存未经清理的原始值，用于审计
+const (
+	AnnotationRepository       = "argus.io/repository"
+	AnnotationRef              = "argus.io/ref"
+	AnnotationSHA              = "argus.io/sha"
+	AnnotationWorkflowPath     = "argus.io/workflow-path"
+	AnnotationActor            = "argus.io/actor"
+	AnnotationInputSHA256      = "argus.io/input-sha256"
+	AnnotationConverterVersion = "argus.io/converter-version"
+	AnnotationDelivery         = "argus.io/delivery"
+)
+
+// SourceContext 描述 GHA 工作流的来源，所有字段都可为空
+type SourceContext struct {
+	Repository   string `json:"repository"`   // owner/repo
+	Ref          string `json:"ref"`          // refs/heads/main
+	SHA          string `json:"sha"`          // 提交 SHA
+	WorkflowPath string `json:"workflowPath"` // .github/workflows/ci.yml
+	Actor        string `json:"actor"`        // 触发者
+	EventName    string `json:"eventName"`    // 触发事件，如 push、pull_request
+	Delivery     string `json:"delivery"`     // webhook 投递 ID
+}
+
+// stampProvenance 在工作流和每个模板上写入来源标签和注解
+func stampProvenance(wf *wfv1.Workflow, ghaName, ghaYAML string, opts ConversionOptions) {
+	sum := sha256.Sum256([]byte(ghaYAML))
+	inputHash := hex.EncodeToString(sum[:])
+	source := opts.Source
+
+	labels := map[string]string{
+		LabelGHAWorkflow:      ghawf.SanitizeName(ghaName),
+		LabelInputHash:        inputHash[:32],
+		LabelConverterVersion: labelValue(Version),
+	}
+	annotations := map[string]string{
+		AnnotationInputSHA256:      inputHash,
+		AnnotationConverterVersion: Version,
+	}
+	setIfPresent := func(label, annotation, value string) {
+		if value == "" {
+			return
+		}
+		if label != "" {
+			if v := labelValue(value); v != "" {
+				labels[label] = v
+			}
+		}
+		annotations[annotation] = value
+	}
+	setIfPresent(LabelRepository, AnnotationRepository, source.Repository)
+	setIfPresent(LabelRef, AnnotationRef, source.Ref)
+	setIfPresent(LabelSHA, AnnotationSHA, source.SHA)
+	setIfPresent("", AnnotationWorkflowPath, source.WorkflowPath)
+	setIfPresent("", AnnotationActor, source.Actor)
+	setIfPresent(LabelDelivery, AnnotationDelivery, source.Delivery)
+	if opts.TenantID != "" {
+		labels[LabelTenant] = labelValue(opts.TenantID)
+	}
+
+	wf.Labels = mergeStringMaps(wf.Labels, labels)
+	wf.Annotations = mergeStringMaps(wf.Annotations, annotations)
+	for i := range wf.Spec.Templates {
+		metadata := &wf.Spec.Templates[i].Metadata
+		metadata.Labels = mergeStringMaps(metadata.Labels, labels)
+		metadata.Annotations = mergeStringMaps(metadata.Annotations, annotations)
+	}
+}
+
+// mergeStringMaps 把 src 合并到 dst，dst 中已有的键保持不变
+func mergeStringMaps(dst, src map[string]string) map[string]string {
+	if dst == nil {
+		dst = make(map[string]string, len(src))
+	}
+	for key, value := range src {
+		if _, ok := dst[key]; !ok {
+			dst[key] = value
+		}
+	}
+	return dst
+}
+
+var (
+	nonLabelSafeRegex = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+	labelEdgeRegex    = regexp.MustCompile(`^[^A-Za-z0-9]+|[^A-Za-z0-9]+$`)
+)
+
+// labelValue 把任意字符串转换为合法的 K8s 标签值（最长 63 个字符，首尾为字母或数字）
+func labelValue(value string) string {
+	value = nonLabelSafeRegex.ReplaceAllString(value, "-")
+	value = labelEdgeRegex.ReplaceAllString(value, "")
+	if len(value) > 63 {
+		value = labelEdgeRegex.ReplaceAllString(value[:63], "")
+	}
+	return value
+}
@@ -0,0 +1,137 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"argo-parser/argowf"
+)
+
+// --- GHA → Argo → GHA 往返转换 ---
+
+// TestRoundTripJobGraph 把 GHA 转换为 Argo 再反向转换，Job 集合和依赖关系应保持不变
+func TestRoundTripJobGraph(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow string
+	}{
+		{name: "single job", workflow: `name: ci
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make test
+`},
+		{name: "chain", workflow: `name: chain
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make
+  test:
+    needs: build
+    runs-on: ubuntu-latest
+    steps:
+      - run: make test
+  deploy:
+    needs: [test]
+    runs-on: ubuntu-latest
+    steps:
+      - run: make deploy
+`},
+		{name: "diamond with redundant needs", workflow: `name: diamond
+on: push
+jobs:
+  Setup:
+    runs-on: ubuntu-latest
+    steps:
+      - run: ./setup
+  lint:
+    needs: setup
+    runs-on: ubuntu-latest
+    steps:
+      - run: make lint
+  unit_test:
+    needs: [Setup]
+    runs-on: ubuntu-latest
+    steps:
+      - run: make test
+  release:
+    needs: [setup, lint, unit_test]
+    if: ${{ github.ref == 'refs/heads/main' }}
+    runs-on: ubuntu-latest
+    steps:
+      - run: make release
+`},
+		{name: "matrix and cache", workflow: `name: matrix
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        go: ["1.22", "1.23"]
+    steps:
+      - uses: actions/cache@v4
+        with:
+          path: vendor
+          key: deps-${{ hashFiles('go.sum') }}
+      - run: go test ./...
+  report:
+    needs: build
+    runs-on: ubuntu-latest
+    steps:
+      - run: ./report
+`},
+		{name: "scheduled", workflow: `name: nightly
+on:
+  schedule:
+    - cron: "0 3 * * *"
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make test
+  notify:
+    needs: build
+    if: ${{ failure() }}
+    runs-on: ubuntu-latest
+    steps:
+      - run: ./notify
+`},
+	}
+	for _, file := range []string{"../../../workflow-parser/.argus/workflows/ci.yml", "../../test.yaml"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests = append(tests, struct {
+			name     string
+			workflow string
+		}{name: filepath.Base(file), workflow: string(data)})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := argowf.ParseJobGraph([]byte(tt.workflow))
+			if err != nil {
+				t.Fatalf("ParseJobGraph: %v", err)
+			}
+			output, _, err := GHAtoArgo(context.Background(), tt.workflow, ConversionOptions{Cache: CacheOptions{Backend: CacheBackendPVC, Claim: "cache"}})
+			if err != nil {
+				t.Fatalf("GHAtoArgo: %v", err)
+			}
+			gha, _, err := argowf.ConvertArgoToGHA(output.Workflow)
+			if err != nil {
+				t.Fatalf("ConvertArgoToGHA: %v", err)
+			}
+			if diffs := argowf.CompareJobGraphs(want, gha.JobGraph()); len(diffs) > 0 {
+				t.Errorf("job graph changed in round trip:\n%v", diffs)
+			}
+		})
+	}
+}
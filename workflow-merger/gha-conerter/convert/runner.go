@@ -0,0 +1,175 @@
+package convert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+
+	"configmap/configmaps"
+	"workflow-parser/ghawf"
+)
+
+// --- runs-on 运行环境配置 ---
+
+// runs-on 同名 ConfigMap 中使用的 key
+const (
+	RunnerKeyImage    = "image"    // 步骤容器镜像
+	RunnerKeyTemplate = "template" // 合并到每个步骤模板的 JSON（RFC 7386 merge patch）
+	RunnerKeyArch     = "arch"     // runner.arch：X64、ARM64、ARM 或 X86
+
+	RunnerKeyWorkspaceStorageClass = "workspaceStorageClass" // 工作区 PVC 的存储类
+	RunnerKeyWorkspaceSize         = "workspaceSize"         // 工作区 PVC 的大小，如 10Gi
+	RunnerKeyWorkspaceAccessMode   = "workspaceAccessMode"   // 工作区 PVC 的访问模式，如 ReadWriteMany
+)
+
+// RunnerConfig 描述一个 runs-on 标签对应的运行环境
+type RunnerConfig struct {
+	Image         string          `json:"image"`              // 步骤容器镜像
+	TemplatePatch json.RawMessage `json:"template,omitempty"` // 转换完成后合并到步骤模板的 JSON，可为空
+	Workspace     WorkspaceConfig `json:"workspace"`          // 工作区卷配置，可为空
+	Arch          string          `json:"arch,omitempty"`     // runner.arch，为空时在运行时按节点架构确定
+}
+
+// runnerArchs 把 GHA 的 runner.arch 映射为 Kubernetes 节点的 kubernetes.io/arch 标签
+var runnerArchs = map[string]string{
+	"X64":   "amd64",
+	"ARM64": "arm64",
+	"ARM":   "arm",
+	"X86":   "386",
+}
+
+// runnerArch 按 runs-on 标签推断 runner.arch：GitHub 的 ARM runner 标签以 -arm 结尾，其他标签在运行时确定
+func runnerArch(label string) string {
+	if strings.HasSuffix(strings.ToLower(label), "-arm") || strings.Contains(strings.ToLower(label), "arm64") {
+		return "ARM64"
+	}
+	return ""
+}
+
+// RunnerConfigProvider 根据 namespace 和 runs-on 标签查找运行环境
+type RunnerConfigProvider interface {
+	RunnerConfig(ctx context.Context, namespace, label string) (*RunnerConfig, error)
+}
+
+// StaticRunnerConfigs 使用内置的镜像映射，不访问集群
+type StaticRunnerConfigs struct{}
+
+func (StaticRunnerConfigs) RunnerConfig(ctx context.Context, namespace, label string) (*RunnerConfig, error) {
+	return &RunnerConfig{Image: mapRunsOnToImage(label), Arch: runnerArch(label)}, nil
+}
+
+// ConfigMapRunnerConfigs 从 runs-on 同名的 ConfigMap 读取运行环境，
+// ConfigMap 不存在时回落到内置映射
+type ConfigMapRunnerConfigs struct {
+	Client kubernetes.Interface
+}
+
+func (c ConfigMapRunnerConfigs) RunnerConfig(ctx context.Context, namespace, label string) (*RunnerConfig, error) {
+	configMap, err := configmaps.Get(ctx, c.Client, namespace, RunnerConfigMapName(label))
+	if apierrors.IsNotFound(err) {
+		return StaticRunnerConfigs{}.RunnerConfig(ctx, namespace, label)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get runner ConfigMap %s in namespace %s: %w", label, namespace, err)
+	}
+
+	config := &RunnerConfig{Image: configMap.Data[RunnerKeyImage]}
+	if config.Image == "" {
+		config.Image = mapRunsOnToImage(label)
+	}
+	config.Arch = strings.ToUpper(configMap.Data[RunnerKeyArch])
+	if config.Arch == "" {
+		config.Arch = runnerArch(label)
+	} else if _, ok := runnerArchs[config.Arch]; !ok {
+		return nil, fmt.Errorf("runner ConfigMap %s/%s: key %q must be X64, ARM64, ARM or X86, got %q", namespace, label, RunnerKeyArch, configMap.Data[RunnerKeyArch])
+	}
+	if patch := configMap.Data[RunnerKeyTemplate]; patch != "" {
+		if !json.Valid([]byte(patch)) {
+			return nil, fmt.Errorf("runner ConfigMap %s/%s: key %q is not valid JSON", namespace, label, RunnerKeyTemplate)
+		}
+		config.TemplatePatch = json.RawMessage(patch)
+	}
+	config.Workspace = WorkspaceConfig{
+		StorageClass: configMap.Data[RunnerKeyWorkspaceStorageClass],
+		Size:         configMap.Data[RunnerKeyWorkspaceSize],
+		AccessMode:   corev1.PersistentVolumeAccessMode(configMap.Data[RunnerKeyWorkspaceAccessMode]),
+	}
+	if size := config.Workspace.Size; size != "" {
+		if _, err := resource.ParseQuantity(size); err != nil {
+			return nil, fmt.Errorf("runner ConfigMap %s/%s: key %q: %w", namespace, label, RunnerKeyWorkspaceSize, err)
+		}
+	}
+	return config, nil
+}
+
+// RunnerConfigMapName 返回 runs-on 标签对应的 ConfigMap 名称
+func RunnerConfigMapName(label string) string {
+	return ghawf.SanitizeName(label)
+}
+
+// selectRunnerArch 把步骤调度到与 runner.arch 一致的节点上，runs-on 模板中的 nodeSelector 可以覆盖
+func selectRunnerArch(template *wfv1.Template, arch string) {
+	nodeArch, ok := runnerArchs[arch]
+	if !ok {
+		return
+	}
+	if template.NodeSelector == nil {
+		template.NodeSelector = map[string]string{}
+	}
+	template.NodeSelector[corev1.LabelArchStable] = nodeArch
+}
+
+// applyTemplatePatch 把 JSON merge patch 合并到模板上
+func applyTemplatePatch(template *wfv1.Template, patch json.RawMessage) error {
+	if len(patch) == 0 {
+		return nil
+	}
+	original, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	var target, changes interface{}
+	if err := json.Unmarshal(original, &target); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return err
+	}
+	merged, err := json.Marshal(mergePatch(target, changes))
+	if err != nil {
+		return err
+	}
+	var result wfv1.Template
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return fmt.Errorf("patched template is invalid: %w", err)
+	}
+	*template = result
+	return nil
+}
+
+// mergePatch 实现 RFC 7386：对象递归合并，null 删除字段，其他值直接替换
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
@@ -0,0 +1,177 @@
+package convert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/nektos/act/pkg/model"
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"argo-parser/argowf"
+	"workflow-parser/ghawf"
+)
+
+// --- on.schedule：转换为 CronWorkflow ---
+
+// ScheduleTimezone 是 CronWorkflow 的时区，GHA 的 cron 按 UTC 计算
+const ScheduleTimezone = "UTC"
+
+// githubScheduleParameter 承载 github.event.schedule 的工作流参数
+const githubScheduleParameter = "github-event-schedule"
+
+// ConversionOutput 是转换结果；工作流由 on.schedule 触发时 Crons 非空，每个 cron 表达式一个 CronWorkflow，
+// 输出和提交的是 Crons，它们的 workflowSpec 与 Workflow 的 spec 只差 github.event.schedule 参数
+type ConversionOutput struct {
+	Workflow *wfv1.Workflow
+	Crons    []*wfv1.CronWorkflow
+}
+
+// Objects 返回要输出或提交的对象
+func (o *ConversionOutput) Objects() []interface{} {
+	if len(o.Crons) == 0 {
+		return []interface{}{o.Workflow}
+	}
+	objects := make([]interface{}, len(o.Crons))
+	for i, cron := range o.Crons {
+		objects[i] = cron
+	}
+	return objects
+}
+
+// ghaSchedules 返回 on.schedule 中的 cron 表达式，没有配置时返回 nil
+func ghaSchedules(wf *model.Workflow) []string {
+	entries, _ := wf.OnEvent("schedule").([]interface{})
+	var schedules []string
+	for _, entry := range entries {
+		if item, ok := entry.(map[string]interface{}); ok {
+			if expr, ok := item["cron"].(string); ok && strings.TrimSpace(expr) != "" {
+				schedules = append(schedules, strings.TrimSpace(expr))
+			}
+		}
+	}
+	return schedules
+}
+
+// scheduledConversion 判断是否输出 CronWorkflow：工作流配置了 on.schedule，且来源事件为空或为 schedule。
+// 由其他事件（如 webhook 的 push）触发时仍然输出 Workflow
+func scheduledConversion(schedules []string, source SourceContext) bool {
+	return len(schedules) > 0 && (source.EventName == "" || source.EventName == "schedule")
+}
+
+// validateSchedules 检查 cron 表达式是标准的 5 段格式
+func validateSchedules(schedules []string) error {
+	for i, expr := range schedules {
+		if _, err := cron.ParseStandard(expr); err != nil {
+			return fmt.Errorf("%w: on.schedule[%d].cron %q: %v", ErrInvalidWorkflow, i, expr, err)
+		}
+	}
+	return nil
+}
+
+// forbidSkipsQueuedRun 说明 Forbid 与 GHA 排队行为的差异
+const forbidSkipsQueuedRun = "GitHub Actions queues a run while another run of the group is in progress, the CronWorkflow uses Forbid and skips it"
+
+// concurrencyPolicy 把工作流级 concurrency 映射为 CronWorkflow 的 concurrencyPolicy：
+// cancel-in-progress 为 true 时用新运行替换正在运行的（Replace），否则跳过新运行（Forbid）；
+// 未配置时允许并发（Allow）。CronWorkflow 的所有运行属于同一个组，group 的值不影响结果。
+// GHA 会让新运行排队等待（同一组最多一个），Argo 没有对应的策略，Forbid 会丢掉这次运行，因此返回告警消息；
+// 无法在转换时求值的 cancel-in-progress 同样返回告警消息
+func concurrencyPolicy(node *yaml.Node) (wfv1.ConcurrencyPolicy, string) {
+	if node == nil {
+		return wfv1.AllowConcurrent, ""
+	}
+	if node.Kind == yaml.ScalarNode {
+		return wfv1.ForbidConcurrent, forbidSkipsQueuedRun
+	}
+	var concurrency struct {
+		Group            string `yaml:"group"`
+		CancelInProgress string `yaml:"cancel-in-progress"`
+	}
+	if err := node.Decode(&concurrency); err != nil {
+		return wfv1.ForbidConcurrent, fmt.Sprintf("concurrency cannot be read, Forbid is used: %v", err)
+	}
+	switch strings.TrimSpace(concurrency.CancelInProgress) {
+	case "true":
+		return wfv1.ReplaceConcurrent, ""
+	case "", "false":
+		return wfv1.ForbidConcurrent, forbidSkipsQueuedRun
+	default:
+		return wfv1.ForbidConcurrent, fmt.Sprintf("concurrency.cancel-in-progress %q cannot be evaluated at conversion time, Forbid is used", concurrency.CancelInProgress)
+	}
+}
+
+// cronBaseName 返回 CronWorkflow 的基础名称：有来源仓库时为 "<仓库>-<工作流>"，
+// 不同仓库的同名工作流提交到同一个 namespace 时不会互相覆盖
+func cronBaseName(repository, workflow string) string {
+	if repository == "" {
+		return ghawf.SanitizeName(workflow)
+	}
+	return ghawf.SanitizeName(repository + "-" + workflow)
+}
+
+// cronWorkflowName 返回 CronWorkflow 的名称，index 为 0 时没有序号后缀。超过 argowf.MaxCronWorkflowNameLength 时
+// 截断 base，并在序号前加上完整名称的哈希，截断后前缀相同的名称也不会冲突
+func cronWorkflowName(base string, index int) string {
+	name, number := base, ""
+	if index > 0 {
+		number = fmt.Sprintf("-%d", index)
+		name += number
+	}
+	if len(name) <= argowf.MaxCronWorkflowNameLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8] + number
+	return strings.TrimRight(base[:argowf.MaxCronWorkflowNameLength-len(suffix)], "-") + suffix
+}
+
+// cronWorkflowsFor 为每个 cron 表达式创建一个 CronWorkflow：Argo 不记录是哪个 schedule 触发的运行，
+// 分开创建后每个 CronWorkflow 的 github.event.schedule 参数就是它自己的表达式。
+// 只有一个 schedule 时名称为 name，否则为 name-1、name-2……（见 cronWorkflowName）；并发策略只在同一个 CronWorkflow 内生效
+func cronWorkflowsFor(wf *wfv1.Workflow, name string, schedules []string, policy wfv1.ConcurrencyPolicy) []*wfv1.CronWorkflow {
+	crons := make([]*wfv1.CronWorkflow, len(schedules))
+	for i, schedule := range schedules {
+		cronName := cronWorkflowName(name, 0)
+		if len(schedules) > 1 {
+			cronName = cronWorkflowName(name, i+1)
+		}
+		spec := *wf.Spec.DeepCopy()
+		for j := range spec.Arguments.Parameters {
+			if spec.Arguments.Parameters[j].Name == githubScheduleParameter {
+				spec.Arguments.Parameters[j].Value = wfv1.AnyStringPtr(schedule)
+			}
+		}
+		crons[i] = cronWorkflowFor(spec, wf, cronName, schedule, policy)
+	}
+	return crons
+}
+
+// cronWorkflowFor 用 spec 创建只有一个 schedule 的 CronWorkflow；来源标签和注解同时写在 CronWorkflow 和它创建的工作流上
+func cronWorkflowFor(spec wfv1.WorkflowSpec, wf *wfv1.Workflow, name, schedule string, policy wfv1.ConcurrencyPolicy) *wfv1.CronWorkflow {
+	return &wfv1.CronWorkflow{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "CronWorkflow",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      mergeStringMaps(nil, wf.Labels),
+			Annotations: mergeStringMaps(nil, wf.Annotations),
+		},
+		Spec: wfv1.CronWorkflowSpec{
+			Schedules:         []string{schedule},
+			Timezone:          ScheduleTimezone,
+			ConcurrencyPolicy: policy,
+			WorkflowSpec:      spec,
+			WorkflowMetadata: &metav1.ObjectMeta{
+				Labels:      mergeStringMaps(nil, wf.Labels),
+				Annotations: mergeStringMaps(nil, wf.Annotations),
+			},
+		},
+	}
+}
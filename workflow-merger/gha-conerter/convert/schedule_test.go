@@ -0,0 +1,134 @@
+package convert
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+)
+
+// --- on.schedule ---
+
+func TestCronWorkflowPerSchedule(t *testing.T) {
+	tests := []struct {
+		name         string
+		schedules    []string
+		concurrency  string
+		wantNames    []string
+		wantPolicy   wfv1.ConcurrencyPolicy
+		wantWarnings []string // schedule 告警消息应包含的文本，按顺序
+	}{
+		{name: "single schedule", schedules: []string{"0 3 * * *"}, wantNames: []string{"nightly"}, wantPolicy: wfv1.AllowConcurrent},
+		{name: "several schedules", schedules: []string{"0 3 * * *", "30 12 * * 1-5"}, wantNames: []string{"nightly-1", "nightly-2"}, wantPolicy: wfv1.AllowConcurrent},
+		{name: "cancel in progress", schedules: []string{"0 3 * * *"}, concurrency: "concurrency:\n  group: nightly\n  cancel-in-progress: true\n", wantNames: []string{"nightly"}, wantPolicy: wfv1.ReplaceConcurrent},
+		{name: "queued group", schedules: []string{"0 3 * * *"}, concurrency: "concurrency: nightly\n", wantNames: []string{"nightly"}, wantPolicy: wfv1.ForbidConcurrent, wantWarnings: []string{"skips it"}},
+		{
+			name:         "queued group with several schedules",
+			schedules:    []string{"0 3 * * *", "30 12 * * 1-5"},
+			concurrency:  "concurrency:\n  group: nightly\n  cancel-in-progress: false\n",
+			wantNames:    []string{"nightly-1", "nightly-2"},
+			wantPolicy:   wfv1.ForbidConcurrent,
+			wantWarnings: []string{"skips it", "each of the 2 CronWorkflows"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := "name: nightly\non:\n  schedule:\n"
+			for _, schedule := range tt.schedules {
+				workflow += "    - cron: \"" + schedule + "\"\n"
+			}
+			workflow += tt.concurrency + "jobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - run: echo \"${{ github.event.schedule }}\"\n"
+
+			output, warnings, err := GHAtoArgo(context.Background(), workflow, ConversionOptions{})
+			if err != nil {
+				t.Fatalf("GHAtoArgo: %v", err)
+			}
+			if len(output.Crons) != len(tt.wantNames) || len(output.Objects()) != len(tt.wantNames) {
+				t.Fatalf("got %d CronWorkflows, want %d", len(output.Crons), len(tt.wantNames))
+			}
+			for i, cron := range output.Crons {
+				if cron.Name != tt.wantNames[i] {
+					t.Errorf("CronWorkflow %d name = %q, want %q", i, cron.Name, tt.wantNames[i])
+				}
+				if len(cron.Spec.Schedules) != 1 || cron.Spec.Schedules[0] != tt.schedules[i] {
+					t.Errorf("CronWorkflow %s schedules = %v, want [%s]", cron.Name, cron.Spec.Schedules, tt.schedules[i])
+				}
+				if cron.Spec.ConcurrencyPolicy != tt.wantPolicy {
+					t.Errorf("CronWorkflow %s concurrencyPolicy = %q, want %q", cron.Name, cron.Spec.ConcurrencyPolicy, tt.wantPolicy)
+				}
+				// github.event.schedule 是触发这个 CronWorkflow 的表达式
+				if got := cron.Spec.WorkflowSpec.Arguments.GetParameterByName(githubScheduleParameter); got == nil || got.Value.String() != tt.schedules[i] {
+					t.Errorf("CronWorkflow %s %s = %v, want %q", cron.Name, githubScheduleParameter, got, tt.schedules[i])
+				}
+			}
+
+			var messages []string
+			for _, warning := range warnings {
+				if warning.Code == WarnSchedule {
+					messages = append(messages, warning.Message)
+				}
+			}
+			if len(messages) != len(tt.wantWarnings) {
+				t.Fatalf("schedule warnings = %q, want %d", messages, len(tt.wantWarnings))
+			}
+			for i, want := range tt.wantWarnings {
+				if !strings.Contains(messages[i], want) {
+					t.Errorf("warning %d = %q, want it to contain %q", i, messages[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCronWorkflowName(t *testing.T) {
+	long := strings.Repeat("a", 40) + "-" + strings.Repeat("b", 20)
+	tests := []struct {
+		name       string
+		repository string
+		workflow   string
+		index      int
+		want       string // 为空时只检查长度和字符
+	}{
+		{name: "workflow only", workflow: "Nightly Build", want: "nightly-build"},
+		{name: "qualified by repository", repository: "octo/app", workflow: "nightly", want: "octo-app-nightly"},
+		{name: "numbered", repository: "octo/app", workflow: "nightly", index: 2, want: "octo-app-nightly-2"},
+		{name: "exactly the limit", workflow: strings.Repeat("a", 52), want: strings.Repeat("a", 52)},
+		{name: "truncated", repository: "octo/app", workflow: long},
+		{name: "truncated and numbered", repository: "octo/app", workflow: long, index: 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cronWorkflowName(cronBaseName(tt.repository, tt.workflow), tt.index)
+			if tt.want != "" && got != tt.want {
+				t.Errorf("name = %q, want %q", got, tt.want)
+			}
+			if len(got) > 52 || strings.HasPrefix(got, "-") || strings.Contains(got, "--") {
+				t.Errorf("name %q is not a valid CronWorkflow name", got)
+			}
+		})
+	}
+
+	// 截断后前缀相同的名称由哈希区分，序号保留在末尾
+	a := cronWorkflowName(strings.Repeat("x", 60)+"-a", 1)
+	b := cronWorkflowName(strings.Repeat("x", 60)+"-b", 1)
+	if a == b || !strings.HasSuffix(a, "-1") {
+		t.Errorf("truncated names = %q, %q, want distinct names ending in -1", a, b)
+	}
+}
+
+// TestCronWorkflowNameFromSource 来源仓库和过长的工作流名称都反映在生成的 CronWorkflow 上，且通过离线校验
+func TestCronWorkflowNameFromSource(t *testing.T) {
+	workflow := "name: " + strings.Repeat("nightly-", 8) + "\non:\n  schedule:\n    - cron: \"0 3 * * *\"\n    - cron: \"0 4 * * *\"\n" +
+		"jobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - run: make\n"
+	output, _, err := GHAtoArgo(context.Background(), workflow, ConversionOptions{Source: SourceContext{Repository: "octo/app"}})
+	if err != nil {
+		t.Fatalf("GHAtoArgo: %v", err)
+	}
+	for i, cron := range output.Crons {
+		if !strings.HasPrefix(cron.Name, "octo-app-nightly-") || len(cron.Name) > 52 || !strings.HasSuffix(cron.Name, "-"+strconv.Itoa(i+1)) {
+			t.Errorf("CronWorkflow %d name = %q", i, cron.Name)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package convert
+
+import (
+	"context"
+	"fmt"
+
+	cronworkflowpkg "github.com/argoproj/argo-workflows/v3/pkg/apiclient/cronworkflow"
+	workflowpkg "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"argo-sdk/argoclient"
+)
+
+// --- 提交到 Argo ---
+
+// SubmitOptions 描述转换完成后如何提交工作流
+type SubmitOptions struct {
+	Namespace string // 提交到的 namespace
+	DryRun    bool   // 使用 Argo 的 server-side dry-run，不真正创建
+}
+
+// SubmittedWorkflow 是提交结果
+type SubmittedWorkflow struct {
+	Kind      string   `json:"kind"` // Workflow 或 CronWorkflow
+	Name      string   `json:"workflowName"`
+	Names     []string `json:"workflowNames,omitempty"` // 有多个 schedule 时创建的所有 CronWorkflow，Name 是第一个
+	Namespace string   `json:"namespace"`
+	DryRun    bool     `json:"dryRun"`
+	Updated   bool     `json:"updated,omitempty"` // 至少一个 CronWorkflow 已经存在，更新为这次的转换结果
+}
+
+// ArgoSubmitter 通过 WorkflowServiceClient 创建工作流，通过 CronWorkflowServiceClient 创建 CronWorkflow
+type ArgoSubmitter struct {
+	ctx        context.Context // apiclient 返回的上下文，携带认证信息
+	client     workflowpkg.WorkflowServiceClient
+	cronClient cronworkflowpkg.CronWorkflowServiceClient // 为 nil 时不能提交 CronWorkflow
+}
+
+// NewArgoSubmitter 创建 Argo 客户端；工作流的 namespace 由每次提交的 SubmitOptions 指定
+func NewArgoSubmitter(config argoclient.ArgoClientConfig) (*ArgoSubmitter, error) {
+	ctx, client, _, err := argoclient.NewArgoClient(config)
+	if err != nil {
+		return nil, err
+	}
+	cronClient, err := client.NewCronWorkflowServiceClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Argo CronWorkflow client: %w", err)
+	}
+	return &ArgoSubmitter{ctx: ctx, client: client.NewWorkflowServiceClient(), cronClient: cronClient}, nil
+}
+
+// NewArgoSubmitterWithClient 使用已有的 WorkflowServiceClient 和 CronWorkflowServiceClient（例如测试用的假服务），
+// cronClient 可以为 nil
+func NewArgoSubmitterWithClient(ctx context.Context, client workflowpkg.WorkflowServiceClient, cronClient cronworkflowpkg.CronWorkflowServiceClient) *ArgoSubmitter {
+	return &ArgoSubmitter{ctx: ctx, client: client, cronClient: cronClient}
+}
+
+// Submit 创建转换结果中的工作流或 CronWorkflow；ctx 的取消会中止请求，认证信息来自 apiclient 上下文。
+// 有多个 CronWorkflow 时按顺序创建，中途失败时已创建的保留；由于已存在的 CronWorkflow 会被更新，重新提交即可补全整组
+func (s *ArgoSubmitter) Submit(ctx context.Context, output *ConversionOutput, opts SubmitOptions) (*SubmittedWorkflow, error) {
+	if len(output.Crons) == 0 {
+		return s.SubmitWorkflow(ctx, output.Workflow, opts)
+	}
+	var result *SubmittedWorkflow
+	for _, cron := range output.Crons {
+		submitted, err := s.SubmitCron(ctx, cron, opts)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			result = submitted
+		}
+		result.Updated = result.Updated || submitted.Updated
+		if len(output.Crons) > 1 {
+			result.Names = append(result.Names, submitted.Name)
+		}
+	}
+	return result, nil
+}
+
+// SubmitWorkflow 创建工作流
+func (s *ArgoSubmitter) SubmitWorkflow(ctx context.Context, workflow *wfv1.Workflow, opts SubmitOptions) (*SubmittedWorkflow, error) {
+	requestCtx, cancel := mergeCancel(s.ctx, ctx)
+	defer cancel()
+
+	workflow.Namespace = opts.Namespace
+	created, err := s.client.CreateWorkflow(requestCtx, &workflowpkg.WorkflowCreateRequest{
+		Namespace:    opts.Namespace,
+		Workflow:     workflow,
+		ServerDryRun: opts.DryRun,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workflow in namespace %s: %w", opts.Namespace, err)
+	}
+	return &SubmittedWorkflow{Kind: "Workflow", Name: created.Name, Namespace: created.Namespace, DryRun: opts.DryRun}, nil
+}
+
+// SubmitCron 创建 CronWorkflow；dry-run 使用 Kubernetes 的 dryRun=All。
+// 同名的 CronWorkflow 已经存在且来自同一仓库的同一工作流时更新它（dry-run 时只检查），否则返回错误
+func (s *ArgoSubmitter) SubmitCron(ctx context.Context, cron *wfv1.CronWorkflow, opts SubmitOptions) (*SubmittedWorkflow, error) {
+	if s.cronClient == nil {
+		return nil, fmt.Errorf("cannot create CronWorkflow %s: no CronWorkflow client configured", cron.Name)
+	}
+	requestCtx, cancel := mergeCancel(s.ctx, ctx)
+	defer cancel()
+
+	cron.Namespace = opts.Namespace
+	request := &cronworkflowpkg.CreateCronWorkflowRequest{Namespace: opts.Namespace, CronWorkflow: cron}
+	if opts.DryRun {
+		request.CreateOptions = &metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}}
+	}
+	created, err := s.cronClient.CreateCronWorkflow(requestCtx, request)
+	if status.Code(err) == codes.AlreadyExists {
+		return s.updateCron(requestCtx, cron, opts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CronWorkflow in namespace %s: %w", opts.Namespace, err)
+	}
+	return &SubmittedWorkflow{Kind: "CronWorkflow", Name: created.Name, Namespace: created.Namespace, DryRun: opts.DryRun}, nil
+}
+
+// updateCron 用 cron 替换已存在的同名 CronWorkflow；只替换来源标签相同的 CronWorkflow，不覆盖手工创建或其他仓库的
+func (s *ArgoSubmitter) updateCron(ctx context.Context, cron *wfv1.CronWorkflow, opts SubmitOptions) (*SubmittedWorkflow, error) {
+	existing, err := s.cronClient.GetCronWorkflow(ctx, &cronworkflowpkg.GetCronWorkflowRequest{Name: cron.Name, Namespace: opts.Namespace})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing CronWorkflow %s in namespace %s: %w", cron.Name, opts.Namespace, err)
+	}
+	for _, label := range []string{LabelGHAWorkflow, LabelRepository} {
+		if existing.Labels[label] != cron.Labels[label] {
+			return nil, fmt.Errorf("CronWorkflow %s already exists in namespace %s and was not converted from this workflow (label %s is %q, want %q)",
+				cron.Name, opts.Namespace, label, existing.Labels[label], cron.Labels[label])
+		}
+	}
+	result := &SubmittedWorkflow{Kind: "CronWorkflow", Name: existing.Name, Namespace: existing.Namespace, DryRun: opts.DryRun, Updated: true}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	cron.ResourceVersion = existing.ResourceVersion
+	if _, err := s.cronClient.UpdateCronWorkflow(ctx, &cronworkflowpkg.UpdateCronWorkflowRequest{Namespace: opts.Namespace, CronWorkflow: cron}); err != nil {
+		return nil, fmt.Errorf("failed to update CronWorkflow %s in namespace %s: %w", cron.Name, opts.Namespace, err)
+	}
+	return result, nil
+}
+
+// mergeCancel 返回携带 base 的值、同时随 cancelCtx 取消的上下文
+func mergeCancel(base, cancelCtx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(base)
+	stop := context.AfterFunc(cancelCtx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
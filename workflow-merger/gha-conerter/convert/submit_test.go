@@ -0,0 +1,143 @@
+package convert
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	cronworkflowpkg "github.com/argoproj/argo-workflows/v3/pkg/apiclient/cronworkflow"
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// --- 提交 CronWorkflow ---
+
+// fakeCronClient 把 CronWorkflow 保存在内存中，按名称区分；未实现的方法被调用时 panic
+type fakeCronClient struct {
+	cronworkflowpkg.CronWorkflowServiceClient
+	crons   map[string]*wfv1.CronWorkflow
+	failOn  string // 创建该名称时返回错误
+	updates int
+}
+
+func (c *fakeCronClient) CreateCronWorkflow(ctx context.Context, in *cronworkflowpkg.CreateCronWorkflowRequest, opts ...grpc.CallOption) (*wfv1.CronWorkflow, error) {
+	name := in.CronWorkflow.Name
+	if name == c.failOn {
+		return nil, errors.New("connection reset")
+	}
+	if _, ok := c.crons[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "cronworkflows.argoproj.io %q already exists", name)
+	}
+	created := in.CronWorkflow.DeepCopy()
+	created.ResourceVersion = "1"
+	if in.CreateOptions == nil {
+		c.crons[name] = created
+	}
+	return created, nil
+}
+
+func (c *fakeCronClient) GetCronWorkflow(ctx context.Context, in *cronworkflowpkg.GetCronWorkflowRequest, opts ...grpc.CallOption) (*wfv1.CronWorkflow, error) {
+	if cron, ok := c.crons[in.Name]; ok {
+		return cron.DeepCopy(), nil
+	}
+	return nil, status.Errorf(codes.NotFound, "cronworkflows.argoproj.io %q not found", in.Name)
+}
+
+func (c *fakeCronClient) UpdateCronWorkflow(ctx context.Context, in *cronworkflowpkg.UpdateCronWorkflowRequest, opts ...grpc.CallOption) (*wfv1.CronWorkflow, error) {
+	if existing := c.crons[in.CronWorkflow.Name]; existing == nil || existing.ResourceVersion != in.CronWorkflow.ResourceVersion {
+		return nil, status.Error(codes.Aborted, "the object has been modified")
+	}
+	updated := in.CronWorkflow.DeepCopy()
+	updated.ResourceVersion = "2"
+	c.crons[updated.Name] = updated
+	c.updates++
+	return updated, nil
+}
+
+const twoSchedules = `name: nightly
+on:
+  schedule:
+    - cron: "0 3 * * *"
+    - cron: "0 4 * * *"
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make
+`
+
+func convertSchedules(t *testing.T) *ConversionOutput {
+	t.Helper()
+	output, _, err := GHAtoArgo(context.Background(), twoSchedules, ConversionOptions{Source: SourceContext{Repository: "octo/app"}})
+	if err != nil {
+		t.Fatalf("GHAtoArgo: %v", err)
+	}
+	return output
+}
+
+// TestSubmitCronsAfterPartialFailure 中途失败后重新提交会更新已创建的 CronWorkflow 并补全剩下的
+func TestSubmitCronsAfterPartialFailure(t *testing.T) {
+	client := &fakeCronClient{crons: map[string]*wfv1.CronWorkflow{}, failOn: "octo-app-nightly-2"}
+	submitter := NewArgoSubmitterWithClient(context.Background(), nil, client)
+	opts := SubmitOptions{Namespace: "workflows"}
+
+	if _, err := submitter.Submit(context.Background(), convertSchedules(t), opts); err == nil {
+		t.Fatal("Submit succeeded, want the error of the second CronWorkflow")
+	}
+	if len(client.crons) != 1 {
+		t.Fatalf("created %d CronWorkflows before the failure, want 1", len(client.crons))
+	}
+
+	client.failOn = ""
+	result, err := submitter.Submit(context.Background(), convertSchedules(t), opts)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if want := []string{"octo-app-nightly-1", "octo-app-nightly-2"}; strings.Join(result.Names, ",") != strings.Join(want, ",") {
+		t.Errorf("names = %v, want %v", result.Names, want)
+	}
+	if len(client.crons) != 2 || client.updates != 1 || !result.Updated {
+		t.Errorf("crons = %d, updates = %d, updated = %v, want 2 CronWorkflows and 1 update", len(client.crons), client.updates, result.Updated)
+	}
+}
+
+func TestSubmitCronExisting(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string // 已存在的 CronWorkflow 的标签
+		dryRun      bool
+		wantErr     string
+		wantUpdates int
+	}{
+		{name: "same workflow", labels: map[string]string{LabelGHAWorkflow: "nightly", LabelRepository: "octo-app"}, wantUpdates: 1},
+		{name: "same workflow, dry run", labels: map[string]string{LabelGHAWorkflow: "nightly", LabelRepository: "octo-app"}, dryRun: true},
+		{name: "created by hand", wantErr: "was not converted from this workflow"},
+		{name: "other repository", labels: map[string]string{LabelGHAWorkflow: "nightly", LabelRepository: "octo-docs"}, wantErr: `label argus.io/repository is "octo-docs"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron := convertSchedules(t).Crons[0]
+			existing := &wfv1.CronWorkflow{}
+			existing.Name, existing.ResourceVersion, existing.Labels = cron.Name, "1", tt.labels
+			client := &fakeCronClient{crons: map[string]*wfv1.CronWorkflow{cron.Name: existing}}
+			submitter := NewArgoSubmitterWithClient(context.Background(), nil, client)
+
+			result, err := submitter.SubmitCron(context.Background(), cron, SubmitOptions{Namespace: "workflows", DryRun: tt.dryRun})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SubmitCron: %v", err)
+			}
+			if !result.Updated || result.DryRun != tt.dryRun || client.updates != tt.wantUpdates {
+				t.Errorf("result = %+v, updates = %d, want %d", result, client.updates, tt.wantUpdates)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"argo-parser/argowf"
+)
+
+// --- 转换结果校验 ---
+
+// ErrInvalidOutput 表示转换生成的 Argo 工作流没有通过离线校验，是转换器的问题而不是 GHA 工作流的问题
+var ErrInvalidOutput = errors.New("generated workflow is invalid")
+
+// validateOutput 用 argowf 的离线校验检查转换结果（Workflow 或 CronWorkflow），有问题时返回包装 ErrInvalidOutput 的错误；
+// 转换结果不引用外部模板，因此不需要模板库
+func validateOutput(resource argowf.ArgoResource) error {
+	issues := argowf.ValidateResource(resource, nil)
+	if len(issues) == 0 {
+		return nil
+	}
+	messages := make([]string, len(issues))
+	for i, issue := range issues {
+		messages[i] = issue.String()
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidOutput, strings.Join(messages, "; "))
+}
@@ -0,0 +1,214 @@
+package convert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/nektos/act/pkg/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// --- 共享工作区 ---
+
+const (
+	// WorkspaceVolumeName 是工作区卷的名称，PerJob 时为每个 Job 创建的卷以它为前缀
+	WorkspaceVolumeName = "workspace"
+	// DefaultWorkspaceSize 是 runs-on ConfigMap 未指定大小时的工作区大小
+	DefaultWorkspaceSize = "1Gi"
+)
+
+// WorkspaceOptions 是工作区的全局配置
+// 默认一次运行的所有 Job 共享一个工作区，前面的 Job 写入的文件后面的 Job 可以读到；
+// 并行的 Job 可能调度到不同节点上，此时 PVC 需要支持 ReadWriteMany。
+// PerJob 为 true 时每个 Job 使用独立的工作区，并行的 Job 互不影响，ReadWriteOnce 即可
+type WorkspaceOptions struct {
+	ExistingClaim string                     // 使用已有的 PVC，每次运行使用独立的子目录；为空时通过 volumeClaimTemplates 创建
+	GC            wfv1.VolumeClaimGCStrategy // volumeClaimTemplates 创建的 PVC 的回收策略
+	DefaultSize   string                     // runs-on ConfigMap 未指定大小时使用
+	PerJob        bool                       // 每个 Job 使用独立的工作区，Job 之间不共享文件
+}
+
+// WorkspaceConfig 是 runs-on ConfigMap 中的工作区配置，字段都可为空
+type WorkspaceConfig struct {
+	StorageClass string                            `json:"storageClass,omitempty"`
+	Size         string                            `json:"size,omitempty"`
+	AccessMode   corev1.PersistentVolumeAccessMode `json:"accessMode,omitempty"`
+}
+
+// ParseVolumeClaimGC 校验回收策略
+func ParseVolumeClaimGC(strategy string) (wfv1.VolumeClaimGCStrategy, error) {
+	switch s := wfv1.VolumeClaimGCStrategy(strategy); s {
+	case wfv1.VolumeClaimGCOnCompletion, wfv1.VolumeClaimGCOnSuccess:
+		return s, nil
+	default:
+		return "", fmt.Errorf("invalid workspace GC strategy %q, must be %s or %s", strategy, wfv1.VolumeClaimGCOnCompletion, wfv1.VolumeClaimGCOnSuccess)
+	}
+}
+
+// workspaceVolume 返回 Job 的工作区卷名称：共享工作区和已有的 PVC 使用同一个卷，
+// PerJob 时通过 volumeClaimTemplates 为每个 Job 创建独立的 PVC
+func workspaceVolume(job string, opts WorkspaceOptions) string {
+	if opts.ExistingClaim != "" || !opts.PerJob {
+		return WorkspaceVolumeName
+	}
+	name := WorkspaceVolumeName + "-" + job
+	if len(name) > 63 {
+		// 卷名称最长 63 个字符，过长时用 Job 名称的哈希区分
+		sum := sha256.Sum256([]byte(job))
+		name = WorkspaceVolumeName + "-" + hex.EncodeToString(sum[:8])
+	}
+	return name
+}
+
+// workingDirectory 把 working-directory 解析为容器中的路径，相对路径相对于 $GITHUB_WORKSPACE
+func workingDirectory(dir string) string {
+	if dir == "" {
+		return DefaultWorkspacePath
+	}
+	if path.IsAbs(dir) {
+		return path.Clean(dir)
+	}
+	return path.Join(DefaultWorkspacePath, dir)
+}
+
+// stepWorkingDirectory 返回 run 步骤的工作目录：步骤的 working-directory > Job 和工作流的 defaults.run.working-directory；
+// 目录中有运行时才知道的表达式时，容器从工作区启动，由返回的 cd 命令在脚本开头切换
+func stepWorkingDirectory(wf *model.Workflow, jobName string, step int, exprs *exprContext, report func(problems []exprProblem, path ...interface{})) (string, string) {
+	job := wf.Jobs[jobName]
+	dir, path := job.Steps[step].WorkingDirectory, []interface{}{"jobs", jobName, "steps", step, "working-directory"}
+	if dir == "" {
+		dir, path = job.Defaults.Run.WorkingDirectory, []interface{}{"jobs", jobName, "defaults", "run", "working-directory"}
+	}
+	if dir == "" {
+		dir, path = wf.Defaults.Run.WorkingDirectory, []interface{}{"defaults", "run", "working-directory"}
+	}
+	if dir == "" {
+		return DefaultWorkspacePath, ""
+	}
+	parts, problems := exprs.parseTemplate(dir)
+	if static, ok := staticTemplate(parts); ok && len(problems) == 0 {
+		return workingDirectory(static), ""
+	}
+	word, renderProblems := exprs.render(parts, targetShellWord)
+	if problems = append(problems, renderProblems...); len(problems) > 0 {
+		report(problems, path...)
+	}
+	return DefaultWorkspacePath, "cd -- " + word + " || exit 1\n"
+}
+
+// mountWorkspace 把 Job 的工作区挂载到 $GITHUB_WORKSPACE；未指定工作目录时以工作区为工作目录
+func mountWorkspace(container *corev1.Container, job string, opts WorkspaceOptions) {
+	mount := corev1.VolumeMount{Name: workspaceVolume(job, opts), MountPath: DefaultWorkspacePath}
+	if opts.ExistingClaim != "" {
+		// 已有的 PVC 被多次运行共享，按工作流名称隔离；PerJob 时再按 Job 隔离
+		mount.SubPath = "{{workflow.name}}"
+		if opts.PerJob {
+			mount.SubPath += "/" + job
+		}
+	}
+	container.VolumeMounts = append(container.VolumeMounts, mount)
+	if container.WorkingDir == "" {
+		container.WorkingDir = DefaultWorkspacePath
+	}
+}
+
+// addWorkspaceVolume 为工作流声明工作区卷；jobs 是每个 Job 模板的 runs-on 工作区配置。
+// 共享工作区的 PVC 取各 Job 中最大的大小，存储类和访问模式按 Job 名称顺序取第一个非空值，其他值产生告警
+func addWorkspaceVolume(wf *wfv1.Workflow, jobs map[string]WorkspaceConfig, opts WorkspaceOptions, warn func(code, job, format string, args ...interface{})) error {
+	if opts.ExistingClaim != "" {
+		wf.Spec.Volumes = append(wf.Spec.Volumes, corev1.Volume{
+			Name: WorkspaceVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: opts.ExistingClaim},
+			},
+		})
+		return nil
+	}
+
+	defaultSize := opts.DefaultSize
+	if defaultSize == "" {
+		defaultSize = DefaultWorkspaceSize
+	}
+	jobNames := make([]string, 0, len(jobs))
+	for name := range jobs {
+		jobNames = append(jobNames, name)
+	}
+	sort.Strings(jobNames)
+
+	claims := make(map[string]*corev1.PersistentVolumeClaim) // 卷名称 -> PVC
+	accessModeSet := make(map[string]bool)                   // 卷名称 -> 访问模式是否来自 runs-on 配置
+	var order []string
+	for _, name := range jobNames {
+		config := jobs[name]
+		size := config.Size
+		if size == "" {
+			size = defaultSize
+		}
+		quantity, err := resource.ParseQuantity(size)
+		if err != nil {
+			return fmt.Errorf("job %s: invalid workspace size %q: %w", name, size, err)
+		}
+
+		volume := workspaceVolume(name, opts)
+		claim, ok := claims[volume]
+		if !ok {
+			accessMode := config.AccessMode
+			if accessMode == "" {
+				accessMode = corev1.ReadWriteOnce
+			}
+			claim = &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: volume},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{accessMode},
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: quantity},
+					},
+				},
+			}
+			if config.StorageClass != "" {
+				storageClass := config.StorageClass
+				claim.Spec.StorageClassName = &storageClass
+			}
+			claims[volume] = claim
+			accessModeSet[volume] = config.AccessMode != ""
+			order = append(order, volume)
+			continue
+		}
+
+		// 共享工作区：合并后面的 Job 的配置
+		if quantity.Cmp(claim.Spec.Resources.Requests[corev1.ResourceStorage]) > 0 {
+			claim.Spec.Resources.Requests[corev1.ResourceStorage] = quantity
+		}
+		if config.StorageClass != "" {
+			if claim.Spec.StorageClassName == nil {
+				storageClass := config.StorageClass
+				claim.Spec.StorageClassName = &storageClass
+			} else if *claim.Spec.StorageClassName != config.StorageClass {
+				warn(WarnWorkspace, name, "workspace storage class %q ignored, the shared workspace uses %q", config.StorageClass, *claim.Spec.StorageClassName)
+			}
+		}
+		if config.AccessMode != "" {
+			if !accessModeSet[volume] {
+				claim.Spec.AccessModes[0], accessModeSet[volume] = config.AccessMode, true
+			} else if claim.Spec.AccessModes[0] != config.AccessMode {
+				warn(WarnWorkspace, name, "workspace access mode %q ignored, the shared workspace uses %q", config.AccessMode, claim.Spec.AccessModes[0])
+			}
+		}
+	}
+	for _, volume := range order {
+		wf.Spec.VolumeClaimTemplates = append(wf.Spec.VolumeClaimTemplates, *claims[volume])
+	}
+
+	strategy := opts.GC
+	if strategy == "" {
+		strategy = wfv1.VolumeClaimGCOnCompletion
+	}
+	wf.Spec.VolumeClaimGC = &wfv1.VolumeClaimGC{Strategy: strategy}
+	return nil
+}
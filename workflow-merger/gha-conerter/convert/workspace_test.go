@@ -0,0 +1,131 @@
+package convert
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// --- 共享工作区 ---
+
+// workspaceWorkflow 的 test Job 读取 build Job 写入工作区的文件
+const workspaceWorkflow = `name: ci
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make > out/app
+  test:
+    runs-on: ubuntu-latest
+    needs: build
+    steps:
+      - run: ./out/app --test
+`
+
+// workspaceMounts 按 GHA Job 名称返回步骤模板的工作区挂载，每个 Job 只有一个步骤
+func workspaceMounts(wf *wfv1.Workflow) map[string]corev1.VolumeMount {
+	mounts := map[string]corev1.VolumeMount{}
+	for _, template := range wf.Spec.Templates {
+		if template.Script == nil {
+			continue
+		}
+		for _, mount := range template.Script.VolumeMounts {
+			if mount.MountPath == DefaultWorkspacePath {
+				mounts[template.Metadata.Annotations[AnnotationGHAJob]] = mount
+			}
+		}
+	}
+	return mounts
+}
+
+func TestWorkspaceVolume(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       WorkspaceOptions
+		wantMounts map[string]corev1.VolumeMount // Job -> 工作区挂载（卷名称和子目录）
+		wantClaims []string                      // volumeClaimTemplates 的名称
+	}{
+		{
+			name:       "shared",
+			wantMounts: map[string]corev1.VolumeMount{"build": {Name: "workspace"}, "test": {Name: "workspace"}},
+			wantClaims: []string{"workspace"},
+		},
+		{
+			name:       "shared existing claim",
+			opts:       WorkspaceOptions{ExistingClaim: "workspaces"},
+			wantMounts: map[string]corev1.VolumeMount{"build": {Name: "workspace", SubPath: "{{workflow.name}}"}, "test": {Name: "workspace", SubPath: "{{workflow.name}}"}},
+		},
+		{
+			name:       "per job",
+			opts:       WorkspaceOptions{PerJob: true},
+			wantMounts: map[string]corev1.VolumeMount{"build": {Name: "workspace-build"}, "test": {Name: "workspace-test"}},
+			wantClaims: []string{"workspace-build", "workspace-test"},
+		},
+		{
+			name:       "per job existing claim",
+			opts:       WorkspaceOptions{ExistingClaim: "workspaces", PerJob: true},
+			wantMounts: map[string]corev1.VolumeMount{"build": {Name: "workspace", SubPath: "{{workflow.name}}/build"}, "test": {Name: "workspace", SubPath: "{{workflow.name}}/test"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := GHAtoArgo(context.Background(), workspaceWorkflow, ConversionOptions{Workspace: tt.opts})
+			if err != nil {
+				t.Fatalf("GHAtoArgo: %v", err)
+			}
+			wf := output.Workflow
+			mounts := workspaceMounts(wf)
+			if len(mounts) != len(tt.wantMounts) {
+				t.Fatalf("workspace mounts = %v, want %v", mounts, tt.wantMounts)
+			}
+			for job, want := range tt.wantMounts {
+				if got := mounts[job]; got.Name != want.Name || got.SubPath != want.SubPath {
+					t.Errorf("job %s mounts volume %q subPath %q, want %q subPath %q", job, got.Name, got.SubPath, want.Name, want.SubPath)
+				}
+			}
+
+			var claims []string
+			for _, claim := range wf.Spec.VolumeClaimTemplates {
+				claims = append(claims, claim.Name)
+			}
+			if strings.Join(claims, ",") != strings.Join(tt.wantClaims, ",") {
+				t.Errorf("volumeClaimTemplates = %v, want %v", claims, tt.wantClaims)
+			}
+		})
+	}
+}
+
+func TestSharedWorkspaceMergesRunnerConfig(t *testing.T) {
+	wf := &wfv1.Workflow{}
+	jobs := map[string]WorkspaceConfig{
+		"build":   {Size: "5Gi", StorageClass: "fast"},
+		"lint":    {AccessMode: corev1.ReadWriteMany},
+		"package": {Size: "2Gi", StorageClass: "slow", AccessMode: corev1.ReadWriteOnce},
+	}
+	var warnings []string
+	warn := func(code, job, format string, args ...interface{}) { warnings = append(warnings, job) }
+	if err := addWorkspaceVolume(wf, jobs, WorkspaceOptions{}, warn); err != nil {
+		t.Fatal(err)
+	}
+	if len(wf.Spec.VolumeClaimTemplates) != 1 {
+		t.Fatalf("got %d volumeClaimTemplates, want one shared workspace", len(wf.Spec.VolumeClaimTemplates))
+	}
+	claim := wf.Spec.VolumeClaimTemplates[0]
+	if size := claim.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "5Gi" {
+		t.Errorf("size = %s, want the largest size 5Gi", size.String())
+	}
+	if claim.Spec.StorageClassName == nil || *claim.Spec.StorageClassName != "fast" {
+		t.Errorf("storageClassName = %v, want fast", claim.Spec.StorageClassName)
+	}
+	if claim.Spec.AccessModes[0] != corev1.ReadWriteMany {
+		t.Errorf("accessMode = %s, want ReadWriteMany", claim.Spec.AccessModes[0])
+	}
+	// package 的存储类和访问模式与共享工作区冲突
+	if len(warnings) != 2 || warnings[0] != "package" || warnings[1] != "package" {
+		t.Errorf("warnings for jobs %v, want two for package", warnings)
+	}
+}
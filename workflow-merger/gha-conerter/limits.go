@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// --- 准入控制：请求大小限制、按客户端限流、公平排队 ---
+
+const (
+	DefaultMaxBodyBytes      = 1 << 20 // /convert 请求体的默认上限（1 MiB）
+	DefaultRateLimit         = 5.0     // 每个客户端每秒允许的默认请求数
+	DefaultRateBurst         = 10      // 每个客户端的默认突发请求数
+	DefaultMaxQueuePerClient = 20      // 每个客户端在队列中的默认最大作业数
+
+	clientIdleTTL = 10 * time.Minute // 限流器闲置多久后被回收
+)
+
+var (
+	// ErrQueueFull 表示全局队列已满
+	ErrQueueFull = errors.New("queue is full")
+	// ErrClientQueueFull 表示该客户端排队的作业已达上限
+	ErrClientQueueFull = errors.New("too many queued jobs for this client")
+)
+
+// clientKey 识别请求来源：通过认证的请求按租户区分；未认证的请求按客户端 IP 区分，
+// 不使用请求头中未经校验的值，否则客户端每次换一个 X-API-Key 即可绕过限流
+func clientKey(r *http.Request, principal *Principal) string {
+	if principal.Method != "none" {
+		return "tenant:" + principal.TenantID
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// readLimitedBody 读取至多 limit 字节的请求体，超出时返回 *http.MaxBytesError
+func readLimitedBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	defer r.Body.Close()
+	return io.ReadAll(r.Body)
+}
+
+// writeRetryAfter 设置 Retry-After 响应头（向上取整到秒，至少 1 秒）
+func writeRetryAfter(w http.ResponseWriter, delay time.Duration) {
+	seconds := int(math.Ceil(delay.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+// --- 按客户端的令牌桶限流 ---
+
+// RateLimiter 为每个客户端维护一个令牌桶
+type RateLimiter struct {
+	mu      sync.Mutex
+	limit   rate.Limit
+	burst   int
+	clients map[string]*clientLimiter
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRateLimiter 创建限流器；perSecond <= 0 表示不限流
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		limit:   rate.Limit(perSecond),
+		burst:   burst,
+		clients: make(map[string]*clientLimiter),
+	}
+}
+
+// Allow 消耗客户端的一个令牌；被拒绝时返回需要等待的时间
+func (l *RateLimiter) Allow(client string) (bool, time.Duration) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	c, ok := l.clients[client]
+	if !ok {
+		c = &clientLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[client] = c
+	}
+	c.lastSeen = now
+
+	reservation := c.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Second
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// Cleanup 周期性回收闲置客户端的限流器
+func (l *RateLimiter) Cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		l.mu.Lock()
+		for client, c := range l.clients {
+			if time.Since(c.lastSeen) > clientIdleTTL {
+				delete(l.clients, client)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// --- 跨客户端的公平队列 ---
+
+// FairQueue 为每个客户端维护独立的 FIFO 队列，并以轮询方式把作业分发给 worker，
+// 避免单个客户端的大量作业阻塞其他客户端
+type FairQueue struct {
+	mu        sync.Mutex
+	queues    map[string][]ConversionJob
+	order     []string // 有待处理作业的客户端，按轮询顺序排列
+	size      int
+	capacity  int
+	perClient int
+	notify    chan struct{}
+}
+
+// NewFairQueue 创建容量为 capacity、单客户端上限为 perClient 的公平队列
+func NewFairQueue(capacity, perClient int) *FairQueue {
+	if perClient <= 0 || perClient > capacity {
+		perClient = capacity
+	}
+	return &FairQueue{
+		queues:    make(map[string][]ConversionJob),
+		capacity:  capacity,
+		perClient: perClient,
+		notify:    make(chan struct{}, 1),
+	}
+}
+
+// Push 将作业加入客户端的队列
+func (q *FairQueue) Push(client string, job ConversionJob) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.size >= q.capacity {
+		return ErrQueueFull
+	}
+	pending := q.queues[client]
+	if len(pending) >= q.perClient {
+		return fmt.Errorf("%w (%d queued)", ErrClientQueueFull, len(pending))
+	}
+	if len(pending) == 0 {
+		q.order = append(q.order, client)
+	}
+	q.queues[client] = append(pending, job)
+	q.size++
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// pop 取出下一个客户端的队首作业
+func (q *FairQueue) pop() (ConversionJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.order) == 0 {
+		return ConversionJob{}, false
+	}
+	client := q.order[0]
+	q.order = q.order[1:]
+
+	pending := q.queues[client]
+	job := pending[0]
+	if len(pending) > 1 {
+		q.queues[client] = pending[1:]
+		q.order = append(q.order, client)
+	} else {
+		delete(q.queues, client)
+	}
+	q.size--
+	return job, true
+}
+
+// Len 返回排队中的作业总数
+func (q *FairQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// Cap 返回队列容量
+func (q *FairQueue) Cap() int {
+	return q.capacity
+}
+
+// Dispatch 持续把作业按轮询顺序交给 out，out 应为无缓冲 channel 以保持公平
+func (q *FairQueue) Dispatch(out chan<- ConversionJob) {
+	for {
+		job, ok := q.pop()
+		if !ok {
+			<-q.notify
+			continue
+		}
+		out <- job
+	}
+}
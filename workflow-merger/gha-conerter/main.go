@@ -1,376 +1,665 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"regexp"
+	"os"
 	"strings"
 	"sync"
+	"time"
 
 	// 1. Argo Workflow API 结构体
 	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
-	corev1 "k8s.io/api/core/v1"
 
-	// 2. nektos/act GHA 解析器
-	"github.com/nektos/act/pkg/model"
+	// 2. GHA -> Argo 转换
+	"gha-converter/convert"
+
+	// 3. Argo 和 Kubernetes 客户端
+	"argo-sdk/argoclient"
 
 	"github.com/google/uuid"
-	"gopkg.in/yaml.v3"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
 )
 
 // --- 线程池（Worker Pool）配置 ---
 
 const (
-	NumWorkers = 5   // 工作 Goroutine 的数量
-	MaxQueue   = 100 // 作业队列的最大缓冲
+	DefaultNumWorkers = 5   // 工作 Goroutine 的默认数量
+	DefaultMinWorkers = 1   // 自动伸缩的默认下限
+	DefaultMaxWorkers = 50  // 自动伸缩的默认上限
+	DefaultMaxQueue   = 100 // 作业队列的默认最大缓冲
+
+	DefaultJobTimeout    = 60 * time.Second       // 单个转换作业的默认超时时间
+	DefaultScaleInterval = 10 * time.Second       // 自动伸缩的默认检查周期
+	DefaultScaleUpWait   = 2 * time.Second        // 平均排队时间超过该值时扩容
+	DefaultScaleDownWait = 100 * time.Millisecond // 平均排队时间低于该值时缩容
 )
 
 // ConversionJob 定义了需要传递给 worker 的作业
 type ConversionJob struct {
-	JobID   string // 唯一的作业 ID
-	GhaYAML string // 输入的 GHA YAML
+	JobID    string                    // 唯一的作业 ID
+	TenantID string                    // 创建作业的租户，只有该租户可以查询或取消
+	GhaYAML  string                    // 输入的 GHA YAML
+	Options  convert.ConversionOptions // 按租户生成的转换选项
+	Ctx      context.Context           // 作业上下文，携带超时与取消信号
+	Cancel   context.CancelFunc        // 取消作业（排队中或运行中）
+	Queued   time.Time                 // 进入队列的时间，用于统计排队等待时长
+	Submit   *convert.SubmitOptions    // 非空时转换完成后提交到 Argo
+	Done     chan struct{}             // 非空时作业结束后关闭，供同步等待结果的处理器使用
 }
 
 // ConversionResult 定义了 worker 的处理结果
 type ConversionResult struct {
-	JobID    string // 原始作业 ID
-	ArgoYAML string // 输出的 Argo YAML
-	Error    error  // 处理过程中发生的错误
+	JobID    string                      // 原始作业 ID
+	TenantID string                      // 作业所属租户
+	ArgoYAML string                      // 输出的 Argo YAML
+	Warnings []convert.ConversionWarning // 转换过程中遇到的不支持特性
+	Workflow *convert.SubmittedWorkflow  // 提交作业创建的 Argo 工作流
+	Error    error                       // 处理过程中发生的错误
 }
 
 // 全局变量：作业队列和结果存储
+// Queue 按客户端公平排队，由分发器逐个交给无缓冲的 JobQueue
+var Queue *FairQueue
 var JobQueue chan ConversionJob
 var ResultStore *sync.Map // 使用 sync.Map 保证并发安全
+var ActiveJobs *sync.Map  // 排队中或运行中的作业: jobID -> ConversionJob
+
+// JobTimeout 为每个作业设置的截止时间，可通过 -job-timeout 配置
+var JobTimeout = DefaultJobTimeout
+
+// Pool 是处理 JobQueue 的线程池
+var Pool *WorkerPool
+
+// 准入控制：请求体上限和按客户端限流
+var MaxBodyBytes int64 = DefaultMaxBodyBytes
+var Limiter *RateLimiter
+
+// 多租户：每个租户的转换配置和 runs-on 运行环境查询
+var Tenants *TenantConfig
+var Runners convert.RunnerConfigProvider = convert.StaticRunnerConfigs{}
+
+// Cache 是 actions/cache 的全局配置
+var Cache = convert.CacheOptions{Backend: convert.CacheBackendArtifact}
+
+// Workspace 是工作区的全局配置，ExistingClaim 可按租户覆盖
+var Workspace = convert.WorkspaceOptions{GC: wfv1.VolumeClaimGCOnCompletion, DefaultSize: convert.DefaultWorkspaceSize}
+
+// Submitter 用于 /submit，未启用提交时为 nil
+var Submitter *convert.ArgoSubmitter
 
 // --- Web 服务入口 (main) ---
 
 func main() {
-	// 1. 初始化作业队列和结果存储
-	JobQueue = make(chan ConversionJob, MaxQueue)
-	ResultStore = &sync.Map{}
+	var poolConfig PoolConfig
+	flag.IntVar(&poolConfig.Workers, "workers", envInt(EnvWorkers, DefaultNumWorkers), "Initial number of workers (env "+EnvWorkers+")")
+	flag.IntVar(&poolConfig.MinWorkers, "min-workers", envInt(EnvMinWorkers, DefaultMinWorkers), "Minimum number of workers when autoscaling (env "+EnvMinWorkers+")")
+	flag.IntVar(&poolConfig.MaxWorkers, "max-workers", envInt(EnvMaxWorkers, DefaultMaxWorkers), "Maximum number of workers when autoscaling (env "+EnvMaxWorkers+")")
+	flag.DurationVar(&poolConfig.ScaleInterval, "scale-interval", envDuration(EnvScaleInterval, DefaultScaleInterval), "Autoscaling check interval, 0 disables autoscaling (env "+EnvScaleInterval+")")
+	flag.DurationVar(&poolConfig.ScaleUpWait, "scale-up-wait", envDuration(EnvScaleUpWait, DefaultScaleUpWait), "Scale up when the average queue wait exceeds this (env "+EnvScaleUpWait+")")
+	flag.DurationVar(&poolConfig.ScaleDownWait, "scale-down-wait", envDuration(EnvScaleDownWait, DefaultScaleDownWait), "Scale down when the average queue wait drops below this (env "+EnvScaleDownWait+")")
+	maxQueue := flag.Int("max-queue", envInt(EnvMaxQueue, DefaultMaxQueue), "Capacity of the job queue (env "+EnvMaxQueue+")")
+	maxQueuePerClient := flag.Int("max-queue-per-client", envInt(EnvMaxQueuePerClient, DefaultMaxQueuePerClient), "Maximum queued jobs per client (env "+EnvMaxQueuePerClient+")")
+	flag.DurationVar(&JobTimeout, "job-timeout", envDuration(EnvJobTimeout, DefaultJobTimeout), "Maximum duration of a single conversion job (env "+EnvJobTimeout+")")
+	flag.Int64Var(&MaxBodyBytes, "max-body-bytes", int64(envInt(EnvMaxBodyBytes, DefaultMaxBodyBytes)), "Maximum size of a /convert request body (env "+EnvMaxBodyBytes+")")
+	rateLimit := flag.Float64("rate-limit", envFloat(EnvRateLimit, DefaultRateLimit), "Requests per second allowed per client, 0 disables rate limiting (env "+EnvRateLimit+")")
+	rateBurst := flag.Int("rate-burst", envInt(EnvRateBurst, DefaultRateBurst), "Burst size of the per-client rate limiter (env "+EnvRateBurst+")")
+	var authConfig AuthConfig
+	flag.StringVar(&authConfig.Methods, "auth", envString(EnvAuth, "none"), "Comma-separated authentication methods: none, apikey, jwt, mtls (env "+EnvAuth+")")
+	flag.StringVar(&authConfig.APIKeysFile, "api-keys-file", os.Getenv(EnvAPIKeysFile), "JSON file with static API keys (env "+EnvAPIKeysFile+")")
+	flag.StringVar(&authConfig.JWKSFile, "jwks-file", os.Getenv(EnvJWKSFile), "JWKS file used to verify bearer tokens (env "+EnvJWKSFile+")")
+	flag.StringVar(&authConfig.JWTIssuer, "jwt-issuer", os.Getenv(EnvJWTIssuer), "Required bearer token issuer (env "+EnvJWTIssuer+")")
+	flag.StringVar(&authConfig.JWTAudience, "jwt-audience", os.Getenv(EnvJWTAudience), "Required bearer token audience (env "+EnvJWTAudience+")")
+	flag.StringVar(&authConfig.TenantClaim, "jwt-tenant-claim", envString(EnvJWTTenantClaim, "tenant"), "Bearer token claim holding the tenant ID (env "+EnvJWTTenantClaim+")")
+	flag.StringVar(&authConfig.AdminClaim, "jwt-admin-claim", envString(EnvJWTAdminClaim, "admin"), "Boolean bearer token claim granting admin access (env "+EnvJWTAdminClaim+")")
+	flag.StringVar(&authConfig.AdminTenants, "mtls-admin-tenants", os.Getenv(EnvMTLSAdminTenants), "Comma-separated certificate tenants granted admin access (env "+EnvMTLSAdminTenants+")")
+	tlsCert := flag.String("tls-cert", os.Getenv(EnvTLSCert), "Server certificate file, enables HTTPS (env "+EnvTLSCert+")")
+	tlsKey := flag.String("tls-key", os.Getenv(EnvTLSKey), "Server private key file (env "+EnvTLSKey+")")
+	tlsClientCA := flag.String("tls-client-ca", os.Getenv(EnvTLSClientCA), "CA bundle used to verify client certificates for mtls (env "+EnvTLSClientCA+")")
+	tenantConfigFile := flag.String("tenant-config", os.Getenv(EnvTenantConfig), "JSON file with per-tenant conversion settings (env "+EnvTenantConfig+")")
+	runnerNamespace := flag.String("runner-namespace", envString(EnvRunnerNamespace, "argo"), "Default namespace of runs-on ConfigMaps (env "+EnvRunnerNamespace+")")
+	runnerConfigMaps := flag.Bool("runner-configmaps", os.Getenv(EnvRunnerConfigMaps) == "true", "Look up runs-on labels in Kubernetes ConfigMaps (env "+EnvRunnerConfigMaps+")")
+	kubeconfig := flag.String("kubeconfig", "", "Path to a kubeconfig, defaults to $KUBECONFIG, ~/.kube/config or in-cluster config")
+	kubeContext := flag.String("kube-context", os.Getenv(EnvKubeContext), "Kubeconfig context to use, defaults to current-context (env "+EnvKubeContext+")")
+	submitEnabled := flag.Bool("submit", os.Getenv(EnvSubmit) == "true", "Enable POST /submit to create workflows in Argo (env "+EnvSubmit+")")
+	workflowNamespace := flag.String("argo-namespace", envString(EnvArgoNamespace, "argo"), "Default namespace for submitted workflows (env "+EnvArgoNamespace+")")
+	var argoConfig argoclient.ArgoClientConfig
+	flag.StringVar(&argoConfig.ServerURL, "argo-server", os.Getenv(EnvArgoServer), "Argo Server host:port; uses kubeconfig when empty (env "+EnvArgoServer+")")
+	flag.StringVar(&argoConfig.Token, "argo-token", os.Getenv(EnvArgoToken), "Bearer token for Argo Server (env "+EnvArgoToken+")")
+	flag.BoolVar(&argoConfig.Secure, "argo-secure", envString(EnvArgoSecure, "true") == "true", "Use TLS when connecting to Argo Server (env "+EnvArgoSecure+")")
+	flag.BoolVar(&argoConfig.InsecureSkipVerify, "argo-insecure-skip-verify", os.Getenv(EnvArgoInsecureSkipVerify) == "true", "Skip Argo Server certificate verification (env "+EnvArgoInsecureSkipVerify+")")
+	workspaceClaim := flag.String("workspace-claim", os.Getenv(EnvWorkspaceClaim), "Existing ReadWriteMany PVC holding the workspaces in a directory per run; a PVC per run is created when empty (env "+EnvWorkspaceClaim+")")
+	flag.BoolVar(&Workspace.PerJob, "workspace-per-job", os.Getenv(EnvWorkspacePerJob) == "true", "Give every job its own workspace instead of one shared by all jobs of a run (env "+EnvWorkspacePerJob+")")
+	flag.StringVar(&Workspace.DefaultSize, "workspace-size", envString(EnvWorkspaceSize, convert.DefaultWorkspaceSize), "Workspace PVC size when the runs-on ConfigMap does not set one (env "+EnvWorkspaceSize+")")
+	workspaceGC := flag.String("workspace-gc", envString(EnvWorkspaceGC, string(wfv1.VolumeClaimGCOnCompletion)), "Workspace PVC GC strategy: OnWorkflowCompletion or OnWorkflowSuccess (env "+EnvWorkspaceGC+")")
+	flag.StringVar(&Cache.Backend, "cache-backend", envString(EnvCacheBackend, convert.CacheBackendArtifact), "Backend for actions/cache: artifact, pvc or none (env "+EnvCacheBackend+")")
+	flag.StringVar(&Cache.Claim, "cache-claim", os.Getenv(EnvCacheClaim), "PVC used by the pvc cache backend (env "+EnvCacheClaim+")")
+	cacheRepository := flag.String("cache-artifact-repository", os.Getenv(EnvCacheArtifactRepository), "Artifact repository for the artifact cache backend as configmap[:key], defaults to the namespace default (env "+EnvCacheArtifactRepository+")")
+	webhookReposDir := flag.String("webhook-repos-dir", os.Getenv(EnvWebhookReposDir), "Directory of repository checkouts as <owner>/<repo>, enables POST /webhook; requires -submit (env "+EnvWebhookReposDir+")")
+	webhookSecret := flag.String("webhook-secret", os.Getenv(EnvWebhookSecret), "Secret used to verify webhook signatures; required unless -webhook-insecure (env "+EnvWebhookSecret+")")
+	webhookInsecure := flag.Bool("webhook-insecure", os.Getenv(EnvWebhookInsecure) == "true", "Accept unsigned webhook deliveries when no secret is set, for local testing only (env "+EnvWebhookInsecure+")")
+	webhookTenant := flag.String("webhook-tenant", envString(EnvWebhookTenant, DefaultTenant), "Tenant owning workflows triggered by webhooks (env "+EnvWebhookTenant+")")
+	webhookDeliveries := flag.String("webhook-deliveries", os.Getenv(EnvWebhookDeliveries), "File recording processed webhook deliveries; kept in memory only when empty (env "+EnvWebhookDeliveries+")")
+	flag.Parse()
+
+	if *maxQueue < 1 {
+		log.Fatalf("max-queue must be at least 1, got %d", *maxQueue)
+	}
+
+	// 0. 初始化认证和租户配置
+	auth, err := authConfig.Build()
+	if err != nil {
+		log.Fatalf("Invalid authentication configuration: %v", err)
+	}
+	if Cache.ArtifactRepositoryRef, err = convert.ParseArtifactRepositoryRef(*cacheRepository); err != nil {
+		log.Fatal(err)
+	}
+	if err := Cache.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if Workspace.GC, err = convert.ParseVolumeClaimGC(*workspaceGC); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := resource.ParseQuantity(Workspace.DefaultSize); err != nil {
+		log.Fatalf("Invalid workspace-size %q: %v", Workspace.DefaultSize, err)
+	}
+	Tenants, err = LoadTenantConfig(*tenantConfigFile, TenantSettings{
+		RunnerNamespace:   *runnerNamespace,
+		WorkflowNamespace: *workflowNamespace,
+		WorkspaceClaim:    *workspaceClaim,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *runnerConfigMaps {
+		client, err := argoclient.NewKubernetesClient(*kubeconfig, *kubeContext)
+		if err != nil {
+			log.Fatalf("Failed to create Kubernetes client: %v", err)
+		}
+		Runners = convert.ConfigMapRunnerConfigs{Client: client}
+	}
+	if *submitEnabled {
+		argoConfig.Kubeconfig = *kubeconfig
+		argoConfig.Context = *kubeContext
+		Submitter, err = convert.NewArgoSubmitter(argoConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *webhookReposDir != "" {
+		if Submitter == nil {
+			log.Fatal("webhook-repos-dir requires -submit")
+		}
+		deliveries, err := LoadDeliveryLog(*webhookDeliveries, DefaultDeliveryRetention)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *webhookSecret == "" && !*webhookInsecure {
+			log.Fatal("webhook-repos-dir requires -webhook-secret; use -webhook-insecure to accept unsigned deliveries")
+		}
+		if *webhookSecret == "" {
+			log.Println("Warning: webhook-insecure is set, webhook signatures are not verified")
+		}
+		Webhook = &WebhookController{
+			Secret:     []byte(*webhookSecret),
+			Insecure:   *webhookSecret == "",
+			Source:     DirWorkflowSource{Root: *webhookReposDir},
+			Tenant:     *webhookTenant,
+			Deliveries: deliveries,
+		}
+	}
 
-	// 2. 启动线程池
-	log.Printf("Starting %d workers...", NumWorkers)
-	startWorkerPool(NumWorkers, JobQueue, ResultStore)
+	// 1. 初始化作业队列、结果存储和准入控制
+	Queue = NewFairQueue(*maxQueue, *maxQueuePerClient)
+	JobQueue = make(chan ConversionJob)
+	ResultStore = &sync.Map{}
+	ActiveJobs = &sync.Map{}
+	Limiter = NewRateLimiter(*rateLimit, *rateBurst)
+	registerQueueMetrics()
+	go Queue.Dispatch(JobQueue)
+	go Limiter.Cleanup(time.Minute)
+
+	// 2. 启动线程池和自动伸缩
+	log.Printf("Starting %d workers (min %d, max %d)...", poolConfig.Workers, poolConfig.MinWorkers, poolConfig.MaxWorkers)
+	Pool, err = NewWorkerPool(poolConfig, JobQueue, Queue, ResultStore)
+	if err != nil {
+		log.Fatalf("Invalid worker pool configuration: %v", err)
+	}
+	go Pool.Autoscale(context.Background())
+
+	// 3. 设置 HTTP 路由，除 /metrics 和 /webhook 外都需要认证
+	http.Handle("/convert", requireAuth(auth, instrumentHandler("convert", handleConvert)))
+	http.Handle("/result/", requireAuth(auth, instrumentHandler("result", handleResult)))
+	http.Handle("/submit", requireAuth(auth, instrumentHandler("submit", handleSubmit)))
+	if Webhook != nil {
+		// webhook 通过签名认证，不使用 API 认证
+		http.Handle("/webhook", instrumentHandler("webhook", Webhook.handleWebhook))
+	}
+	http.Handle("/metrics", promhttp.Handler())
+	if _, ok := auth.(NoneAuthenticator); ok {
+		log.Println("Admin endpoints are disabled: -auth none cannot identify administrators")
+	} else {
+		http.Handle("/admin/workers", requireAuth(auth, requireAdmin(http.HandlerFunc(Pool.handleAdminWorkers))))
+	}
 
-	// 3. 设置 HTTP 路由
-	http.HandleFunc("/convert", handleConvert)
-	http.HandleFunc("/result/", handleGetResult)
+	// 4. 启动 Web 服务，配置证书时使用 HTTPS，配置客户端 CA 时校验客户端证书
+	if *tlsCert == "" {
+		log.Println("Starting server on :8080...")
+		if err := http.ListenAndServe(":8080", nil); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	// 4. 启动 Web 服务
-	log.Println("Starting server on :8080...")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
+	server := &http.Server{Addr: ":8080", TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12}}
+	if *tlsClientCA != "" {
+		caBundle, err := os.ReadFile(*tlsClientCA)
+		if err != nil {
+			log.Fatalf("Failed to read client CA bundle: %v", err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caBundle) {
+			log.Fatalf("No certificates found in client CA bundle %s", *tlsClientCA)
+		}
+		server.TLSConfig.ClientCAs = clientCAs
+		server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	log.Println("Starting HTTPS server on :8080...")
+	if err := server.ListenAndServeTLS(*tlsCert, *tlsKey); err != nil {
 		log.Fatal(err)
 	}
 }
 
 // --- 线程池实现 ---
 
-// startWorkerPool 启动指定数量的 worker goroutine
-func startWorkerPool(numWorkers int, jobQueue <-chan ConversionJob, resultStore *sync.Map) {
-	for i := 1; i <= numWorkers; i++ {
-		go func(workerID int) {
-			log.Printf("Worker %d started", workerID)
-			// 从作业队列中循环读取作业
-			for job := range jobQueue {
-				log.Printf("Worker %d processing job %s", workerID, job.JobID)
-
-				// 执行核心转换逻辑
-				argoWF, err := convertGHAtoArgo(job.GhaYAML)
-				result := ConversionResult{JobID: job.JobID}
-
-				if err != nil {
-					log.Printf("Worker %d failed job %s: %v", workerID, job.JobID, err)
-					result.Error = err
-				} else {
-					// 将 Argo 结构体序列化为 YAML 字符串
-					yamlBytes, marshalErr := yaml.Marshal(argoWF)
-					if marshalErr != nil {
-						result.Error = fmt.Errorf("failed to marshal Argo YAML: %v", marshalErr)
-					} else {
-						result.ArgoYAML = string(yamlBytes)
-						log.Printf("Worker %d completed job %s", workerID, job.JobID)
-					}
-				}
-
-				// 将结果存入 sync.Map
-				resultStore.Store(job.JobID, result)
+// processJob 由 worker 调用，执行单个转换作业并存储结果
+func processJob(workerID int, job ConversionJob, resultStore *sync.Map) {
+	result := ConversionResult{JobID: job.JobID, TenantID: job.TenantID}
+
+	// 作业在排队期间已被取消或超时，直接记录结果
+	if err := job.Ctx.Err(); err != nil {
+		log.Printf("Worker %d skipped job %s: %v", workerID, job.JobID, err)
+		result.Error = err
+		finishJob(resultStore, job, result)
+		return
+	}
+
+	log.Printf("Worker %d processing job %s", workerID, job.JobID)
+	workersBusy.Inc()
+	started := time.Now()
+
+	// 执行核心转换逻辑
+	output, warnings, err := convert.GHAtoArgo(job.Ctx, job.GhaYAML, job.Options)
+	result.Warnings = warnings
+
+	if err != nil {
+		log.Printf("Worker %d failed job %s: %v", workerID, job.JobID, err)
+		result.Error = err
+	} else {
+		// 将 Argo 结构体序列化为 YAML 字符串，多个 CronWorkflow 以 --- 分隔
+		documents := make([]string, 0, 1)
+		for _, object := range output.Objects() {
+			yamlBytes, marshalErr := yaml.Marshal(object)
+			if marshalErr != nil {
+				result.Error = fmt.Errorf("failed to marshal Argo YAML: %v", marshalErr)
+				break
 			}
-		}(i)
+			documents = append(documents, string(yamlBytes))
+		}
+		if result.Error == nil {
+			result.ArgoYAML = strings.Join(documents, "---\n")
+			log.Printf("Worker %d completed job %s", workerID, job.JobID)
+		}
 	}
+
+	// 提交作业：转换成功后通过 Argo API 创建工作流或 CronWorkflow
+	if result.Error == nil && job.Submit != nil {
+		result.Workflow, result.Error = Submitter.Submit(job.Ctx, output, *job.Submit)
+		if result.Error != nil {
+			log.Printf("Worker %d failed to submit job %s: %v", workerID, job.JobID, result.Error)
+		} else {
+			log.Printf("Worker %d submitted job %s as %s/%s (dry run: %v)", workerID, job.JobID, result.Workflow.Namespace, result.Workflow.Name, result.Workflow.DryRun)
+		}
+	}
+
+	observeConversion(started, result)
+	workersBusy.Dec()
+	finishJob(resultStore, job, result)
+}
+
+// finishJob 存储作业结果并释放作业上下文
+func finishJob(resultStore *sync.Map, job ConversionJob, result ConversionResult) {
+	// 将结果存入 sync.Map
+	resultStore.Store(job.JobID, result)
+	ActiveJobs.Delete(job.JobID)
+	job.Cancel()
+	if job.Done != nil {
+		close(job.Done)
+	}
+	jobsTotal.WithLabelValues(jobState(result.Error)).Inc()
+}
+
+// ErrMissingResult 表示作业已结束但结果存储中没有它的结果
+var ErrMissingResult = errors.New("job finished without a result")
+
+// loadJobResult 读取已结束作业的结果
+func loadJobResult(jobID string) (ConversionResult, bool) {
+	value, ok := ResultStore.Load(jobID)
+	if !ok {
+		return ConversionResult{}, false
+	}
+	res, ok := value.(ConversionResult)
+	return res, ok
+}
+
+// takeJobResult 读取并删除结果，用于不通过 /result 查询的同步作业
+func takeJobResult(jobID string) (ConversionResult, bool) {
+	res, ok := loadJobResult(jobID)
+	ResultStore.Delete(jobID)
+	return res, ok
 }
 
 // --- HTTP 处理器 ---
 
-// handleConvert (POST /convert) 接收 GHA YAML 并分发作业
+// handleConvert (POST /convert) 接收 GHA YAML 并分发作业，来源信息见 sourceContextFromRequest
 func handleConvert(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusInternalServerError)
+	principal, client, body, ok := admitRequest(w, r)
+	if !ok {
 		return
 	}
-	defer r.Body.Close()
 
-	if len(body) == 0 {
-		http.Error(w, "Request body is empty", http.StatusBadRequest)
+	// 作业上下文独立于请求，仅受超时和 DELETE 控制
+	job := newJob(context.Background(), principal, body)
+	job.Options.Source = sourceContextFromRequest(r)
+	if !enqueueJob(w, client, job) {
 		return
 	}
 
-	// 1. 创建新作业
-	jobID := uuid.New().String()
-	job := ConversionJob{
-		JobID:   jobID,
-		GhaYAML: string(body),
+	// 成功入队，返回 202 Accepted
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":    "processing",
+		"jobID":     job.JobID,
+		"resultURL": fmt.Sprintf("/result/%s", job.JobID),
+	})
+}
+
+// handleSubmit (POST /submit[?dryRun=true]) 转换 GHA YAML 并提交到 Argo，等待提交完成后返回工作流名称
+func handleSubmit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if Submitter == nil {
+		http.Error(w, "Submission is not enabled on this server", http.StatusNotImplemented)
+		return
 	}
 
-	// 2. 尝试将作业发送到队列
-	select {
-	case JobQueue <- job:
-		// 3. 成功分发，返回 202 Accepted
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusAccepted)
-		json.NewEncoder(w).Encode(map[string]string{
-			"status":    "processing",
-			"jobID":     jobID,
-			"resultURL": fmt.Sprintf("/result/%s", jobID),
-		})
-	default:
-		// 4. 队列已满，返回 503
-		http.Error(w, "Server busy, queue is full", http.StatusServiceUnavailable)
+	principal, client, body, ok := admitRequest(w, r)
+	if !ok {
+		return
 	}
-}
 
-// handleGetResult (GET /result/{jobID}) 查询作业结果
-func handleGetResult(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Only GET method is allowed", http.StatusMethodNotAllowed)
+	// 作业上下文继承自请求：客户端断开时转换和提交都会被取消
+	job := newJob(r.Context(), principal, body)
+	job.Options.Source = sourceContextFromRequest(r)
+	job.Submit = &convert.SubmitOptions{
+		Namespace: Tenants.For(principal.TenantID).WorkflowNamespace,
+		DryRun:    r.URL.Query().Get("dryRun") == "true",
+	}
+	job.Done = make(chan struct{})
+	if !enqueueJob(w, client, job) {
 		return
 	}
 
-	jobID := strings.TrimPrefix(r.URL.Path, "/result/")
-	if jobID == "" {
-		http.Error(w, "Job ID is missing", http.StatusBadRequest)
+	select {
+	case <-job.Done:
+	case <-r.Context().Done():
+		log.Printf("Client disconnected, submit job %s canceled", job.JobID)
 		return
 	}
 
-	// 1. 从 sync.Map 中加载结果
-	result, ok := ResultStore.Load(jobID)
+	res, ok := loadJobResult(job.JobID)
 	if !ok {
-		// 结果尚未准备好
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{
-			"status":  "not_found",
-			"message": "Job not found or still processing",
-		})
+		http.Error(w, fmt.Sprintf("Failed to submit workflow: %v", ErrMissingResult), http.StatusInternalServerError)
 		return
 	}
-
-	// 2. 转换结果类型
-	res := result.(ConversionResult)
-
-	// 3. 检查处理是否出错
 	if res.Error != nil {
-		http.Error(w, fmt.Sprintf("Failed to process job: %v", res.Error), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to submit workflow: %v", res.Error), errorStatusCode(res.Error))
 		return
 	}
 
-	// 4. 返回成功的 YAML 结果
-	w.Header().Set("Content-Type", "application/x-yaml")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(res.ArgoYAML))
+	status := http.StatusCreated
+	if res.Workflow.DryRun {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jobID":        job.JobID,
+		"kind":         res.Workflow.Kind,
+		"workflowName": res.Workflow.Name,
+		"namespace":    res.Workflow.Namespace,
+		"dryRun":       res.Workflow.DryRun,
+		"warnings":     res.Warnings,
+	})
 }
 
-// --- 核心转换逻辑 ---
+// sourceContextFromRequest 从查询参数读取来源信息：?repo=&ref=&sha=&path=&actor=&event=
+func sourceContextFromRequest(r *http.Request) convert.SourceContext {
+	query := r.URL.Query()
+	return convert.SourceContext{
+		Repository:   query.Get("repo"),
+		Ref:          query.Get("ref"),
+		SHA:          query.Get("sha"),
+		WorkflowPath: query.Get("path"),
+		Actor:        query.Get("actor"),
+		EventName:    query.Get("event"),
+	}
+}
+
+// admitRequest 执行准入控制并读取请求体；返回 false 时已写好错误响应
+func admitRequest(w http.ResponseWriter, r *http.Request) (*Principal, string, []byte, bool) {
+	// 1. 按租户限流；未启用认证时所有请求属于默认租户，按 IP 区分客户端
+	principal := principalFromContext(r.Context())
+	client := clientKey(r, principal)
+	if ok, delay := Limiter.Allow(client); !ok {
+		admissionRejections.WithLabelValues("rate_limited").Inc()
+		writeRetryAfter(w, delay)
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+		return nil, "", nil, false
+	}
 
-// convertGHAtoArgo 使用 nektos/act 解析器执行转换
-func convertGHAtoArgo(ghaYAML string) (*wfv1.Workflow, error) {
-	// 1. 使用 nektos/act/pkg/model 解析 GHA YAML
-	ghaReader := strings.NewReader(ghaYAML)
-	ghaWF, err := model.ReadWorkflow(ghaReader, false) // 添加第二个参数 false
+	// 2. 读取请求体，超过上限返回 413
+	body, err := readLimitedBody(w, r, MaxBodyBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse GHA YAML using 'act': %v", err)
-	}
-
-	// 2. 创建 Argo Workflow 基础结构
-	argoWF := &wfv1.Workflow{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "argoproj.io/v1alpha1",
-			Kind:       "Workflow",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: sanitizeName(ghaWF.Name) + "-",
-		},
-		Spec: wfv1.WorkflowSpec{
-			Templates: []wfv1.Template{},
-		},
-	}
-
-	// 3. 编排 Job (GHA Job -> Argo DAG Task)
-	var jobNames []string
-	jobTemplates := make(map[string]wfv1.Template)
-	jobDependencies := make(map[string][]string)
-
-	for jobName, ghaJob := range ghaWF.Jobs {
-		jobTemplateName := sanitizeName(jobName)
-		jobNames = append(jobNames, jobTemplateName)
-
-		// 修复：调用 Needs() 方法而不是直接访问字段
-		needs := ghaJob.Needs()
-		jobDependencies[jobTemplateName] = needs // 记录依赖
-
-		// 为 GHA Job 创建一个 Argo "steps" 模板
-		jobTemplate := wfv1.Template{
-			Name:  jobTemplateName,
-			Steps: []wfv1.ParallelSteps{}, // 修复：使用正确的类型
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			admissionRejections.WithLabelValues("body_too_large").Inc()
+			http.Error(w, fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+			return nil, "", nil, false
 		}
+		http.Error(w, "Failed to read request body", http.StatusInternalServerError)
+		return nil, "", nil, false
+	}
 
-		// GHA 步骤 -> Argo 模板
-		var stepTemplates []wfv1.Template
+	if len(body) == 0 {
+		http.Error(w, "Request body is empty", http.StatusBadRequest)
+		return nil, "", nil, false
+	}
+	return principal, client, body, true
+}
 
-		for i, ghaStep := range ghaJob.Steps {
-			stepName := sanitizeName(ghaStep.Name)
-			if stepName == "" {
-				stepName = fmt.Sprintf("step-%d", i)
-			}
-			stepTemplateName := fmt.Sprintf("%s-%s", jobTemplateName, stepName)
-
-			// a. 将 GHA step 添加到 Job 的 "steps" 序列中
-			jobTemplate.Steps = append(jobTemplate.Steps, wfv1.ParallelSteps{
-				{
-					Name:     stepName,
-					Template: stepTemplateName,
-				},
-			})
-
-			// b. 创建 GHA step 对应的 Argo Template
-			// 修复：调用 RunsOn() 方法并获取第一个运行环境
-			runsOn := ghaJob.RunsOn()
-			var baseImage string
-			if len(runsOn) > 0 {
-				baseImage = mapRunsOnToImage(runsOn[0])
-			} else {
-				baseImage = "alpine:latest"
-			}
+// newJob 创建一个带超时的新作业，parent 的取消会传递给作业
+func newJob(parent context.Context, principal *Principal, body []byte) ConversionJob {
+	ctx, cancel := context.WithTimeout(parent, JobTimeout)
+	return ConversionJob{
+		JobID:    uuid.New().String(),
+		TenantID: principal.TenantID,
+		GhaYAML:  string(body),
+		Options:  conversionOptionsFor(principal.TenantID),
+		Ctx:      ctx,
+		Cancel:   cancel,
+		Queued:   time.Now(),
+	}
+}
 
-			stepTemplate := wfv1.Template{
-				Name: stepTemplateName,
-			}
+// enqueueJob 将作业加入该客户端的公平队列；返回 false 时已写好错误响应
+func enqueueJob(w http.ResponseWriter, client string, job ConversionJob) bool {
+	ActiveJobs.Store(job.JobID, job)
+	err := Queue.Push(client, job)
+	if err == nil {
+		return true
+	}
 
-			if ghaStep.Run != "" {
-				// 转换 GHA 'run' -> Argo 'script'
-				stepTemplate.Script = &wfv1.ScriptTemplate{
-					Container: corev1.Container{ // 修复：使用 corev1.Container
-						Image:   baseImage,
-						Command: []string{"bash", "-c"}, // GHA 默认使用 bash
-					},
-					Source: ghaStep.Run,
-				}
-			} else if ghaStep.Uses != "" {
-				// 转换 GHA 'uses' -> 占位符 (Placeholder)
-				withParams := ""
-				if ghaStep.With != nil {
-					withParams = fmt.Sprintf("Parameters (with): %v", ghaStep.With)
-				}
-
-				stepTemplate.Script = &wfv1.ScriptTemplate{
-					Container: corev1.Container{ // 修复：使用 corev1.Container
-						Image:   "alpine:latest",
-						Command: []string{"sh", "-c"},
-					},
-					Source: fmt.Sprintf(`
-echo "****************************************************************"
-echo "TODO: Manually implement GHA Action: %s"
-echo "%s"
-echo "****************************************************************"
-exit 1
-`, ghaStep.Uses, withParams),
-				}
-			} else {
-				// 跳过空步骤
-				continue
-			}
-			stepTemplates = append(stepTemplates, stepTemplate)
-		}
+	ActiveJobs.Delete(job.JobID)
+	job.Cancel()
+	writeRetryAfter(w, averageRetryDelay())
+	if errors.Is(err, ErrClientQueueFull) {
+		// 该客户端排队过多，返回 429
+		admissionRejections.WithLabelValues("client_queue_full").Inc()
+		http.Error(w, fmt.Sprintf("Too many queued jobs: %v", err), http.StatusTooManyRequests)
+		return false
+	}
+	// 队列已满，返回 503
+	queueRejections.Inc()
+	http.Error(w, "Server busy, queue is full", http.StatusServiceUnavailable)
+	return false
+}
+
+// averageRetryDelay 用当前平均排队时间估算客户端应等待多久再重试
+func averageRetryDelay() time.Duration {
+	return Pool.averageWait()
+}
 
-		// 存储这个 Job 模板和它依赖的 Step 模板
-		jobTemplates[jobTemplateName] = jobTemplate
-		argoWF.Spec.Templates = append(argoWF.Spec.Templates, jobTemplate)
-		argoWF.Spec.Templates = append(argoWF.Spec.Templates, stepTemplates...)
+// handleResult (/result/{jobID}) 根据请求方法分发到查询或取消
+func handleResult(w http.ResponseWriter, r *http.Request) {
+	jobID := strings.TrimPrefix(r.URL.Path, "/result/")
+	if jobID == "" {
+		http.Error(w, "Job ID is missing", http.StatusBadRequest)
+		return
 	}
 
-	// 4. 设置 Entrypoint (入口点)
-	if len(jobNames) == 1 {
-		// 单 Job 工作流：直接以该 Job 模板为入口
-		argoWF.Spec.Entrypoint = jobNames[0]
-	} else {
-		// 多 Job 工作流：创建一个 DAG (有向无环图)
-		dagTemplate := wfv1.Template{
-			Name: "main-dag",
-			DAG:  &wfv1.DAGTemplate{},
-		}
-		for _, jobTplName := range jobNames {
-			dagTask := wfv1.DAGTask{
-				Name:     jobTplName,
-				Template: jobTplName,
-			}
-			// 添加 GHA 的 'needs' 依赖
-			if deps, ok := jobDependencies[jobTplName]; ok && len(deps) > 0 {
-				dagTask.Dependencies = deps
-			}
-			dagTemplate.DAG.Tasks = append(dagTemplate.DAG.Tasks, dagTask)
-		}
+	switch r.Method {
+	case http.MethodGet:
+		handleGetResult(w, r, jobID)
+	case http.MethodDelete:
+		handleCancelJob(w, r, jobID)
+	default:
+		http.Error(w, "Only GET and DELETE methods are allowed", http.StatusMethodNotAllowed)
+	}
+}
 
-		argoWF.Spec.Entrypoint = dagTemplate.Name
-		argoWF.Spec.Templates = append(argoWF.Spec.Templates, dagTemplate)
+// handleCancelJob (DELETE /result/{jobID}) 取消排队中或运行中的作业
+func handleCancelJob(w http.ResponseWriter, r *http.Request, jobID string) {
+	tenant := principalFromContext(r.Context()).TenantID
+	value, _ := ActiveJobs.Load(jobID)
+	job, ok := value.(ConversionJob)
+	if !ok || job.TenantID != tenant {
+		// 作业不存在、已经结束或属于其他租户（不暴露其存在）
+		status := http.StatusNotFound
+		if done, finished := loadJobResult(jobID); finished && done.TenantID == tenant {
+			status = http.StatusConflict
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "not_cancelable",
+			"message": "Job not found or already finished",
+		})
+		return
 	}
 
-	// Argo v3.5+ 需要设置 Parallelism
-	parallelism := int64(50) // 修复：使用 int64 而不是 IntOrString
-	argoWF.Spec.Parallelism = &parallelism
+	// worker 会在下一个检查点观察到取消信号并记录结果
+	job.Cancel()
+	log.Printf("Job %s canceled by client", jobID)
 
-	return argoWF, nil
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "canceling",
+		"jobID":  jobID,
+	})
 }
 
-// --- 辅助函数 ---
-
-var (
-	// 用于 K8s 名称清理 (DNS-1123 标签)
-	nonDNSSafeRegex = regexp.MustCompile(`[^a-z0-9-]+`)
-	edgeDashRegex   = regexp.MustCompile(`^-+|-+$`)
-)
+// handleGetResult (GET /result/{jobID}) 查询作业结果
+func handleGetResult(w http.ResponseWriter, r *http.Request, jobID string) {
+	// 1. 从 sync.Map 中加载结果，其他租户的作业视为不存在
+	res, ok := loadJobResult(jobID)
+	if ok && res.TenantID != principalFromContext(r.Context()).TenantID {
+		ok = false
+	}
+	if !ok {
+		// 结果尚未准备好
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "not_found",
+			"message": "Job not found or still processing",
+		})
+		return
+	}
 
-// sanitizeName 将 GHA 名称转换为 Argo/K8s 兼容的名称
-func sanitizeName(name string) string {
-	if name == "" {
-		return "unnamed"
+	// 2. 检查处理是否出错
+	if res.Error != nil {
+		http.Error(w, fmt.Sprintf("Failed to process job: %v", res.Error), errorStatusCode(res.Error))
+		return
 	}
-	name = strings.ToLower(name)
-	name = nonDNSSafeRegex.ReplaceAllString(name, "-")
-	name = edgeDashRegex.ReplaceAllString(name, "")
-	if len(name) > 63 {
-		name = name[:63]
+
+	// 3. 返回成功的 YAML 结果，告警代码通过响应头返回
+	if len(res.Warnings) > 0 {
+		codes := make([]string, 0, len(res.Warnings))
+		for _, warning := range res.Warnings {
+			codes = append(codes, warning.Code)
+		}
+		w.Header().Set("X-Conversion-Warnings", strings.Join(codes, ","))
 	}
-	return name
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(res.ArgoYAML))
 }
 
-// mapRunsOnToImage 简单映射 GHA 'runs-on' 到容器镜像
-func mapRunsOnToImage(runsOn string) string {
-	if strings.Contains(runsOn, "ubuntu-22.04") || strings.Contains(runsOn, "ubuntu-latest") {
-		return "ubuntu:22.04"
+// --- 核心转换逻辑 ---
+
+// errorStatusCode 将作业错误映射为 HTTP 状态码
+func errorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return http.StatusGone
+	case errors.Is(err, convert.ErrInvalidWorkflow):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
 	}
-	if strings.Contains(runsOn, "ubuntu-20.04") {
-		return "ubuntu:20.04"
+}
+
+// conversionOptionsFor 根据租户配置生成转换选项
+func conversionOptionsFor(tenant string) convert.ConversionOptions {
+	settings := Tenants.For(tenant)
+	workspace := Workspace
+	workspace.ExistingClaim = settings.WorkspaceClaim
+	return convert.ConversionOptions{
+		TenantID:        tenant,
+		RunnerNamespace: settings.RunnerNamespace,
+		Runners:         Runners,
+		Workspace:       workspace,
+		Cache:           Cache,
 	}
-	// 默认值
-	return "alpine:latest"
 }
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	cronworkflowpkg "github.com/argoproj/argo-workflows/v3/pkg/apiclient/cronworkflow"
+	workflowpkg "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"google.golang.org/grpc"
+
+	"gha-converter/convert"
+)
+
+// --- 假的 Argo API 服务 ---
+
+// fakeArgo 记录收到的创建请求；未实现的方法由嵌入的 nil 接口提供，被调用时 panic
+type fakeArgo struct {
+	workflowpkg.WorkflowServiceClient
+	cron fakeCronArgo
+
+	mu        sync.Mutex
+	workflows []*workflowpkg.WorkflowCreateRequest
+	err       error  // 非空时所有创建请求都返回该错误
+	onCreate  func() // 非空时在每个创建请求中调用，用于检查提交时的服务状态
+}
+
+type fakeCronArgo struct {
+	cronworkflowpkg.CronWorkflowServiceClient
+	argo *fakeArgo
+}
+
+func newFakeArgo() *fakeArgo {
+	argo := &fakeArgo{}
+	argo.cron.argo = argo
+	return argo
+}
+
+// submitter 返回连接到假服务的 ArgoSubmitter
+func (f *fakeArgo) submitter() *convert.ArgoSubmitter {
+	return convert.NewArgoSubmitterWithClient(context.Background(), f, &f.cron)
+}
+
+func (f *fakeArgo) CreateWorkflow(ctx context.Context, in *workflowpkg.WorkflowCreateRequest, opts ...grpc.CallOption) (*wfv1.Workflow, error) {
+	if f.onCreate != nil {
+		f.onCreate()
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.workflows = append(f.workflows, in)
+	created := in.Workflow.DeepCopy()
+	created.Namespace = in.Namespace
+	if created.Name == "" {
+		created.Name = created.GenerateName + "x7k2p"
+	}
+	return created, nil
+}
+
+func (f *fakeCronArgo) CreateCronWorkflow(ctx context.Context, in *cronworkflowpkg.CreateCronWorkflowRequest, opts ...grpc.CallOption) (*wfv1.CronWorkflow, error) {
+	f.argo.mu.Lock()
+	defer f.argo.mu.Unlock()
+	if f.argo.err != nil {
+		return nil, f.argo.err
+	}
+	created := in.CronWorkflow.DeepCopy()
+	created.Namespace = in.Namespace
+	return created, nil
+}
+
+// created 返回收到的工作流创建请求
+func (f *fakeArgo) created() []*workflowpkg.WorkflowCreateRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*workflowpkg.WorkflowCreateRequest(nil), f.workflows...)
+}
+
+// setupServer 初始化全局队列、结果存储和一个 worker，测试结束后恢复 Submitter
+func setupServer(t *testing.T, submitter *convert.ArgoSubmitter) {
+	t.Helper()
+	var err error
+	Tenants, err = LoadTenantConfig("", TenantSettings{RunnerNamespace: "argo", WorkflowNamespace: "workflows"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Queue = NewFairQueue(DefaultMaxQueue, DefaultMaxQueuePerClient)
+	JobQueue = make(chan ConversionJob)
+	ResultStore = &sync.Map{}
+	ActiveJobs = &sync.Map{}
+	Limiter = NewRateLimiter(0, 0)
+	Submitter = submitter
+	t.Cleanup(func() { Submitter = nil })
+
+	// 测试结束时等待 worker 退出，下一个测试才能重新设置全局变量
+	jobs, results := JobQueue, ResultStore
+	done, stopped := make(chan struct{}), make(chan struct{})
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+	})
+	go Queue.Dispatch(jobs)
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case job := <-jobs:
+				processJob(1, job, results)
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+const pushWorkflow = `name: ci
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make test
+`
+
+const scheduleWorkflow = `name: nightly
+on:
+  schedule:
+    - cron: "0 3 * * *"
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make test
+`
+
+// --- /submit ---
+
+func TestHandleSubmit(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		body       string
+		argoErr    error
+		noSubmit   bool
+		wantStatus int
+		wantKind   string
+		wantDryRun bool
+		wantCreate int // 假服务收到的 Workflow 创建请求数
+	}{
+		{name: "workflow", method: http.MethodPost, body: pushWorkflow, wantStatus: http.StatusCreated, wantKind: "Workflow", wantCreate: 1},
+		{name: "dry run", method: http.MethodPost, query: "?dryRun=true", body: pushWorkflow, wantStatus: http.StatusOK, wantKind: "Workflow", wantDryRun: true, wantCreate: 1},
+		{name: "cron workflow", method: http.MethodPost, body: scheduleWorkflow, wantStatus: http.StatusCreated, wantKind: "CronWorkflow"},
+		{name: "invalid workflow", method: http.MethodPost, body: "jobs: [", wantStatus: http.StatusUnprocessableEntity},
+		{name: "argo error", method: http.MethodPost, body: pushWorkflow, argoErr: errors.New("admission webhook denied the request"), wantStatus: http.StatusInternalServerError},
+		{name: "method not allowed", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed},
+		{name: "submission disabled", method: http.MethodPost, body: pushWorkflow, noSubmit: true, wantStatus: http.StatusNotImplemented},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argo := newFakeArgo()
+			argo.err = tt.argoErr
+			submitter := argo.submitter()
+			if tt.noSubmit {
+				submitter = nil
+			}
+			setupServer(t, submitter)
+
+			req := httptest.NewRequest(tt.method, "/submit"+tt.query, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handleSubmit(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := len(argo.created()); got != tt.wantCreate {
+				t.Errorf("CreateWorkflow called %d times, want %d", got, tt.wantCreate)
+			}
+			if tt.wantKind == "" {
+				return
+			}
+
+			var resp struct {
+				JobID        string `json:"jobID"`
+				Kind         string `json:"kind"`
+				WorkflowName string `json:"workflowName"`
+				Namespace    string `json:"namespace"`
+				DryRun       bool   `json:"dryRun"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid response %q: %v", rec.Body, err)
+			}
+			if resp.Kind != tt.wantKind || resp.DryRun != tt.wantDryRun {
+				t.Errorf("kind = %q, dryRun = %v, want %q, %v", resp.Kind, resp.DryRun, tt.wantKind, tt.wantDryRun)
+			}
+			if resp.Namespace != "workflows" || resp.WorkflowName == "" || resp.JobID == "" {
+				t.Errorf("unexpected response %+v", resp)
+			}
+			for _, req := range argo.created() {
+				if req.Namespace != "workflows" || req.ServerDryRun != tt.wantDryRun {
+					t.Errorf("CreateWorkflow namespace = %q, dryRun = %v", req.Namespace, req.ServerDryRun)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// --- Prometheus 指标 ---
+
+const metricsNamespace = "gha_converter"
+
+// 作业的最终状态，用作 jobs_total 等指标的 state 标签
+const (
+	JobStateSucceeded = "succeeded"
+	JobStateFailed    = "failed"
+	JobStateCanceled  = "canceled"
+	JobStateTimeout   = "timeout"
+)
+
+var (
+	jobsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs_total",
+		Help:      "Number of conversion jobs by final state.",
+	}, []string{"state"})
+
+	conversionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "conversion_duration_seconds",
+		Help:      "Time spent by a worker converting a single job.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"state"})
+
+	queueWaitDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "queue_wait_seconds",
+		Help:      "Time a job spent in JobQueue before a worker picked it up.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	})
+
+	queueRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "queue_rejections_total",
+		Help:      "Number of /convert requests rejected with 503 because the job queue was full.",
+	})
+
+	admissionRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "admission_rejections_total",
+		Help:      "Number of /convert requests rejected by admission control, by reason.",
+	}, []string{"reason"})
+
+	unsupportedFeatures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "unsupported_feature_warnings_total",
+		Help:      "Number of unsupported-feature warnings emitted during conversion, by warning code.",
+	}, []string{"code"})
+
+	workersBusy = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "workers_busy",
+		Help:      "Number of workers currently converting a job.",
+	})
+
+	workersTotal = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "workers",
+		Help:      "Number of workers in the pool.",
+	})
+
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by handler, method and status code.",
+	}, []string{"handler", "method", "code"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by handler.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"handler", "method", "code"})
+)
+
+// registerQueueMetrics 注册队列长度和容量指标，必须在 Queue 初始化之后调用
+func registerQueueMetrics() {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "queue_length",
+		Help:      "Number of jobs waiting to be picked up by a worker.",
+	}, func() float64 { return float64(Queue.Len()) })
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "queue_capacity",
+		Help:      "Capacity of the job queue.",
+	}, func() float64 { return float64(Queue.Cap()) })
+}
+
+// instrumentHandler 为 HTTP 处理器记录请求数和延迟
+func instrumentHandler(name string, handler http.HandlerFunc) http.Handler {
+	labels := prometheus.Labels{"handler": name}
+	return promhttp.InstrumentHandlerCounter(
+		httpRequests.MustCurryWith(labels),
+		promhttp.InstrumentHandlerDuration(httpDuration.MustCurryWith(labels), handler),
+	)
+}
+
+// jobState 根据作业错误得出最终状态
+func jobState(err error) string {
+	switch {
+	case err == nil:
+		return JobStateSucceeded
+	case errors.Is(err, context.DeadlineExceeded):
+		return JobStateTimeout
+	case errors.Is(err, context.Canceled):
+		return JobStateCanceled
+	default:
+		return JobStateFailed
+	}
+}
+
+// observeConversion 记录一次转换的耗时和告警
+func observeConversion(started time.Time, result ConversionResult) {
+	conversionDuration.WithLabelValues(jobState(result.Error)).Observe(time.Since(started).Seconds())
+	for _, warning := range result.Warnings {
+		unsupportedFeatures.WithLabelValues(warning.Code).Inc()
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// --- 可伸缩的线程池 ---
+
+// PoolConfig 定义线程池的大小限制和自动伸缩策略
+type PoolConfig struct {
+	Workers       int           // 初始 worker 数量
+	MinWorkers    int           // 自动伸缩下限
+	MaxWorkers    int           // 自动伸缩上限
+	ScaleInterval time.Duration // 自动伸缩检查周期，0 表示关闭自动伸缩
+	ScaleUpWait   time.Duration // 平均排队时间超过该值时扩容
+	ScaleDownWait time.Duration // 平均排队时间低于该值且队列为空时缩容
+}
+
+// WorkerPool 管理一组从 JobQueue 读取作业的 worker，支持运行时调整大小
+type WorkerPool struct {
+	mu          sync.Mutex
+	config      PoolConfig
+	jobQueue    <-chan ConversionJob
+	queue       *FairQueue // 排队中的作业，用于伸缩决策和状态查询
+	resultStore *sync.Map
+	stops       []chan struct{} // 每个 worker 的停止信号，按启动顺序排列
+	nextID      int
+
+	waitMu   sync.Mutex
+	waitEWMA float64 // 排队等待时间的指数移动平均（秒）
+}
+
+// waitEWMAWeight 是新样本在排队时间移动平均中的权重
+const waitEWMAWeight = 0.2
+
+// NewWorkerPool 创建线程池并启动 config.Workers 个 worker
+func NewWorkerPool(config PoolConfig, jobQueue <-chan ConversionJob, queue *FairQueue, resultStore *sync.Map) (*WorkerPool, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	pool := &WorkerPool{
+		config:      config,
+		jobQueue:    jobQueue,
+		queue:       queue,
+		resultStore: resultStore,
+	}
+	pool.Resize(config.Workers)
+	return pool, nil
+}
+
+// validate 检查线程池配置是否自洽
+func (c PoolConfig) validate() error {
+	if c.MinWorkers < 1 {
+		return fmt.Errorf("min workers must be at least 1, got %d", c.MinWorkers)
+	}
+	if c.MaxWorkers < c.MinWorkers {
+		return fmt.Errorf("max workers (%d) must not be less than min workers (%d)", c.MaxWorkers, c.MinWorkers)
+	}
+	if c.Workers < c.MinWorkers || c.Workers > c.MaxWorkers {
+		return fmt.Errorf("workers (%d) must be between %d and %d", c.Workers, c.MinWorkers, c.MaxWorkers)
+	}
+	return nil
+}
+
+// Size 返回当前 worker 数量
+func (p *WorkerPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.stops)
+}
+
+// Resize 将 worker 数量调整为 n（限制在 [MinWorkers, MaxWorkers] 内），返回调整后的数量
+// 被停止的 worker 会先完成手头的作业再退出
+func (p *WorkerPool) Resize(n int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if n < p.config.MinWorkers {
+		n = p.config.MinWorkers
+	}
+	if n > p.config.MaxWorkers {
+		n = p.config.MaxWorkers
+	}
+
+	for len(p.stops) < n {
+		p.nextID++
+		stop := make(chan struct{})
+		p.stops = append(p.stops, stop)
+		go p.runWorker(p.nextID, stop)
+	}
+	for len(p.stops) > n {
+		last := len(p.stops) - 1
+		close(p.stops[last])
+		p.stops = p.stops[:last]
+	}
+
+	workersTotal.Set(float64(n))
+	return n
+}
+
+// SetLimits 调整自动伸缩的上下限，并把当前大小收敛到新范围内
+func (p *WorkerPool) SetLimits(minWorkers, maxWorkers int) error {
+	p.mu.Lock()
+	config := p.config
+	config.MinWorkers, config.MaxWorkers = minWorkers, maxWorkers
+	config.Workers = min(max(len(p.stops), minWorkers), maxWorkers)
+	if err := config.validate(); err != nil {
+		p.mu.Unlock()
+		return err
+	}
+	p.config = config
+	p.mu.Unlock()
+
+	p.Resize(config.Workers)
+	return nil
+}
+
+// runWorker 是单个 worker 的主循环
+func (p *WorkerPool) runWorker(workerID int, stop <-chan struct{}) {
+	log.Printf("Worker %d started", workerID)
+	for {
+		select {
+		case <-stop:
+			log.Printf("Worker %d stopped", workerID)
+			return
+		case job, ok := <-p.jobQueue:
+			if !ok {
+				return
+			}
+			p.observeWait(time.Since(job.Queued))
+			processJob(workerID, job, p.resultStore)
+		}
+	}
+}
+
+// observeWait 记录一次排队等待时间
+func (p *WorkerPool) observeWait(wait time.Duration) {
+	queueWaitDuration.Observe(wait.Seconds())
+
+	p.waitMu.Lock()
+	p.waitEWMA = waitEWMAWeight*wait.Seconds() + (1-waitEWMAWeight)*p.waitEWMA
+	p.waitMu.Unlock()
+}
+
+// averageWait 返回排队等待时间的移动平均
+func (p *WorkerPool) averageWait() time.Duration {
+	p.waitMu.Lock()
+	defer p.waitMu.Unlock()
+	return time.Duration(p.waitEWMA * float64(time.Second))
+}
+
+// Autoscale 按排队等待时间周期性地调整 worker 数量，直到 ctx 结束
+func (p *WorkerPool) Autoscale(ctx context.Context) {
+	p.mu.Lock()
+	config := p.config
+	p.mu.Unlock()
+	if config.ScaleInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(config.ScaleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.scaleOnce()
+		}
+	}
+}
+
+// scaleOnce 执行一次伸缩决策：排队过久则扩容一倍，空闲则缩容一个
+func (p *WorkerPool) scaleOnce() {
+	p.mu.Lock()
+	config := p.config
+	size := len(p.stops)
+	p.mu.Unlock()
+
+	wait := p.averageWait()
+	switch {
+	case wait > config.ScaleUpWait && size < config.MaxWorkers:
+		newSize := p.Resize(size * 2)
+		log.Printf("Autoscale: average queue wait %v, workers %d -> %d", wait, size, newSize)
+	case wait < config.ScaleDownWait && p.queue.Len() == 0 && size > config.MinWorkers:
+		newSize := p.Resize(size - 1)
+		log.Printf("Autoscale: average queue wait %v, workers %d -> %d", wait, size, newSize)
+	}
+
+	// 队列为空时让移动平均逐步回落，避免一次突发长期阻止缩容
+	if p.queue.Len() == 0 {
+		p.observeIdle()
+	}
+}
+
+// observeIdle 在没有新样本时衰减排队时间移动平均
+func (p *WorkerPool) observeIdle() {
+	p.waitMu.Lock()
+	p.waitEWMA *= 1 - waitEWMAWeight
+	p.waitMu.Unlock()
+}
+
+// --- 管理接口 ---
+
+// poolStatus 是 /admin/workers 的响应体
+type poolStatus struct {
+	Workers     int     `json:"workers"`
+	MinWorkers  int     `json:"minWorkers"`
+	MaxWorkers  int     `json:"maxWorkers"`
+	QueueLength int     `json:"queueLength"`
+	QueueCap    int     `json:"queueCapacity"`
+	AverageWait float64 `json:"averageWaitSeconds"`
+}
+
+// poolResizeRequest 是 PUT /admin/workers 的请求体，未设置的字段保持不变
+type poolResizeRequest struct {
+	Workers    *int `json:"workers"`
+	MinWorkers *int `json:"minWorkers"`
+	MaxWorkers *int `json:"maxWorkers"`
+}
+
+// handleAdminWorkers (GET/PUT /admin/workers) 查询或调整线程池大小
+func (p *WorkerPool) handleAdminWorkers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req poolResizeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if req.MinWorkers != nil || req.MaxWorkers != nil {
+			p.mu.Lock()
+			minWorkers, maxWorkers := p.config.MinWorkers, p.config.MaxWorkers
+			p.mu.Unlock()
+			if req.MinWorkers != nil {
+				minWorkers = *req.MinWorkers
+			}
+			if req.MaxWorkers != nil {
+				maxWorkers = *req.MaxWorkers
+			}
+			if err := p.SetLimits(minWorkers, maxWorkers); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if req.Workers != nil {
+			size := p.Resize(*req.Workers)
+			log.Printf("Worker pool resized to %d by admin request", size)
+		}
+	default:
+		http.Error(w, "Only GET and PUT methods are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p.mu.Lock()
+	status := poolStatus{
+		Workers:     len(p.stops),
+		MinWorkers:  p.config.MinWorkers,
+		MaxWorkers:  p.config.MaxWorkers,
+		QueueLength: p.queue.Len(),
+		QueueCap:    p.queue.Cap(),
+	}
+	p.mu.Unlock()
+	status.AverageWait = p.averageWait().Seconds()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,34 @@
+package main
+
+import "workflow-parser/ghawf"
+
+// --- on 触发条件匹配 ---
+
+// WebhookEvent 是一次 webhook 投递：投递 ID 和事件；触发条件由 ghawf.MatchTrigger 判断，与 simulate 一致
+type WebhookEvent struct {
+	ghawf.EventContext
+	Delivery string
+}
+
+// changedFilesFromPayload 汇总 push 负载中各提交新增、修改和删除的文件；负载没有提交列表时返回 nil
+func changedFilesFromPayload(payload map[string]interface{}) []string {
+	commits, ok := payload["commits"].([]interface{})
+	if !ok {
+		return nil
+	}
+	files := []string{}
+	seen := map[string]bool{}
+	for _, c := range commits {
+		commit, _ := c.(map[string]interface{})
+		for _, key := range []string{"added", "modified", "removed"} {
+			list, _ := commit[key].([]interface{})
+			for _, item := range list {
+				if file, ok := item.(string); ok && !seen[file] {
+					seen[file] = true
+					files = append(files, file)
+				}
+			}
+		}
+	}
+	return files
+}
@@ -6,6 +6,7 @@ toolchain go1.24.9
 
 require (
 	github.com/nektos/act v0.2.82
+	golang.org/x/time v0.11.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"math"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
 
 	// 1. 导入 act 的 model 包
 	"github.com/nektos/act/pkg/model"
+	"golang.org/x/time/rate"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,13 +27,73 @@ import (
 // #############################################################################
 
 const (
-	MaxWorkers = 5   // 池中最大 "worker" (goroutine) 数量
-	MaxQueue   = 100 // 任务队列的最大容量
+	DefaultMaxWorkers = 5   // 池中 "worker" (goroutine) 的默认数量
+	DefaultMaxQueue   = 100 // 任务队列的默认容量
+
+	DefaultJobTimeout = 60 * time.Second // 单个任务的默认超时时间
+
+	DefaultMaxBodyBytes = 1 << 20 // 请求体的默认上限（1 MiB）
+	DefaultRateLimit    = 5.0     // 每个客户端每秒允许的默认请求数
+	DefaultRateBurst    = 10      // 每个客户端的默认突发请求数
+)
+
+// 可通过命令行参数或环境变量配置的运行参数
+var (
+	MaxWorkers = DefaultMaxWorkers // -workers / WORKFLOW_MERGER_WORKERS
+	MaxQueue   = DefaultMaxQueue   // -max-queue / WORKFLOW_MERGER_MAX_QUEUE
+	JobTimeout = DefaultJobTimeout // -job-timeout / WORKFLOW_MERGER_JOB_TIMEOUT
+
+	MaxBodyBytes int64 = DefaultMaxBodyBytes // -max-body-bytes / WORKFLOW_MERGER_MAX_BODY_BYTES
+	RateLimit          = DefaultRateLimit    // -rate-limit / WORKFLOW_MERGER_RATE_LIMIT，0 表示不限流
+	RateBurst          = DefaultRateBurst    // -rate-burst / WORKFLOW_MERGER_RATE_BURST
 )
 
+// envInt 读取整数环境变量，未设置或无效时返回默认值
+func envInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("忽略无效的环境变量 %s=%q: %v", name, value, err)
+		return def
+	}
+	return n
+}
+
+// envFloat 读取浮点数环境变量，未设置或无效时返回默认值
+func envFloat(name string, def float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("忽略无效的环境变量 %s=%q: %v", name, value, err)
+		return def
+	}
+	return f
+}
+
+// envDuration 读取时长环境变量（如 "30s"），未设置或无效时返回默认值
+func envDuration(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("忽略无效的环境变量 %s=%q: %v", name, value, err)
+		return def
+	}
+	return d
+}
+
 // ConversionJob 定义了我们的 "任务"
 // 它包含了需要处理的数据，以及一个用于回传结果的 channel
 type ConversionJob struct {
+	Ctx        context.Context       // 任务上下文：客户端断开或超时都会取消它
 	Payload    []byte                // 原始的 YAML 数据
 	ResultChan chan ConversionResult // 用于回传结果的通道
 }
@@ -50,15 +120,23 @@ func StartWorkerPool() {
 
 			// 3. Worker 循环地从 JobQueue 中读取任务
 			for job := range JobQueue {
+				// 任务在排队期间已被取消（客户端断开或超时），直接丢弃
+				if err := job.Ctx.Err(); err != nil {
+					log.Printf("Worker %d 跳过已取消的任务: %v", workerID, err)
+					continue
+				}
+
 				log.Printf("Worker %d 开始处理任务", workerID)
 
 				// 4. 执行 "转换" 逻辑
-				convertedData, err := convertWorkflow(job.Payload)
+				convertedData, err := convertWorkflow(job.Ctx, job.Payload)
 
 				// 5. 将结果通过 ResultChan 发送回给提交者 (HTTP 处理器)
-				job.ResultChan <- ConversionResult{
-					Data:  convertedData,
-					Error: err,
+				// 如果提交者已经离开，不再阻塞等待
+				select {
+				case job.ResultChan <- ConversionResult{Data: convertedData, Error: err}:
+				case <-job.Ctx.Done():
+					log.Printf("Worker %d 丢弃结果，任务已取消: %v", workerID, job.Ctx.Err())
 				}
 			}
 		}(i)
@@ -76,7 +154,7 @@ type SimplifiedWorkflow struct {
 }
 
 // convertWorkflow 是核心的转换函数
-func convertWorkflow(yamlData []byte) (string, error) {
+func convertWorkflow(ctx context.Context, yamlData []byte) (string, error) {
 	var workflow model.Workflow
 
 	// 1. 使用 gopkg.in/yaml.v3 将 YAML 字节流解析到 act 的 model.Workflow 结构体中
@@ -89,6 +167,9 @@ func convertWorkflow(yamlData []byte) (string, error) {
 	// 你可以在这里实现你自己的复杂转换逻辑，例如转为 GitLab CI 或 Jenkinsfile 格式
 	jobIDs := make([]string, 0, len(workflow.Jobs))
 	for jobID := range workflow.Jobs {
+		if err := ctx.Err(); err != nil {
+			return "", fmt.Errorf("转换已取消: %w", err)
+		}
 		jobIDs = append(jobIDs, jobID)
 	}
 
@@ -111,6 +192,57 @@ func convertWorkflow(yamlData []byte) (string, error) {
 // 3. Golang Web 服务
 // #############################################################################
 
+// clientLimiters 为每个客户端 IP 维护一个令牌桶
+var (
+	clientLimitersMu sync.Mutex
+	clientLimiters   = make(map[string]*rate.Limiter)
+)
+
+// clientKey 按客户端 IP 识别请求来源；服务没有认证，不使用 X-API-Key 等请求头，
+// 否则客户端每次换一个值即可绕过限流
+func clientKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// allowRequest 消耗客户端的一个令牌，被拒绝时返回建议的重试等待时间
+func allowRequest(client string) (bool, time.Duration) {
+	if RateLimit <= 0 {
+		return true, 0
+	}
+
+	clientLimitersMu.Lock()
+	limiter, ok := clientLimiters[client]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(RateLimit), RateBurst)
+		clientLimiters[client] = limiter
+	}
+	clientLimitersMu.Unlock()
+
+	now := time.Now()
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Second
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// setRetryAfter 设置 Retry-After 响应头（向上取整到秒，至少 1 秒）
+func setRetryAfter(w http.ResponseWriter, delay time.Duration) {
+	seconds := int(math.Ceil(delay.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
 // handleConversion 是我们的 API 处理器
 func handleConversion(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -118,9 +250,22 @@ func handleConversion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 1. 读取请求体 (YAML)
+	// 0. 按客户端限流
+	if ok, delay := allowRequest(clientKey(r)); !ok {
+		setRetryAfter(w, delay)
+		http.Error(w, "请求过于频繁", http.StatusTooManyRequests)
+		return
+	}
+
+	// 1. 读取请求体 (YAML)，超过上限返回 413
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodyBytes)
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("请求体超过 %d 字节", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, "读取请求体失败", http.StatusBadRequest)
 		return
 	}
@@ -132,10 +277,14 @@ func handleConversion(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 2. 创建一个用于接收此特定请求结果的 channel
-	resultChan := make(chan ConversionResult)
+	// 带 1 个缓冲，worker 回传结果时不会因提交者离开而阻塞
+	resultChan := make(chan ConversionResult, 1)
 
-	// 3. 创建一个新任务
+	// 3. 创建一个新任务，上下文继承自请求：客户端断开时任务随之取消
+	ctx, cancel := context.WithTimeout(r.Context(), JobTimeout)
+	defer cancel()
 	job := ConversionJob{
+		Ctx:        ctx,
 		Payload:    body,
 		ResultChan: resultChan,
 	}
@@ -146,6 +295,7 @@ func handleConversion(w http.ResponseWriter, r *http.Request) {
 		log.Println("任务已提交到队列")
 	default:
 		// 如果 JobQueue 已满，立即返回错误
+		setRetryAfter(w, time.Second)
 		http.Error(w, "服务繁忙，任务队列已满", http.StatusServiceUnavailable)
 		return
 	}
@@ -154,7 +304,19 @@ func handleConversion(w http.ResponseWriter, r *http.Request) {
 	// 注意：这里 HTTP 处理器会阻塞，直到 worker 处理完毕
 	// 这是一种同步的 API 风格，但后端处理是并发池化的
 	log.Println("等待任务结果...")
-	result := <-resultChan
+	var result ConversionResult
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			log.Printf("任务超时: %v", ctx.Err())
+			http.Error(w, "转换超时", http.StatusGatewayTimeout)
+			return
+		}
+		// 客户端已断开，无需再写响应
+		log.Printf("客户端已断开，任务取消: %v", ctx.Err())
+		return
+	}
 
 	// 6. 处理结果
 	if result.Error != nil {
@@ -171,6 +333,18 @@ func handleConversion(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
+	flag.IntVar(&MaxWorkers, "workers", envInt("WORKFLOW_MERGER_WORKERS", DefaultMaxWorkers), "worker 数量 (环境变量 WORKFLOW_MERGER_WORKERS)")
+	flag.IntVar(&MaxQueue, "max-queue", envInt("WORKFLOW_MERGER_MAX_QUEUE", DefaultMaxQueue), "任务队列容量 (环境变量 WORKFLOW_MERGER_MAX_QUEUE)")
+	flag.DurationVar(&JobTimeout, "job-timeout", envDuration("WORKFLOW_MERGER_JOB_TIMEOUT", DefaultJobTimeout), "单个转换任务的最长执行时间 (环境变量 WORKFLOW_MERGER_JOB_TIMEOUT)")
+	flag.Int64Var(&MaxBodyBytes, "max-body-bytes", int64(envInt("WORKFLOW_MERGER_MAX_BODY_BYTES", DefaultMaxBodyBytes)), "请求体最大字节数 (环境变量 WORKFLOW_MERGER_MAX_BODY_BYTES)")
+	flag.Float64Var(&RateLimit, "rate-limit", envFloat("WORKFLOW_MERGER_RATE_LIMIT", DefaultRateLimit), "每个客户端每秒允许的请求数，0 表示不限流 (环境变量 WORKFLOW_MERGER_RATE_LIMIT)")
+	flag.IntVar(&RateBurst, "rate-burst", envInt("WORKFLOW_MERGER_RATE_BURST", DefaultRateBurst), "每个客户端的突发请求数 (环境变量 WORKFLOW_MERGER_RATE_BURST)")
+	flag.Parse()
+
+	if MaxWorkers < 1 || MaxQueue < 1 {
+		log.Fatalf("workers 和 max-queue 必须大于 0 (workers=%d, max-queue=%d)", MaxWorkers, MaxQueue)
+	}
+
 	// 启动 Worker 池
 	StartWorkerPool()
 	log.Println("Worker 池已启动")
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// --- 请求体上限和限流 ---
+
+// withLimits 在测试期间替换限流参数，并清空已有的令牌桶
+func withLimits(t *testing.T, maxBodyBytes int64, limit float64, burst int) {
+	t.Helper()
+	oldBody, oldLimit, oldBurst := MaxBodyBytes, RateLimit, RateBurst
+	MaxBodyBytes, RateLimit, RateBurst = maxBodyBytes, limit, burst
+	resetLimiters := func() {
+		clientLimitersMu.Lock()
+		clientLimiters = make(map[string]*rate.Limiter)
+		clientLimitersMu.Unlock()
+	}
+	resetLimiters()
+	t.Cleanup(func() {
+		MaxBodyBytes, RateLimit, RateBurst = oldBody, oldLimit, oldBurst
+		resetLimiters()
+	})
+}
+
+func postConversion(remoteAddr, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/convert", strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	handleConversion(rec, req)
+	return rec
+}
+
+func TestHandleConversionBodyLimit(t *testing.T) {
+	withLimits(t, 16, 0, 0)
+
+	rec := postConversion("192.0.2.1:1234", strings.Repeat("a", 17))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d: %s", rec.Code, http.StatusRequestEntityTooLarge, rec.Body)
+	}
+	// 空请求体在上限之内，按普通的参数错误处理
+	if rec := postConversion("192.0.2.1:1234", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty body status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleConversionRateLimit(t *testing.T) {
+	withLimits(t, DefaultMaxBodyBytes, 0.5, 2)
+
+	// 突发额度内的请求通过限流，空请求体返回 400
+	for i := 0; i < 2; i++ {
+		if rec := postConversion("192.0.2.1:1234", ""); rec.Code != http.StatusBadRequest {
+			t.Fatalf("request %d status = %d, want %d", i, rec.Code, http.StatusBadRequest)
+		}
+	}
+	rec := postConversion("192.0.2.1:5678", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if seconds, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || seconds < 1 || seconds > 2 {
+		t.Errorf("Retry-After = %q, want 1-2 seconds", rec.Header().Get("Retry-After"))
+	}
+
+	// X-API-Key 不影响限流，其他 IP 有自己的令牌桶
+	req := httptest.NewRequest(http.MethodPost, "/convert", strings.NewReader(""))
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-API-Key", "another-key")
+	rec = httptest.NewRecorder()
+	handleConversion(rec, req)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("changing X-API-Key bypassed the limit: status = %d", rec.Code)
+	}
+	if rec := postConversion("198.51.100.7:1234", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("other client status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nektos/act/pkg/model"
+
+	"workflow-parser/ghawf"
+)
+
+// --- 命令行 ---
+
+// defaultWorkflowDir 是没有指定文件时检查的工作流目录
+const defaultWorkflowDir = ".argus/workflows"
+
+// runLint 检查工作流文件并输出结果，返回进程退出码：有 error 级别的问题时为 1，无法读取或解析时为 2
+func runLint(files []string, format, runnersDir, runnerLabels, secrets string) int {
+	if format != "text" && format != "sarif" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q, must be text or sarif\n", format)
+		return 2
+	}
+	opts := ghawf.LintOptions{RunnerLabels: map[string]bool{}, Secrets: map[string]bool{}}
+	if runnersDir != "" {
+		labels, err := ghawf.LoadRunnerConfigMaps(runnersDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to load runner ConfigMaps: %v\n", err)
+			return 2
+		}
+		opts.RunnerLabels = labels
+	}
+	for _, label := range splitList(runnerLabels) {
+		opts.RunnerLabels[ghawf.SanitizeName(label)] = true
+	}
+	for _, secret := range splitList(secrets) {
+		opts.Secrets[secret] = true
+	}
+
+	if len(files) == 0 {
+		for _, pattern := range []string{"*.yml", "*.yaml"} {
+			matches, _ := filepath.Glob(filepath.Join(defaultWorkflowDir, pattern))
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+	}
+
+	var findings []ghawf.Finding
+	status := 0
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			status = 2
+			continue
+		}
+		result, err := ghawf.LintWorkflow(file, src, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			status = 2
+			continue
+		}
+		findings = append(findings, result...)
+	}
+
+	if format == "sarif" {
+		if err := ghawf.WriteSARIF(os.Stdout, findings); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	} else {
+		for _, f := range findings {
+			fmt.Println(f)
+		}
+	}
+	for _, f := range findings {
+		if f.Level == ghawf.LevelError && status == 0 {
+			status = 1
+		}
+	}
+	return status
+}
+
+// splitList 拆分逗号分隔的列表，忽略空元素
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// runPlan 为工作流文件或目录创建执行计划并按格式输出，返回进程退出码
+func runPlan(paths []string, event, format string) int {
+	if len(paths) == 0 {
+		paths = []string{defaultWorkflowDir}
+	}
+	if len(paths) > 1 {
+		fmt.Fprintf(os.Stderr, "Error: -plan takes one workflow file or directory\n")
+		return 2
+	}
+	planner, err := model.NewWorkflowPlanner(paths[0], false, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to load workflows: %v\n", err)
+		return 2
+	}
+	var plan *model.Plan
+	if event == "" {
+		plan, err = planner.PlanAll()
+	} else {
+		plan, err = planner.PlanEvent(event)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to create plan: %v\n", err)
+		return 2
+	}
+
+	report := ghawf.NewPlanReport(plan, event)
+	switch format {
+	case "text":
+		printPlan(plan)
+	case "json":
+		err = ghawf.WritePlanJSON(os.Stdout, report)
+	case "dot":
+		err = ghawf.WriteDOT(os.Stdout, ghawf.PlanGraph(report))
+	case "mermaid":
+		err = ghawf.WriteMermaid(os.Stdout, ghawf.PlanGraph(report))
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q, must be text, json, dot or mermaid\n", format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+// runSimulate 模拟事件并输出结果，返回进程退出码
+func runSimulate(paths []string, event, payload, changedFiles, format string) int {
+	if event == "" {
+		fmt.Fprintf(os.Stderr, "Error: -simulate needs -event\n")
+		return 2
+	}
+	if len(paths) == 0 {
+		paths = []string{defaultWorkflowDir}
+	}
+	ctx, err := ghawf.LoadEventContext(event, payload, changedFiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	var workflows []*model.Workflow
+	for _, path := range paths {
+		loaded, err := ghawf.LoadWorkflows(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		workflows = append(workflows, loaded...)
+	}
+
+	plan := ghawf.SimulateEvent(workflows, ctx)
+	switch format {
+	case "text":
+		ghawf.WriteEventPlanText(os.Stdout, plan)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(plan); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q, must be text or json\n", format)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,545 @@
+package ghawf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nektos/act/pkg/exprparser"
+	"github.com/nektos/act/pkg/model"
+)
+
+// --- 按事件模拟执行 ---
+
+// EventContext 是一次触发的事件：事件名称、事件负载和变更的文件
+type EventContext struct {
+	Name    string
+	Payload map[string]interface{}
+	// ChangedFiles 是事件涉及的文件；为 nil 表示未知，此时不检查 paths 过滤器
+	ChangedFiles []string
+	// StrictPaths 为 true 时，文件未知的事件不触发声明了 paths 或 paths-ignore 的工作流，
+	// 用于不能补全文件列表的场景（如 webhook 的 pull_request 负载不含文件）
+	StrictPaths bool
+}
+
+// EventPlan 是事件对一组工作流的模拟结果
+type EventPlan struct {
+	Event     string            `json:"event"`
+	Ref       string            `json:"ref,omitempty"`
+	Workflows []WorkflowTrigger `json:"workflows"`
+}
+
+// WorkflowTrigger 是一个工作流是否被触发，以及被触发时每个 Job 是否运行
+type WorkflowTrigger struct {
+	File      string        `json:"file"`
+	Name      string        `json:"name,omitempty"`
+	Triggered bool          `json:"triggered"`
+	Reason    string        `json:"reason,omitempty"` // 未触发的原因
+	Notes     []string      `json:"notes,omitempty"`  // 无法模拟、按通过处理的条件
+	Jobs      []JobDecision `json:"jobs,omitempty"`
+}
+
+// JobDecision 是一个 Job 在模拟中是否运行；依赖的 Job 都假定成功
+type JobDecision struct {
+	JobID  string `json:"jobId"`
+	Run    bool   `json:"run"`
+	Reason string `json:"reason,omitempty"` // 跳过的原因
+}
+
+// defaultActivityTypes 是没有配置 types 时触发工作流的活动类型，未列出的事件接受所有类型
+var defaultActivityTypes = map[string][]string{
+	"pull_request":        {"opened", "synchronize", "reopened"},
+	"pull_request_target": {"opened", "synchronize", "reopened"},
+}
+
+// giteaActions 把 Gitea 的活动类型映射为 GitHub 的名称
+var giteaActions = map[string]string{
+	"synchronized": "synchronize",
+}
+
+// LoadEventContext 读取事件负载 JSON 和变更文件列表；路径为空时对应内容为空，changedFiles 为 - 时从 stdin 读取
+func LoadEventContext(name, payloadPath, changedFilesPath string) (EventContext, error) {
+	event := EventContext{Name: name, Payload: map[string]interface{}{}}
+	if payloadPath != "" {
+		data, err := os.ReadFile(payloadPath)
+		if err != nil {
+			return event, fmt.Errorf("failed to read event payload: %w", err)
+		}
+		if err := json.Unmarshal(data, &event.Payload); err != nil {
+			return event, fmt.Errorf("failed to parse event payload %s: %w", payloadPath, err)
+		}
+	}
+	if changedFilesPath != "" {
+		var data []byte
+		var err error
+		if changedFilesPath == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(changedFilesPath)
+		}
+		if err != nil {
+			return event, fmt.Errorf("failed to read changed files: %w", err)
+		}
+		event.ChangedFiles = []string{}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				event.ChangedFiles = append(event.ChangedFiles, line)
+			}
+		}
+	}
+	return event, nil
+}
+
+// LoadWorkflows 读取工作流文件，或目录中的所有 .yml/.yaml 文件，按文件名排序
+func LoadWorkflows(path string) ([]*model.Workflow, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		for _, pattern := range []string{"*.yml", "*.yaml"} {
+			matches, _ := filepath.Glob(filepath.Join(path, pattern))
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+	}
+	var workflows []*model.Workflow
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		wf, err := model.ReadWorkflow(f, false)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to parse workflow: %w", file, err)
+		}
+		wf.File = filepath.Base(file)
+		workflows = append(workflows, wf)
+	}
+	return workflows, nil
+}
+
+// SimulateEvent 判断每个工作流是否被事件触发：检查 on 中的事件、branches/tags、paths 和 types 过滤器，
+// 再按依赖顺序计算 Job 级 if，依赖的 Job 假定成功
+func SimulateEvent(workflows []*model.Workflow, event EventContext) EventPlan {
+	github := GitHubContextFor(event)
+	plan := EventPlan{Event: event.Name, Ref: github.Ref, Workflows: []WorkflowTrigger{}}
+	for _, wf := range workflows {
+		trigger := WorkflowTrigger{File: wf.File, Name: wf.Name}
+		trigger.Triggered, trigger.Reason, trigger.Notes = MatchTrigger(wf, event, github)
+		if trigger.Triggered {
+			trigger.Jobs = simulateJobs(wf, event, github)
+		}
+		plan.Workflows = append(plan.Workflows, trigger)
+	}
+	return plan
+}
+
+// GitHubContextFor 从事件负载得出 github 上下文中的 ref、sha 等字段，GitHub 和 Gitea 的负载结构相同
+func GitHubContextFor(event EventContext) *model.GithubContext {
+	p := event.Payload
+	github := &model.GithubContext{
+		Event:      p,
+		EventName:  event.Name,
+		Ref:        PayloadString(p, "ref"),
+		Sha:        PayloadString(p, "after"),
+		Repository: PayloadString(p, "repository", "full_name"),
+		Actor:      PayloadString(p, "sender", "login"),
+		Action:     PayloadString(p, "action"),
+	}
+	github.RepositoryOwner, _, _ = strings.Cut(github.Repository, "/")
+	switch event.Name {
+	case "pull_request", "pull_request_target", "pull_request_review", "pull_request_review_comment":
+		github.HeadRef = PayloadString(p, "pull_request", "head", "ref")
+		github.BaseRef = PayloadString(p, "pull_request", "base", "ref")
+		github.Sha = PayloadString(p, "pull_request", "head", "sha")
+		if number := PayloadString(p, "number"); number != "" && event.Name != "pull_request_target" {
+			github.Ref = "refs/pull/" + number + "/merge"
+		} else if github.BaseRef != "" {
+			github.Ref = "refs/heads/" + github.BaseRef
+		}
+	case "workflow_dispatch", "schedule":
+		if branch := PayloadString(p, "repository", "default_branch"); github.Ref == "" && branch != "" {
+			github.Ref = "refs/heads/" + branch
+		}
+	}
+	switch {
+	case strings.HasPrefix(github.Ref, "refs/heads/"):
+		github.RefName, github.RefType = strings.TrimPrefix(github.Ref, "refs/heads/"), "branch"
+	case strings.HasPrefix(github.Ref, "refs/tags/"):
+		github.RefName, github.RefType = strings.TrimPrefix(github.Ref, "refs/tags/"), "tag"
+	default:
+		github.RefName = strings.TrimPrefix(github.Ref, "refs/")
+	}
+	return github
+}
+
+// MatchTrigger 检查工作流的 on 是否匹配事件，返回是否触发、不触发的原因和未检查的条件；
+// github 由 GitHubContextFor 得出，webhook 控制器和 SimulateEvent 使用同一套规则
+func MatchTrigger(wf *model.Workflow, event EventContext, github *model.GithubContext) (bool, string, []string) {
+	found := false
+	for _, name := range wf.On() {
+		found = found || name == event.Name
+	}
+	if !found {
+		return false, fmt.Sprintf("not triggered by %s, on: %s", event.Name, strings.Join(wf.On(), ", ")), nil
+	}
+	filters, _ := wf.OnEvent(event.Name).(map[string]interface{})
+	var notes []string
+
+	// 1. types
+	types := filterList(filters, "types")
+	if types == nil {
+		types = defaultActivityTypes[event.Name]
+	}
+	if len(types) > 0 {
+		action := PayloadString(event.Payload, "action")
+		if mapped, ok := giteaActions[action]; ok {
+			action = mapped
+		}
+		switch {
+		case action == "":
+			notes = append(notes, "types not checked: the payload has no action")
+		case !containsString(types, action):
+			return false, fmt.Sprintf("activity type %q is not in types [%s]", action, strings.Join(types, ", ")), nil
+		}
+	}
+
+	// 2. branches 和 tags：pull_request 按目标分支匹配；只配置了分支或标签过滤器时，另一类 ref 不触发
+	branches, branchesIgnore := filterList(filters, "branches"), filterList(filters, "branches-ignore")
+	tags, tagsIgnore := filterList(filters, "tags"), filterList(filters, "tags-ignore")
+	hasBranchFilter, hasTagFilter := branches != nil || branchesIgnore != nil, tags != nil || tagsIgnore != nil
+	ref, kind := github.RefName, "branch"
+	if strings.HasPrefix(event.Name, "pull_request") {
+		ref = github.BaseRef
+	} else if github.RefType == "tag" {
+		kind = "tag"
+	}
+	switch {
+	case !hasBranchFilter && !hasTagFilter:
+	case ref == "":
+		notes = append(notes, "branches and tags not checked: the payload has no ref")
+	case kind == "tag" && !hasTagFilter:
+		return false, fmt.Sprintf("tag %s does not run: only branch filters are configured", ref), nil
+	case kind == "tag":
+		if reason := matchFilter("tag", ref, tags, tagsIgnore); reason != "" {
+			return false, reason, nil
+		}
+	case !hasBranchFilter && event.Name == "push":
+		return false, fmt.Sprintf("branch %s does not run: only tag filters are configured", ref), nil
+	default:
+		if reason := matchFilter("branch", ref, branches, branchesIgnore); reason != "" {
+			return false, reason, nil
+		}
+	}
+
+	// 3. paths：至少一个文件匹配 paths；paths-ignore 要求至少一个文件不被忽略
+	paths, pathsIgnore := filterList(filters, "paths"), filterList(filters, "paths-ignore")
+	if paths != nil || pathsIgnore != nil {
+		switch {
+		case event.ChangedFiles == nil && event.StrictPaths:
+			return false, "paths cannot be checked: the changed files are unknown", nil
+		case event.ChangedFiles == nil:
+			notes = append(notes, "paths not checked: the changed files are unknown")
+		case paths != nil && !anyMatch(event.ChangedFiles, paths):
+			return false, fmt.Sprintf("no changed file matches paths [%s]", strings.Join(paths, ", ")), nil
+		case pathsIgnore != nil && allMatch(event.ChangedFiles, pathsIgnore):
+			return false, fmt.Sprintf("all changed files match paths-ignore [%s]", strings.Join(pathsIgnore, ", ")), nil
+		}
+	}
+	return true, "", notes
+}
+
+// matchFilter 检查 ref 是否满足包含和排除过滤器，不满足时返回原因
+func matchFilter(kind, ref string, include, exclude []string) string {
+	if include != nil && !matchPatterns(ref, include) {
+		return fmt.Sprintf("%s %s does not match [%s]", kind, ref, strings.Join(include, ", "))
+	}
+	if exclude != nil && matchPatterns(ref, exclude) {
+		return fmt.Sprintf("%s %s is ignored by [%s]", kind, ref, strings.Join(exclude, ", "))
+	}
+	return ""
+}
+
+func anyMatch(files, patterns []string) bool {
+	for _, file := range files {
+		if matchPatterns(file, patterns) {
+			return true
+		}
+	}
+	return false
+}
+
+func allMatch(files, patterns []string) bool {
+	for _, file := range files {
+		if !matchPatterns(file, patterns) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPatterns 按 GHA 过滤器语义匹配：模式依次生效，! 开头的模式排除之前匹配的值
+func matchPatterns(value string, patterns []string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		if negated := strings.HasPrefix(pattern, "!"); negated {
+			if matched && filterRegex(pattern[1:]).MatchString(value) {
+				matched = false
+			}
+		} else if !matched && filterRegex(pattern).MatchString(value) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// filterRegex 把 GHA 过滤器模式转换为正则：* 不匹配 /，** 匹配任意字符，**/ 还可以匹配空目录前缀（**/README.md 匹配根目录的 README.md），
+// ? 和 + 作用于前一个字符，[] 为字符集合
+func filterRegex(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			switch {
+			case strings.HasPrefix(pattern[i:], "**/"):
+				b.WriteString("(?:.*/)?")
+				i += 2
+			case strings.HasPrefix(pattern[i:], "**"):
+				b.WriteString(".*")
+				i++
+			default:
+				b.WriteString("[^/]*")
+			}
+		case '?', '+':
+			b.WriteByte(c)
+		case '[':
+			if end := strings.IndexByte(pattern[i:], ']'); end > 0 {
+				b.WriteString(pattern[i : i+end+1])
+				i += end
+			} else {
+				b.WriteString(`\[`)
+			}
+		case '\\':
+			if i+1 < len(pattern) {
+				b.WriteString(regexp.QuoteMeta(pattern[i+1 : i+2]))
+				i++
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return regexp.MustCompile("^" + regexp.QuoteMeta(pattern) + "$")
+	}
+	return re
+}
+
+// simulateJobs 按依赖顺序计算每个 Job 的 if；Job 运行时假定成功，跳过时依赖它的 Job 默认也被跳过。
+// act 的 success() 等状态函数从 Run.Workflow 的 Job.Result 读取依赖的结果，因此结果记录在 Job 的副本上，不修改调用方的 wf
+func simulateJobs(wf *model.Workflow, event EventContext, github *model.GithubContext) []JobDecision {
+	inputs := dispatchInputs(wf, event)
+	simulated := *wf
+	simulated.Jobs = make(map[string]*model.Job, len(wf.Jobs))
+	for id, job := range wf.Jobs {
+		copied := *job
+		copied.Result = ""
+		simulated.Jobs[id] = &copied
+	}
+	wf = &simulated
+	var decisions []JobDecision
+	for _, id := range jobOrder(wf) {
+		job := wf.Jobs[id]
+		needs := map[string]exprparser.Needs{}
+		var skippedNeeds []string
+		for _, need := range job.Needs() {
+			result := "skipped"
+			if dep, ok := wf.Jobs[need]; ok && dep.Result != "" {
+				result = dep.Result
+			}
+			if result == "skipped" {
+				skippedNeeds = append(skippedNeeds, need)
+			}
+			needs[need] = exprparser.Needs{Outputs: map[string]string{}, Result: result}
+		}
+
+		decision := JobDecision{JobID: id, Run: true}
+		env := &exprparser.EvaluationEnvironment{
+			Github:   github,
+			Env:      map[string]string{},
+			Job:      &model.JobContext{Status: "success"},
+			Steps:    map[string]*model.StepResult{},
+			Runner:   map[string]interface{}{"os": "Linux", "arch": "X64"},
+			Secrets:  map[string]string{},
+			Vars:     map[string]string{},
+			Strategy: map[string]interface{}{},
+			Matrix:   map[string]interface{}{},
+			Needs:    needs,
+			Inputs:   inputs,
+		}
+		interpreter := exprparser.NewInterpeter(env, exprparser.Config{Run: &model.Run{Workflow: wf, JobID: id}, Context: "job"})
+		condition := strings.TrimSpace(job.If.Value)
+		value, err := interpreter.Evaluate(condition, exprparser.DefaultStatusCheckSuccess)
+		switch {
+		case err != nil:
+			decision.Run, decision.Reason = false, fmt.Sprintf("if cannot be evaluated: %v", err)
+		case exprparser.IsTruthy(value):
+		case len(skippedNeeds) > 0:
+			decision.Run, decision.Reason = false, fmt.Sprintf("needs %s skipped", strings.Join(skippedNeeds, ", "))
+		default:
+			decision.Run, decision.Reason = false, fmt.Sprintf("if evaluated to false: %s", condition)
+		}
+		job.Result = "skipped"
+		if decision.Run {
+			job.Result = "success"
+		}
+		decisions = append(decisions, decision)
+	}
+	return decisions
+}
+
+// jobOrder 返回 Job 的拓扑顺序，同一层按 Job ID 排序；循环依赖中的 Job 排在最后
+func jobOrder(wf *model.Workflow) []string {
+	ids := wf.GetJobIDs()
+	sort.Strings(ids)
+	done := map[string]bool{}
+	var order []string
+	for len(order) < len(ids) {
+		var ready []string
+		for _, id := range ids {
+			if done[id] {
+				continue
+			}
+			blocked := false
+			for _, need := range wf.Jobs[id].Needs() {
+				_, exists := wf.Jobs[need]
+				blocked = blocked || (exists && !done[need])
+			}
+			if !blocked {
+				ready = append(ready, id)
+			}
+		}
+		if len(ready) == 0 {
+			for _, id := range ids {
+				if !done[id] {
+					ready = append(ready, id)
+				}
+			}
+		}
+		for _, id := range ready {
+			done[id] = true
+		}
+		order = append(order, ready...)
+	}
+	return order
+}
+
+// dispatchInputs 返回 inputs 上下文：workflow_dispatch 输入的默认值，被负载中的 inputs 覆盖
+func dispatchInputs(wf *model.Workflow, event EventContext) map[string]interface{} {
+	inputs := map[string]interface{}{}
+	if dispatch := wf.WorkflowDispatchConfig(); dispatch != nil {
+		for name, input := range dispatch.Inputs {
+			if input.Type == "boolean" {
+				inputs[name] = input.Default == "true"
+			} else {
+				inputs[name] = input.Default
+			}
+		}
+	}
+	if values, ok := event.Payload["inputs"].(map[string]interface{}); ok {
+		for name, value := range values {
+			inputs[name] = value
+		}
+	}
+	return inputs
+}
+
+// filterList 读取过滤器中的字符串列表，未配置时返回 nil
+func filterList(filters map[string]interface{}, key string) []string {
+	raw, ok := filters[key]
+	if !ok {
+		return nil
+	}
+	list := []string{}
+	switch v := raw.(type) {
+	case string:
+		list = append(list, v)
+	case []interface{}:
+		for _, item := range v {
+			list = append(list, fmt.Sprint(item))
+		}
+	}
+	return list
+}
+
+// PayloadString 按路径读取负载中的值，数字等非字符串值格式化为字符串，不存在时返回空
+func PayloadString(payload map[string]interface{}, path ...string) string {
+	var value interface{} = payload
+	for _, key := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = object[key]
+	}
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%v", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteEventPlanText 以文本输出模拟结果
+func WriteEventPlanText(w io.Writer, plan EventPlan) {
+	fmt.Fprintf(w, "Event: %s", plan.Event)
+	if plan.Ref != "" {
+		fmt.Fprintf(w, " (%s)", plan.Ref)
+	}
+	fmt.Fprintln(w)
+	for _, trigger := range plan.Workflows {
+		name := trigger.File
+		if trigger.Name != "" {
+			name += " (" + trigger.Name + ")"
+		}
+		if !trigger.Triggered {
+			fmt.Fprintf(w, "  - %s: not triggered: %s\n", name, trigger.Reason)
+			continue
+		}
+		fmt.Fprintf(w, "  + %s: triggered\n", name)
+		for _, note := range trigger.Notes {
+			fmt.Fprintf(w, "      note: %s\n", note)
+		}
+		for _, job := range trigger.Jobs {
+			if job.Run {
+				fmt.Fprintf(w, "      + %s\n", job.JobID)
+			} else {
+				fmt.Fprintf(w, "      - %s: skipped: %s\n", job.JobID, job.Reason)
+			}
+		}
+	}
+}
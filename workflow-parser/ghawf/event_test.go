@@ -0,0 +1,277 @@
+package ghawf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+// --- 按事件模拟执行 ---
+
+func TestMatchPatterns(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		value    string
+		want     bool
+	}{
+		{[]string{"main"}, "main", true},
+		{[]string{"main"}, "main-2", false},
+		{[]string{"releases/*"}, "releases/v1", true},
+		{[]string{"releases/*"}, "releases/v1/hotfix", false},
+		{[]string{"releases/**"}, "releases/v1/hotfix", true},
+		{[]string{"**"}, "a/b/c", true},
+		{[]string{"**/README.md"}, "README.md", true},
+		{[]string{"**/README.md"}, "docs/README.md", true},
+		{[]string{"**/README.md"}, "docs/guide/README.md", true},
+		{[]string{"**/README.md"}, "xREADME.md", false},
+		{[]string{"docs/**/*.md"}, "docs/index.md", true},
+		{[]string{"docs/**/*.md"}, "docs/a/b/index.md", true},
+		{[]string{"*.go"}, "pkg/main.go", false},
+		{[]string{"**.go"}, "pkg/main.go", true},
+		{[]string{"v?.0"}, ".0", true}, // ? 作用于前一个字符：v 出现零次或一次
+		{[]string{"v?.0"}, "v.0", true},
+		{[]string{"v?.0"}, "vv.0", false},
+		{[]string{"v1+"}, "v111", true},
+		{[]string{"v1+"}, "v", false},
+		{[]string{"v[12].x"}, "v2.x", true},
+		{[]string{"v[12].x"}, "v3.x", false},
+		{[]string{"v[0-9]"}, "v7", true},
+		{[]string{"a.b"}, "axb", false}, // 其他正则元字符按字面匹配
+		{[]string{`\*`}, "*", true},
+		{[]string{"feature/**", "!feature/wip-*"}, "feature/login", true},
+		{[]string{"feature/**", "!feature/wip-*"}, "feature/wip-1", false},
+		// 模式依次生效：排除之后的模式可以重新包含
+		{[]string{"feature/**", "!feature/wip-*", "feature/wip-keep"}, "feature/wip-keep", true},
+		{[]string{"!feature/wip-*", "feature/**"}, "feature/wip-1", true},
+		{[]string{"!main"}, "main", false},
+	}
+	for _, tt := range tests {
+		if got := matchPatterns(tt.value, tt.patterns); got != tt.want {
+			t.Errorf("matchPatterns(%q, %q) = %v, want %v (regex %s)", tt.value, tt.patterns, got, tt.want, filterRegex(tt.patterns[len(tt.patterns)-1]))
+		}
+	}
+}
+
+// readWorkflow 解析测试用的工作流，File 设为 name
+func readWorkflow(t *testing.T, name, src string) *model.Workflow {
+	t.Helper()
+	wf, err := model.ReadWorkflow(strings.NewReader(src), false)
+	if err != nil {
+		t.Fatalf("ReadWorkflow: %v", err)
+	}
+	wf.File = name
+	return wf
+}
+
+func TestMatchTrigger(t *testing.T) {
+	const jobs = "jobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - run: make\n"
+	push := func(ref string, files ...string) EventContext {
+		return EventContext{Name: "push", Payload: map[string]interface{}{"ref": ref}, ChangedFiles: files}
+	}
+	pullRequest := func(action, base string, files ...string) EventContext {
+		return EventContext{Name: "pull_request", ChangedFiles: files, Payload: map[string]interface{}{
+			"action": action, "number": 7.0,
+			"pull_request": map[string]interface{}{"base": map[string]interface{}{"ref": base}, "head": map[string]interface{}{"ref": "feature"}},
+		}}
+	}
+	strict := func(event EventContext) EventContext {
+		event.StrictPaths = true
+		return event
+	}
+	tests := []struct {
+		name      string
+		on        string
+		event     EventContext
+		want      bool
+		wantNotes []string
+		wantWhy   string // 不触发的原因应包含的文本
+	}{
+		{name: "other event", on: "on: pull_request\n", event: push("refs/heads/main"), wantWhy: "not triggered by push, on: pull_request"},
+		{name: "no filters", on: "on: push\n", event: push("refs/heads/main"), want: true},
+		{name: "branch matches", on: "on:\n  push:\n    branches: [main, 'releases/**']\n", event: push("refs/heads/releases/v1/rc"), want: true},
+		{name: "branch does not match", on: "on:\n  push:\n    branches: [main]\n", event: push("refs/heads/dev"), wantWhy: "branch dev does not match [main]"},
+		{name: "branch ignored", on: "on:\n  push:\n    branches-ignore: ['wip/*']\n", event: push("refs/heads/wip/x"), wantWhy: "branch wip/x is ignored by [wip/*]"},
+		{name: "tag without tag filter", on: "on:\n  push:\n    branches: [main]\n", event: push("refs/tags/v1"), wantWhy: "only branch filters are configured"},
+		{name: "tag matches", on: "on:\n  push:\n    tags: ['v*']\n", event: push("refs/tags/v1.2"), want: true},
+		{name: "tag ignored", on: "on:\n  push:\n    tags-ignore: ['v*-rc']\n", event: push("refs/tags/v1-rc"), wantWhy: "tag v1-rc is ignored"},
+		{name: "branch without branch filter", on: "on:\n  push:\n    tags: ['v*']\n", event: push("refs/heads/main"), wantWhy: "only tag filters are configured"},
+		{name: "paths match", on: "on:\n  push:\n    paths: ['**/README.md']\n", event: push("refs/heads/main", "README.md"), want: true},
+		{name: "paths do not match", on: "on:\n  push:\n    paths: ['src/**']\n", event: push("refs/heads/main", "docs/a.md"), wantWhy: "no changed file matches paths [src/**]"},
+		{name: "paths with negation", on: "on:\n  push:\n    paths: ['src/**', '!src/**/*.md']\n", event: push("refs/heads/main", "src/a/README.md"), wantWhy: "no changed file matches"},
+		{name: "paths-ignore with one relevant file", on: "on:\n  push:\n    paths-ignore: ['docs/**']\n", event: push("refs/heads/main", "docs/a.md", "main.go"), want: true},
+		{name: "paths-ignore covers every file", on: "on:\n  push:\n    paths-ignore: ['docs/**', '**/*.md']\n", event: push("refs/heads/main", "docs/a.md", "README.md"), wantWhy: "all changed files match paths-ignore"},
+		{name: "paths unknown", on: "on:\n  push:\n    paths: ['src/**']\n", event: push("refs/heads/main"), want: true, wantNotes: []string{"paths not checked: the changed files are unknown"}},
+		{name: "paths unknown with strict paths", on: "on:\n  pull_request:\n    paths-ignore: ['docs/**']\n", event: strict(pullRequest("opened", "main")), wantWhy: "paths cannot be checked: the changed files are unknown"},
+		{name: "strict paths without paths filter", on: "on: pull_request\n", event: strict(pullRequest("opened", "main")), want: true},
+		{name: "pull_request default types", on: "on:\n  pull_request:\n    branches: [main]\n", event: pullRequest("synchronize", "main"), want: true},
+		{name: "gitea activity type", on: "on: pull_request\n", event: pullRequest("synchronized", "main"), want: true},
+		{name: "pull_request closed", on: "on: pull_request\n", event: pullRequest("closed", "main"), wantWhy: `activity type "closed" is not in types [opened, synchronize, reopened]`},
+		{name: "pull_request types", on: "on:\n  pull_request:\n    types: [closed]\n", event: pullRequest("closed", "main"), want: true},
+		{name: "pull_request base branch", on: "on:\n  pull_request:\n    branches: [main]\n", event: pullRequest("opened", "dev"), wantWhy: "branch dev does not match [main]"},
+		{name: "no action and no ref", on: "on:\n  pull_request:\n    branches: [main]\n", event: EventContext{Name: "pull_request", Payload: map[string]interface{}{}}, want: true, wantNotes: []string{"types not checked: the payload has no action", "branches and tags not checked: the payload has no ref"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := readWorkflow(t, "ci.yml", tt.on+jobs)
+			got, why, notes := MatchTrigger(wf, tt.event, GitHubContextFor(tt.event))
+			if got != tt.want {
+				t.Errorf("triggered = %v (%s), want %v", got, why, tt.want)
+			}
+			if !strings.Contains(why, tt.wantWhy) || (tt.wantWhy == "") != (why == "") {
+				t.Errorf("reason = %q, want %q", why, tt.wantWhy)
+			}
+			if !reflect.DeepEqual(notes, tt.wantNotes) {
+				t.Errorf("notes = %q, want %q", notes, tt.wantNotes)
+			}
+		})
+	}
+}
+
+func TestGitHubContextFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   EventContext
+		wantRef string
+		wantSha string
+		refName string
+		refType string
+	}{
+		{name: "push branch", event: EventContext{Name: "push", Payload: map[string]interface{}{"ref": "refs/heads/main", "after": "abc"}}, wantRef: "refs/heads/main", wantSha: "abc", refName: "main", refType: "branch"},
+		{name: "push tag", event: EventContext{Name: "push", Payload: map[string]interface{}{"ref": "refs/tags/v1"}}, wantRef: "refs/tags/v1", refName: "v1", refType: "tag"},
+		{
+			name: "pull_request",
+			event: EventContext{Name: "pull_request", Payload: map[string]interface{}{"number": 7.0, "pull_request": map[string]interface{}{
+				"base": map[string]interface{}{"ref": "main"}, "head": map[string]interface{}{"ref": "feature", "sha": "def"}}}},
+			wantRef: "refs/pull/7/merge", wantSha: "def", refName: "pull/7/merge",
+		},
+		{name: "workflow_dispatch", event: EventContext{Name: "workflow_dispatch", Payload: map[string]interface{}{"repository": map[string]interface{}{"default_branch": "trunk"}}}, wantRef: "refs/heads/trunk", refName: "trunk", refType: "branch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			github := GitHubContextFor(tt.event)
+			if github.Ref != tt.wantRef || github.Sha != tt.wantSha || github.RefName != tt.refName || github.RefType != tt.refType {
+				t.Errorf("ref %q sha %q ref_name %q ref_type %q, want %q %q %q %q", github.Ref, github.Sha, github.RefName, github.RefType, tt.wantRef, tt.wantSha, tt.refName, tt.refType)
+			}
+		})
+	}
+}
+
+func TestSimulateJobs(t *testing.T) {
+	wf := readWorkflow(t, "ci.yml", `on:
+  push:
+  workflow_dispatch:
+    inputs:
+      deploy:
+        type: boolean
+        default: false
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make
+  deploy:
+    needs: build
+    if: inputs.deploy
+    runs-on: ubuntu-latest
+    steps:
+      - run: make deploy
+  notify:
+    needs: deploy
+    runs-on: ubuntu-latest
+    steps:
+      - run: make notify
+  cleanup:
+    needs: deploy
+    if: always()
+    runs-on: ubuntu-latest
+    steps:
+      - run: make clean
+  tagged:
+    needs: build
+    if: startsWith(github.ref, 'refs/tags/')
+    runs-on: ubuntu-latest
+    steps:
+      - run: make release
+  broken:
+    if: fromJSON('{')
+    runs-on: ubuntu-latest
+    steps:
+      - run: make
+`)
+	tests := []struct {
+		name  string
+		event EventContext
+		want  []JobDecision
+	}{
+		{
+			name:  "skipped job propagates to its needs",
+			event: EventContext{Name: "push", Payload: map[string]interface{}{"ref": "refs/heads/main"}},
+			want: []JobDecision{
+				{JobID: "broken", Reason: "if cannot be evaluated"},
+				{JobID: "build", Run: true},
+				{JobID: "deploy", Reason: "if evaluated to false: inputs.deploy"},
+				{JobID: "tagged", Reason: "if evaluated to false"},
+				{JobID: "cleanup", Run: true},
+				{JobID: "notify", Reason: "needs deploy skipped"},
+			},
+		},
+		{
+			name:  "dispatch inputs",
+			event: EventContext{Name: "workflow_dispatch", Payload: map[string]interface{}{"ref": "refs/tags/v1", "inputs": map[string]interface{}{"deploy": true}}},
+			want: []JobDecision{
+				{JobID: "broken", Reason: "if cannot be evaluated"},
+				{JobID: "build", Run: true},
+				{JobID: "deploy", Run: true},
+				{JobID: "tagged", Run: true},
+				{JobID: "cleanup", Run: true},
+				{JobID: "notify", Run: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := simulateJobs(wf, tt.event, GitHubContextFor(tt.event))
+			if len(got) != len(tt.want) {
+				t.Fatalf("decisions = %+v, want %d", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got[i].JobID != want.JobID || got[i].Run != want.Run || !strings.Contains(got[i].Reason, want.Reason) || (want.Reason == "") != (got[i].Reason == "") {
+					t.Errorf("decision %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+
+	// 模拟结果不写入调用方的工作流，同一个工作流可以按不同事件重复模拟
+	for id, job := range wf.Jobs {
+		if job.Result != "" {
+			t.Errorf("job %s result = %q after simulation, want it untouched", id, job.Result)
+		}
+	}
+}
+
+func TestSimulateEvent(t *testing.T) {
+	workflows := []*model.Workflow{
+		readWorkflow(t, "ci.yml", "name: ci\non:\n  push:\n    branches: [main]\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - run: make\n"),
+		readWorkflow(t, "docs.yml", "on:\n  push:\n    paths: ['docs/**']\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - run: make\n"),
+	}
+	plan := SimulateEvent(workflows, EventContext{Name: "push", Payload: map[string]interface{}{"ref": "refs/heads/main"}, ChangedFiles: []string{"main.go"}})
+	if plan.Event != "push" || plan.Ref != "refs/heads/main" || len(plan.Workflows) != 2 {
+		t.Fatalf("plan = %+v", plan)
+	}
+	if ci := plan.Workflows[0]; !ci.Triggered || ci.Name != "ci" || len(ci.Jobs) != 1 || !ci.Jobs[0].Run {
+		t.Errorf("ci.yml = %+v, want triggered with build running", ci)
+	}
+	if docs := plan.Workflows[1]; docs.Triggered || docs.Jobs != nil {
+		t.Errorf("docs.yml = %+v, want not triggered", docs)
+	}
+
+	var out strings.Builder
+	WriteEventPlanText(&out, plan)
+	want := "Event: push (refs/heads/main)\n  + ci.yml (ci): triggered\n      + build\n  - docs.yml: not triggered: no changed file matches paths [docs/**]\n"
+	if out.String() != want {
+		t.Errorf("text output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,662 @@
+// Package ghawf 离线分析 GitHub Actions 工作流：检查问题、导出执行计划、按事件模拟触发
+package ghawf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nektos/act/pkg/model"
+	"github.com/rhysd/actionlint"
+	"gopkg.in/yaml.v3"
+)
+
+// --- 离线检查 ---
+
+// 检查规则
+const (
+	RuleUndefinedNeeds      = "undefined-needs"      // needs 引用了不存在的 Job
+	RuleUnusedJobOutput     = "unused-job-output"    // Job 输出没有被任何 Job 或 workflow_call 输出引用
+	RuleUndeclaredReference = "undeclared-reference" // ${{ }} 引用了未声明的 inputs、secrets、steps、needs 或 matrix
+	RuleScriptInjection     = "script-injection"     // github.event.* 等外部输入直接插入 run 脚本
+	RuleDeprecatedCommand   = "deprecated-command"   // ::set-output 等已废弃的工作流命令
+	RuleUnknownRunner       = "unknown-runner"       // runs-on 标签没有对应的运行环境 ConfigMap
+	RuleInvalidExpression   = "invalid-expression"   // ${{ }} 无法解析
+)
+
+// 问题级别，与 SARIF 的 level 一致
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+// lintRule 描述一条检查规则，用于 SARIF 输出
+type lintRule struct {
+	ID          string
+	Level       string
+	Description string
+}
+
+// lintRules 是所有检查规则，顺序即 SARIF 中的规则顺序
+var lintRules = []lintRule{
+	{RuleUndefinedNeeds, LevelError, "needs references a job that does not exist"},
+	{RuleUnusedJobOutput, LevelWarning, "job output is never referenced"},
+	{RuleUndeclaredReference, LevelError, "expression references an undeclared input, secret, step, job or matrix key"},
+	{RuleScriptInjection, LevelError, "untrusted event data is interpolated into a run script"},
+	{RuleDeprecatedCommand, LevelWarning, "deprecated workflow command"},
+	{RuleUnknownRunner, LevelWarning, "runs-on label is not covered by any runner ConfigMap"},
+	{RuleInvalidExpression, LevelError, "expression cannot be parsed"},
+}
+
+// Finding 是检查发现的一个问题，行列从 1 开始，未知时为 0
+type Finding struct {
+	Rule    string `json:"rule"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", f.File, f.Line, f.Column, f.Level, f.Message, f.Rule)
+}
+
+// LintOptions 是检查时使用的外部信息
+type LintOptions struct {
+	// RunnerLabels 是有运行环境 ConfigMap 的 runs-on 标签，按 ConfigMap 名称（即规范化后的标签）匹配
+	RunnerLabels map[string]bool
+	// Secrets 是仓库中已配置的 secret；为空且工作流没有声明 workflow_call.secrets 时不检查 secrets 引用
+	Secrets map[string]bool
+}
+
+// builtinRunnerLabels 是转换器内置镜像映射覆盖的标签，没有 ConfigMap 也能转换
+var builtinRunnerLabels = []string{"ubuntu-latest", "ubuntu-22.04", "ubuntu-20.04"}
+
+// deprecatedCommandRegex 匹配已废弃的工作流命令
+var deprecatedCommandRegex = regexp.MustCompile(`::(set-output|save-state|set-env|add-path)\b`)
+
+// deprecatedCommands 是废弃命令的替代方式
+var deprecatedCommands = map[string]string{
+	"set-output": "$GITHUB_OUTPUT",
+	"save-state": "$GITHUB_STATE",
+	"set-env":    "$GITHUB_ENV",
+	"add-path":   "$GITHUB_PATH",
+}
+
+// LoadRunnerConfigMaps 读取目录中 .yaml/.yml 文件里的 ConfigMap 名称，其他资源被跳过
+func LoadRunnerConfigMaps(dir string) (map[string]bool, error) {
+	names := map[string]bool{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read YAML file: %w", err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		for {
+			var doc struct {
+				Kind     string `yaml:"kind"`
+				Metadata struct {
+					Name string `yaml:"name"`
+				} `yaml:"metadata"`
+			}
+			if err := decoder.Decode(&doc); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if doc.Kind == "ConfigMap" && doc.Metadata.Name != "" {
+				names[doc.Metadata.Name] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// LintWorkflow 检查一个 GHA 工作流，返回按位置排序的问题；工作流无法解析时返回错误
+func LintWorkflow(file string, src []byte, opts LintOptions) ([]Finding, error) {
+	wf, schemaErr := model.ReadWorkflow(bytes.NewReader(src), false)
+	if schemaErr != nil {
+		// act 的 schema 校验把无法解析的 ${{ }} 也当作错误；跳过校验再解析一次，能按位置报告这些表达式时返回检查结果
+		wf = new(model.Workflow)
+		if err := yaml.Unmarshal(src, (*unvalidatedWorkflow)(wf)); err != nil {
+			return nil, fmt.Errorf("%s: failed to parse workflow: %w", file, schemaErr)
+		}
+	}
+	l := &linter{
+		file:    file,
+		wf:      wf,
+		source:  NewYAMLSource(string(src)),
+		opts:    opts,
+		inputs:  map[string]bool{},
+		used:    map[string]map[string]bool{},
+		jobKeys: map[string]string{},
+	}
+	for id := range wf.Jobs {
+		l.jobKeys[strings.ToLower(id)] = id
+	}
+	l.collectDeclarations()
+
+	for _, id := range l.jobIDs() {
+		l.lintJob(id, wf.Jobs[id])
+	}
+	l.lintWorkflowFields()
+	l.lintUnusedOutputs()
+
+	if schemaErr != nil && !l.reported(RuleInvalidExpression) {
+		return nil, fmt.Errorf("%s: failed to parse workflow: %w", file, schemaErr)
+	}
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		a, b := l.findings[i], l.findings[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.findings, nil
+}
+
+// unvalidatedWorkflow 按 model.Workflow 的字段解析，不经过 act 的 schema 校验
+type unvalidatedWorkflow model.Workflow
+
+// linter 保存一个工作流的检查状态
+type linter struct {
+	file     string
+	wf       *model.Workflow
+	source   *YAMLSource
+	opts     LintOptions
+	inputs   map[string]bool            // workflow_dispatch 和 workflow_call 的输入，小写
+	secrets  map[string]bool            // 已知的 secret，小写；nil 表示不检查
+	used     map[string]map[string]bool // 被引用的 Job 输出：小写 Job ID -> 输出名，"*" 表示动态访问
+	jobKeys  map[string]string          // 小写 Job ID -> Job ID
+	findings []Finding
+}
+
+// exprScope 是一处表达式所在的位置和可以访问的上下文
+type exprScope struct {
+	jobID string
+	job   *model.Job
+	steps int  // 可以引用的步骤数量，-1 表示 steps 上下文不可用
+	run   bool // 是否是 run 脚本
+}
+
+func (l *linter) report(rule, level string, n *yaml.Node, offset int, format string, args ...interface{}) {
+	line, column := l.source.Position(n, offset)
+	l.findings = append(l.findings, Finding{Rule: rule, Level: level, Message: fmt.Sprintf(format, args...), File: l.file, Line: line, Column: column})
+}
+
+// reported 判断是否已经报告了某条规则的问题
+func (l *linter) reported(rule string) bool {
+	for _, f := range l.findings {
+		if f.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) jobIDs() []string {
+	ids := l.wf.GetJobIDs()
+	sort.Strings(ids)
+	return ids
+}
+
+// collectDeclarations 收集工作流声明的输入和 secret
+func (l *linter) collectDeclarations() {
+	if dispatch := l.wf.WorkflowDispatchConfig(); dispatch != nil {
+		for name := range dispatch.Inputs {
+			l.inputs[strings.ToLower(name)] = true
+		}
+	}
+	if l.isCallable() {
+		for name := range l.wf.WorkflowCallConfig().Inputs {
+			l.inputs[strings.ToLower(name)] = true
+		}
+	}
+
+	if len(l.opts.Secrets) > 0 || l.source.Node("on", "workflow_call", "secrets") != nil {
+		l.secrets = map[string]bool{"github_token": true}
+		for name := range l.opts.Secrets {
+			l.secrets[strings.ToLower(name)] = true
+		}
+		if secrets := l.source.Node("on", "workflow_call", "secrets"); secrets != nil && secrets.Kind == yaml.MappingNode {
+			for i := 0; i < len(secrets.Content); i += 2 {
+				l.secrets[strings.ToLower(secrets.Content[i].Value)] = true
+			}
+		}
+	}
+}
+
+func (l *linter) isCallable() bool {
+	for _, event := range l.wf.On() {
+		if event == "workflow_call" {
+			return true
+		}
+	}
+	return false
+}
+
+// lintJob 检查 Job 的依赖、运行环境和表达式
+func (l *linter) lintJob(id string, job *model.Job) {
+	jobNode := l.source.Node("jobs", id)
+
+	// 1. needs
+	needsNode := l.source.Node("jobs", id, "needs")
+	for i, need := range job.Needs() {
+		if _, ok := l.jobKeys[strings.ToLower(need)]; ok {
+			continue
+		}
+		n := needsNode
+		if needsNode != nil && needsNode.Kind == yaml.SequenceNode && i < len(needsNode.Content) {
+			n = needsNode.Content[i]
+		}
+		l.report(RuleUndefinedNeeds, LevelError, n, 0, "job %q needs %q, which does not exist", id, need)
+	}
+
+	// 2. runs-on：转换器使用第一个标签查找运行环境
+	if runsOn := job.RunsOn(); len(runsOn) > 0 && !strings.Contains(runsOn[0], "${{") && !l.knownRunner(runsOn[0]) {
+		n := l.source.Node("jobs", id, "runs-on")
+		if n != nil && n.Kind == yaml.SequenceNode && len(n.Content) > 0 {
+			n = n.Content[0]
+		}
+		l.report(RuleUnknownRunner, LevelWarning, n, 0, "runs-on label %q has no runner ConfigMap %q; the converter falls back to the default image", runsOn[0], SanitizeName(runsOn[0]))
+	}
+
+	// 3. 表达式：步骤可以引用之前的步骤，Job 输出可以引用所有步骤，其余 Job 字段不能引用步骤
+	if jobNode == nil || jobNode.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(jobNode.Content); i += 2 {
+		key, value := jobNode.Content[i].Value, jobNode.Content[i+1]
+		switch key {
+		case "steps":
+			for s, stepNode := range value.Content {
+				l.lintStep(id, job, s, stepNode)
+			}
+		case "outputs":
+			l.lintNode(value, "", exprScope{jobID: id, job: job, steps: len(job.Steps)})
+		default:
+			l.lintNode(value, key, exprScope{jobID: id, job: job, steps: -1})
+		}
+	}
+}
+
+// lintStep 检查一个步骤的表达式和 run 脚本
+func (l *linter) lintStep(id string, job *model.Job, index int, stepNode *yaml.Node) {
+	if stepNode.Kind != yaml.MappingNode {
+		return
+	}
+	var uses string
+	for i := 0; i+1 < len(stepNode.Content); i += 2 {
+		if stepNode.Content[i].Value == "uses" {
+			uses = stepNode.Content[i+1].Value
+		}
+	}
+	scope := exprScope{jobID: id, job: job, steps: index}
+	for i := 0; i+1 < len(stepNode.Content); i += 2 {
+		key, value := stepNode.Content[i].Value, stepNode.Content[i+1]
+		switch {
+		case key == "run":
+			l.lintRun(value)
+			l.lintNode(value, key, exprScope{jobID: id, job: job, steps: index, run: true})
+		case key == "with" && strings.HasPrefix(uses, "actions/github-script@"):
+			// github-script 的 script 同样会被直接执行
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				s := scope
+				s.run = value.Content[j].Value == "script"
+				l.lintNode(value.Content[j+1], "", s)
+			}
+		default:
+			l.lintNode(value, key, scope)
+		}
+	}
+}
+
+// lintRun 检查 run 脚本中已废弃的工作流命令
+func (l *linter) lintRun(n *yaml.Node) {
+	if n.Kind != yaml.ScalarNode {
+		return
+	}
+	for _, m := range deprecatedCommandRegex.FindAllStringSubmatchIndex(n.Value, -1) {
+		command := n.Value[m[2]:m[3]]
+		l.report(RuleDeprecatedCommand, LevelWarning, n, m[0], "::%s is deprecated, write to %s instead", command, deprecatedCommands[command])
+	}
+}
+
+// lintWorkflowFields 检查工作流级字段的表达式，workflow_call 的输出可以引用 Job 输出
+func (l *linter) lintWorkflowFields() {
+	for _, key := range []string{"run-name", "env", "concurrency"} {
+		if n := l.source.Node(key); n != nil {
+			l.lintNode(n, key, exprScope{steps: -1})
+		}
+	}
+	if outputs := l.source.Node("on", "workflow_call", "outputs"); outputs != nil {
+		l.lintNode(outputs, "", exprScope{steps: -1})
+	}
+}
+
+// lintUnusedOutputs 报告没有被引用的 Job 输出
+func (l *linter) lintUnusedOutputs() {
+	for _, id := range l.jobIDs() {
+		used := l.used[strings.ToLower(id)]
+		if used["*"] {
+			continue
+		}
+		outputs := l.source.Node("jobs", id, "outputs")
+		if outputs == nil || outputs.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(outputs.Content); i += 2 {
+			name := outputs.Content[i].Value
+			if !used[strings.ToLower(name)] {
+				l.report(RuleUnusedJobOutput, LevelWarning, outputs.Content[i], 0, "output %q of job %q is never used", name, id)
+			}
+		}
+	}
+}
+
+// lintNode 检查节点中所有字符串里的 ${{ }}；key 为 if 时整个值都是表达式
+func (l *linter) lintNode(n *yaml.Node, key string, scope exprScope) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		l.lintString(n, key == "if", scope)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			// 映射的键也可能包含表达式，如 env 中的变量名
+			l.lintString(n.Content[i], false, scope)
+			l.lintNode(n.Content[i+1], n.Content[i].Value, scope)
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			l.lintNode(item, "", scope)
+		}
+	}
+}
+
+// lintString 解析字符串中的 ${{ }} 并检查其中的上下文引用
+func (l *linter) lintString(n *yaml.Node, implicit bool, scope exprScope) {
+	s := n.Value
+	if n.Tag != "!!str" && n.Tag != "" {
+		return
+	}
+	if implicit && !strings.Contains(s, "${{") {
+		// if 的值省略了 ${{ }}，按整体解析
+		node, err := actionlint.NewExprParser().Parse(actionlint.NewExprLexer(s + "}}"))
+		if err != nil {
+			l.report(RuleInvalidExpression, LevelError, n, err.Offset, "invalid expression %q: %s", s, err.Message)
+			return
+		}
+		l.checkExpression(n, 0, node, scope)
+		return
+	}
+	pos := 0
+	for {
+		i := strings.Index(s[pos:], "${{")
+		if i < 0 {
+			return
+		}
+		body := pos + i + len("${{")
+		lexer := actionlint.NewExprLexer(s[body:])
+		node, err := actionlint.NewExprParser().Parse(lexer)
+		if err != nil {
+			end := strings.Index(s[body:], "}}")
+			if end < 0 {
+				end = len(s) - body
+			}
+			l.report(RuleInvalidExpression, LevelError, n, body+err.Offset, "invalid expression %q: %s", strings.TrimSpace(s[body:body+end]), err.Message)
+			pos = body + end
+			continue
+		}
+		l.checkExpression(n, body, node, scope)
+		pos = body + lexer.Offset()
+	}
+}
+
+// contextRef 是表达式中对上下文属性的一次访问，如 needs.build.outputs.version
+type contextRef struct {
+	path    []string // 小写的属性路径，动态下标为 "*"
+	offset  int      // 在表达式中的偏移量
+	dynamic bool     // 路径之后还有无法静态确定的访问
+}
+
+// checkExpression 检查一个表达式中的上下文引用；offset 是表达式在节点值中的偏移量
+func (l *linter) checkExpression(n *yaml.Node, offset int, expr actionlint.ExprNode, scope exprScope) {
+	for _, ref := range contextRefs(expr) {
+		if message := l.checkReference(ref, scope); message != "" {
+			l.report(RuleUndeclaredReference, LevelError, n, offset+ref.offset, "%s", message)
+		}
+		if scope.run && untrustedInput(ref.path) {
+			l.report(RuleScriptInjection, LevelError, n, offset+ref.offset,
+				"%s is controlled by the event sender and is interpolated into the script; pass it through an environment variable instead", strings.Join(ref.path, "."))
+		}
+	}
+}
+
+// untrustedInput 判断引用的值是否可能被事件发送者控制
+func untrustedInput(path []string) bool {
+	if len(path) < 2 || path[0] != "github" {
+		return false
+	}
+	return path[1] == "event" || path[1] == "head_ref"
+}
+
+// checkReference 检查一个上下文引用并记录对 Job 输出的使用，返回问题描述；合法的引用返回空
+func (l *linter) checkReference(ref contextRef, scope exprScope) string {
+	path := ref.path
+	if len(path) < 2 || path[1] == "*" {
+		// 整体访问上下文，如 toJSON(needs)
+		if len(path) >= 1 && (path[0] == "needs" || path[0] == "jobs") {
+			for id := range l.jobKeys {
+				l.markUsed(id, "*")
+			}
+		}
+		return ""
+	}
+	name := path[1]
+	switch path[0] {
+	case "inputs":
+		if !l.inputs[name] {
+			return fmt.Sprintf("input %q is not declared in workflow_dispatch or workflow_call inputs", name)
+		}
+	case "secrets":
+		if l.secrets != nil && !l.secrets[name] {
+			return fmt.Sprintf("secret %q is not declared", name)
+		}
+	case "steps":
+		if scope.job == nil {
+			return "steps context is only available inside a job"
+		}
+		if scope.steps < 0 {
+			return "steps context is only available in steps and job outputs"
+		}
+		for i, step := range scope.job.Steps {
+			if strings.ToLower(step.ID) != name {
+				continue
+			}
+			if i >= scope.steps {
+				return fmt.Sprintf("step %q does not run before this point", name)
+			}
+			return ""
+		}
+		return fmt.Sprintf("step %q is not defined in job %q", name, scope.jobID)
+	case "needs":
+		if scope.job == nil {
+			return "needs context is only available inside a job"
+		}
+		listed := false
+		for _, need := range scope.job.Needs() {
+			listed = listed || strings.ToLower(need) == name
+		}
+		if !listed {
+			return fmt.Sprintf("job %q is not listed in needs of job %q", name, scope.jobID)
+		}
+		return l.checkJobOutput(name, path[2:], ref.dynamic)
+	case "jobs":
+		// workflow_call 的输出引用 jobs.<id>.outputs.<name>
+		if _, ok := l.jobKeys[name]; !ok {
+			return fmt.Sprintf("job %q does not exist", name)
+		}
+		return l.checkJobOutput(name, path[2:], ref.dynamic)
+	case "matrix":
+		if scope.job == nil {
+			return "matrix context is only available inside a job"
+		}
+		keys, known := matrixKeys(scope.job)
+		if scope.job.Strategy == nil || scope.job.Strategy.RawMatrix.Kind == 0 {
+			return fmt.Sprintf("job %q has no strategy.matrix, matrix.%s is undefined", scope.jobID, name)
+		}
+		if known && !keys[name] {
+			return fmt.Sprintf("matrix key %q is not defined in job %q", name, scope.jobID)
+		}
+	}
+	return ""
+}
+
+// checkJobOutput 记录对 Job 输出的使用并检查输出已声明；rest 是 Job ID 之后的路径
+func (l *linter) checkJobOutput(jobID string, rest []string, dynamic bool) string {
+	if len(rest) == 0 || rest[0] != "outputs" {
+		// needs.<id>.result 等
+		return ""
+	}
+	if len(rest) < 2 || rest[1] == "*" || dynamic {
+		l.markUsed(jobID, "*")
+		return ""
+	}
+	output := rest[1]
+	l.markUsed(jobID, output)
+	job := l.wf.Jobs[l.jobKeys[jobID]]
+	for name := range job.Outputs {
+		if strings.ToLower(name) == output {
+			return ""
+		}
+	}
+	return fmt.Sprintf("job %q has no output %q", l.jobKeys[jobID], output)
+}
+
+func (l *linter) markUsed(jobID, output string) {
+	if l.used[jobID] == nil {
+		l.used[jobID] = map[string]bool{}
+	}
+	l.used[jobID][output] = true
+}
+
+func (l *linter) knownRunner(label string) bool {
+	for _, builtin := range builtinRunnerLabels {
+		if strings.Contains(label, builtin) {
+			return true
+		}
+	}
+	return l.opts.RunnerLabels[SanitizeName(label)]
+}
+
+// matrixKeys 返回矩阵的键，包括 include 中新增的键；矩阵由表达式生成时 known 为 false
+func matrixKeys(job *model.Job) (keys map[string]bool, known bool) {
+	if job.Strategy == nil || job.Strategy.RawMatrix.Kind != yaml.MappingNode {
+		return nil, false
+	}
+	keys = map[string]bool{}
+	matrix := job.Strategy.RawMatrix
+	for i := 0; i+1 < len(matrix.Content); i += 2 {
+		key, value := matrix.Content[i].Value, matrix.Content[i+1]
+		switch key {
+		case "exclude":
+		case "include":
+			if value.Kind != yaml.SequenceNode {
+				return nil, false
+			}
+			for _, item := range value.Content {
+				if item.Kind != yaml.MappingNode {
+					return nil, false
+				}
+				for j := 0; j < len(item.Content); j += 2 {
+					keys[strings.ToLower(item.Content[j].Value)] = true
+				}
+			}
+		default:
+			if strings.Contains(key, "${{") {
+				return nil, false
+			}
+			keys[strings.ToLower(key)] = true
+		}
+	}
+	return keys, true
+}
+
+// contextRefs 返回表达式中所有对上下文属性的访问，每条访问链只取最长的路径
+func contextRefs(node actionlint.ExprNode) []contextRef {
+	if path, ok := contextPath(node); ok {
+		return []contextRef{{path: path, offset: node.Token().Offset}}
+	}
+	var refs []contextRef
+	switch n := node.(type) {
+	case *actionlint.ObjectDerefNode:
+		refs = dynamicRefs(n.Receiver)
+	case *actionlint.IndexAccessNode:
+		refs = append(dynamicRefs(n.Operand), contextRefs(n.Index)...)
+	case *actionlint.ArrayDerefNode:
+		refs = dynamicRefs(n.Receiver)
+	case *actionlint.NotOpNode:
+		refs = contextRefs(n.Operand)
+	case *actionlint.CompareOpNode:
+		refs = append(contextRefs(n.Left), contextRefs(n.Right)...)
+	case *actionlint.LogicalOpNode:
+		refs = append(contextRefs(n.Left), contextRefs(n.Right)...)
+	case *actionlint.FuncCallNode:
+		for _, arg := range n.Args {
+			refs = append(refs, contextRefs(arg)...)
+		}
+	}
+	return refs
+}
+
+// dynamicRefs 返回访问链前缀中的引用，之后的访问无法静态确定
+func dynamicRefs(node actionlint.ExprNode) []contextRef {
+	refs := contextRefs(node)
+	if len(refs) == 1 && refs[0].offset == node.Token().Offset {
+		refs[0].dynamic = true
+	}
+	return refs
+}
+
+// contextPath 把 a.b['c'].d 形式的访问链转换为路径；含有动态下标时返回 false
+func contextPath(node actionlint.ExprNode) ([]string, bool) {
+	switch n := node.(type) {
+	case *actionlint.VariableNode:
+		return []string{strings.ToLower(n.Name)}, true
+	case *actionlint.ObjectDerefNode:
+		if path, ok := contextPath(n.Receiver); ok {
+			return append(path, strings.ToLower(n.Property)), true
+		}
+	case *actionlint.IndexAccessNode:
+		index, ok := n.Index.(*actionlint.StringNode)
+		if !ok {
+			return nil, false
+		}
+		if path, ok := contextPath(n.Operand); ok {
+			return append(path, strings.ToLower(index.Value)), true
+		}
+	case *actionlint.ArrayDerefNode:
+		if path, ok := contextPath(n.Receiver); ok {
+			return append(path, "*"), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,231 @@
+package ghawf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- 离线检查 ---
+
+func TestLintWorkflowRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow string
+		opts     LintOptions
+		want     []string // Finding 的 "行:列 规则 消息"，按位置排序
+	}{
+		{
+			name: "clean workflow",
+			workflow: `on:
+  workflow_dispatch:
+    inputs:
+      target:
+        type: string
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    outputs:
+      version: ${{ steps.version.outputs.value }}
+    steps:
+      - id: version
+        run: echo "value=1" >> "$GITHUB_OUTPUT"
+  deploy:
+    needs: build
+    runs-on: ubuntu-22.04
+    steps:
+      - run: ./deploy "$TARGET" ${{ needs.build.outputs.version }}
+        env:
+          TARGET: ${{ inputs.target }}
+          TITLE: ${{ github.event.head_commit.message }}
+`,
+		},
+		{
+			name:     RuleUndefinedNeeds,
+			workflow: "on: push\njobs:\n  test:\n    needs: [build, lint]\n    runs-on: ubuntu-latest\n    steps:\n      - run: make\n  lint:\n    runs-on: ubuntu-latest\n    steps:\n      - run: make lint\n",
+			want:     []string{`4:13 undefined-needs job "test" needs "build", which does not exist`},
+		},
+		{
+			name:     RuleUnusedJobOutput,
+			workflow: "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    outputs:\n      version: ${{ steps.v.outputs.value }}\n      digest: ${{ steps.v.outputs.digest }}\n    steps:\n      - id: v\n        run: make\n  deploy:\n    needs: build\n    runs-on: ubuntu-latest\n    steps:\n      - run: echo ${{ needs.build.outputs.version }}\n",
+			want:     []string{`7:7 unused-job-output output "digest" of job "build" is never used`},
+		},
+		{
+			name:     "unused job output with dynamic access",
+			workflow: "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    outputs:\n      version: v1\n    steps:\n      - run: make\n  deploy:\n    needs: build\n    runs-on: ubuntu-latest\n    steps:\n      - run: echo '${{ toJSON(needs.build.outputs) }}'\n",
+		},
+		{
+			name: RuleUndeclaredReference,
+			workflow: `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    if: inputs.force
+    outputs:
+      missing: ${{ needs.lint.outputs.x }}
+    steps:
+      - run: echo ${{ steps.later.outputs.x }} ${{ matrix.os }}
+      - id: later
+        run: echo ${{ steps.none.outputs.x }} ${{ secrets.TOKEN }}
+`,
+			opts: LintOptions{Secrets: map[string]bool{"DEPLOY_KEY": true}},
+			want: []string{
+				`5:9 undeclared-reference input "force" is not declared in workflow_dispatch or workflow_call inputs`,
+				`7:7 unused-job-output output "missing" of job "build" is never used`,
+				`7:20 undeclared-reference job "lint" is not listed in needs of job "build"`,
+				`9:23 undeclared-reference step "later" does not run before this point`,
+				`9:52 undeclared-reference job "build" has no strategy.matrix, matrix.os is undefined`,
+				`11:23 undeclared-reference step "none" is not defined in job "build"`,
+				`11:51 undeclared-reference secret "token" is not declared`,
+			},
+		},
+		{
+			name:     "undeclared matrix key and job output",
+			workflow: "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    strategy:\n      matrix:\n        os: [a]\n        include:\n          - arch: x\n    steps:\n      - run: echo ${{ matrix.os }} ${{ matrix.arch }} ${{ matrix.go }}\n  test:\n    needs: build\n    runs-on: ubuntu-latest\n    steps:\n      - run: echo ${{ needs.build.outputs.none }}\n",
+			want: []string{
+				`11:59 undeclared-reference matrix key "go" is not defined in job "build"`,
+				`16:23 undeclared-reference job "build" has no output "none"`,
+			},
+		},
+		{
+			name:     RuleScriptInjection,
+			workflow: "on: pull_request\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - run: echo \"${{ github.event.pull_request.title }}\" ${{ github.head_ref }} ${{ github.sha }}\n      - uses: actions/github-script@v7\n        with:\n          script: console.log(\"${{ github.event.issue.body }}\")\n          github-token: ${{ github.event.issue.body }}\n",
+			want: []string{
+				`6:24 script-injection github.event.pull_request.title is controlled by the event sender and is interpolated into the script; pass it through an environment variable instead`,
+				`6:64 script-injection github.head_ref is controlled by the event sender and is interpolated into the script; pass it through an environment variable instead`,
+				`9:36 script-injection github.event.issue.body is controlled by the event sender and is interpolated into the script; pass it through an environment variable instead`,
+			},
+		},
+		{
+			name:     RuleDeprecatedCommand,
+			workflow: "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - run: |\n          echo \"::set-output name=x::1\"\n          echo \"::add-path::/bin\"\n",
+			want: []string{
+				`7:17 deprecated-command ::set-output is deprecated, write to $GITHUB_OUTPUT instead`,
+				`8:17 deprecated-command ::add-path is deprecated, write to $GITHUB_PATH instead`,
+			},
+		},
+		{
+			name:     RuleUnknownRunner,
+			workflow: "on: push\njobs:\n  build:\n    runs-on: [linux-arm-npu, self-hosted]\n    steps:\n      - run: make\n  test:\n    runs-on: linux-x86\n    steps:\n      - run: make\n  dynamic:\n    runs-on: ${{ matrix.os }}\n    strategy:\n      matrix:\n        os: [a]\n    steps:\n      - run: make\n",
+			opts:     LintOptions{RunnerLabels: map[string]bool{"linux-x86": true}},
+			want:     []string{`4:15 unknown-runner runs-on label "linux-arm-npu" has no runner ConfigMap "linux-arm-npu"; the converter falls back to the default image`},
+		},
+		{
+			name:     RuleInvalidExpression,
+			workflow: "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    if: github.ref ==\n    steps:\n      - run: echo ${{ github.sha ) }} ok\n",
+			want: []string{
+				`5:22 invalid-expression invalid expression "github.ref ==": unexpected end of input while parsing variable access, function call, null, bool, int, float or string. expecting "IDENT", "(", "INTEGER", "FLOAT", "STRING"`,
+				`7:34 invalid-expression invalid expression "github.sha )": parser did not reach end of input after parsing the expression. 1 remaining token(s) in the input: ")"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := LintWorkflow("ci.yml", []byte(tt.workflow), tt.opts)
+			if err != nil {
+				t.Fatalf("LintWorkflow: %v", err)
+			}
+			var got []string
+			for _, f := range findings {
+				if f.File != "ci.yml" {
+					t.Errorf("finding file = %q, want ci.yml", f.File)
+				}
+				got = append(got, fmt.Sprintf("%d:%d %s %s", f.Line, f.Column, f.Rule, f.Message))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestLintWorkflowLevels(t *testing.T) {
+	levels := map[string]string{}
+	for _, rule := range lintRules {
+		levels[rule.ID] = rule.Level
+	}
+	findings, err := LintWorkflow("ci.yml", []byte("on: push\njobs:\n  build:\n    needs: missing\n    runs-on: custom\n    steps:\n      - run: echo ::set-output name=x::1\n"), LintOptions{})
+	if err != nil {
+		t.Fatalf("LintWorkflow: %v", err)
+	}
+	if len(findings) != 3 {
+		t.Fatalf("findings = %v, want 3", findings)
+	}
+	for _, f := range findings {
+		if f.Level != levels[f.Rule] {
+			t.Errorf("%s level = %q, want %q", f.Rule, f.Level, levels[f.Rule])
+		}
+	}
+
+	// 无法解析的 YAML 和与表达式无关的 schema 错误仍然返回错误
+	for _, src := range []string{"jobs: [", "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    stepz: []\n"} {
+		if _, err := LintWorkflow("bad.yml", []byte(src), LintOptions{}); err == nil || !strings.HasPrefix(err.Error(), "bad.yml: failed to parse workflow") {
+			t.Errorf("LintWorkflow(%q) error = %v, want a parse error naming the file", src, err)
+		}
+	}
+}
+
+func TestLoadRunnerConfigMaps(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"runners.yaml":    "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: linux-arm\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: token\n",
+		"nested/x86.yml":  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: linux-x86\n",
+		"notes/README.md": "kind: ConfigMap",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err := LoadRunnerConfigMaps(dir)
+	if err != nil {
+		t.Fatalf("LoadRunnerConfigMaps: %v", err)
+	}
+	if len(names) != 2 || !names["linux-arm"] || !names["linux-x86"] {
+		t.Errorf("names = %v, want linux-arm and linux-x86", names)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	findings, err := LintWorkflow("ci.yml", []byte("on: push\njobs:\n  build:\n    needs: missing\n    runs-on: ubuntu-latest\n    steps:\n      - run: make\n"), LintOptions{})
+	if err != nil {
+		t.Fatalf("LintWorkflow: %v", err)
+	}
+	var out bytes.Buffer
+	if err := WriteSARIF(&out, findings); err != nil {
+		t.Fatalf("WriteSARIF: %v", err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Level  string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Tool.Driver.Rules) != len(lintRules) {
+		t.Fatalf("SARIF log = %+v", log)
+	}
+	if results := log.Runs[0].Results; len(results) != 1 || results[0].RuleID != RuleUndefinedNeeds || results[0].Level != LevelError {
+		t.Errorf("results = %+v, want one undefined-needs error", results)
+	}
+}
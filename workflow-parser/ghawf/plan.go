@@ -0,0 +1,129 @@
+package ghawf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+// --- 执行计划导出 ---
+
+// PlanReport 是执行计划的结构化表示
+type PlanReport struct {
+	Event  string      `json:"event,omitempty"` // 为空表示 PlanAll 得到的完整计划
+	Stages []PlanStage `json:"stages"`
+}
+
+// PlanStage 是一个阶段，阶段内的 Job 并行执行
+type PlanStage struct {
+	Index int       `json:"index"` // 从 1 开始
+	Runs  []PlanRun `json:"runs"`
+}
+
+// PlanRun 是阶段中的一个 Job
+type PlanRun struct {
+	Workflow string   `json:"workflow"`
+	File     string   `json:"file"`
+	JobID    string   `json:"jobId"`
+	Name     string   `json:"name,omitempty"`
+	Needs    []string `json:"needs,omitempty"`
+	RunsOn   []string `json:"runsOn,omitempty"`
+	Steps    int      `json:"steps"`
+	Matrix   int      `json:"matrix"` // matrix 组合数，没有 matrix 时为 1
+}
+
+// NewPlanReport 把 act 的执行计划转换为结构化表示
+func NewPlanReport(plan *model.Plan, event string) PlanReport {
+	report := PlanReport{Event: event, Stages: []PlanStage{}}
+	for i, stage := range plan.Stages {
+		s := PlanStage{Index: i + 1, Runs: []PlanRun{}}
+		for _, run := range stage.Runs {
+			job := run.Job()
+			r := PlanRun{
+				Workflow: run.Workflow.Name,
+				File:     run.Workflow.File,
+				JobID:    run.JobID,
+				Needs:    job.Needs(),
+				RunsOn:   job.RunsOn(),
+				Steps:    len(job.Steps),
+			}
+			if job.Name != run.JobID {
+				r.Name = job.Name
+			}
+			if matrixes, err := job.GetMatrixes(); err == nil {
+				r.Matrix = len(matrixes)
+			}
+			s.Runs = append(s.Runs, r)
+		}
+		report.Stages = append(report.Stages, s)
+	}
+	return report
+}
+
+// WritePlanJSON 以 JSON 输出执行计划
+func WritePlanJSON(w io.Writer, report PlanReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(report)
+}
+
+// PlanGraph 把执行计划转换为图：每个阶段一个分组，needs 为边；计划包含多个工作流时标签带上工作流名称
+func PlanGraph(report PlanReport) *RenderGraph {
+	workflows := map[string]string{}
+	for _, stage := range report.Stages {
+		for _, run := range stage.Runs {
+			workflows[run.File] = run.Workflow
+		}
+	}
+	title := "plan"
+	if len(workflows) == 1 {
+		for _, name := range workflows {
+			title = name
+		}
+	}
+	if report.Event != "" {
+		title += " (" + report.Event + ")"
+	}
+	g := &RenderGraph{Name: title}
+
+	ids := map[string]string{} // 工作流文件 + Job ID -> 节点 ID
+	key := func(file, jobID string) string { return file + "\x00" + jobID }
+	for _, stage := range report.Stages {
+		cluster := fmt.Sprintf("stage%d", stage.Index)
+		g.Clusters = append(g.Clusters, renderCluster{ID: cluster, Label: fmt.Sprintf("stage %d", stage.Index)})
+		for _, run := range stage.Runs {
+			id := fmt.Sprintf("n%d", len(g.Nodes)+1)
+			ids[key(run.File, run.JobID)] = id
+			g.Nodes = append(g.Nodes, renderNode{ID: id, Label: planRunLabel(run, len(workflows) > 1), Cluster: cluster})
+		}
+	}
+	for _, stage := range report.Stages {
+		for _, run := range stage.Runs {
+			for _, need := range run.Needs {
+				if from, ok := ids[key(run.File, need)]; ok {
+					g.addEdge(from, ids[key(run.File, run.JobID)])
+				}
+			}
+		}
+	}
+	return g
+}
+
+// planRunLabel 返回 Job 节点的标签：Job ID、运行环境和 matrix 组合数
+func planRunLabel(run PlanRun, withWorkflow bool) string {
+	lines := []string{run.JobID}
+	if withWorkflow {
+		lines[0] = run.Workflow + " / " + run.JobID
+	}
+	if len(run.RunsOn) > 0 {
+		lines = append(lines, "runs-on: "+strings.Join(run.RunsOn, ", "))
+	}
+	if run.Matrix > 1 {
+		lines = append(lines, fmt.Sprintf("matrix: %d", run.Matrix))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,121 @@
+package ghawf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// --- 图渲染 ---
+
+// RenderGraph 是输出为 DOT 或 Mermaid 的有向图；节点可以属于嵌套的分组
+type RenderGraph struct {
+	Name     string
+	Clusters []renderCluster
+	Nodes    []renderNode
+	Edges    []renderEdge
+}
+
+// renderCluster 是一个节点分组，Parent 为空表示顶层分组
+type renderCluster struct {
+	ID     string
+	Label  string
+	Parent string
+}
+
+// renderNode 是一个节点，Cluster 为空表示不属于任何分组；Label 中的换行分隔多行文字
+type renderNode struct {
+	ID      string
+	Label   string
+	Cluster string
+}
+
+// renderEdge 是一条依赖边，From 先于 To 执行
+type renderEdge struct {
+	From string
+	To   string
+}
+
+// addEdge 添加一条边，重复的边被忽略
+func (g *RenderGraph) addEdge(from, to string) {
+	for _, e := range g.Edges {
+		if e.From == from && e.To == to {
+			return
+		}
+	}
+	g.Edges = append(g.Edges, renderEdge{From: from, To: to})
+}
+
+// WriteDOT 以 Graphviz DOT 格式输出
+func WriteDOT(w io.Writer, g *RenderGraph) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "digraph %s {\n", dotQuote(g.Name))
+	fmt.Fprintf(b, "  rankdir=LR;\n")
+	fmt.Fprintf(b, "  node [shape=box, style=rounded];\n")
+	var writeCluster func(parent, indent string)
+	writeCluster = func(parent, indent string) {
+		for _, n := range g.Nodes {
+			if n.Cluster == parent {
+				fmt.Fprintf(b, "%s%s [label=%s];\n", indent, dotQuote(n.ID), dotQuote(n.Label))
+			}
+		}
+		for _, c := range g.Clusters {
+			if c.Parent == parent {
+				fmt.Fprintf(b, "%ssubgraph %s {\n", indent, dotQuote("cluster_"+c.ID))
+				fmt.Fprintf(b, "%s  label=%s;\n", indent, dotQuote(c.Label))
+				writeCluster(c.ID, indent+"  ")
+				fmt.Fprintf(b, "%s}\n", indent)
+			}
+		}
+	}
+	writeCluster("", "  ")
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "  %s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+	}
+	fmt.Fprintf(b, "}\n")
+	return b.Flush()
+}
+
+// WriteMermaid 以 Mermaid flowchart 格式输出，可以直接放入 Markdown 的 mermaid 代码块
+func WriteMermaid(w io.Writer, g *RenderGraph) error {
+	b := bufio.NewWriter(w)
+	if g.Name != "" {
+		fmt.Fprintf(b, "---\ntitle: %s\n---\n", mermaidQuote(g.Name))
+	}
+	fmt.Fprintf(b, "flowchart LR\n")
+	var writeCluster func(parent, indent string)
+	writeCluster = func(parent, indent string) {
+		for _, n := range g.Nodes {
+			if n.Cluster == parent {
+				fmt.Fprintf(b, "%s%s[\"%s\"]\n", indent, n.ID, mermaidQuote(n.Label))
+			}
+		}
+		for _, c := range g.Clusters {
+			if c.Parent == parent {
+				fmt.Fprintf(b, "%ssubgraph %s [\"%s\"]\n", indent, c.ID, mermaidQuote(c.Label))
+				writeCluster(c.ID, indent+"  ")
+				fmt.Fprintf(b, "%send\n", indent)
+			}
+		}
+	}
+	writeCluster("", "  ")
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "  %s --> %s\n", e.From, e.To)
+	}
+	return b.Flush()
+}
+
+// dotQuote 返回 DOT 的带引号字符串，换行输出为 \n
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// mermaidQuote 转义 Mermaid 标签中的引号，换行输出为 <br/>
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return strings.ReplaceAll(s, "\n", "<br/>")
+}
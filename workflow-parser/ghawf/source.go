@@ -0,0 +1,111 @@
+package ghawf
+
+import (
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// --- 名称 ---
+
+var (
+	// nonDNSSafeRegex 和 edgeDashRegex 用于把名称清理为 DNS-1123 标签
+	nonDNSSafeRegex = regexp.MustCompile(`[^a-z0-9-]+`)
+	edgeDashRegex   = regexp.MustCompile(`^-+|-+$`)
+)
+
+// SanitizeName 把 GHA 名称规范化为 DNS-1123 标签，转换器和 lint 共用：
+// 工作流、Job 和步骤的模板名称，runs-on 标签对应的 ConfigMap 名称，以及 GHA 工作流名称标签的值
+func SanitizeName(name string) string {
+	if name == "" {
+		return "unnamed"
+	}
+	name = strings.ToLower(name)
+	name = nonDNSSafeRegex.ReplaceAllString(name, "-")
+	name = edgeDashRegex.ReplaceAllString(name, "")
+	if len(name) > 63 {
+		name = edgeDashRegex.ReplaceAllString(name[:63], "")
+	}
+	return name
+}
+
+// --- 源码位置 ---
+
+// YAMLSource 把字段值中的偏移量换算为 GHA YAML 中的行列，用于告警定位
+type YAMLSource struct {
+	lines []string
+	root  *yaml.Node
+}
+
+// NewYAMLSource 解析 YAML 源码；无法解析时 Node 总是返回 nil
+func NewYAMLSource(src string) *YAMLSource {
+	source := &YAMLSource{lines: strings.Split(src, "\n")}
+	var doc yaml.Node
+	if yaml.Unmarshal([]byte(src), &doc) == nil && len(doc.Content) > 0 {
+		source.root = doc.Content[0]
+	}
+	return source
+}
+
+// Node 按路径查找节点，路径元素为映射的键（string）或序列的下标（int），找不到时返回 nil
+func (s *YAMLSource) Node(path ...interface{}) *yaml.Node {
+	n := s.root
+	for _, element := range path {
+		if n == nil {
+			return nil
+		}
+		switch key := element.(type) {
+		case string:
+			if n.Kind != yaml.MappingNode {
+				return nil
+			}
+			var next *yaml.Node
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == key {
+					next = n.Content[i+1]
+					break
+				}
+			}
+			n = next
+		case int:
+			if n.Kind != yaml.SequenceNode || key >= len(n.Content) {
+				return nil
+			}
+			n = n.Content[key]
+		}
+	}
+	return n
+}
+
+// Position 返回节点值中第 offset 个字节的行列（从 1 开始）；多行的折叠和引号标量只能给出近似位置
+func (s *YAMLSource) Position(n *yaml.Node, offset int) (int, int) {
+	if n == nil {
+		return 0, 0
+	}
+	value := n.Value
+	if offset > len(value) {
+		offset = len(value)
+	}
+	line := strings.Count(value[:offset], "\n")
+	column := offset - (strings.LastIndex(value[:offset], "\n") + 1)
+	switch n.Style {
+	case yaml.LiteralStyle, yaml.FoldedStyle:
+		// 块标量的内容从下一行开始，缩进取第一行内容
+		indent := 0
+		if n.Line < len(s.lines) {
+			first := s.lines[n.Line]
+			indent = len(first) - len(strings.TrimLeft(first, " "))
+		}
+		return n.Line + 1 + line, indent + 1 + column
+	case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		if line == 0 {
+			return n.Line, n.Column + 1 + column
+		}
+	default:
+		if line == 0 {
+			return n.Line, n.Column + column
+		}
+	}
+	return n.Line + line, column + 1
+}
@@ -1,6 +1,7 @@
 package main
  
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -10,6 +11,28 @@ import (
 )
  
 func main() {
+	lint := flag.Bool("lint", false, "lint the workflow files given as arguments (default: all workflows in .argus/workflows) instead of printing plans")
+	plan := flag.Bool("plan", false, "print the execution plan of the workflow file or directory given as argument (default: .argus/workflows)")
+	simulate := flag.Bool("simulate", false, "report which workflows and jobs run for -event with -payload and -changed-files")
+	event := flag.String("event", "", "event to plan for with -plan, such as push; all jobs are planned when empty")
+	payload := flag.String("payload", "", "event payload JSON file for -simulate")
+	changedFiles := flag.String("changed-files", "", "file listing the changed files one per line for -simulate, - for stdin; paths filters are not checked when empty")
+	format := flag.String("format", "text", "output format: text or sarif with -lint; text, json, dot or mermaid with -plan; text or json with -simulate")
+	runnersDir := flag.String("runners", "", "directory of runner ConfigMap YAML files; runs-on labels without a ConfigMap are reported")
+	runnerLabels := flag.String("runner-labels", "", "comma-separated runs-on labels that have a runner ConfigMap in the cluster")
+	secrets := flag.String("secrets", "", "comma-separated secrets configured for the repository; references to other secrets are reported")
+	flag.Parse()
+
+	if *lint {
+		os.Exit(runLint(flag.Args(), *format, *runnersDir, *runnerLabels, *secrets))
+	}
+	if *simulate {
+		os.Exit(runSimulate(flag.Args(), *event, *payload, *changedFiles, *format))
+	}
+	if *plan {
+		os.Exit(runPlan(flag.Args(), *event, *format))
+	}
+
 	// 方式1: 从文件解析单个工作流
 	parseFromFile()
 	